@@ -18,18 +18,22 @@ type contextKey string
 
 const startTimeKey contextKey = "start_time"
 
-func NewGinServer(subscriptionHandler *handler.SubscriptionHandler, logger *zap.Logger) *gin.Engine {
+func NewGinServer(subscriptionHandler *handler.SubscriptionHandler, adminHandler *handler.AdminHandler, healthHandler *handler.HealthHandler, cfg *config.Config, logger *zap.Logger) *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
+	// Match on the raw path so encoded slashes in service names stay inside
+	// a single path parameter.
+	router.UseRawPath = true
 
 	router.Use(gin.Recovery())
+	router.Use(handler.Backoff(cfg.Server.RetryAfter))
 	router.Use(func(c *gin.Context) {
 		start := time.Now()
 		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), startTimeKey, start))
 		c.Next()
 	})
 
-	handler.SetupRoutes(router, subscriptionHandler, logger)
+	handler.SetupRoutes(router, subscriptionHandler, adminHandler, healthHandler, cfg.Admin.Token, cfg.Server.StrictQueryParams, logger)
 
 	logger.Info("gin server initialized")
 	return router
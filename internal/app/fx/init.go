@@ -7,6 +7,7 @@ func ComponentsList() []interface{} {
 		RepositoryComponent,
 		ServiceComponent,
 		HandlerComponent,
+		JobComponent,
 		HTTPComponent,
 	}
 }
@@ -3,25 +3,42 @@ package fx
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"subscription-service/internal/clock"
 	"subscription-service/internal/config"
 	"subscription-service/internal/handler"
+	"subscription-service/internal/job"
+	"subscription-service/internal/metrics"
+	"subscription-service/internal/publisher"
 	"subscription-service/internal/repository"
 	"subscription-service/internal/service"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxevent"
 	"go.uber.org/zap"
 )
 
+// stopTimeout bounds the whole shutdown: HTTP drain, outbox drain and
+// closing the pool. It leaves room for the default outbox drain timeout.
+const stopTimeout = 30 * time.Second
+
+// Module wires the application. fx runs OnStop hooks in reverse order of
+// registration, so the component order here is also the shutdown order in
+// reverse: the HTTP server stops taking requests first, then the jobs stop
+// and the outbox drains, and the database pool closes last.
 func Module() fx.Option {
 	return fx.Options(
+		fx.StopTimeout(stopTimeout),
+
 		LoggerComponent(),
 		StorageComponent(),
 		RepositoryComponent(),
 		ServiceComponent(),
 		HandlerComponent(),
+		JobComponent(),
 		HTTPComponent(),
 
 		fx.WithLogger(func(logger *zap.Logger) fxevent.Logger {
@@ -41,19 +58,50 @@ func StorageComponent() fx.Option {
 	return fx.Options(
 		fx.Provide(NewDatabase),
 		fx.Invoke(RegisterDatabaseLifecycle),
+		fx.Invoke(RegisterDatabaseWarmup),
 	)
 }
 
 func RepositoryComponent() fx.Option {
-	return fx.Provide(NewSubscriptionRepository)
+	return fx.Provide(
+		NewSubscriptionRepository,
+		NewOutboxRepository,
+	)
 }
 
 func ServiceComponent() fx.Option {
-	return fx.Provide(NewSubscriptionService)
+	return fx.Provide(
+		clock.New,
+		NewSubscriptionValidator,
+		NewSubscriptionService,
+		NewBatchService,
+		NewRecomputeService,
+		NewPurgeService,
+	)
 }
 
 func HandlerComponent() fx.Option {
-	return fx.Provide(NewSubscriptionHandler)
+	return fx.Provide(
+		NewSubscriptionHandler,
+		NewAdminHandler,
+		NewHealthHandler,
+	)
+}
+
+func JobComponent() fx.Option {
+	return fx.Options(
+		fx.Provide(
+			NewRenewalService,
+			NewPublisher,
+			NewOutboxRelay,
+			NewKPIService,
+		),
+		fx.Invoke(RegisterRenewalJob),
+		fx.Invoke(RegisterOutboxJob),
+		fx.Invoke(RegisterMetricsJob),
+		fx.Invoke(RegisterPurgeJob),
+		fx.Invoke(RegisterRecomputeJob),
+	)
 }
 
 func HTTPComponent() fx.Option {
@@ -105,7 +153,16 @@ func NewDatabase(logger *zap.Logger, cfg *config.Config) (*pgxpool.Pool, error)
 		zap.String("dbname", cfg.Database.DBName),
 	)
 
-	pool, err := pgxpool.New(context.Background(), dsn)
+	poolConfig, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		logger.Error("invalid database config", zap.Error(err))
+		return nil, err
+	}
+	if cfg.Database.MinConns > 0 {
+		poolConfig.MinConns = cfg.Database.MinConns
+	}
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
 		logger.Error("failed to create connection pool", zap.Error(err))
 		return nil, err
@@ -120,16 +177,245 @@ func NewDatabase(logger *zap.Logger, cfg *config.Config) (*pgxpool.Pool, error)
 	return pool, nil
 }
 
-func NewSubscriptionRepository(db *pgxpool.Pool, logger *zap.Logger) repository.SubscriptionRepository {
-	return repository.NewSubscriptionRepository(db, logger)
+func NewSubscriptionRepository(db *pgxpool.Pool, cfg *config.Config, logger *zap.Logger) repository.SubscriptionRepository {
+	return repository.NewSubscriptionRepository(repository.WithAcquireTimeout(db, cfg.Database.AcquireTimeout), repository.NewTxConfig(cfg.Database), cfg.Subscription.EnforceUniqueActive, logger)
+}
+
+func NewOutboxRepository(db *pgxpool.Pool, cfg *config.Config, logger *zap.Logger) repository.OutboxRepository {
+	outbox := cfg.Jobs.Outbox
+	retry := repository.OutboxRetry{
+		MaxAttempts:  outbox.MaxAttempts,
+		Backoff:      outbox.RetryBackoff,
+		MaxBackoff:   outbox.MaxRetryBackoff,
+		ClaimTimeout: outbox.ClaimTimeout,
+	}
+	if retry.MaxAttempts <= 0 {
+		retry.MaxAttempts = 10
+	}
+	if retry.Backoff <= 0 {
+		retry.Backoff = 5 * time.Second
+	}
+	if retry.MaxBackoff <= 0 {
+		retry.MaxBackoff = 10 * time.Minute
+	}
+	if retry.ClaimTimeout <= 0 {
+		retry.ClaimTimeout = 5 * time.Minute
+	}
+	return repository.NewOutboxRepository(repository.WithAcquireTimeout(db, cfg.Database.AcquireTimeout), retry, logger)
+}
+
+func NewSubscriptionValidator(repo repository.SubscriptionRepository, cfg *config.Config, logger *zap.Logger) *service.SubscriptionValidator {
+	return service.NewSubscriptionValidator(repo, cfg.Subscription, logger)
+}
+
+func NewSubscriptionService(repo repository.SubscriptionRepository, validator *service.SubscriptionValidator, cfg *config.Config, clock clock.Clock, logger *zap.Logger) service.SubscriptionService {
+	svc := service.NewSubscriptionService(repo, validator, cfg.Subscription, clock, logger)
+	if !cfg.Cache.Enabled {
+		return svc
+	}
+
+	logger.Info("subscription read cache enabled", zap.Duration("ttl", cfg.Cache.TTL), zap.Duration("stale_window", cfg.Cache.StaleWindow))
+	return service.NewCachedSubscriptionService(svc, cfg.Cache, clock, logger)
+}
+
+func NewRenewalService(repo repository.SubscriptionRepository, clock clock.Clock, logger *zap.Logger) service.RenewalService {
+	return service.NewRenewalService(repo, clock, logger)
 }
 
-func NewSubscriptionService(repo repository.SubscriptionRepository, logger *zap.Logger) service.SubscriptionService {
-	return service.NewSubscriptionService(repo, logger)
+func NewPublisher(cfg *config.Config, logger *zap.Logger) publisher.Publisher {
+	outbox := cfg.Jobs.Outbox
+	if outbox.WebhookURL == "" {
+		logger.Info("no webhook configured, outbox events will be logged")
+		return publisher.NewLogPublisher(logger)
+	}
+
+	timeout := outbox.WebhookTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return publisher.NewWebhookPublisher(outbox.WebhookURL, timeout, logger)
+}
+
+func NewOutboxRelay(repo repository.OutboxRepository, pub publisher.Publisher, cfg *config.Config, logger *zap.Logger) service.OutboxRelay {
+	batchSize := cfg.Jobs.Outbox.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	return service.NewOutboxRelay(repo, pub, batchSize, logger)
+}
+
+func NewKPIService(repo repository.SubscriptionRepository, clock clock.Clock, logger *zap.Logger) service.KPIService {
+	return service.NewKPIService(repo, clock, logger)
+}
+
+func NewPurgeService(repo repository.SubscriptionRepository, cfg *config.Config, clock clock.Clock, logger *zap.Logger) service.PurgeService {
+	return service.NewPurgeService(repo, cfg.Jobs.Purge.BatchSize, clock, logger)
+}
+
+func NewBatchService(svc service.SubscriptionService, cfg *config.Config, clock clock.Clock, logger *zap.Logger) service.BatchService {
+	return service.NewBatchService(svc, cfg.Subscription.BatchIdempotencyTTL, cfg.Subscription.BatchIdempotencyMaxKeys, clock, logger)
+}
+
+func NewRecomputeService(repo repository.SubscriptionRepository, clock clock.Clock, logger *zap.Logger) service.RecomputeService {
+	return service.NewRecomputeService(repo, clock, logger)
+}
+
+func NewSubscriptionHandler(svc service.SubscriptionService, batch service.BatchService, logger *zap.Logger) *handler.SubscriptionHandler {
+	return handler.NewSubscriptionHandler(svc, batch, logger)
 }
 
-func NewSubscriptionHandler(svc service.SubscriptionService, logger *zap.Logger) *handler.SubscriptionHandler {
-	return handler.NewSubscriptionHandler(svc, logger)
+func NewAdminHandler(db *pgxpool.Pool, clock clock.Clock, recompute service.RecomputeService, purge service.PurgeService, logger *zap.Logger) *handler.AdminHandler {
+	return handler.NewAdminHandler(db, clock, recompute, purge, logger)
+}
+
+func NewHealthHandler(db *pgxpool.Pool, cfg *config.Config, clock clock.Clock, logger *zap.Logger) *handler.HealthHandler {
+	return handler.NewHealthHandler(db, cfg.Health, clock, logger)
+}
+
+func RegisterRenewalJob(lc fx.Lifecycle, svc service.RenewalService, cfg *config.Config, logger *zap.Logger) {
+	if !cfg.Jobs.Renewal.Enabled {
+		logger.Info("renewal job disabled")
+		return
+	}
+
+	interval := cfg.Jobs.Renewal.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	renewalJob := job.NewRenewalJob(svc, interval, logger)
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			renewalJob.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return renewalJob.Stop(ctx)
+		},
+	})
+}
+
+// RegisterDatabaseWarmup opens the pool's connections on start when the
+// warm-up is enabled. StorageComponent comes before HTTPComponent, so this
+// hook finishes before the server starts listening.
+func RegisterDatabaseWarmup(lc fx.Lifecycle, db *pgxpool.Pool, cfg *config.Config, logger *zap.Logger) {
+	if !cfg.Database.Warmup.Enabled {
+		return
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			return repository.WarmUp(ctx, db, cfg.Database.Warmup.Query, logger)
+		},
+	})
+}
+
+func RegisterOutboxJob(lc fx.Lifecycle, relay service.OutboxRelay, cfg *config.Config, logger *zap.Logger) {
+	if !cfg.Jobs.Outbox.Enabled {
+		logger.Info("outbox job disabled")
+		return
+	}
+
+	interval := cfg.Jobs.Outbox.Interval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	drainTimeout := cfg.Jobs.Outbox.DrainTimeout
+	if drainTimeout <= 0 {
+		drainTimeout = 10 * time.Second
+	}
+
+	outboxJob := job.NewOutboxJob(relay, interval, drainTimeout, logger)
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			outboxJob.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return outboxJob.Stop(ctx)
+		},
+	})
+}
+
+func RegisterMetricsJob(lc fx.Lifecycle, svc service.KPIService, cfg *config.Config, logger *zap.Logger) error {
+	if !cfg.Jobs.Metrics.Enabled {
+		logger.Info("metrics job disabled")
+		return nil
+	}
+
+	interval := cfg.Jobs.Metrics.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	gauges, err := metrics.NewKPIGauges(prometheus.DefaultRegisterer)
+	if err != nil {
+		return err
+	}
+
+	metricsJob := job.NewMetricsJob(svc, gauges, interval, logger)
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			metricsJob.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return metricsJob.Stop(ctx)
+		},
+	})
+	return nil
+}
+
+func RegisterPurgeJob(lc fx.Lifecycle, svc service.PurgeService, cfg *config.Config, logger *zap.Logger) {
+	if !cfg.Jobs.Purge.Enabled {
+		logger.Info("purge job disabled")
+		return
+	}
+
+	interval := cfg.Jobs.Purge.Interval
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+
+	purgeJob := job.NewPurgeJob(svc, interval, cfg.Jobs.Purge.OlderThan, logger)
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			purgeJob.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return purgeJob.Stop(ctx)
+		},
+	})
+}
+
+func RegisterRecomputeJob(lc fx.Lifecycle, svc service.RecomputeService, cfg *config.Config, logger *zap.Logger) {
+	if !cfg.Jobs.Recompute.Enabled {
+		logger.Info("recompute job disabled")
+		return
+	}
+
+	interval := cfg.Jobs.Recompute.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	recomputeJob := job.NewRecomputeJob(svc, interval, cfg.Jobs.Recompute.BatchSize, logger)
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			recomputeJob.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return recomputeJob.Stop(ctx)
+		},
+	})
 }
 
 func RegisterDatabaseLifecycle(lc fx.Lifecycle, logger *zap.Logger, db *pgxpool.Pool) {
@@ -0,0 +1,19 @@
+package clock
+
+import "time"
+
+// Clock abstracts the current time so that date-relative logic can be
+// driven deterministically.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func New() Clock {
+	return systemClock{}
+}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
@@ -1,20 +1,39 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"time"
 
+	"github.com/google/uuid"
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Database DatabaseConfig `yaml:"database"`
-	Logger   LoggerConfig   `yaml:"logger"`
+	Server       ServerConfig       `yaml:"server"`
+	Database     DatabaseConfig     `yaml:"database"`
+	Logger       LoggerConfig       `yaml:"logger"`
+	Jobs         JobsConfig         `yaml:"jobs"`
+	Subscription SubscriptionConfig `yaml:"subscription"`
+	Admin        AdminConfig        `yaml:"admin"`
+	Cache        CacheConfig        `yaml:"cache"`
+	Health       HealthConfig       `yaml:"health"`
 }
 
 type ServerConfig struct {
 	Host string `yaml:"host"`
 	Port int    `yaml:"port"`
+	// StrictQueryParams rejects unknown query parameters on the list and
+	// total-cost endpoints instead of ignoring them.
+	StrictQueryParams bool `yaml:"strict_query_params"`
+	// RetryAfter is the back-off suggested on unavailable responses.
+	RetryAfter RetryAfterConfig `yaml:"retry_after"`
+}
+
+// RetryAfterConfig sets the Retry-After sent with 503 responses, rounded up
+// to whole seconds. Zero means one second.
+type RetryAfterConfig struct {
+	Unavailable time.Duration `yaml:"unavailable"`
 }
 
 type DatabaseConfig struct {
@@ -24,13 +43,163 @@ type DatabaseConfig struct {
 	Password string `yaml:"password"`
 	DBName   string `yaml:"dbname"`
 	SSLMode  string `yaml:"sslmode"`
+	// AcquireTimeout bounds the wait for a pooled connection; when it runs
+	// out the request fails with 503 instead of queueing indefinitely.
+	AcquireTimeout time.Duration `yaml:"acquire_timeout"`
+	// IsolationLevel applies to write transactions: read_committed,
+	// repeatable_read or serializable. Empty means repeatable_read.
+	IsolationLevel string `yaml:"isolation_level"`
+	// TxRetries is how many times a write transaction that hit a
+	// serialization failure or deadlock is retried. Zero means the default
+	// of 3.
+	TxRetries int `yaml:"tx_retries"`
+	// MinConns is how many connections the pool keeps open. Zero keeps the
+	// pgx default of none.
+	MinConns int32 `yaml:"min_conns"`
+	// Warmup opens the pool's connections at startup, before the server
+	// accepts traffic.
+	Warmup WarmupConfig `yaml:"warmup"`
+}
+
+// WarmupConfig controls the startup connection warm-up. When enabled,
+// min_conns connections (at least one) are opened and pinged; Query also
+// runs a trivial query on each.
+type WarmupConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Query   bool `yaml:"query"`
 }
 
+const (
+	IsolationReadCommitted  = "read_committed"
+	IsolationRepeatableRead = "repeatable_read"
+	IsolationSerializable   = "serializable"
+)
+
 type LoggerConfig struct {
 	Level    string `yaml:"level"`
 	Encoding string `yaml:"encoding"`
 }
 
+type SubscriptionConfig struct {
+	RequireEndDate bool `yaml:"require_end_date"`
+	// EmptyServiceNotFound makes the per-service listing answer 404 instead
+	// of an empty page when no subscriptions exist for the name.
+	EmptyServiceNotFound bool `yaml:"empty_service_not_found"`
+	// EnforceUniqueActive rejects writes that would give a user two live
+	// subscriptions to one service with overlapping dates.
+	EnforceUniqueActive bool `yaml:"enforce_unique_active"`
+	// MaxCostWindowMonths caps the number of months a total-cost window may
+	// span. Zero means the default of 120.
+	MaxCostWindowMonths int `yaml:"max_cost_window_months"`
+	// DefaultUserID is used for creates that omit user_id, for single-tenant
+	// deployments. When empty user_id stays required.
+	DefaultUserID string `yaml:"default_user_id"`
+	// DedupWindow, when positive, makes an identical create (every stored
+	// field the same) within the window return the subscription created
+	// first instead of a duplicate.
+	DedupWindow time.Duration `yaml:"dedup_window"`
+	// DedupMaxEntries caps how many recent creates are remembered for
+	// deduplication. Zero means the default of 10000.
+	DedupMaxEntries int `yaml:"dedup_max_entries"`
+	// BatchIdempotencyTTL is how long a bulk create sent with an
+	// Idempotency-Key header is remembered for replay. Zero means the
+	// default of 24h.
+	BatchIdempotencyTTL time.Duration `yaml:"batch_idempotency_ttl"`
+	// BatchIdempotencyMaxKeys caps how many Idempotency-Keys are remembered
+	// at once, in-flight batches included. Zero means the default of 1000.
+	BatchIdempotencyMaxKeys int `yaml:"batch_idempotency_max_keys"`
+	// MaxOffset is the deepest offset list endpoints accept. Zero means
+	// the default of 10000.
+	MaxOffset int `yaml:"max_offset"`
+	// ServiceNamesTTL is how long the distinct service names are cached.
+	ServiceNamesTTL time.Duration `yaml:"service_names_ttl"`
+}
+
+// AdminConfig protects the /admin routes. Leaving Token empty disables them.
+type AdminConfig struct {
+	Token string `yaml:"token"`
+}
+
+// CacheConfig controls the in-memory read cache. Entries are fresh for TTL
+// and may be served stale for a further StaleWindow while they refresh.
+// Timeout bounds each load from the database.
+type CacheConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	TTL         time.Duration `yaml:"ttl"`
+	StaleWindow time.Duration `yaml:"stale_window"`
+	Timeout     time.Duration `yaml:"timeout"`
+}
+
+// HealthConfig tunes the readiness probe. A database ping gets PingTimeout;
+// its result is reused for CacheTTL when it passed and for FailureCacheTTL,
+// which may not be longer, when it failed.
+type HealthConfig struct {
+	PingTimeout     time.Duration `yaml:"ping_timeout"`
+	CacheTTL        time.Duration `yaml:"cache_ttl"`
+	FailureCacheTTL time.Duration `yaml:"failure_cache_ttl"`
+}
+
+type JobsConfig struct {
+	Renewal   RenewalJobConfig   `yaml:"renewal"`
+	Outbox    OutboxJobConfig    `yaml:"outbox"`
+	Metrics   MetricsJobConfig   `yaml:"metrics"`
+	Purge     PurgeJobConfig     `yaml:"purge"`
+	Recompute RecomputeJobConfig `yaml:"recompute"`
+}
+
+type RenewalJobConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// MetricsJobConfig controls the business KPI gauges on /metrics. Interval is
+// both the refresh period and the window new and churned counts cover.
+type MetricsJobConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// RecomputeJobConfig controls the periodic refresh of derived subscription
+// fields, which go stale as dates pass. BatchSize rows are locked and
+// updated per transaction.
+type RecomputeJobConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Interval  time.Duration `yaml:"interval"`
+	BatchSize int           `yaml:"batch_size"`
+}
+
+// PurgeJobConfig controls the permanent removal of soft-deleted
+// subscriptions. When enabled, every Interval the job removes those deleted
+// more than OlderThan ago. BatchSize, also used by the admin purge endpoint,
+// is how many rows each delete statement removes.
+type PurgeJobConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Interval  time.Duration `yaml:"interval"`
+	OlderThan time.Duration `yaml:"older_than"`
+	BatchSize int           `yaml:"batch_size"`
+}
+
+// OutboxJobConfig controls the outbox relay. Events are always written to the
+// outbox; with the relay disabled they accumulate until it is turned on.
+// Without a WebhookURL events are only logged. On shutdown the relay keeps
+// publishing for up to DrainTimeout before giving up on what is left.
+// A failed event is retried after RetryBackoff, doubling per failure up to
+// MaxRetryBackoff, and dead-lettered after MaxAttempts failures. A claimed
+// event that is neither sent nor failed within ClaimTimeout, because its
+// relay died, is picked up again.
+type OutboxJobConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	Interval        time.Duration `yaml:"interval"`
+	BatchSize       int           `yaml:"batch_size"`
+	WebhookURL      string        `yaml:"webhook_url"`
+	WebhookTimeout  time.Duration `yaml:"webhook_timeout"`
+	DrainTimeout    time.Duration `yaml:"drain_timeout"`
+	MaxAttempts     int           `yaml:"max_attempts"`
+	RetryBackoff    time.Duration `yaml:"retry_backoff"`
+	MaxRetryBackoff time.Duration `yaml:"max_retry_backoff"`
+	ClaimTimeout    time.Duration `yaml:"claim_timeout"`
+}
+
 func Load(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -42,5 +211,47 @@ func Load(path string) (*Config, error) {
 		return nil, err
 	}
 
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
 	return &cfg, nil
 }
+
+func (c *Config) validate() error {
+	if c.Subscription.DefaultUserID != "" {
+		if _, err := uuid.Parse(c.Subscription.DefaultUserID); err != nil {
+			return fmt.Errorf("subscription.default_user_id: %w", err)
+		}
+	}
+
+	switch c.Database.IsolationLevel {
+	case "", IsolationReadCommitted, IsolationRepeatableRead, IsolationSerializable:
+	default:
+		return fmt.Errorf("database.isolation_level: unsupported level %q", c.Database.IsolationLevel)
+	}
+	if c.Database.TxRetries < 0 {
+		return fmt.Errorf("database.tx_retries must not be negative")
+	}
+	if c.Database.MinConns < 0 {
+		return fmt.Errorf("database.min_conns must not be negative")
+	}
+
+	if c.Jobs.Outbox.MaxAttempts < 0 {
+		return fmt.Errorf("jobs.outbox.max_attempts must not be negative")
+	}
+
+	if c.Jobs.Purge.Enabled && c.Jobs.Purge.OlderThan <= 0 {
+		return fmt.Errorf("jobs.purge.older_than must be positive when the purge job is enabled")
+	}
+
+	if c.Server.RetryAfter.Unavailable < 0 {
+		return fmt.Errorf("server.retry_after.unavailable must not be negative")
+	}
+
+	if c.Health.CacheTTL > 0 && c.Health.FailureCacheTTL > c.Health.CacheTTL {
+		return fmt.Errorf("health.failure_cache_ttl must not exceed health.cache_ttl")
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDefaultUserID(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr bool
+	}{
+		{name: "unset", yaml: "subscription: {}\n"},
+		{name: "valid uuid", yaml: "subscription:\n  default_user_id: 60601fee-2bf1-4721-ae6f-7636e79a0cba\n"},
+		{name: "not a uuid", yaml: "subscription:\n  default_user_id: tenant-1\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(tt.yaml), 0o600); err != nil {
+				t.Fatalf("write config: %v", err)
+			}
+
+			_, err := Load(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,5 @@
+package domain
+
+type AnonymizeUserResponse struct {
+	Anonymized int64 `json:"anonymized"`
+}
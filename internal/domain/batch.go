@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+const MaxBatchSize = 100
+
+// MaxIdempotencyKeyLength caps the Idempotency-Key header of bulk creates.
+const MaxIdempotencyKeyLength = 255
+
+// ErrIdempotencyKeyReused means an Idempotency-Key was sent again with a
+// different request body than the one it was first used for.
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+
+// ErrIdempotencyKeysExhausted means every remembered Idempotency-Key belongs
+// to a batch still in flight, so a new key cannot be taken until one ends.
+var ErrIdempotencyKeysExhausted = errors.New("too many idempotent batches in flight")
+
+const (
+	BatchStatusCreated = "created"
+	BatchStatusUpdated = "updated"
+	BatchStatusDeleted = "deleted"
+	BatchStatusFailed  = "failed"
+)
+
+// BatchResult is the outcome of one item in a batch request. Index is the
+// item's position in the request; ID is set whenever the subscription is
+// known, and Error only when Status is failed.
+type BatchResult struct {
+	Index  int        `json:"index"`
+	ID     *uuid.UUID `json:"id"`
+	Status string     `json:"status"`
+	Error  string     `json:"error,omitempty"`
+}
+
+// BatchResponse lists one result per request item, always in request order
+// so Results[i] describes item i.
+type BatchResponse struct {
+	Results   []BatchResult `json:"results"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+}
+
+type BatchCreateRequest struct {
+	Items []CreateSubscriptionRequest `json:"items" binding:"required,min=1,max=100"`
+}
+
+type BatchUpdateItem struct {
+	ID uuid.UUID `json:"id"`
+	UpdateSubscriptionRequest
+}
+
+type BatchUpdateRequest struct {
+	Items []BatchUpdateItem `json:"items" binding:"required,min=1,max=100"`
+}
+
+type BatchDeleteRequest struct {
+	IDs []uuid.UUID `json:"ids" binding:"required,min=1,max=100"`
+}
+
+// NewBatchResponse tallies results into a response. results[i] must be the
+// outcome of item i: the batch service writes each result into its item's
+// slot, so the order never depends on how the items were processed.
+func NewBatchResponse(results []BatchResult) *BatchResponse {
+	response := &BatchResponse{Results: results}
+	for _, result := range results {
+		if result.Status == BatchStatusFailed {
+			response.Failed++
+		} else {
+			response.Succeeded++
+		}
+	}
+	return response
+}
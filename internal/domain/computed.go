@@ -0,0 +1,22 @@
+package domain
+
+const ExpandComputed = "computed"
+
+// SubscriptionComputed holds values derived from a subscription as of a
+// given day. TotalPaidToDate counts each billed month from the start up to
+// AsOf, skipping months whose first day falls in a pause, the same way the
+// total-cost report does. It is in whole units of the subscription's
+// currency, rounded down, and TotalPaidToDateMinor in minor units.
+type SubscriptionComputed struct {
+	AsOf                 string `json:"as_of"`
+	IsActive             bool   `json:"is_active"`
+	DaysUntilRenewal     *int   `json:"days_until_renewal"`
+	MonthsBilled         int    `json:"months_billed"`
+	TotalPaidToDate      int    `json:"total_paid_to_date"`
+	TotalPaidToDateMinor int    `json:"total_paid_to_date_minor"`
+}
+
+type SubscriptionWithComputed struct {
+	*Subscription
+	Computed SubscriptionComputed `json:"computed"`
+}
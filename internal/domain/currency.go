@@ -0,0 +1,173 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency applies to subscriptions created without a currency,
+// including every row that predates the field.
+const DefaultCurrency = "RUB"
+
+// currencyExponents holds the ISO 4217 minor-unit exponent of each supported
+// currency: prices are stored as integers in units of 10^-exponent of the
+// currency, so a price of 1999 is 19.99 RUB, 1999 JPY or 1.999 BHD.
+var currencyExponents = map[string]int{
+	"BHD": 3,
+	"CHF": 2,
+	"CNY": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"JOD": 3,
+	"JPY": 0,
+	"KRW": 0,
+	"KWD": 3,
+	"OMR": 3,
+	"RUB": 2,
+	"TND": 3,
+	"USD": 2,
+}
+
+// CurrencyExponent returns the minor-unit exponent of code and whether the
+// currency is supported.
+func CurrencyExponent(code string) (int, bool) {
+	exponent, ok := currencyExponents[code]
+	return exponent, ok
+}
+
+// Currencies lists the supported currency codes in alphabetical order.
+func Currencies() []string {
+	codes := make([]string, 0, len(currencyExponents))
+	for code := range currencyExponents {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// minorUnitsPerUnit returns 10^exponent of code, using DefaultCurrency's
+// exponent for unsupported currencies.
+func minorUnitsPerUnit(code string) int64 {
+	exponent, ok := CurrencyExponent(code)
+	if !ok {
+		exponent = currencyExponents[DefaultCurrency]
+	}
+	scale := int64(1)
+	for range exponent {
+		scale *= 10
+	}
+	return scale
+}
+
+// WholeUnits converts an amount in minor units of code into whole units,
+// rounding down. Integer prices and totals were whole units before
+// subscriptions carried a currency, and the price and total_cost fields keep
+// that meaning; the _minor fields carry the exact amounts.
+func WholeUnits(minor int64, code string) int64 {
+	return minor / minorUnitsPerUnit(code)
+}
+
+// FromWholeUnits converts whole units of code into minor units, reporting
+// false when the result does not fit in an int64.
+func FromWholeUnits(whole int64, code string) (int64, bool) {
+	scale := minorUnitsPerUnit(code)
+	if whole > math.MaxInt64/scale || whole < math.MinInt64/scale {
+		return 0, false
+	}
+	return whole * scale, true
+}
+
+// CurrencyTotal is a sum over the subscriptions in one currency, in minor
+// units, in whole units rounded down, and as a decimal string.
+type CurrencyTotal struct {
+	Currency   string `json:"currency"`
+	Total      int64  `json:"total"`
+	TotalMinor int64  `json:"total_minor"`
+	Amount     string `json:"amount"`
+}
+
+// NewCurrencyTotal describes a sum of minor units of code.
+func NewCurrencyTotal(code string, minor int64) CurrencyTotal {
+	return CurrencyTotal{
+		Currency:   code,
+		Total:      WholeUnits(minor, code),
+		TotalMinor: minor,
+		Amount:     FormatAmount(minor, code),
+	}
+}
+
+// FormatAmount renders an amount held in minor units of code as a decimal
+// string with exactly the currency's number of fraction digits, e.g. 1999
+// is "19.99" in RUB, "1999" in JPY and "1.999" in BHD. Unsupported
+// currencies are formatted with DefaultCurrency's exponent.
+func FormatAmount(minor int64, code string) string {
+	exponent, ok := CurrencyExponent(code)
+	if !ok {
+		exponent = currencyExponents[DefaultCurrency]
+	}
+
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+	}
+	digits := strconv.FormatUint(absInt64(minor), 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	split := len(digits) - exponent
+	return sign + digits[:split] + "." + digits[split:]
+}
+
+// ParseAmount converts a non-negative decimal string in units of code, such
+// as "19.99", into minor units. It rejects more fraction digits than the
+// currency has, so "1.5" is not a valid JPY amount and "1.2345" is not a
+// valid BHD one.
+func ParseAmount(value, code string) (int64, error) {
+	exponent, ok := CurrencyExponent(code)
+	if !ok {
+		return 0, fmt.Errorf("unsupported currency %q", code)
+	}
+
+	whole, fraction, hasPoint := strings.Cut(value, ".")
+	if whole == "" || !isDigits(whole) || (hasPoint && (fraction == "" || !isDigits(fraction))) {
+		return 0, fmt.Errorf("amount %q is not a decimal number", value)
+	}
+	if len(fraction) > exponent {
+		if exponent == 0 {
+			return 0, fmt.Errorf("%s amounts are whole numbers", code)
+		}
+		return 0, fmt.Errorf("%s amounts have at most %d decimal places", code, exponent)
+	}
+
+	digits := strings.TrimLeft(whole+fraction+strings.Repeat("0", exponent-len(fraction)), "0")
+	if digits == "" {
+		return 0, nil
+	}
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("amount %q is too large", value)
+	}
+	return minor, nil
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func absInt64(value int64) uint64 {
+	if value < 0 {
+		return uint64(-(value + 1)) + 1
+	}
+	return uint64(value)
+}
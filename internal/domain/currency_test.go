@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		minor    int64
+		currency string
+		want     string
+	}{
+		{name: "two decimals", minor: 1999, currency: "RUB", want: "19.99"},
+		{name: "two decimals below one unit", minor: 5, currency: "USD", want: "0.05"},
+		{name: "zero decimals", minor: 1999, currency: "JPY", want: "1999"},
+		{name: "zero decimals zero", minor: 0, currency: "JPY", want: "0"},
+		{name: "three decimals", minor: 1999, currency: "BHD", want: "1.999"},
+		{name: "three decimals below one unit", minor: 7, currency: "BHD", want: "0.007"},
+		{name: "negative", minor: -1050, currency: "EUR", want: "-10.50"},
+		{name: "unsupported currency uses the default scale", minor: 1999, currency: "XXX", want: "19.99"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatAmount(tt.minor, tt.currency); got != tt.want {
+				t.Errorf("FormatAmount(%d, %s) = %q, want %q", tt.minor, tt.currency, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{name: "two decimals", value: "19.99", currency: "RUB", want: 1999},
+		{name: "two decimals short fraction", value: "19.9", currency: "RUB", want: 1990},
+		{name: "two decimals whole", value: "19", currency: "RUB", want: 1900},
+		{name: "two decimals too precise", value: "19.999", currency: "RUB", wantErr: true},
+		{name: "zero decimals", value: "1999", currency: "JPY", want: 1999},
+		{name: "zero decimals with fraction", value: "1999.5", currency: "JPY", wantErr: true},
+		{name: "zero decimals with zero fraction", value: "1999.0", currency: "JPY", wantErr: true},
+		{name: "three decimals", value: "1.999", currency: "BHD", want: 1999},
+		{name: "three decimals short fraction", value: "1.5", currency: "BHD", want: 1500},
+		{name: "three decimals too precise", value: "1.9999", currency: "BHD", wantErr: true},
+		{name: "leading zeros", value: "0.050", currency: "KWD", want: 50},
+		{name: "zero", value: "0", currency: "RUB", want: 0},
+		{name: "negative", value: "-1.00", currency: "RUB", wantErr: true},
+		{name: "missing whole part", value: ".50", currency: "RUB", wantErr: true},
+		{name: "missing fraction", value: "1.", currency: "RUB", wantErr: true},
+		{name: "not a number", value: "ten", currency: "RUB", wantErr: true},
+		{name: "exponent notation", value: "1e3", currency: "JPY", wantErr: true},
+		{name: "too large", value: "99999999999999999999", currency: "JPY", wantErr: true},
+		{name: "unsupported currency", value: "1.00", currency: "XXX", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAmount(tt.value, tt.currency)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseAmount(%q, %s) = %d, want an error", tt.value, tt.currency, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseAmount(%q, %s): %v", tt.value, tt.currency, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseAmount(%q, %s) = %d, want %d", tt.value, tt.currency, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAmountRoundTrip(t *testing.T) {
+	for _, currency := range Currencies() {
+		for _, minor := range []int64{1, 10, 999, 1000, 123456789} {
+			formatted := FormatAmount(minor, currency)
+			parsed, err := ParseAmount(formatted, currency)
+			if err != nil || parsed != minor {
+				t.Errorf("%s: FormatAmount(%d) = %q parsed back as %d, %v", currency, minor, formatted, parsed, err)
+			}
+		}
+	}
+}
+
+func TestWholeUnits(t *testing.T) {
+	tests := []struct {
+		name     string
+		minor    int64
+		currency string
+		want     int64
+	}{
+		{name: "two decimals round down", minor: 1999, currency: "RUB", want: 19},
+		{name: "zero decimals", minor: 1999, currency: "JPY", want: 1999},
+		{name: "three decimals", minor: 1999, currency: "BHD", want: 1},
+		{name: "below one unit", minor: 99, currency: "USD", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WholeUnits(tt.minor, tt.currency); got != tt.want {
+				t.Errorf("WholeUnits(%d, %s) = %d, want %d", tt.minor, tt.currency, got, tt.want)
+			}
+			minor, ok := FromWholeUnits(tt.want, tt.currency)
+			if !ok || minor > tt.minor || WholeUnits(minor, tt.currency) != tt.want {
+				t.Errorf("FromWholeUnits(%d, %s) = %d, %v, want the start of the unit", tt.want, tt.currency, minor, ok)
+			}
+		})
+	}
+
+	if _, ok := FromWholeUnits(math.MaxInt64/10, "BHD"); ok {
+		t.Error("FromWholeUnits overflowed without reporting it")
+	}
+}
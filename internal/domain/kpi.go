@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// KPISnapshot holds the business metrics as of AsOf. New and Churned count
+// over the window ending then: subscriptions created, and subscriptions
+// that ended or were deleted. MonthlyRecurringRevenue is in minor units,
+// keyed by currency.
+type KPISnapshot struct {
+	AsOf                    time.Time
+	ActiveSubscriptions     int64
+	MonthlyRecurringRevenue map[string]int64
+	New                     int64
+	Churned                 int64
+}
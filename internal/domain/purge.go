@@ -0,0 +1,11 @@
+package domain
+
+import "time"
+
+const DefaultPurgeBatchSize = 500
+
+// PurgeResponse reports a purge of subscriptions soft-deleted before Cutoff.
+type PurgeResponse struct {
+	Purged int64     `json:"purged"`
+	Cutoff time.Time `json:"cutoff"`
+}
@@ -0,0 +1,14 @@
+package domain
+
+import "github.com/google/uuid"
+
+// ReassignUserRequest moves every live subscription of FromUserID to
+// ToUserID.
+type ReassignUserRequest struct {
+	FromUserID uuid.UUID `json:"from_user_id"`
+	ToUserID   uuid.UUID `json:"to_user_id"`
+}
+
+type ReassignUserResponse struct {
+	Moved int64 `json:"moved"`
+}
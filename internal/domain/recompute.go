@@ -0,0 +1,30 @@
+package domain
+
+import "github.com/google/uuid"
+
+const (
+	DefaultRecomputeBatchSize = 500
+	MaxRecomputeBatchSize     = 5000
+)
+
+// RecomputeRequest scopes a derived field recompute. Every field is
+// optional: an empty request walks all subscriptions. AfterID resumes a run
+// from the last_id of an earlier response and MaxBatches caps how much one
+// call does.
+type RecomputeRequest struct {
+	UserID      *uuid.UUID `json:"user_id,omitempty"`
+	ServiceName *string    `json:"service_name,omitempty"`
+	AfterID     *uuid.UUID `json:"after_id,omitempty"`
+	BatchSize   int        `json:"batch_size,omitempty" binding:"omitempty,min=1,max=5000"`
+	MaxBatches  int        `json:"max_batches,omitempty" binding:"omitempty,min=1"`
+}
+
+// RecomputeResponse reports how far a recompute got. When Done is false,
+// repeat the request with after_id set to LastID to continue.
+type RecomputeResponse struct {
+	Processed int        `json:"processed"`
+	Updated   int        `json:"updated"`
+	Batches   int        `json:"batches"`
+	LastID    *uuid.UUID `json:"last_id,omitempty"`
+	Done      bool       `json:"done"`
+}
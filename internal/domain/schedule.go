@@ -0,0 +1,51 @@
+package domain
+
+import "github.com/google/uuid"
+
+const (
+	ScheduleEventBilling = "billing"
+	ScheduleEventRenewal = "renewal"
+	ScheduleEventExpiry  = "expiry"
+)
+
+// DefaultScheduleDays and MaxScheduleDays bound how far ahead a schedule
+// request looks.
+const (
+	DefaultScheduleDays = 30
+	MaxScheduleDays     = 366
+)
+
+// ScheduleRequest asks for the billing, renewal and expiry events due in
+// the WithinDays days starting today. The embedded list filters narrow the
+// subscriptions considered; their active_from and active_to are replaced by
+// the window, and limit and offset page through the events.
+type ScheduleRequest struct {
+	ListSubscriptionsRequest
+	WithinDays int `form:"within_days"`
+}
+
+// ScheduleEvent is one upcoming event of a subscription. Billing events fall
+// on the start date and every billing period after it; a renewal is the
+// renewal job extending an auto-renewing subscription on its end date; an
+// expiry is the end date of one that does not renew. Price is what the
+// subscription charges per period in whole units of Currency, rounded down,
+// and PriceMinor the same in minor units.
+type ScheduleEvent struct {
+	Date           string    `json:"date"`
+	Type           string    `json:"type" enums:"billing,renewal,expiry"`
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	ServiceName    string    `json:"service_name"`
+	Price          int       `json:"price"`
+	PriceMinor     int       `json:"price_minor"`
+	Currency       string    `json:"currency"`
+}
+
+type ScheduleResponse struct {
+	From   string          `json:"from"`
+	To     string          `json:"to"`
+	Data   []ScheduleEvent `json:"data"`
+	Total  int64           `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
@@ -1,51 +1,400 @@
 package domain
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type Subscription struct {
-	ID          uuid.UUID `json:"id" db:"id"`
-	ServiceName string    `json:"service_name" db:"service_name"`
-	Price       int       `json:"price" db:"price"`
-	UserID      uuid.UUID `json:"user_id" db:"user_id"`
-	StartDate   string    `json:"start_date" db:"start_date"`
-	EndDate     *string   `json:"end_date,omitempty" db:"end_date"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          uuid.UUID              `json:"id" db:"id"`
+	ServiceName string                 `json:"service_name" db:"service_name"`
+	Price       int                    `json:"price"`
+	PriceMinor  int                    `json:"price_minor" db:"price"`
+	UserID      uuid.UUID              `json:"user_id" db:"user_id"`
+	StartDate   string                 `json:"start_date" db:"start_date"`
+	EndDate     *string                `json:"end_date,omitempty" db:"end_date"`
+	AutoRenew   bool                   `json:"auto_renew" db:"auto_renew"`
+	Metadata    map[string]interface{} `json:"metadata" db:"metadata"`
+	Tags        []string               `json:"tags" db:"tags"`
+	// BillingPeriod is how often the subscription is charged; Price is the
+	// amount charged each period.
+	BillingPeriod string `json:"billing_period" db:"billing_period"`
+	// Price is in whole units of Currency, rounded down, as it was before
+	// subscriptions carried a currency. PriceMinor is the exact price in
+	// minor units (kopecks for RUB, yen for JPY, fils for BHD) and Amount
+	// the same price as a decimal string, e.g. "19.99".
+	Currency string `json:"currency" db:"currency"`
+	Amount   string `json:"amount"`
+	// Status and NextRenewalDate are derived from the dates and auto_renew.
+	// Writes set them and the recompute job refreshes them as dates pass;
+	// see also POST /admin/recompute.
+	Status          string    `json:"status" db:"status"`
+	NextRenewalDate *string   `json:"next_renewal_date,omitempty" db:"next_renewal_date"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+	// DeletedAt is only ever set on rows returned by admin reads with
+	// include_deleted, including admin delta pulls, where deletions are
+	// reported rather than hidden.
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
+const (
+	SubscriptionStatusActive    = "active"
+	SubscriptionStatusScheduled = "scheduled"
+	SubscriptionStatusExpired   = "expired"
+)
+
+const (
+	BillingPeriodMonthly   = "monthly"
+	BillingPeriodQuarterly = "quarterly"
+	BillingPeriodYearly    = "yearly"
+
+	// DefaultBillingPeriod applies to subscriptions created without a period,
+	// including every row that predates the field.
+	DefaultBillingPeriod = BillingPeriodMonthly
+)
+
+var BillingPeriods = []string{BillingPeriodMonthly, BillingPeriodQuarterly, BillingPeriodYearly}
+
+// BillingPeriodCount aggregates the subscriptions billed on one period.
+// Totals sums their per-period prices separately for each currency;
+// TotalCost is the DefaultCurrency sum in whole units, the only total
+// before subscriptions carried a currency.
+type BillingPeriodCount struct {
+	Period    string          `json:"period"`
+	Count     int64           `json:"count"`
+	TotalCost int64           `json:"total_cost"`
+	Totals    []CurrencyTotal `json:"totals"`
+}
+
+// ReplaceMediaType is the Content-Type of a PUT body holding a full
+// subscription, which creates or replaces the subscription at the id. A
+// plain JSON body is a partial update instead.
+const ReplaceMediaType = "application/vnd.subscription+json"
+
 type CreateSubscriptionRequest struct {
-	ServiceName string    `json:"service_name" binding:"required"`
-	Price       int       `json:"price" binding:"required,min=1"`
-	UserID      uuid.UUID `json:"user_id" binding:"required"`
-	StartDate   string    `json:"start_date" binding:"required"`
-	EndDate     *string   `json:"end_date,omitempty"`
+	ServiceName string          `json:"service_name" binding:"required"`
+	Price       int             `json:"price,omitempty"`
+	UserID      uuid.UUID       `json:"user_id"`
+	StartDate   string          `json:"start_date" binding:"required"`
+	EndDate     *string         `json:"end_date,omitempty"`
+	AutoRenew   bool            `json:"auto_renew"`
+	Metadata    json.RawMessage `json:"metadata,omitempty" swaggertype:"object"`
+	Tags        []string        `json:"tags,omitempty"`
+	// BillingPeriod defaults to DefaultBillingPeriod.
+	BillingPeriod string `json:"billing_period,omitempty" enums:"monthly,quarterly,yearly"`
+	// Currency is an ISO 4217 code and defaults to DefaultCurrency. The
+	// price is given once, as Price in whole units of it, PriceMinor in
+	// minor units or Amount as a decimal string, e.g. "19.99".
+	Currency   string `json:"currency,omitempty"`
+	PriceMinor int    `json:"price_minor,omitempty"`
+	Amount     string `json:"amount,omitempty"`
 }
 
 type UpdateSubscriptionRequest struct {
-	ServiceName *string `json:"service_name,omitempty"`
-	Price       *int    `json:"price,omitempty"`
-	StartDate   *string `json:"start_date,omitempty"`
-	EndDate     *string `json:"end_date,omitempty"`
+	ServiceName *string         `json:"service_name,omitempty"`
+	Price       *int            `json:"price,omitempty"`
+	StartDate   *string         `json:"start_date,omitempty"`
+	EndDate     *string         `json:"end_date,omitempty"`
+	AutoRenew   *bool           `json:"auto_renew,omitempty"`
+	Metadata    json.RawMessage `json:"metadata,omitempty" swaggertype:"object"`
+	// Tags replaces the tag set when present; an empty list clears it.
+	Tags          []string `json:"tags,omitempty"`
+	BillingPeriod *string  `json:"billing_period,omitempty" enums:"monthly,quarterly,yearly"`
+	// Currency changes the scale the price is read in, so it must come with
+	// a new price. At most one of Price in whole units, PriceMinor in minor
+	// units and Amount as a decimal string sets it, in the currency the
+	// subscription has after the update.
+	Currency   *string `json:"currency,omitempty"`
+	PriceMinor *int    `json:"price_minor,omitempty"`
+	Amount     *string `json:"amount,omitempty"`
+	// ClearEndDate removes the end date, making the subscription open-ended.
+	// An omitted or null end_date leaves the end date unchanged, so clearing
+	// it takes this flag; it cannot be combined with end_date.
+	ClearEndDate bool `json:"clear_end_date,omitempty"`
+}
+
+// CloneSubscriptionRequest overrides fields of the copy. At most one of
+// Price in whole units and PriceMinor in minor units of the source's
+// currency replaces its price.
+type CloneSubscriptionRequest struct {
+	Price      *int    `json:"price,omitempty"`
+	PriceMinor *int    `json:"price_minor,omitempty"`
+	StartDate  *string `json:"start_date,omitempty"`
+	EndDate    *string `json:"end_date,omitempty"`
+}
+
+// SubscriptionPause is an inclusive date window during which a subscription
+// is not billed.
+type SubscriptionPause struct {
+	ID             uuid.UUID `json:"id"`
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	PauseStart     string    `json:"pause_start"`
+	PauseEnd       string    `json:"pause_end"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type CreatePauseRequest struct {
+	PauseStart string `json:"pause_start" binding:"required"`
+	PauseEnd   string `json:"pause_end" binding:"required"`
 }
 
 type ListSubscriptionsRequest struct {
-	UserID      *string `form:"user_id"`
-	ServiceName *string `form:"service_name"`
-	Limit       int     `form:"limit"`
-	Offset      int     `form:"offset"`
+	// UserID may be repeated or comma-separated to match any of several
+	// users.
+	UserID []string `form:"user_id"`
+	// ServiceName may be repeated or comma-separated; each value is a
+	// case-insensitive substring match, and a subscription matches when any
+	// value does.
+	ServiceName []string `form:"service_name"`
+	// ServiceNamePrefix is a case-sensitive prefix match, for namespaced
+	// names such as "aws:ec2". It combines with ServiceName.
+	ServiceNamePrefix *string `form:"service_name_prefix"`
+	// MinPrice and MaxPrice bound the price in whole units of each
+	// subscription's currency, as the price field reports it.
+	MinPrice *int `form:"min_price"`
+	MaxPrice *int `form:"max_price"`
+	// ActiveFrom and ActiveTo select subscriptions active at any point in
+	// the inclusive window; either bound may be omitted.
+	ActiveFrom *string `form:"active_from"`
+	ActiveTo   *string `form:"active_to"`
+	// UpdatedSince turns the list into a delta pull: only rows changed after
+	// this RFC 3339 timestamp are returned, oldest change first. Deleted rows
+	// are only included, so clients can drop them, together with
+	// IncludeDeleted, which takes the admin token.
+	UpdatedSince *string `form:"updated_since"`
+	// UpdatedAfterID completes the delta cursor: rows changed exactly at
+	// updated_since are returned too when their id sorts after this one.
+	// Clients pass the previous page's X-Latest-Update-ID so that a page
+	// ending inside a group of rows sharing one updated_at loses none of
+	// them. It requires updated_since.
+	UpdatedAfterID *string `form:"updated_after_id"`
+	Tag            *string `form:"tag"`
+	// OpenEnded selects subscriptions without an end date when true and
+	// fixed-term ones when false.
+	OpenEnded *bool `form:"open_ended"`
+	// IncludeDeleted adds soft-deleted rows to the results. Only admins may
+	// set it.
+	IncludeDeleted  bool `form:"include_deleted"`
+	Limit           int  `form:"limit"`
+	Offset          int  `form:"offset"`
+	WithActiveCount bool `form:"with_active_count"`
+	// Metadata holds metadata.<key>=<value> query filters; it is filled by
+	// the handler since the keys are dynamic.
+	Metadata map[string]string `form:"-"`
+}
+
+// MaxServiceNameFilters and MaxUserIDFilters cap how many values one list
+// request may filter by.
+const (
+	MaxServiceNameFilters = 20
+	MaxUserIDFilters      = 50
+)
+
+// ServiceNames splits the service_name values on commas and drops blank
+// entries.
+func (r *ListSubscriptionsRequest) ServiceNames() []string {
+	return splitQueryValues(r.ServiceName)
+}
+
+// UserIDs splits the user_id values on commas and drops blank entries.
+func (r *ListSubscriptionsRequest) UserIDs() []string {
+	return splitQueryValues(r.UserID)
+}
+
+func splitQueryValues(values []string) []string {
+	var items []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+type ExportSubscriptionsRequest struct {
+	ListSubscriptionsRequest
+	// Sort is a column name, optionally prefixed with "-" for descending
+	// order. Rows are always tie-broken by id.
+	Sort string `form:"sort"`
+}
+
+type ServiceSubscriptionsRequest struct {
+	Limit  int `form:"limit"`
+	Offset int `form:"offset"`
+}
+
+// ServiceNamesRequest narrows the distinct service names to those starting
+// with Prefix, compared case-insensitively. A zero Limit returns them all.
+type ServiceNamesRequest struct {
+	Prefix string `form:"prefix"`
+	Limit  int    `form:"limit" binding:"omitempty,min=0"`
+}
+
+type ServiceNamesResponse struct {
+	Data []string `json:"data"`
+}
+
+// ServiceSubscriptionsResponse lists a service's subscriptions together with
+// aggregates over the ones active today. Revenue holds the monthly revenue
+// of each currency; MonthlyRevenue is the DefaultCurrency one in whole
+// units, the only revenue before subscriptions carried a currency.
+type ServiceSubscriptionsResponse struct {
+	ServiceName     string          `json:"service_name"`
+	SubscriberCount int64           `json:"subscriber_count"`
+	MonthlyRevenue  int64           `json:"monthly_revenue"`
+	Revenue         []CurrencyTotal `json:"revenue"`
+	Data            []*Subscription `json:"data"`
+	Total           int64           `json:"total"`
+	Limit           int             `json:"limit"`
+	Offset          int             `json:"offset"`
 }
 
 type TotalCostRequest struct {
 	UserID      *string `form:"user_id"`
 	ServiceName *string `form:"service_name"`
-	StartDate   string  `form:"start_date" binding:"required"`
-	EndDate     string  `form:"end_date" binding:"required"`
+	StartDate   string  `form:"start_date"`
+	EndDate     string  `form:"end_date"`
+	Period      string  `form:"period"`
+	// Currency selects the subscriptions to total and defaults to
+	// DefaultCurrency; subscriptions in other currencies are not included.
+	Currency *string `form:"currency"`
+	// GroupBy adds a per-group breakdown; only service_name is supported.
+	GroupBy string `form:"group_by"`
+	// Order sorts the breakdown: cost_desc (default), cost_asc or
+	// service_name.
+	Order  string `form:"order"`
+	Limit  int    `form:"limit"`
+	Offset int    `form:"offset"`
 }
 
+// ServiceCost is the cost of one service. TotalCost is in whole units,
+// rounded down, TotalCostMinor in minor units and Amount a decimal string.
+type ServiceCost struct {
+	ServiceName    string `json:"service_name"`
+	TotalCost      int64  `json:"total_cost"`
+	TotalCostMinor int64  `json:"total_cost_minor"`
+	Amount         string `json:"amount"`
+}
+
+// TotalCostBreakdown is one page of per-service costs. Total counts every
+// group, not just the ones on the page.
+type TotalCostBreakdown struct {
+	Data   []ServiceCost `json:"data"`
+	Total  int64         `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+}
+
+// TotalCostResponse carries the total in whole units of Currency, rounded
+// down, as it did before subscriptions carried a currency; TotalCostMinor
+// is the exact total in minor units and Amount the same as a decimal
+// string.
 type TotalCostResponse struct {
-	TotalCost int `json:"total_cost"`
+	TotalCost      int                 `json:"total_cost"`
+	TotalCostMinor int                 `json:"total_cost_minor"`
+	Currency       string              `json:"currency"`
+	Amount         string              `json:"amount"`
+	Breakdown      *TotalCostBreakdown `json:"breakdown,omitempty"`
+}
+
+const (
+	TotalCostGroupByService = "service_name"
+
+	TotalCostOrderCostDesc    = "cost_desc"
+	TotalCostOrderCostAsc     = "cost_asc"
+	TotalCostOrderServiceName = "service_name"
+)
+
+const (
+	HistoryActionRenewed    = "renewed"
+	HistoryActionReassigned = "reassigned"
+	HistoryActionAnonymized = "anonymized"
+)
+
+var ErrSubscriptionNotFound = errors.New("subscription not found")
+
+var ErrServiceNotFound = errors.New("service not found")
+
+var ErrPauseNotFound = errors.New("pause not found")
+
+// ErrDatabaseUnavailable means no database connection could be obtained in
+// time; the request may succeed if retried shortly.
+var ErrDatabaseUnavailable = errors.New("database unavailable")
+
+var ErrDuplicateSubscription = errors.New("user already has an active subscription for this service")
+
+var ErrSubscriptionIDConflict = errors.New("subscription id is already in use")
+
+// ErrSubscriptionModified means the subscription changed after it was read,
+// so a write based on that read was not applied.
+var ErrSubscriptionModified = errors.New("subscription was modified concurrently")
+
+// ErrWriteConflict means a write kept losing serialization conflicts to
+// concurrent ones and gave up; it may succeed if sent again.
+var ErrWriteConflict = errors.New("write conflicted with concurrent writes")
+
+// OffsetTooLargeError rejects offset pagination past the configured depth,
+// where the skipped rows make the query expensive.
+type OffsetTooLargeError struct {
+	Offset int
+	Max    int
+}
+
+func (e *OffsetTooLargeError) Error() string {
+	return fmt.Sprintf("offset %d exceeds the maximum of %d; use cursor pagination instead (updated_since delta pulls or /subscriptions/export)", e.Offset, e.Max)
+}
+
+// DuplicateSubscriptionError reports a uniqueness violation together with the
+// existing subscriptions it conflicts with.
+type DuplicateSubscriptionError struct {
+	Conflicts ValidationErrors
+}
+
+func (e *DuplicateSubscriptionError) Error() string {
+	return ErrDuplicateSubscription.Error()
+}
+
+func (e *DuplicateSubscriptionError) Unwrap() error {
+	return ErrDuplicateSubscription
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors collects every problem found in a request so they can be
+// reported together rather than one at a time.
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Field + ": " + fieldErr.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// SubscriptionWithWarnings is the create response when warnings are asked
+// for. Warnings flag input that is valid but looks like a mistake.
+type SubscriptionWithWarnings struct {
+	Subscription *Subscription `json:"subscription"`
+	Warnings     []FieldError  `json:"warnings"`
+}
+
+// ValidateSubscriptionResponse is the dry-run result. Problems would make the
+// write fail; warnings, such as an overlap while uniqueness is not enforced,
+// would not.
+type ValidateSubscriptionResponse struct {
+	Valid    bool         `json:"valid"`
+	Problems []FieldError `json:"problems"`
+	Warnings []FieldError `json:"warnings"`
 }
@@ -0,0 +1,32 @@
+package domain
+
+import "github.com/google/uuid"
+
+const (
+	MaxTagsPerSubscription = 20
+	MaxTagLength           = 50
+)
+
+// BulkTagFilter selects the subscriptions a bulk tag operation touches. A
+// filter naming neither IDs nor a user is broad and needs Confirm.
+type BulkTagFilter struct {
+	IDs         []uuid.UUID `json:"ids,omitempty"`
+	UserID      *uuid.UUID  `json:"user_id,omitempty"`
+	ServiceName *string     `json:"service_name,omitempty"`
+	Tag         *string     `json:"tag,omitempty"`
+}
+
+func (f *BulkTagFilter) IsBroad() bool {
+	return len(f.IDs) == 0 && f.UserID == nil
+}
+
+type BulkTagRequest struct {
+	Filter  BulkTagFilter `json:"filter"`
+	Add     []string      `json:"add,omitempty"`
+	Remove  []string      `json:"remove,omitempty"`
+	Confirm bool          `json:"confirm"`
+}
+
+type BulkTagResponse struct {
+	Affected int64 `json:"affected"`
+}
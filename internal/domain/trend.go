@@ -0,0 +1,32 @@
+package domain
+
+const (
+	TrendGranularityDay   = "day"
+	TrendGranularityWeek  = "week"
+	TrendGranularityMonth = "month"
+)
+
+// MaxTrendPoints caps how many intervals one trend request may span.
+const MaxTrendPoints = 1000
+
+// TrendRequest asks for the active subscription count over [Start, End],
+// one point per Granularity interval. The embedded list filters narrow the
+// subscriptions counted; their limit and offset are ignored.
+type TrendRequest struct {
+	ListSubscriptionsRequest
+	Start       string `form:"start"`
+	End         string `form:"end"`
+	Granularity string `form:"granularity"`
+}
+
+// TrendPoint is the number of subscriptions active on the last day of the
+// interval starting at Date, or on the trend's end date for the final one.
+type TrendPoint struct {
+	Date  string `json:"date"`
+	Count int64  `json:"count"`
+}
+
+type TrendResponse struct {
+	Granularity string       `json:"granularity"`
+	Data        []TrendPoint `json:"data"`
+}
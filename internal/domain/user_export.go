@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HistoryEntry is one recorded change to a subscription, such as a renewal
+// or a reassignment. Details is the action-specific payload, if any.
+type HistoryEntry struct {
+	ID             uuid.UUID       `json:"id"`
+	SubscriptionID uuid.UUID       `json:"subscription_id"`
+	Action         string          `json:"action"`
+	Details        json.RawMessage `json:"details,omitempty" swaggertype:"object"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// UserExportSubscription is one subscription in a user data export, with
+// everything recorded against it. Soft-deleted subscriptions are included
+// and marked by deleted_at.
+type UserExportSubscription struct {
+	Subscription
+	Pauses  []SubscriptionPause `json:"pauses"`
+	History []HistoryEntry      `json:"history"`
+}
+
+// UserExport is the document returned for a data subject access request:
+// every subscription ever held by UserID. A complete export carries
+// ExportedAt; one that failed after streaming began carries Error instead,
+// and its Subscriptions are incomplete.
+type UserExport struct {
+	UserID        uuid.UUID                `json:"user_id"`
+	ExportedAt    *time.Time               `json:"exported_at,omitempty"`
+	Error         string                   `json:"error,omitempty"`
+	Subscriptions []UserExportSubscription `json:"subscriptions"`
+}
@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"subscription-service/internal/clock"
+	"subscription-service/internal/domain"
+	"subscription-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+type AdminHandler struct {
+	db        *pgxpool.Pool
+	clock     clock.Clock
+	recompute service.RecomputeService
+	purge     service.PurgeService
+	startedAt time.Time
+	logger    *zap.Logger
+}
+
+func NewAdminHandler(db *pgxpool.Pool, clock clock.Clock, recompute service.RecomputeService, purge service.PurgeService, logger *zap.Logger) *AdminHandler {
+	return &AdminHandler{
+		db:        db,
+		clock:     clock,
+		recompute: recompute,
+		purge:     purge,
+		startedAt: clock.Now(),
+		logger:    logger,
+	}
+}
+
+type DiagnosticsResponse struct {
+	Goroutines    int               `json:"goroutines"`
+	UptimeSeconds float64           `json:"uptime_seconds"`
+	Memory        MemoryStats       `json:"memory"`
+	Database      DatabasePoolStats `json:"database"`
+}
+
+type MemoryStats struct {
+	HeapAllocBytes uint64 `json:"heap_alloc_bytes"`
+	HeapSysBytes   uint64 `json:"heap_sys_bytes"`
+	HeapObjects    uint64 `json:"heap_objects"`
+	NumGC          uint32 `json:"num_gc"`
+}
+
+type DatabasePoolStats struct {
+	TotalConns        int32 `json:"total_conns"`
+	IdleConns         int32 `json:"idle_conns"`
+	AcquiredConns     int32 `json:"acquired_conns"`
+	MaxConns          int32 `json:"max_conns"`
+	AcquireCount      int64 `json:"acquire_count"`
+	EmptyAcquireCount int64 `json:"empty_acquire_count"`
+}
+
+// Diagnostics reports goroutine, memory, GC, uptime and database pool stats
+// for environments where pprof is not exposed. It is served outside the
+// /api/v1 group and so is not part of the swagger docs.
+func (h *AdminHandler) Diagnostics(c *gin.Context) {
+	h.logger.Info("handler: diagnostics request")
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	pool := h.db.Stat()
+
+	c.JSON(http.StatusOK, DiagnosticsResponse{
+		Goroutines:    runtime.NumGoroutine(),
+		UptimeSeconds: h.clock.Now().Sub(h.startedAt).Seconds(),
+		Memory: MemoryStats{
+			HeapAllocBytes: mem.HeapAlloc,
+			HeapSysBytes:   mem.HeapSys,
+			HeapObjects:    mem.HeapObjects,
+			NumGC:          mem.NumGC,
+		},
+		Database: DatabasePoolStats{
+			TotalConns:        pool.TotalConns(),
+			IdleConns:         pool.IdleConns(),
+			AcquiredConns:     pool.AcquiredConns(),
+			MaxConns:          pool.MaxConns(),
+			AcquireCount:      pool.AcquireCount(),
+			EmptyAcquireCount: pool.EmptyAcquireCount(),
+		},
+	})
+}
+
+// Recompute recalculates derived subscription fields in batches. The body is
+// optional; see domain.RecomputeRequest for the filters and the cursor used
+// to resume a run that stopped early.
+func (h *AdminHandler) Recompute(c *gin.Context) {
+	h.logger.Info("handler: recompute request")
+
+	var req domain.RecomputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.recompute.Recompute(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("failed to recompute derived fields", zap.Error(err))
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Purge permanently removes subscriptions soft-deleted more than older_than
+// ago and reports how many went. older_than is a number of days such as 90d
+// or a Go duration such as 720h.
+func (h *AdminHandler) Purge(c *gin.Context) {
+	h.logger.Info("handler: purge request")
+
+	raw := c.Query("older_than")
+	if raw == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "older_than is required"})
+		return
+	}
+	olderThan, err := parseRetention(raw)
+	if err != nil {
+		h.logger.Error("invalid older_than parameter", zap.String("older_than", raw), zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.purge.Purge(c.Request.Context(), olderThan)
+	if err != nil {
+		h.logger.Error("failed to purge deleted subscriptions", zap.Error(err))
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// parseRetention accepts whole days written as <n>d as well as anything
+// time.ParseDuration understands.
+func parseRetention(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid older_than %q: days must be a whole number", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid older_than %q: use days such as 90d or a duration such as 720h", value)
+	}
+	return d, nil
+}
+
+// AdminAuth guards admin routes with a static bearer token. With no token
+// configured every admin request is refused.
+func AdminAuth(token string, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authorizeAdmin(c, token, logger) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// adminOnlyFlag lets a public route take a boolean query parameter that only
+// admins may turn on; requests setting it must carry the admin token.
+// Values that do not parse are left for the handler's binding to reject.
+func adminOnlyFlag(param, token string, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if enabled, err := strconv.ParseBool(c.Query(param)); err == nil && enabled {
+			if !authorizeAdmin(c, token, logger) {
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// authorizeAdmin checks the bearer token and aborts the request when it is
+// missing or wrong.
+func authorizeAdmin(c *gin.Context, token string, logger *zap.Logger) bool {
+	if token == "" {
+		logger.Warn("admin request rejected: no admin token configured", zap.String("path", c.Request.URL.Path))
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin endpoints are disabled"})
+		return false
+	}
+
+	provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+		logger.Warn("admin request rejected: invalid token", zap.String("path", c.Request.URL.Path))
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid admin token"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+func TestDiagnostics(t *testing.T) {
+	// The pool connects lazily, so its stats are readable without a
+	// database behind it.
+	pool, err := pgxpool.New(context.Background(), "postgres://diagnostics@127.0.0.1:1/none")
+	if err != nil {
+		t.Fatalf("pool: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	logger := zap.NewNop()
+	clock := newFakeClock(time.Date(2025, time.March, 15, 12, 0, 0, 0, time.UTC))
+	admin := NewAdminHandler(pool, clock, nil, nil, logger)
+	clock.Advance(90 * time.Second)
+
+	newRouter := func(token string) *gin.Engine {
+		router := gin.New()
+		SetupRoutes(router, newTestHandler(&fakeSubscriptionService{}), admin, nil, token, false, logger)
+		return router
+	}
+
+	t.Run("admin gate", func(t *testing.T) {
+		tests := []struct {
+			name          string
+			token         string
+			authorization string
+			wantStatus    int
+		}{
+			{name: "no token sent", token: testAdminToken, wantStatus: http.StatusUnauthorized},
+			{name: "wrong token", token: testAdminToken, authorization: "Bearer nope", wantStatus: http.StatusUnauthorized},
+			{name: "admin endpoints disabled", authorization: "Bearer " + testAdminToken, wantStatus: http.StatusForbidden},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rec := getDiagnostics(newRouter(tt.token), tt.authorization)
+				if rec.Code != tt.wantStatus {
+					t.Errorf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body)
+				}
+			})
+		}
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		rec := getDiagnostics(newRouter(testAdminToken), "Bearer "+testAdminToken)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+		}
+
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(rec.Body.Bytes(), &raw); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		assertKeys(t, "", raw, "goroutines", "uptime_seconds", "memory", "database")
+		for object, keys := range map[string][]string{
+			"memory":   {"heap_alloc_bytes", "heap_sys_bytes", "heap_objects", "num_gc"},
+			"database": {"total_conns", "idle_conns", "acquired_conns", "max_conns", "acquire_count", "empty_acquire_count"},
+		} {
+			var nested map[string]json.RawMessage
+			if err := json.Unmarshal(raw[object], &nested); err != nil {
+				t.Fatalf("decode %s: %v", object, err)
+			}
+			assertKeys(t, object, nested, keys...)
+		}
+
+		var got DiagnosticsResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if got.Goroutines < 1 {
+			t.Errorf("goroutines = %d, want at least 1", got.Goroutines)
+		}
+		if got.UptimeSeconds != 90 {
+			t.Errorf("uptime_seconds = %v, want 90", got.UptimeSeconds)
+		}
+		if got.Memory.HeapAllocBytes == 0 || got.Memory.HeapSysBytes < got.Memory.HeapAllocBytes {
+			t.Errorf("memory = %+v, want a non-zero heap no larger than its reservation", got.Memory)
+		}
+		if got.Database.MaxConns < 1 || got.Database.TotalConns != 0 {
+			t.Errorf("database = %+v, want an unused pool with a connection limit", got.Database)
+		}
+	})
+}
+
+func getDiagnostics(router *gin.Engine, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/admin/diagnostics", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func assertKeys(t *testing.T, object string, got map[string]json.RawMessage, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		if _, ok := got[key]; !ok {
+			t.Errorf("%q missing from %q", key, object)
+		}
+	}
+}
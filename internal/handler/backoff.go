@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"subscription-service/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	backoffKey            = "backoff"
+	defaultRetryAfterWait = time.Second
+)
+
+// Backoff makes the configured Retry-After available to every handler that
+// answers 503.
+func Backoff(cfg config.RetryAfterConfig) gin.HandlerFunc {
+	wait := cfg.Unavailable
+	if wait <= 0 {
+		wait = defaultRetryAfterWait
+	}
+
+	return func(c *gin.Context) {
+		c.Set(backoffKey, wait)
+		c.Next()
+	}
+}
+
+// writeUnavailable writes a 503 response with a Retry-After header, so every
+// unavailable answer tells clients how long to wait. Without the Backoff
+// middleware it falls back to one second.
+func writeUnavailable(c *gin.Context, body gin.H) {
+	wait := defaultRetryAfterWait
+	if value, ok := c.Get(backoffKey); ok {
+		wait = value.(time.Duration)
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	c.JSON(http.StatusServiceUnavailable, body)
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRetryAfterScenarios(t *testing.T) {
+	clock := newFakeClock(time.Date(2025, time.March, 15, 12, 0, 0, 0, time.UTC))
+	ready := func(err error) gin.HandlerFunc {
+		return newTestHealthHandler(&fakePinger{err: err}, config.HealthConfig{}, clock).Ready
+	}
+	fail := func(err error) gin.HandlerFunc {
+		return func(c *gin.Context) { writeError(c, err) }
+	}
+	poolExhausted := fmt.Errorf("%w: no connection available after 1s", domain.ErrDatabaseUnavailable)
+
+	scenarios := []struct {
+		name       string
+		handler    gin.HandlerFunc
+		wantStatus int
+		throttled  bool
+	}{
+		{name: "pool exhausted", handler: fail(poolExhausted), wantStatus: http.StatusServiceUnavailable, throttled: true},
+		{name: "readiness ping fails", handler: ready(errors.New("connection refused")), wantStatus: http.StatusServiceUnavailable, throttled: true},
+		{name: "readiness ping passes", handler: ready(nil), wantStatus: http.StatusOK},
+		{name: "internal error", handler: fail(errors.New("boom")), wantStatus: http.StatusInternalServerError},
+	}
+	waits := []struct {
+		name    string
+		backoff *config.RetryAfterConfig
+		want    string
+	}{
+		{name: "without the middleware", want: "1"},
+		{name: "unset", backoff: &config.RetryAfterConfig{}, want: "1"},
+		{name: "under a second rounds up", backoff: &config.RetryAfterConfig{Unavailable: 200 * time.Millisecond}, want: "1"},
+		{name: "fractional seconds round up", backoff: &config.RetryAfterConfig{Unavailable: 2500 * time.Millisecond}, want: "3"},
+		{name: "whole seconds", backoff: &config.RetryAfterConfig{Unavailable: 30 * time.Second}, want: "30"},
+	}
+
+	for _, scenario := range scenarios {
+		for _, wait := range waits {
+			t.Run(scenario.name+"/"+wait.name, func(t *testing.T) {
+				router := gin.New()
+				if wait.backoff != nil {
+					router.Use(Backoff(*wait.backoff))
+				}
+				router.GET("/", scenario.handler)
+
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+				if rec.Code != scenario.wantStatus {
+					t.Errorf("status = %d, want %d", rec.Code, scenario.wantStatus)
+				}
+				want := ""
+				if scenario.throttled {
+					want = wait.want
+				}
+				if got := rec.Header().Get("Retry-After"); got != want {
+					t.Errorf("Retry-After = %q, want %q", got, want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"subscription-service/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// BatchCreateSubscriptions godoc
+// @Summary Create subscriptions in bulk
+// @Description Create up to 100 subscriptions; each item succeeds or fails on its own. With an Idempotency-Key header the whole batch runs once: repeating the request with the same key returns the original response, marked by an Idempotent-Replayed header, and creates nothing.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string false "Key identifying this batch across retries, at most 255 characters"
+// @Param batch body domain.BatchCreateRequest true "Subscriptions to create"
+// @Success 200 {object} domain.BatchResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /subscriptions/batch [post]
+func (h *SubscriptionHandler) BatchCreateSubscriptions(c *gin.Context) {
+	h.logger.Info("handler: batch create request")
+
+	var req domain.BatchCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key := c.GetHeader("Idempotency-Key")
+	if key == "" {
+		response := h.batch.Create(c.Request.Context(), &req)
+		h.logger.Info("batch create finished", zap.Int("succeeded", response.Succeeded), zap.Int("failed", response.Failed))
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	if len(key) > domain.MaxIdempotencyKeyLength {
+		h.logger.Error("idempotency key too long", zap.Int("length", len(key)))
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Idempotency-Key must be at most %d characters", domain.MaxIdempotencyKeyLength)})
+		return
+	}
+
+	response, replayed, err := h.batch.CreateIdempotent(c.Request.Context(), key, &req)
+	if err != nil {
+		h.logger.Error("failed to run idempotent batch create", zap.String("idempotency_key", key), zap.Error(err))
+		writeError(c, err)
+		return
+	}
+
+	if replayed {
+		c.Header("Idempotent-Replayed", "true")
+	}
+	h.logger.Info("batch create finished", zap.Int("succeeded", response.Succeeded), zap.Int("failed", response.Failed), zap.Bool("replayed", replayed))
+	c.JSON(http.StatusOK, response)
+}
+
+// BatchUpdateSubscriptions godoc
+// @Summary Update subscriptions in bulk
+// @Description Update up to 100 subscriptions by id; each item succeeds or fails on its own
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param batch body domain.BatchUpdateRequest true "Updates keyed by subscription id"
+// @Success 200 {object} domain.BatchResponse
+// @Failure 400 {object} map[string]interface{}
+// @Router /subscriptions/batch [put]
+func (h *SubscriptionHandler) BatchUpdateSubscriptions(c *gin.Context) {
+	h.logger.Info("handler: batch update request")
+
+	var req domain.BatchUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := h.batch.Update(c.Request.Context(), &req)
+	h.logger.Info("batch update finished", zap.Int("succeeded", response.Succeeded), zap.Int("failed", response.Failed))
+	c.JSON(http.StatusOK, response)
+}
+
+// BatchDeleteSubscriptions godoc
+// @Summary Delete subscriptions in bulk
+// @Description Delete up to 100 subscriptions by id; each item succeeds or fails on its own
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param batch body domain.BatchDeleteRequest true "Subscription ids to delete"
+// @Success 200 {object} domain.BatchResponse
+// @Failure 400 {object} map[string]interface{}
+// @Router /subscriptions/batch/delete [post]
+func (h *SubscriptionHandler) BatchDeleteSubscriptions(c *gin.Context) {
+	h.logger.Info("handler: batch delete request")
+
+	var req domain.BatchDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := h.batch.Delete(c.Request.Context(), &req)
+	h.logger.Info("batch delete finished", zap.Int("succeeded", response.Succeeded), zap.Int("failed", response.Failed))
+	c.JSON(http.StatusOK, response)
+}
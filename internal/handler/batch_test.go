@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"subscription-service/internal/clock"
+	"subscription-service/internal/domain"
+	"subscription-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// newBatchRouter mounts the real routes with batches run by the real batch
+// service over svc.
+func newBatchRouter(svc service.SubscriptionService) *gin.Engine {
+	logger := zap.NewNop()
+	router := gin.New()
+	SetupRoutes(router, NewSubscriptionHandler(svc, service.NewBatchService(svc, 0, 0, clock.New(), logger), logger), nil, nil, testAdminToken, false, logger)
+	return router
+}
+
+func TestBatchSizeLimits(t *testing.T) {
+	// Binding rejects these, so the service is never reached.
+	router := newBatchRouter(&fakeSubscriptionService{})
+
+	ids := func(n int) string {
+		quoted := make([]string, n)
+		for i := range quoted {
+			quoted[i] = `"` + uuid.NewString() + `"`
+		}
+		return `{"ids":[` + strings.Join(quoted, ",") + `]}`
+	}
+	items := func(n int, item string) string {
+		return `{"items":[` + strings.TrimSuffix(strings.Repeat(item+",", n), ",") + `]}`
+	}
+	createItem := `{"service_name":"Netflix","price":400,"user_id":"` + uuid.NewString() + `","start_date":"2025-01-01"}`
+	updateItem := `{"id":"` + uuid.NewString() + `","price":500}`
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+	}{
+		{"create without items", http.MethodPost, "/api/v1/subscriptions/batch", `{}`},
+		{"create with no items", http.MethodPost, "/api/v1/subscriptions/batch", items(0, createItem)},
+		{"create past the limit", http.MethodPost, "/api/v1/subscriptions/batch", items(domain.MaxBatchSize+1, createItem)},
+		{"update with no items", http.MethodPut, "/api/v1/subscriptions/batch", items(0, updateItem)},
+		{"update past the limit", http.MethodPut, "/api/v1/subscriptions/batch", items(domain.MaxBatchSize+1, updateItem)},
+		{"delete with no ids", http.MethodPost, "/api/v1/subscriptions/batch/delete", ids(0)},
+		{"delete past the limit", http.MethodPost, "/api/v1/subscriptions/batch/delete", ids(domain.MaxBatchSize + 1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(router, tt.method, tt.path, tt.body, false)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400 (%s)", rec.Code, rec.Body)
+			}
+		})
+	}
+
+	t.Run("delete at the limit", func(t *testing.T) {
+		router := newBatchRouter(&fakeSubscriptionService{
+			delete: func(context.Context, uuid.UUID) error { return nil },
+		})
+		rec := do(router, http.MethodPost, "/api/v1/subscriptions/batch/delete", ids(domain.MaxBatchSize), false)
+		if rec.Code != http.StatusOK {
+			t.Errorf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+		}
+	})
+}
+
+func TestBatchResultSchema(t *testing.T) {
+	created := uuid.MustParse("00000000-0000-4000-8000-000000000001")
+	deleted := uuid.MustParse("00000000-0000-4000-8000-000000000002")
+	missing := uuid.MustParse("00000000-0000-4000-8000-000000000003")
+
+	router := newBatchRouter(&fakeSubscriptionService{
+		create: func(_ context.Context, req *domain.CreateSubscriptionRequest) (*domain.Subscription, error) {
+			if req.ServiceName == "" {
+				return nil, domain.ValidationErrors{{Field: "service_name", Message: "service_name is required"}}
+			}
+			return &domain.Subscription{ID: created}, nil
+		},
+		delete: func(_ context.Context, id uuid.UUID) error {
+			if id == missing {
+				return domain.ErrSubscriptionNotFound
+			}
+			return nil
+		},
+	})
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		want string
+	}{
+		{
+			name: "create",
+			path: "/api/v1/subscriptions/batch",
+			body: `{"items":[{"service_name":"Netflix"},{"service_name":""}]}`,
+			want: `{"results":[
+				{"index":0,"id":"` + created.String() + `","status":"created"},
+				{"index":1,"id":null,"status":"failed","error":"service_name: service_name is required"}
+			],"succeeded":1,"failed":1}`,
+		},
+		{
+			name: "delete",
+			path: "/api/v1/subscriptions/batch/delete",
+			body: `{"ids":["` + missing.String() + `","` + deleted.String() + `"]}`,
+			want: `{"results":[
+				{"index":0,"id":"` + missing.String() + `","status":"failed","error":"` + domain.ErrSubscriptionNotFound.Error() + `"},
+				{"index":1,"id":"` + deleted.String() + `","status":"deleted"}
+			],"succeeded":1,"failed":1}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(router, http.MethodPost, tt.path, tt.body, false)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+			}
+
+			var got, want interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatalf("decode want: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("body = %s, want %s", rec.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchResultsFollowRequestOrder(t *testing.T) {
+	// Every third item fails, so failures are spread through the batch.
+	idFor := func(name string) uuid.UUID { return uuid.NewSHA1(uuid.NameSpaceURL, []byte(name)) }
+	router := newBatchRouter(&fakeSubscriptionService{
+		create: func(_ context.Context, req *domain.CreateSubscriptionRequest) (*domain.Subscription, error) {
+			var index int
+			if _, err := fmt.Sscanf(req.ServiceName, "Service %d", &index); err != nil {
+				return nil, err
+			}
+			if index%3 == 0 {
+				return nil, domain.ValidationErrors{{Field: "service_name", Message: "rejected"}}
+			}
+			return &domain.Subscription{ID: idFor(req.ServiceName)}, nil
+		},
+	})
+
+	items := make([]string, domain.MaxBatchSize)
+	for i := range items {
+		items[i] = fmt.Sprintf(`{"service_name":"Service %d","price":400,"user_id":"%s","start_date":"2025-01-01"}`, i, uuid.NewString())
+	}
+	rec := do(router, http.MethodPost, "/api/v1/subscriptions/batch", `{"items":[`+strings.Join(items, ",")+`]}`, false)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", rec.Code, rec.Body)
+	}
+
+	var response domain.BatchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(response.Results) != domain.MaxBatchSize {
+		t.Fatalf("results = %d, want %d", len(response.Results), domain.MaxBatchSize)
+	}
+
+	failed := 0
+	for i, result := range response.Results {
+		if result.Index != i {
+			t.Errorf("results[%d].index = %d", i, result.Index)
+		}
+		if i%3 == 0 {
+			failed++
+			if result.Status != domain.BatchStatusFailed || result.ID != nil || result.Error == "" {
+				t.Errorf("results[%d] = %+v, want a failure without an id", i, result)
+			}
+			continue
+		}
+		if want := idFor(fmt.Sprintf("Service %d", i)); result.Status != domain.BatchStatusCreated || result.ID == nil || *result.ID != want {
+			t.Errorf("results[%d] = %+v, want created with id %s", i, result, want)
+		}
+	}
+	if response.Failed != failed || response.Succeeded != domain.MaxBatchSize-failed {
+		t.Errorf("succeeded = %d, failed = %d, want %d and %d", response.Succeeded, response.Failed, domain.MaxBatchSize-failed, failed)
+	}
+}
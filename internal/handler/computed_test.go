@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"subscription-service/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestGetSubscriptionExpand(t *testing.T) {
+	id := uuid.MustParse("00000000-0000-4000-8000-000000000001")
+	subscription := &domain.Subscription{ID: id, ServiceName: "Netflix", StartDate: "2025-01-01"}
+	days := 16
+
+	logger := zap.NewNop()
+	router := gin.New()
+	SetupRoutes(router, newTestHandler(&fakeSubscriptionService{
+		getByID: func(context.Context, uuid.UUID) (*domain.Subscription, error) { return subscription, nil },
+		getComputed: func(context.Context, uuid.UUID) (*domain.SubscriptionWithComputed, error) {
+			return &domain.SubscriptionWithComputed{
+				Subscription: subscription,
+				Computed:     domain.SubscriptionComputed{AsOf: "2025-03-15", IsActive: true, DaysUntilRenewal: &days, MonthsBilled: 3},
+			}, nil
+		},
+	}), nil, nil, testAdminToken, false, logger)
+
+	tests := []struct {
+		name         string
+		query        string
+		admin        bool
+		wantStatus   int
+		wantComputed bool
+	}{
+		{name: "default response is unchanged", wantStatus: http.StatusOK},
+		{name: "computed fields", query: "?expand=computed", wantStatus: http.StatusOK, wantComputed: true},
+		{name: "unknown expansion", query: "?expand=history", wantStatus: http.StatusBadRequest},
+		{name: "deleted rows cannot be expanded", query: "?include_deleted=true&expand=computed", admin: true, wantStatus: http.StatusBadRequest},
+		{name: "deleted rows still need the admin token", query: "?include_deleted=true&expand=computed", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(router, http.MethodGet, "/api/v1/subscriptions/"+id.String()+tt.query, "", tt.admin)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				ID       uuid.UUID                    `json:"id"`
+				Computed *domain.SubscriptionComputed `json:"computed"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body.ID != id {
+				t.Errorf("id = %s, want %s", body.ID, id)
+			}
+			if got := body.Computed != nil; got != tt.wantComputed {
+				t.Fatalf("computed present = %v, want %v", got, tt.wantComputed)
+			}
+			if tt.wantComputed && (!body.Computed.IsActive || body.Computed.DaysUntilRenewal == nil || *body.Computed.DaysUntilRenewal != days) {
+				t.Errorf("computed = %+v, want the service's fields", *body.Computed)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"subscription-service/internal/clock"
+	"subscription-service/internal/config"
+	"subscription-service/internal/repository"
+	"subscription-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// testDatabaseURLEnv names a Postgres database with the migrations applied,
+// as for the repository tests. Tests that need one are skipped when it is
+// unset, and the tables are emptied before each.
+const testDatabaseURLEnv = "TEST_DATABASE_URL"
+
+const testAdminToken = "test-admin-token"
+
+func newTestPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	url := os.Getenv(testDatabaseURLEnv)
+	if url == "" {
+		t.Skipf("%s is not set", testDatabaseURLEnv)
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	if _, err := pool.Exec(ctx, "TRUNCATE subscriptions, subscription_history, subscription_pauses, outbox_events"); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	return pool
+}
+
+// newTestRouter mounts the real routes over a repository on db, with admin
+// endpoints unlocked by testAdminToken.
+func newTestRouter(db repository.DB, cfg config.SubscriptionConfig) *gin.Engine {
+	logger := zap.NewNop()
+	repo := repository.NewSubscriptionRepository(db, repository.TxConfig{IsolationLevel: pgx.RepeatableRead, MaxRetries: 3}, cfg.EnforceUniqueActive, logger)
+	subscriptions := service.NewSubscriptionService(repo, service.NewSubscriptionValidator(repo, cfg, logger), cfg, clock.New(), logger)
+	batch := service.NewBatchService(subscriptions, 0, 0, clock.New(), logger)
+
+	router := gin.New()
+	SetupRoutes(router, NewSubscriptionHandler(subscriptions, batch, logger), nil, nil, testAdminToken, false, logger)
+	return router
+}
+
+// do sends one request through router, as the admin when admin is set.
+func do(router *gin.Engine, method, target, body string, admin bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if admin {
+		req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestIncludeDeleted(t *testing.T) {
+	pool := newTestPool(t)
+	router := newTestRouter(pool, config.SubscriptionConfig{})
+	userID := uuid.NewString()
+
+	create := func(service string) uuid.UUID {
+		t.Helper()
+		rec := do(router, http.MethodPost, "/api/v1/subscriptions", `{"service_name":"`+service+`","price":400,"user_id":"`+userID+`","start_date":"2025-01-01"}`, false)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("create %s: status = %d, body %s", service, rec.Code, rec.Body)
+		}
+		var sub domain.Subscription
+		if err := json.Unmarshal(rec.Body.Bytes(), &sub); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return sub.ID
+	}
+	live := create("Netflix")
+	deleted := create("Spotify")
+	if rec := do(router, http.MethodDelete, "/api/v1/subscriptions/"+deleted.String(), "", false); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	t.Run("get", func(t *testing.T) {
+		tests := []struct {
+			name        string
+			id          uuid.UUID
+			query       string
+			admin       bool
+			wantStatus  int
+			wantDeleted bool
+		}{
+			{name: "deleted row is gone by default", id: deleted, wantStatus: http.StatusNotFound},
+			{name: "deleted row is gone for admins too without the flag", id: deleted, admin: true, wantStatus: http.StatusNotFound},
+			{name: "the flag needs the admin token", id: deleted, query: "?include_deleted=true", wantStatus: http.StatusUnauthorized},
+			{name: "admin sees the deleted row, marked", id: deleted, query: "?include_deleted=true", admin: true, wantStatus: http.StatusOK, wantDeleted: true},
+			{name: "admin sees a live row unmarked", id: live, query: "?include_deleted=true", admin: true, wantStatus: http.StatusOK},
+			{name: "false is the default", id: deleted, query: "?include_deleted=false", wantStatus: http.StatusNotFound},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rec := do(router, http.MethodGet, "/api/v1/subscriptions/"+tt.id.String()+tt.query, "", tt.admin)
+				if rec.Code != tt.wantStatus {
+					t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body)
+				}
+				if tt.wantStatus != http.StatusOK {
+					return
+				}
+				var sub domain.Subscription
+				if err := json.Unmarshal(rec.Body.Bytes(), &sub); err != nil {
+					t.Fatalf("decode: %v", err)
+				}
+				if (sub.DeletedAt != nil) != tt.wantDeleted {
+					t.Errorf("deleted_at = %v, want set: %v", sub.DeletedAt, tt.wantDeleted)
+				}
+			})
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			query      string
+			admin      bool
+			wantStatus int
+			wantIDs    []uuid.UUID
+		}{
+			{name: "live rows by default", wantStatus: http.StatusOK, wantIDs: []uuid.UUID{live}},
+			{name: "the flag needs the admin token", query: "&include_deleted=true", wantStatus: http.StatusUnauthorized},
+			{name: "admin sees deleted rows, counted", query: "&include_deleted=true", admin: true, wantStatus: http.StatusOK, wantIDs: []uuid.UUID{live, deleted}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rec := do(router, http.MethodGet, "/api/v1/subscriptions?user_id="+userID+tt.query, "", tt.admin)
+				if rec.Code != tt.wantStatus {
+					t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body)
+				}
+				if tt.wantStatus != http.StatusOK {
+					return
+				}
+				var page struct {
+					Data  []domain.Subscription `json:"data"`
+					Total int64                 `json:"total"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+					t.Fatalf("decode: %v", err)
+				}
+				if page.Total != int64(len(tt.wantIDs)) || len(page.Data) != len(tt.wantIDs) {
+					t.Fatalf("total = %d with %d rows, want %d", page.Total, len(page.Data), len(tt.wantIDs))
+				}
+				got := make(map[uuid.UUID]bool, len(page.Data))
+				for _, sub := range page.Data {
+					got[sub.ID] = true
+					if (sub.DeletedAt != nil) != (sub.ID == deleted) {
+						t.Errorf("row %s deleted_at = %v", sub.ID, sub.DeletedAt)
+					}
+				}
+				for _, id := range tt.wantIDs {
+					if !got[id] {
+						t.Errorf("row %s missing", id)
+					}
+				}
+			})
+		}
+	})
+}
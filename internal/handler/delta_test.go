@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestListSubscriptionsDeltaHeaders(t *testing.T) {
+	changedAt := time.Date(2025, time.March, 1, 10, 0, 0, 123456000, time.UTC)
+	low := uuid.MustParse("00000000-0000-4000-8000-000000000001")
+	high := uuid.MustParse("00000000-0000-4000-8000-000000000002")
+
+	tests := []struct {
+		name       string
+		query      string
+		page       []*domain.Subscription
+		wantUpdate string
+		wantID     string
+	}{
+		{
+			name:  "ties on updated_at are broken by id",
+			query: "?updated_since=2025-03-01T00:00:00Z",
+			page: []*domain.Subscription{
+				{ID: high, UpdatedAt: changedAt, CreatedAt: changedAt},
+				{ID: low, UpdatedAt: changedAt, CreatedAt: changedAt},
+			},
+			wantUpdate: "2025-03-01T10:00:00.123456Z",
+			wantID:     high.String(),
+		},
+		{
+			name:  "newest updated_at wins over a larger id",
+			query: "?updated_since=2025-03-01T00:00:00Z",
+			page: []*domain.Subscription{
+				{ID: high, UpdatedAt: changedAt.Add(-time.Second), CreatedAt: changedAt},
+				{ID: low, UpdatedAt: changedAt, CreatedAt: changedAt},
+			},
+			wantUpdate: "2025-03-01T10:00:00.123456Z",
+			wantID:     low.String(),
+		},
+		{
+			name:       "empty page echoes the cursor",
+			query:      "?updated_since=2025-03-01T10:00:00.123456Z&updated_after_id=" + low.String(),
+			wantUpdate: "2025-03-01T10:00:00.123456Z",
+			wantID:     low.String(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(&fakeSubscriptionService{
+				list: func(ctx context.Context, req *domain.ListSubscriptionsRequest) ([]*domain.Subscription, int64, error) {
+					return tt.page, int64(len(tt.page)), nil
+				},
+			})
+
+			rec := serve(http.MethodGet, "/subscriptions", "/subscriptions"+tt.query, "", nil, h.ListSubscriptions)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get("X-Latest-Update"); got != tt.wantUpdate {
+				t.Errorf("X-Latest-Update = %q, want %q", got, tt.wantUpdate)
+			}
+			if got := rec.Header().Get("X-Latest-Update-ID"); got != tt.wantID {
+				t.Errorf("X-Latest-Update-ID = %q, want %q", got, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestListSubscriptionsDeltaHidesDeletions(t *testing.T) {
+	pool := newTestPool(t)
+	router := newTestRouter(pool, config.SubscriptionConfig{})
+	since := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+	create := func(service string) uuid.UUID {
+		t.Helper()
+		rec := do(router, http.MethodPost, "/api/v1/subscriptions", `{"service_name":"`+service+`","price":400,"user_id":"`+uuid.NewString()+`","start_date":"2025-01-01"}`, false)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("create %s: status = %d, body %s", service, rec.Code, rec.Body)
+		}
+		var sub domain.Subscription
+		if err := json.Unmarshal(rec.Body.Bytes(), &sub); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return sub.ID
+	}
+	kept := create("Netflix")
+	deleted := create("Spotify")
+	if rec := do(router, http.MethodDelete, "/api/v1/subscriptions/"+deleted.String(), "", false); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	tests := []struct {
+		name        string
+		query       string
+		admin       bool
+		wantStatus  int
+		wantIDs     []uuid.UUID
+		wantDeleted string
+	}{
+		{
+			name:        "anonymous pull sees only live rows",
+			query:       "?updated_since=" + since,
+			wantStatus:  http.StatusOK,
+			wantIDs:     []uuid.UUID{kept},
+			wantDeleted: "0",
+		},
+		{
+			name:       "deletions need the admin token",
+			query:      "?include_deleted=true&updated_since=" + since,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:        "admin pull sees deletions",
+			query:       "?include_deleted=true&updated_since=" + since,
+			admin:       true,
+			wantStatus:  http.StatusOK,
+			wantIDs:     []uuid.UUID{kept, deleted},
+			wantDeleted: "1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(router, http.MethodGet, "/api/v1/subscriptions"+tt.query, "", tt.admin)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var page struct {
+				Data []domain.Subscription `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			got := make(map[uuid.UUID]bool, len(page.Data))
+			for _, sub := range page.Data {
+				got[sub.ID] = true
+				if sub.ID == deleted && sub.DeletedAt == nil {
+					t.Error("deleted row has no deleted_at")
+				}
+			}
+			if len(got) != len(tt.wantIDs) {
+				t.Errorf("got %d rows, want %d", len(got), len(tt.wantIDs))
+			}
+			for _, id := range tt.wantIDs {
+				if !got[id] {
+					t.Errorf("row %s missing", id)
+				}
+			}
+			if got := rec.Header().Get("X-Deleted-Count"); got != tt.wantDeleted {
+				t.Errorf("X-Deleted-Count = %q, want %q", got, tt.wantDeleted)
+			}
+		})
+	}
+}
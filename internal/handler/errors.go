@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"subscription-service/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeError maps a service error to its HTTP response. Malformed input is
+// rejected with 400 by the handlers before the service runs; everything the
+// service reports as a rule violation on well-formed input is a 422. Paging
+// past the maximum offset is the exception: it is a 400, like any other
+// request the client has to reshape rather than correct.
+func writeError(c *gin.Context, err error) {
+	var problems domain.ValidationErrors
+	var duplicate *domain.DuplicateSubscriptionError
+	var offsetTooLarge *domain.OffsetTooLargeError
+
+	switch {
+	case errors.As(err, &problems):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "details": problems})
+	case errors.As(err, &offsetTooLarge):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "max_offset": offsetTooLarge.Max})
+	case errors.As(err, &duplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "details": duplicate.Conflicts})
+	case errors.Is(err, domain.ErrIdempotencyKeyReused):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrSubscriptionIDConflict),
+		errors.Is(err, domain.ErrSubscriptionModified),
+		errors.Is(err, domain.ErrWriteConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrSubscriptionNotFound),
+		errors.Is(err, domain.ErrPauseNotFound),
+		errors.Is(err, domain.ErrServiceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrDatabaseUnavailable),
+		errors.Is(err, domain.ErrIdempotencyKeysExhausted):
+		writeUnavailable(c, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestWriteErrorUnavailable(t *testing.T) {
+	unavailable := fmt.Errorf("%w: no connection available after 1s", domain.ErrDatabaseUnavailable)
+
+	tests := []struct {
+		name           string
+		backoff        *config.RetryAfterConfig
+		err            error
+		wantStatus     int
+		wantRetryAfter string
+	}{
+		{name: "default wait", err: unavailable, wantStatus: http.StatusServiceUnavailable, wantRetryAfter: "1"},
+		{name: "configured wait rounds up", backoff: &config.RetryAfterConfig{Unavailable: 2500 * time.Millisecond}, err: unavailable, wantStatus: http.StatusServiceUnavailable, wantRetryAfter: "3"},
+		{name: "other failures are not throttling", err: errors.New("boom"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			if tt.backoff != nil {
+				router.Use(Backoff(*tt.backoff))
+			}
+			router.GET("/", func(c *gin.Context) { writeError(c, tt.err) })
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestSaturatedPoolIsUnavailable(t *testing.T) {
+	url := os.Getenv(testDatabaseURLEnv)
+	if url == "" {
+		t.Skipf("%s is not set", testDatabaseURLEnv)
+	}
+
+	ctx := context.Background()
+	poolConfig, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("parse %s: %v", testDatabaseURLEnv, err)
+	}
+	poolConfig.MaxConns = 1
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer pool.Close()
+
+	held, err := pool.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer held.Release()
+
+	router := newTestRouter(repository.WithAcquireTimeout(pool, 50*time.Millisecond), config.SubscriptionConfig{})
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+	}{
+		{name: "read", method: http.MethodGet, target: "/api/v1/subscriptions/" + uuid.NewString()},
+		{name: "list", method: http.MethodGet, target: "/api/v1/subscriptions"},
+		{name: "write", method: http.MethodPost, target: "/api/v1/subscriptions", body: `{"service_name":"Netflix","price":400,"user_id":"` + uuid.NewString() + `","start_date":"2025-01-01"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(router, tt.method, tt.target, tt.body, false)
+
+			if rec.Code != http.StatusServiceUnavailable {
+				t.Errorf("status = %d (%s), want 503", rec.Code, rec.Body)
+			}
+			if rec.Header().Get("Retry-After") == "" {
+				t.Error("503 without Retry-After")
+			}
+		})
+	}
+}
+
+func TestCreateSubscriptionMalformedVersusInvalid(t *testing.T) {
+	// Every request here fails before the repository is reached.
+	router := newTestRouter(nil, config.SubscriptionConfig{})
+	userID := uuid.NewString()
+
+	tests := []struct {
+		name        string
+		body        string
+		wantStatus  int
+		wantDetails []string
+	}{
+		{name: "truncated JSON", body: `{"service_name":"Netflix",`, wantStatus: http.StatusBadRequest},
+		{name: "wrong JSON type", body: `{"service_name":"Netflix","price":"400","user_id":"` + userID + `","start_date":"2025-01-01"}`, wantStatus: http.StatusBadRequest},
+		{name: "malformed user id", body: `{"service_name":"Netflix","price":400,"user_id":"42","start_date":"2025-01-01"}`, wantStatus: http.StatusBadRequest},
+		{name: "missing service name", body: `{"price":400,"user_id":"` + userID + `","start_date":"2025-01-01"}`, wantStatus: http.StatusBadRequest},
+		// Price and user_id are not required by binding: the price may come
+		// as price_minor or amount, and user_id may have a configured default.
+		{
+			name:        "missing price",
+			body:        `{"service_name":"Netflix","user_id":"` + userID + `","start_date":"2025-01-01"}`,
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantDetails: []string{"price"},
+		},
+		{
+			name:        "missing user id",
+			body:        `{"service_name":"Netflix","price":400,"start_date":"2025-01-01"}`,
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantDetails: []string{"user_id"},
+		},
+		{
+			name:        "end before start",
+			body:        `{"service_name":"Netflix","price":400,"user_id":"` + userID + `","start_date":"2025-06-01","end_date":"2025-01-01"}`,
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantDetails: []string{"end_date"},
+		},
+		{
+			name:        "every problem is listed",
+			body:        `{"service_name":"Netflix","price":-1,"user_id":"` + userID + `","start_date":"2025-02-30","end_date":"2025-13"}`,
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantDetails: []string{"price", "start_date", "end_date"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(router, http.MethodPost, "/api/v1/subscriptions", tt.body, false)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body)
+			}
+
+			var body struct {
+				Error   string              `json:"error"`
+				Details []domain.FieldError `json:"details"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if body.Error == "" {
+				t.Error("error message missing")
+			}
+			var got []string
+			for _, detail := range body.Details {
+				got = append(got, detail.Field)
+			}
+			if !reflect.DeepEqual(got, tt.wantDetails) {
+				t.Errorf("details = %v, want %v", got, tt.wantDetails)
+			}
+		})
+	}
+}
+
+func TestListSubscriptionsOffsetTooLarge(t *testing.T) {
+	h := newTestHandler(&fakeSubscriptionService{
+		list: func(_ context.Context, req *domain.ListSubscriptionsRequest) ([]*domain.Subscription, int64, error) {
+			return nil, 0, &domain.OffsetTooLargeError{Offset: req.Offset, Max: 1000}
+		},
+	})
+
+	rec := serve(http.MethodGet, "/subscriptions", "/subscriptions?offset=1001", "", nil, h.ListSubscriptions)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400 (%s)", rec.Code, rec.Body)
+	}
+	var body struct {
+		Error     string `json:"error"`
+		MaxOffset int    `json:"max_offset"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.MaxOffset != 1000 || !strings.Contains(body.Error, "cursor pagination") {
+		t.Errorf("body = %+v, want max_offset 1000 and a pointer to cursor pagination", body)
+	}
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestExportSubscriptionsIsReproducible(t *testing.T) {
+	pool := newTestPool(t)
+	router := newTestRouter(pool, config.SubscriptionConfig{})
+
+	// Every row shares created_at and the prices repeat, so only the id
+	// tiebreaker makes the order total; metadata has several keys to show
+	// they are always written in the same order.
+	if _, err := pool.Exec(context.Background(), `
+		INSERT INTO subscriptions (service_name, price, user_id, start_date, metadata, tags, created_at, updated_at)
+		SELECT 'service-' || (g % 5), 100 * (1 + g % 3), gen_random_uuid(), DATE '2025-01-01',
+		       jsonb_build_object('b', g, 'a', 'x', 'c', g % 2 = 0), ARRAY['t' || (g % 4)],
+		       TIMESTAMPTZ '2025-01-01 00:00:00+00', TIMESTAMPTZ '2025-01-01 00:00:00+00'
+		FROM generate_series(1, 1200) AS g`); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "default order"},
+		{name: "sorted by price", query: "?sort=price"},
+		{name: "sorted by descending service name", query: "?sort=-service_name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := do(router, http.MethodGet, "/api/v1/subscriptions/export"+tt.query, "", false)
+			second := do(router, http.MethodGet, "/api/v1/subscriptions/export"+tt.query, "", false)
+
+			if first.Code != http.StatusOK || second.Code != http.StatusOK {
+				t.Fatalf("status = %d, %d, want 200 (%s)", first.Code, second.Code, first.Body)
+			}
+			if lines := bytes.Count(first.Body.Bytes(), []byte("\n")); lines != 1200 {
+				t.Errorf("exported %d rows, want 1200", lines)
+			}
+			if !bytes.Equal(first.Body.Bytes(), second.Body.Bytes()) {
+				t.Error("two exports of unchanged data differ")
+			}
+		})
+	}
+}
+
+func TestExportSubscriptionsFailureMidStream(t *testing.T) {
+	stored := []*domain.Subscription{
+		{ID: uuid.New(), ServiceName: "Netflix", Tags: []string{}},
+		{ID: uuid.New(), ServiceName: "Spotify", Tags: []string{}},
+		{ID: uuid.New(), ServiceName: "GitHub", Tags: []string{}},
+	}
+
+	tests := []struct {
+		name string
+		// failAfter makes the export fail once that many subscriptions
+		// were handed over; negative means it succeeds.
+		failAfter  int
+		wantStatus int
+		wantRows   int
+		wantError  bool
+	}{
+		{name: "complete export has no error line", failAfter: -1, wantStatus: http.StatusOK, wantRows: 3},
+		{name: "failure before anything is written", failAfter: 0, wantStatus: http.StatusServiceUnavailable},
+		{name: "failure mid-stream ends with an error line", failAfter: 2, wantStatus: http.StatusOK, wantRows: 2, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newBatchRouter(&fakeSubscriptionService{
+				export: func(_ context.Context, _ *domain.ExportSubscriptionsRequest, fn func(*domain.Subscription) error) error {
+					for i, subscription := range stored {
+						if i == tt.failAfter {
+							return fmt.Errorf("%w: connection lost", domain.ErrDatabaseUnavailable)
+						}
+						if err := fn(subscription); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+			})
+
+			rec := do(router, http.MethodGet, "/api/v1/subscriptions/export", "", false)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			lines := bytes.Split(bytes.TrimSuffix(rec.Body.Bytes(), []byte("\n")), []byte("\n"))
+			if tt.wantError {
+				var last struct {
+					Error string `json:"error"`
+				}
+				if err := json.Unmarshal(lines[len(lines)-1], &last); err != nil || last.Error == "" {
+					t.Fatalf("last line = %s, want an error line", lines[len(lines)-1])
+				}
+				lines = lines[:len(lines)-1]
+			}
+			if len(lines) != tt.wantRows {
+				t.Fatalf("rows = %d, want %d", len(lines), tt.wantRows)
+			}
+			for i, line := range lines {
+				var got domain.Subscription
+				if err := json.Unmarshal(line, &got); err != nil {
+					t.Fatalf("decode row %d %s: %v", i, line, err)
+				}
+				if got.ID != stored[i].ID {
+					t.Errorf("row %d id = %s, want %s", i, got.ID, stored[i].ID)
+				}
+			}
+		})
+	}
+}
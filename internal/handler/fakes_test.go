@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"subscription-service/internal/domain"
+	"subscription-service/internal/jsonpatch"
+	"subscription-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// fakeClock is a clock.Clock that only moves when told to.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// fakeSubscriptionService implements the service methods a test sets;
+// calling any other method panics on the nil embedded interface.
+type fakeSubscriptionService struct {
+	service.SubscriptionService
+
+	create         func(ctx context.Context, req *domain.CreateSubscriptionRequest) (*domain.Subscription, error)
+	getByID        func(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)
+	getComputed    func(ctx context.Context, id uuid.UUID) (*domain.SubscriptionWithComputed, error)
+	list           func(ctx context.Context, req *domain.ListSubscriptionsRequest) ([]*domain.Subscription, int64, error)
+	update         func(ctx context.Context, id uuid.UUID, req *domain.UpdateSubscriptionRequest) (*domain.Subscription, error)
+	put            func(ctx context.Context, id uuid.UUID, req *domain.CreateSubscriptionRequest) (*domain.Subscription, bool, error)
+	patch          func(ctx context.Context, id uuid.UUID, patch []jsonpatch.Operation) (*domain.Subscription, error)
+	delete         func(ctx context.Context, id uuid.UUID) error
+	createWarnings func(req *domain.CreateSubscriptionRequest) []domain.FieldError
+	exportUser     func(ctx context.Context, userID uuid.UUID, fn func(*domain.UserExportSubscription) error) (time.Time, error)
+	export         func(ctx context.Context, req *domain.ExportSubscriptionsRequest, fn func(*domain.Subscription) error) error
+	reassignUser   func(ctx context.Context, req *domain.ReassignUserRequest) (*domain.ReassignUserResponse, error)
+	bulkTag        func(ctx context.Context, req *domain.BulkTagRequest) (*domain.BulkTagResponse, error)
+}
+
+func (s *fakeSubscriptionService) Create(ctx context.Context, req *domain.CreateSubscriptionRequest) (*domain.Subscription, error) {
+	return s.create(ctx, req)
+}
+
+func (s *fakeSubscriptionService) CreateWarnings(req *domain.CreateSubscriptionRequest) []domain.FieldError {
+	return s.createWarnings(req)
+}
+
+func (s *fakeSubscriptionService) ExportUser(ctx context.Context, userID uuid.UUID, fn func(*domain.UserExportSubscription) error) (time.Time, error) {
+	return s.exportUser(ctx, userID, fn)
+}
+
+func (s *fakeSubscriptionService) Export(ctx context.Context, req *domain.ExportSubscriptionsRequest, fn func(*domain.Subscription) error) error {
+	return s.export(ctx, req, fn)
+}
+
+func (s *fakeSubscriptionService) BulkTag(ctx context.Context, req *domain.BulkTagRequest) (*domain.BulkTagResponse, error) {
+	return s.bulkTag(ctx, req)
+}
+
+func (s *fakeSubscriptionService) ReassignUser(ctx context.Context, req *domain.ReassignUserRequest) (*domain.ReassignUserResponse, error) {
+	return s.reassignUser(ctx, req)
+}
+
+func (s *fakeSubscriptionService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	return s.getByID(ctx, id)
+}
+
+func (s *fakeSubscriptionService) GetComputed(ctx context.Context, id uuid.UUID) (*domain.SubscriptionWithComputed, error) {
+	return s.getComputed(ctx, id)
+}
+
+func (s *fakeSubscriptionService) List(ctx context.Context, req *domain.ListSubscriptionsRequest) ([]*domain.Subscription, int64, error) {
+	return s.list(ctx, req)
+}
+
+func (s *fakeSubscriptionService) Update(ctx context.Context, id uuid.UUID, req *domain.UpdateSubscriptionRequest) (*domain.Subscription, error) {
+	return s.update(ctx, id, req)
+}
+
+func (s *fakeSubscriptionService) Put(ctx context.Context, id uuid.UUID, req *domain.CreateSubscriptionRequest) (*domain.Subscription, bool, error) {
+	return s.put(ctx, id, req)
+}
+
+func (s *fakeSubscriptionService) Patch(ctx context.Context, id uuid.UUID, patch []jsonpatch.Operation) (*domain.Subscription, error) {
+	return s.patch(ctx, id, patch)
+}
+
+func (s *fakeSubscriptionService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.delete(ctx, id)
+}
+
+func newTestHandler(svc service.SubscriptionService) *SubscriptionHandler {
+	return NewSubscriptionHandler(svc, nil, zap.NewNop())
+}
+
+// serve runs one request through a router with handler mounted at path.
+func serve(method, path, target, body string, headers map[string]string, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Handle(method, path, handler)
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"subscription-service/internal/domain"
+)
+
+var subscriptionFields = tagFieldNames(reflect.TypeOf(domain.Subscription{}), "json")
+
+// tagFieldNames collects the names given to the fields of t by the struct
+// tag key, skipping untagged and "-" fields.
+func tagFieldNames(t reflect.Type, key string) map[string]struct{} {
+	fields := make(map[string]struct{}, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get(key)
+		name := strings.Split(tag, ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = struct{}{}
+	}
+	return fields
+}
+
+// parseFields parses a comma-separated ?fields= value and validates every
+// entry against the known subscription JSON fields.
+func parseFields(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(raw, ",")
+	fields := make([]string, 0, len(parts))
+	for _, part := range parts {
+		field := strings.TrimSpace(part)
+		if field == "" {
+			continue
+		}
+		if _, ok := subscriptionFields[field]; !ok {
+			return nil, fmt.Errorf("unknown field: %s", field)
+		}
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+func projectSubscription(subscription *domain.Subscription, fields []string) (map[string]interface{}, error) {
+	data, err := json.Marshal(subscription)
+	if err != nil {
+		return nil, err
+	}
+
+	var full map[string]interface{}
+	if err := json.Unmarshal(data, &full); err != nil {
+		return nil, err
+	}
+
+	projected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if value, ok := full[field]; ok {
+			projected[field] = value
+		}
+	}
+
+	return projected, nil
+}
+
+func projectSubscriptions(subscriptions []*domain.Subscription, fields []string) ([]map[string]interface{}, error) {
+	result := make([]map[string]interface{}, len(subscriptions))
+	for i, subscription := range subscriptions {
+		projected, err := projectSubscription(subscription, fields)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = projected
+	}
+	return result, nil
+}
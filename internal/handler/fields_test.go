@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty means every field", raw: ""},
+		{name: "blank means every field", raw: "  "},
+		{name: "subset in the order given", raw: "price,id,service_name", want: []string{"price", "id", "service_name"}},
+		{name: "spaces and empty entries are ignored", raw: " id , ,price,", want: []string{"id", "price"}},
+		{name: "unknown field", raw: "id,password", wantErr: true},
+		{name: "field names are case-sensitive", raw: "ID", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFields(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseFields(%q) = %v, want an error", tt.raw, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFields(%q): %v", tt.raw, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFields(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProjectSubscription(t *testing.T) {
+	subscription := &domain.Subscription{
+		ID:          uuid.MustParse("00000000-0000-4000-8000-000000000001"),
+		ServiceName: "Netflix",
+		Price:       4,
+		PriceMinor:  400,
+		Currency:    "RUB",
+	}
+
+	// end_date is omitted from the JSON when unset, so asking for it
+	// leaves it out rather than reporting null.
+	projected, err := projectSubscription(subscription, []string{"service_name", "price_minor", "end_date"})
+	if err != nil {
+		t.Fatalf("projectSubscription: %v", err)
+	}
+	want := map[string]interface{}{"service_name": "Netflix", "price_minor": float64(400)}
+	if !reflect.DeepEqual(projected, want) {
+		t.Errorf("projected = %v, want %v", projected, want)
+	}
+}
+
+func TestGetSubscriptionFields(t *testing.T) {
+	id := uuid.MustParse("00000000-0000-4000-8000-000000000001")
+
+	tests := []struct {
+		name       string
+		fields     string
+		wantStatus int
+		wantKeys   []string
+	}{
+		{name: "subset", fields: "id,service_name", wantStatus: http.StatusOK, wantKeys: []string{"id", "service_name"}},
+		{name: "unknown field", fields: "id,password", wantStatus: http.StatusBadRequest, wantKeys: []string{"error"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(&fakeSubscriptionService{
+				getByID: func(context.Context, uuid.UUID) (*domain.Subscription, error) {
+					return &domain.Subscription{ID: id, ServiceName: "Netflix", PriceMinor: 400, Currency: "RUB"}, nil
+				},
+			})
+
+			rec := serve(http.MethodGet, "/subscriptions/:id", "/subscriptions/"+id.String()+"?fields="+tt.fields, "", nil, h.GetSubscription)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d (%s), want %d", rec.Code, rec.Body, tt.wantStatus)
+			}
+
+			var body map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			keys := make([]string, 0, len(body))
+			for key := range body {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			if !reflect.DeepEqual(keys, tt.wantKeys) {
+				t.Errorf("keys = %v, want %v", keys, tt.wantKeys)
+			}
+		})
+	}
+}
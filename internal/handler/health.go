@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"subscription-service/internal/clock"
+	"subscription-service/internal/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPingTimeout     = time.Second
+	defaultHealthCacheTTL  = 2 * time.Second
+	defaultFailureCacheTTL = 500 * time.Millisecond
+)
+
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// HealthHandler serves the liveness and readiness probes. Readiness pings
+// the database, but reuses a recent result so frequent probing does not
+// turn into a steady stream of pings.
+type HealthHandler struct {
+	db              pinger
+	clock           clock.Clock
+	pingTimeout     time.Duration
+	cacheTTL        time.Duration
+	failureCacheTTL time.Duration
+	logger          *zap.Logger
+
+	mu        sync.Mutex
+	lastErr   error
+	checkedAt time.Time
+}
+
+func NewHealthHandler(db *pgxpool.Pool, cfg config.HealthConfig, clock clock.Clock, logger *zap.Logger) *HealthHandler {
+	h := &HealthHandler{
+		db:              db,
+		clock:           clock,
+		pingTimeout:     cfg.PingTimeout,
+		cacheTTL:        cfg.CacheTTL,
+		failureCacheTTL: cfg.FailureCacheTTL,
+		logger:          logger,
+	}
+	if h.pingTimeout <= 0 {
+		h.pingTimeout = defaultPingTimeout
+	}
+	if h.cacheTTL <= 0 {
+		h.cacheTTL = defaultHealthCacheTTL
+	}
+	if h.failureCacheTTL <= 0 || h.failureCacheTTL > h.cacheTTL {
+		h.failureCacheTTL = min(defaultFailureCacheTTL, h.cacheTTL)
+	}
+	return h
+}
+
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Ready answers 200 when the database is reachable and 503 when it is not.
+func (h *HealthHandler) Ready(c *gin.Context) {
+	if err := h.check(c.Request.Context()); err != nil {
+		writeUnavailable(c, gin.H{"status": "unavailable", "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// check returns the cached ping result while it is fresh and pings
+// otherwise. The lock is held during the ping so concurrent probes wait for
+// one ping instead of each sending their own. The ping is detached from the
+// probe's own cancellation, so a client that hangs up cannot leave behind a
+// cached failure that says nothing about the database.
+func (h *HealthHandler) check(ctx context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ttl := h.cacheTTL
+	if h.lastErr != nil {
+		ttl = h.failureCacheTTL
+	}
+	if !h.checkedAt.IsZero() && h.clock.Now().Sub(h.checkedAt) < ttl {
+		return h.lastErr
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), h.pingTimeout)
+	defer cancel()
+
+	err := h.db.Ping(ctx)
+	if err != nil {
+		h.logger.Warn("readiness check failed", zap.Error(err))
+	}
+	h.lastErr = err
+	h.checkedAt = h.clock.Now()
+	return err
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"subscription-service/internal/config"
+
+	"go.uber.org/zap"
+)
+
+// fakePinger counts pings and answers each with err, or, when block is set,
+// waits for the ping's context to end. Like a real pool, it fails a ping
+// whose context is already done.
+type fakePinger struct {
+	pings    int
+	err      error
+	block    bool
+	deadline time.Time
+}
+
+func (p *fakePinger) Ping(ctx context.Context) error {
+	p.pings++
+	p.deadline, _ = ctx.Deadline()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if p.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return p.err
+}
+
+func newTestHealthHandler(db *fakePinger, cfg config.HealthConfig, clock *fakeClock) *HealthHandler {
+	h := NewHealthHandler(nil, cfg, clock, zap.NewNop())
+	h.db = db
+	return h
+}
+
+func TestReadinessCache(t *testing.T) {
+	clock := newFakeClock(time.Date(2025, time.March, 15, 12, 0, 0, 0, time.UTC))
+	db := &fakePinger{}
+	h := newTestHealthHandler(db, config.HealthConfig{CacheTTL: 2 * time.Second, FailureCacheTTL: 500 * time.Millisecond}, clock)
+
+	steps := []struct {
+		name       string
+		advance    time.Duration
+		err        error
+		wantStatus int
+		wantPings  int
+	}{
+		{name: "first probe pings", wantStatus: http.StatusOK, wantPings: 1},
+		{name: "passing result is reused", advance: 1999 * time.Millisecond, wantStatus: http.StatusOK, wantPings: 1},
+		{name: "passing result expires after its TTL", advance: time.Millisecond, wantStatus: http.StatusOK, wantPings: 2},
+		{name: "database goes down, cached pass still served", advance: time.Second, err: errors.New("connection refused"), wantStatus: http.StatusOK, wantPings: 2},
+		{name: "failure is seen once the pass expires", advance: time.Second, err: errors.New("connection refused"), wantStatus: http.StatusServiceUnavailable, wantPings: 3},
+		{name: "failing result is reused", advance: 499 * time.Millisecond, wantStatus: http.StatusServiceUnavailable, wantPings: 3},
+		{name: "failing result expires sooner", advance: time.Millisecond, wantStatus: http.StatusOK, wantPings: 4},
+	}
+
+	for _, step := range steps {
+		clock.Advance(step.advance)
+		db.err = step.err
+		rec := serve(http.MethodGet, "/ready", "/ready", "", nil, h.Ready)
+		if rec.Code != step.wantStatus {
+			t.Errorf("%s: status = %d, want %d", step.name, rec.Code, step.wantStatus)
+		}
+		if db.pings != step.wantPings {
+			t.Errorf("%s: pings = %d, want %d", step.name, db.pings, step.wantPings)
+		}
+		if rec.Code == http.StatusServiceUnavailable && rec.Header().Get("Retry-After") == "" {
+			t.Errorf("%s: 503 without Retry-After", step.name)
+		}
+	}
+}
+
+func TestReadinessCacheDefaults(t *testing.T) {
+	tests := []struct {
+		name                 string
+		cfg                  config.HealthConfig
+		wantTTL, wantFailure time.Duration
+		wantPingTimeout      time.Duration
+	}{
+		{
+			name:            "unset",
+			wantTTL:         defaultHealthCacheTTL,
+			wantFailure:     defaultFailureCacheTTL,
+			wantPingTimeout: defaultPingTimeout,
+		},
+		{
+			name:            "configured",
+			cfg:             config.HealthConfig{PingTimeout: 300 * time.Millisecond, CacheTTL: 5 * time.Second, FailureCacheTTL: time.Second},
+			wantTTL:         5 * time.Second,
+			wantFailure:     time.Second,
+			wantPingTimeout: 300 * time.Millisecond,
+		},
+		{
+			name:            "failure TTL longer than the pass TTL",
+			cfg:             config.HealthConfig{CacheTTL: time.Second, FailureCacheTTL: 10 * time.Second},
+			wantTTL:         time.Second,
+			wantFailure:     defaultFailureCacheTTL,
+			wantPingTimeout: defaultPingTimeout,
+		},
+		{
+			name:            "pass TTL shorter than the default failure TTL",
+			cfg:             config.HealthConfig{CacheTTL: 200 * time.Millisecond},
+			wantTTL:         200 * time.Millisecond,
+			wantFailure:     200 * time.Millisecond,
+			wantPingTimeout: defaultPingTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealthHandler(nil, tt.cfg, newFakeClock(time.Time{}), zap.NewNop())
+			if h.cacheTTL != tt.wantTTL {
+				t.Errorf("cacheTTL = %v, want %v", h.cacheTTL, tt.wantTTL)
+			}
+			if h.failureCacheTTL != tt.wantFailure {
+				t.Errorf("failureCacheTTL = %v, want %v", h.failureCacheTTL, tt.wantFailure)
+			}
+			if h.pingTimeout != tt.wantPingTimeout {
+				t.Errorf("pingTimeout = %v, want %v", h.pingTimeout, tt.wantPingTimeout)
+			}
+		})
+	}
+}
+
+func TestReadinessPingTimeout(t *testing.T) {
+	db := &fakePinger{block: true}
+	h := newTestHealthHandler(db, config.HealthConfig{PingTimeout: 20 * time.Millisecond}, newFakeClock(time.Now()))
+
+	start := time.Now()
+	rec := serve(http.MethodGet, "/ready", "/ready", "", nil, h.Ready)
+	end := time.Now()
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), context.DeadlineExceeded.Error()) {
+		t.Errorf("body = %s, want the ping's deadline error", rec.Body.String())
+	}
+	// The deadline is set 20ms after some instant during the probe.
+	if db.deadline.Before(start.Add(20*time.Millisecond)) || db.deadline.After(end.Add(20*time.Millisecond)) {
+		t.Errorf("ping deadline = %v after the probe started, want 20ms after a moment within it", db.deadline.Sub(start))
+	}
+	if elapsed := end.Sub(start); elapsed > time.Second {
+		t.Errorf("probe took %v, want it cut short by the ping timeout", elapsed)
+	}
+}
+
+func TestReadinessIgnoresProbeCancellation(t *testing.T) {
+	clock := newFakeClock(time.Date(2025, time.March, 15, 12, 0, 0, 0, time.UTC))
+	db := &fakePinger{}
+	h := newTestHealthHandler(db, config.HealthConfig{CacheTTL: 2 * time.Second, FailureCacheTTL: 500 * time.Millisecond}, clock)
+
+	// A probe whose client already hung up still gets a real ping.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := h.check(ctx); err != nil {
+		t.Fatalf("check with a cancelled probe = %v, want the ping's result", err)
+	}
+
+	// What was cached is that ping's pass, not the probe's cancellation.
+	clock.Advance(time.Second)
+	rec := serve(http.MethodGet, "/ready", "/ready", "", nil, h.Ready)
+	if rec.Code != http.StatusOK {
+		t.Errorf("status after a cancelled probe = %d, want 200", rec.Code)
+	}
+	if db.pings != 1 {
+		t.Errorf("pings = %d, want 1", db.pings)
+	}
+}
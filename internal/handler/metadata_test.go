@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestListSubscriptionsMetadataQuery(t *testing.T) {
+	var got map[string]string
+	h := newTestHandler(&fakeSubscriptionService{
+		list: func(ctx context.Context, req *domain.ListSubscriptionsRequest) ([]*domain.Subscription, int64, error) {
+			got = req.Metadata
+			return nil, 0, nil
+		},
+	})
+
+	rec := serve(http.MethodGet, "/subscriptions", "/subscriptions?metadata.team=infra&metadata.seats=4&metadata.=x&service_name=flix", "", nil, h.ListSubscriptions)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	want := map[string]string{"team": "infra", "seats": "4"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("metadata = %v, want %v", got, want)
+	}
+}
+
+func TestSubscriptionMetadata(t *testing.T) {
+	pool := newTestPool(t)
+	router := newTestRouter(pool, config.SubscriptionConfig{})
+	userID := uuid.NewString()
+
+	create := func(service, metadata string) *domain.Subscription {
+		t.Helper()
+		rec := do(router, http.MethodPost, "/api/v1/subscriptions", `{"service_name":"`+service+`","price":400,"user_id":"`+userID+`","start_date":"2025-01-01","metadata":`+metadata+`}`, false)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("create %s: status = %d, body %s", service, rec.Code, rec.Body)
+		}
+		var sub domain.Subscription
+		if err := json.Unmarshal(rec.Body.Bytes(), &sub); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return &sub
+	}
+	infra := create("Netflix", `{"team":"infra","seats":4,"external_ref":"A-1"}`)
+	create("Spotify", `{"team":"design","seats":4}`)
+
+	rec := do(router, http.MethodGet, "/api/v1/subscriptions/"+infra.ID.String(), "", false)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get: status = %d, body %s", rec.Code, rec.Body)
+	}
+	var read domain.Subscription
+	if err := json.Unmarshal(rec.Body.Bytes(), &read); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	wantMetadata := map[string]interface{}{"team": "infra", "seats": float64(4), "external_ref": "A-1"}
+	if !reflect.DeepEqual(read.Metadata, wantMetadata) {
+		t.Errorf("metadata = %v, want %v", read.Metadata, wantMetadata)
+	}
+
+	filters := []struct {
+		name      string
+		query     string
+		wantCount int
+	}{
+		{name: "string value", query: "?metadata.team=infra", wantCount: 1},
+		{name: "number value", query: "?metadata.seats=4", wantCount: 2},
+		{name: "every key must match", query: "?metadata.team=design&metadata.external_ref=A-1", wantCount: 0},
+		{name: "number does not match its string", query: "?metadata.external_ref=4", wantCount: 0},
+	}
+	for _, tt := range filters {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(router, http.MethodGet, "/api/v1/subscriptions"+tt.query+"&user_id="+userID, "", false)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+			}
+			var page struct {
+				Data []domain.Subscription `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(page.Data) != tt.wantCount {
+				t.Errorf("got %d rows, want %d", len(page.Data), tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestCreateSubscriptionRejectsNonObjectMetadata(t *testing.T) {
+	// The service rejects these before reaching the repository, so no
+	// database is needed.
+	router := newTestRouter(nil, config.SubscriptionConfig{})
+	userID := uuid.NewString()
+
+	// Metadata that is not JSON cannot be bound and is a 400; JSON that is
+	// not an object is well formed but breaks a rule, so it is a 422 naming
+	// the field.
+	tests := []struct {
+		name       string
+		metadata   string
+		wantStatus int
+	}{
+		{name: "malformed", metadata: `{"team":`, wantStatus: http.StatusBadRequest},
+		{name: "array", metadata: `["infra"]`, wantStatus: http.StatusUnprocessableEntity},
+		{name: "scalar", metadata: `"infra"`, wantStatus: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(router, http.MethodPost, "/api/v1/subscriptions", `{"service_name":"Hulu","price":400,"user_id":"`+userID+`","start_date":"2025-01-01","metadata":`+tt.metadata+`}`, false)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusUnprocessableEntity {
+				return
+			}
+			var body struct {
+				Details []domain.FieldError `json:"details"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(body.Details) != 1 || body.Details[0].Field != "metadata" {
+				t.Errorf("details = %+v, want one metadata problem", body.Details)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// parseIDParam parses the :id path parameter as a UUID. On failure it writes
+// the shared 400 response and returns false, so callers just return.
+func (h *SubscriptionHandler) parseIDParam(c *gin.Context) (uuid.UUID, bool) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.Error("invalid subscription id", zap.String("id", idStr), zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid subscription id",
+			"field": "id",
+			"value": idStr,
+		})
+		return uuid.UUID{}, false
+	}
+
+	return id, true
+}
+
+func (h *SubscriptionHandler) parseUserIDParam(c *gin.Context) (uuid.UUID, bool) {
+	userIDStr := c.Param("user_id")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		h.logger.Error("invalid user id", zap.String("user_id", userIDStr), zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid user id",
+			"field": "user_id",
+			"value": userIDStr,
+		})
+		return uuid.UUID{}, false
+	}
+
+	return userID, true
+}
+
+const metadataQueryPrefix = "metadata."
+
+// metadataQuery collects metadata.<key>=<value> query parameters.
+func metadataQuery(c *gin.Context) map[string]string {
+	var metadata map[string]string
+	for key, values := range c.Request.URL.Query() {
+		if !strings.HasPrefix(key, metadataQueryPrefix) || len(values) == 0 {
+			continue
+		}
+		name := strings.TrimPrefix(key, metadataQueryPrefix)
+		if name == "" {
+			continue
+		}
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		metadata[name] = values[0]
+	}
+	return metadata
+}
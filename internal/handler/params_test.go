@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestMalformedSubscriptionID(t *testing.T) {
+	logger := zap.NewNop()
+	router := gin.New()
+	// The service is never reached: every route rejects the id first.
+	SetupRoutes(router, newTestHandler(&fakeSubscriptionService{}), nil, nil, testAdminToken, false, logger)
+
+	const badID = "not-a-uuid"
+	routes := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodGet, "/api/v1/subscriptions/" + badID, ""},
+		{http.MethodPut, "/api/v1/subscriptions/" + badID, `{"price":500}`},
+		{http.MethodDelete, "/api/v1/subscriptions/" + badID, ""},
+		{http.MethodPost, "/api/v1/subscriptions/" + badID + "/validate", `{"price":500}`},
+		{http.MethodPost, "/api/v1/subscriptions/" + badID + "/clone", `{}`},
+		{http.MethodPost, "/api/v1/subscriptions/" + badID + "/pauses", `{"pause_start":"2025-01-01","pause_end":"2025-02-01"}`},
+		{http.MethodDelete, "/api/v1/subscriptions/" + badID + "/pauses/00000000-0000-4000-8000-000000000001", ""},
+	}
+
+	want := map[string]interface{}{"error": "invalid subscription id", "field": "id", "value": badID}
+
+	for _, route := range routes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			req := httptest.NewRequest(route.method, route.path, strings.NewReader(route.body))
+			if route.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d (%s), want %d", rec.Code, rec.Body, http.StatusBadRequest)
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if !reflect.DeepEqual(body, want) {
+				t.Errorf("body = %v, want %v", body, want)
+			}
+		})
+	}
+}
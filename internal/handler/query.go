@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"subscription-service/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// queryParams lists the query parameters an endpoint understands. Prefixes
+// cover dynamic keys such as metadata.<key>.
+type queryParams struct {
+	names    map[string]struct{}
+	prefixes []string
+}
+
+var (
+	listQueryParams      = newQueryParams(domain.ListSubscriptionsRequest{}, []string{"fields"}, metadataQueryPrefix)
+	totalCostQueryParams = newQueryParams(domain.TotalCostRequest{}, nil)
+	trendQueryParams     = newQueryParams(domain.ListSubscriptionsRequest{}, []string{"start", "end", "granularity"}, metadataQueryPrefix)
+	scheduleQueryParams  = newQueryParams(domain.ListSubscriptionsRequest{}, []string{"within_days"}, metadataQueryPrefix)
+)
+
+func newQueryParams(request interface{}, extra []string, prefixes ...string) queryParams {
+	names := tagFieldNames(reflect.TypeOf(request), "form")
+	for _, name := range extra {
+		names[name] = struct{}{}
+	}
+	return queryParams{names: names, prefixes: prefixes}
+}
+
+func (p queryParams) allows(name string) bool {
+	if _, ok := p.names[name]; ok {
+		return true
+	}
+	for _, prefix := range p.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// strictQuery rejects requests with query parameters outside allowed when
+// enabled, so a typo such as usr_id fails loudly instead of silently
+// dropping the filter. Disabled, it lets every request through.
+func strictQuery(enabled bool, allowed queryParams, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !enabled {
+			c.Next()
+			return
+		}
+
+		var unknown []string
+		for name := range c.Request.URL.Query() {
+			if !allowed.allows(name) {
+				unknown = append(unknown, name)
+			}
+		}
+		if len(unknown) == 0 {
+			c.Next()
+			return
+		}
+
+		sort.Strings(unknown)
+		logger.Warn("rejecting unknown query parameters", zap.Strings("params", unknown))
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"error":   fmt.Sprintf("unknown query parameter: %s", strings.Join(unknown, ", ")),
+			"unknown": unknown,
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"subscription-service/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestStrictQuery(t *testing.T) {
+	tests := []struct {
+		name        string
+		enabled     bool
+		allowed     queryParams
+		query       string
+		wantUnknown []string
+	}{
+		{name: "known list filters", enabled: true, allowed: listQueryParams, query: "?user_id=x&service_name=y&limit=5&fields=id&metadata.team=infra"},
+		{name: "unknown list parameters", enabled: true, allowed: listQueryParams, query: "?usr_id=x&service_name=y&sort=z", wantUnknown: []string{"sort", "usr_id"}},
+		{name: "known total-cost parameters", enabled: true, allowed: totalCostQueryParams, query: "?start_date=2025-01-01&end_date=2025-12-01&group_by=service&currency=RUB"},
+		{name: "metadata is a list filter only", enabled: true, allowed: totalCostQueryParams, query: "?period=P1M&metadata.team=infra", wantUnknown: []string{"metadata.team"}},
+		{name: "disabled lets anything through", allowed: listQueryParams, query: "?usr_id=x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/", strictQuery(tt.enabled, tt.allowed, zap.NewNop()), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/"+tt.query, nil))
+
+			if tt.wantUnknown == nil {
+				if rec.Code != http.StatusNoContent {
+					t.Errorf("status = %d, want 204 (%s)", rec.Code, rec.Body)
+				}
+				return
+			}
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400 (%s)", rec.Code, rec.Body)
+			}
+			var body struct {
+				Unknown []string `json:"unknown"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !reflect.DeepEqual(body.Unknown, tt.wantUnknown) {
+				t.Errorf("unknown = %v, want %v", body.Unknown, tt.wantUnknown)
+			}
+		})
+	}
+}
+
+func TestStrictQueryRoutes(t *testing.T) {
+	logger := zap.NewNop()
+	router := gin.New()
+	SetupRoutes(router, newTestHandler(&fakeSubscriptionService{
+		list: func(context.Context, *domain.ListSubscriptionsRequest) ([]*domain.Subscription, int64, error) {
+			return nil, 0, nil
+		},
+	}), nil, nil, testAdminToken, true, logger)
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+	}{
+		{name: "list with known filters", target: "/api/v1/subscriptions?service_name=flix", wantStatus: http.StatusOK},
+		{name: "list with a typo", target: "/api/v1/subscriptions?usr_id=x", wantStatus: http.StatusBadRequest},
+		{name: "total-cost with a typo", target: "/api/v1/subscriptions/total-cost?period=P1M&strat_date=2025-01-01", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(router, http.MethodGet, tt.target, "", false)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestReassignSubscriptionsNeedsAdmin(t *testing.T) {
+	from, to := uuid.New(), uuid.New()
+	body := fmt.Sprintf(`{"from_user_id":%q,"to_user_id":%q}`, from, to)
+
+	tests := []struct {
+		name       string
+		admin      bool
+		wantStatus int
+		wantCalls  int
+	}{
+		{name: "without the admin token", wantStatus: http.StatusUnauthorized},
+		{name: "with the admin token", admin: true, wantStatus: http.StatusOK, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			router := newBatchRouter(&fakeSubscriptionService{
+				reassignUser: func(_ context.Context, req *domain.ReassignUserRequest) (*domain.ReassignUserResponse, error) {
+					calls++
+					if req.FromUserID != from || req.ToUserID != to {
+						return nil, fmt.Errorf("reassigned %s to %s, want %s to %s", req.FromUserID, req.ToUserID, from, to)
+					}
+					return &domain.ReassignUserResponse{Moved: 3}, nil
+				},
+			})
+
+			rec := do(router, http.MethodPost, "/api/v1/subscriptions/reassign", body, tt.admin)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("service called %d times, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response domain.ReassignUserResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("decode %s: %v", rec.Body, err)
+			}
+			if response.Moved != 3 {
+				t.Errorf("moved = %d, want 3", response.Moved)
+			}
+		})
+	}
+}
@@ -2,31 +2,68 @@ package handler
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"go.uber.org/zap"
 )
 
-func SetupRoutes(router *gin.Engine, subscriptionHandler *SubscriptionHandler, logger *zap.Logger) {
+func SetupRoutes(router *gin.Engine, subscriptionHandler *SubscriptionHandler, adminHandler *AdminHandler, healthHandler *HealthHandler, adminToken string, strictQueryParams bool, logger *zap.Logger) {
 	logger.Info("setting up routes")
 
+	includeDeleted := adminOnlyFlag("include_deleted", adminToken, logger)
+	adminOnly := AdminAuth(adminToken, logger)
+
 	api := router.Group("/api/v1")
 	{
 		subscriptions := api.Group("/subscriptions")
 		{
 			subscriptions.POST("", subscriptionHandler.CreateSubscription)
-			subscriptions.GET("", subscriptionHandler.ListSubscriptions)
-			subscriptions.GET("/:id", subscriptionHandler.GetSubscription)
+			subscriptions.POST("/validate", subscriptionHandler.ValidateSubscription)
+			subscriptions.POST("/batch", subscriptionHandler.BatchCreateSubscriptions)
+			subscriptions.PUT("/batch", subscriptionHandler.BatchUpdateSubscriptions)
+			subscriptions.POST("/batch/delete", subscriptionHandler.BatchDeleteSubscriptions)
+			subscriptions.POST("/tags", adminOnly, subscriptionHandler.BulkTagSubscriptions)
+			subscriptions.POST("/reassign", adminOnly, subscriptionHandler.ReassignSubscriptions)
+			subscriptions.GET("", includeDeleted, strictQuery(strictQueryParams, listQueryParams, logger), subscriptionHandler.ListSubscriptions)
+			subscriptions.GET("/:id", includeDeleted, subscriptionHandler.GetSubscription)
 			subscriptions.PUT("/:id", subscriptionHandler.UpdateSubscription)
 			subscriptions.DELETE("/:id", subscriptionHandler.DeleteSubscription)
-			subscriptions.GET("/total-cost", subscriptionHandler.CalculateTotalCost)
+			subscriptions.POST("/:id/validate", subscriptionHandler.ValidateSubscriptionUpdate)
+			subscriptions.POST("/:id/clone", subscriptionHandler.CloneSubscription)
+			subscriptions.POST("/:id/pauses", subscriptionHandler.AddPause)
+			subscriptions.DELETE("/:id/pauses/:pause_id", subscriptionHandler.RemovePause)
+			subscriptions.GET("/by-period", includeDeleted, strictQuery(strictQueryParams, listQueryParams, logger), subscriptionHandler.ListByBillingPeriod)
+			subscriptions.GET("/trend", includeDeleted, strictQuery(strictQueryParams, trendQueryParams, logger), subscriptionHandler.SubscriptionTrend)
+			subscriptions.GET("/schedule", includeDeleted, strictQuery(strictQueryParams, scheduleQueryParams, logger), subscriptionHandler.SubscriptionSchedule)
+			subscriptions.GET("/total-cost", strictQuery(strictQueryParams, totalCostQueryParams, logger), subscriptionHandler.CalculateTotalCost)
+			subscriptions.GET("/export", includeDeleted, subscriptionHandler.ExportSubscriptions)
+		}
+
+		users := api.Group("/users", AdminAuth(adminToken, logger))
+		{
+			users.GET("/:user_id/export", subscriptionHandler.ExportUserData)
+			users.POST("/:user_id/anonymize", subscriptionHandler.AnonymizeUser)
+		}
+
+		services := api.Group("/services")
+		{
+			services.GET("", subscriptionHandler.ListServiceNames)
+			services.GET("/:name/subscriptions", subscriptionHandler.ListServiceSubscriptions)
 		}
 	}
 
+	admin := router.Group("/admin", AdminAuth(adminToken, logger))
+	{
+		admin.GET("/diagnostics", adminHandler.Diagnostics)
+		admin.POST("/recompute", adminHandler.Recompute)
+		admin.DELETE("/purge", adminHandler.Purge)
+	}
+
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	router.GET("/health", healthHandler.Live)
+	router.GET("/ready", healthHandler.Ready)
 
 	logger.Info("routes setup completed")
 }
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestListServiceSubscriptions(t *testing.T) {
+	pool := newTestPool(t)
+	router := newTestRouter(pool, config.SubscriptionConfig{})
+	alice, bob, carol := uuid.NewString(), uuid.NewString(), uuid.NewString()
+
+	for _, body := range []string{
+		`{"service_name":"Yandex Plus","price":300,"user_id":"` + alice + `","start_date":"2025-01-01"}`,
+		// A yearly price counts a twelfth of itself each month.
+		`{"service_name":"Yandex Plus","price":1200,"user_id":"` + bob + `","start_date":"2025-01-01","billing_period":"yearly"}`,
+		// A second subscription of the same user adds revenue, not a subscriber.
+		`{"service_name":"Yandex Plus","price_minor":500,"currency":"USD","user_id":"` + bob + `","start_date":"2025-01-01"}`,
+		// Ended subscriptions are listed but not aggregated.
+		`{"service_name":"Yandex Plus","price":999,"user_id":"` + carol + `","start_date":"2024-01-01","end_date":"2024-06-01"}`,
+		`{"service_name":"Netflix","price":700,"user_id":"` + alice + `","start_date":"2025-01-01"}`,
+	} {
+		if rec := do(router, http.MethodPost, "/api/v1/subscriptions", body, false); rec.Code != http.StatusCreated {
+			t.Fatalf("create: status = %d, body %s", rec.Code, rec.Body)
+		}
+	}
+
+	rec := do(router, http.MethodGet, "/api/v1/services/Yandex%20Plus/subscriptions", "", false)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+	var got domain.ServiceSubscriptionsResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	if got.ServiceName != "Yandex Plus" {
+		t.Errorf("service_name = %q, want %q", got.ServiceName, "Yandex Plus")
+	}
+	if got.SubscriberCount != 2 {
+		t.Errorf("subscriber_count = %d, want 2", got.SubscriberCount)
+	}
+	if got.MonthlyRevenue != 400 {
+		t.Errorf("monthly_revenue = %d, want 400", got.MonthlyRevenue)
+	}
+	wantRevenue := []domain.CurrencyTotal{
+		domain.NewCurrencyTotal("RUB", 40000),
+		domain.NewCurrencyTotal("USD", 500),
+	}
+	if !reflect.DeepEqual(got.Revenue, wantRevenue) {
+		t.Errorf("revenue = %+v, want %+v", got.Revenue, wantRevenue)
+	}
+	if got.Total != 4 || len(got.Data) != 4 {
+		t.Errorf("total = %d with %d rows, want 4 of each", got.Total, len(got.Data))
+	}
+	for _, sub := range got.Data {
+		if sub.ServiceName != "Yandex Plus" {
+			t.Errorf("listed a %q subscription", sub.ServiceName)
+		}
+	}
+}
+
+func TestListServiceSubscriptionsUnknownName(t *testing.T) {
+	pool := newTestPool(t)
+
+	tests := []struct {
+		name       string
+		cfg        config.SubscriptionConfig
+		wantStatus int
+	}{
+		{name: "empty page by default", wantStatus: http.StatusOK},
+		{name: "not found when configured", cfg: config.SubscriptionConfig{EmptyServiceNotFound: true}, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(pool, tt.cfg)
+
+			rec := do(router, http.MethodGet, "/api/v1/services/Nothing%20Here/subscriptions", "", false)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got domain.ServiceSubscriptionsResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if got.Total != 0 || len(got.Data) != 0 || got.SubscriberCount != 0 {
+				t.Errorf("got %+v, want an empty page", got)
+			}
+		})
+	}
+}
@@ -1,9 +1,17 @@
 package handler
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"subscription-service/internal/domain"
+	"subscription-service/internal/jsonpatch"
 	"subscription-service/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -13,30 +21,46 @@ import (
 
 type SubscriptionHandler struct {
 	service service.SubscriptionService
+	batch   service.BatchService
 	logger  *zap.Logger
 }
 
-func NewSubscriptionHandler(service service.SubscriptionService, logger *zap.Logger) *SubscriptionHandler {
+func NewSubscriptionHandler(service service.SubscriptionService, batch service.BatchService, logger *zap.Logger) *SubscriptionHandler {
 	return &SubscriptionHandler{
 		service: service,
+		batch:   batch,
 		logger:  logger,
 	}
 }
 
 // CreateSubscription godoc
 // @Summary Create a new subscription
-// @Description Create a new subscription record
+// @Description Create a new subscription record. The price is given once, as price, price_minor or amount; user_id may be omitted when a default user is configured. A missing price or user_id returns 422 with details naming the field.
 // @Tags subscriptions
 // @Accept json
 // @Produce json
 // @Param subscription body domain.CreateSubscriptionRequest true "Subscription data"
+// @Param warnings query bool false "Wrap the response as {subscription, warnings} listing valid but unusual input"
 // @Success 201 {object} domain.Subscription
 // @Failure 400 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
 // @Router /subscriptions [post]
 func (h *SubscriptionHandler) CreateSubscription(c *gin.Context) {
 	h.logger.Info("handler: create subscription request")
 
+	var withWarnings bool
+	if raw := c.Query("warnings"); raw != "" {
+		var err error
+		if withWarnings, err = strconv.ParseBool(raw); err != nil {
+			h.logger.Error("invalid warnings parameter", zap.String("warnings", raw))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "warnings must be a boolean"})
+			return
+		}
+	}
+
 	var req domain.CreateSubscriptionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.Error("failed to bind request", zap.Error(err))
@@ -47,69 +71,155 @@ func (h *SubscriptionHandler) CreateSubscription(c *gin.Context) {
 	subscription, err := h.service.Create(c.Request.Context(), &req)
 	if err != nil {
 		h.logger.Error("failed to create subscription", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 
 	h.logger.Info("subscription created successfully", zap.String("id", subscription.ID.String()))
+	if withWarnings {
+		c.JSON(http.StatusCreated, domain.SubscriptionWithWarnings{
+			Subscription: subscription,
+			Warnings:     h.service.CreateWarnings(&req),
+		})
+		return
+	}
 	c.JSON(http.StatusCreated, subscription)
 }
 
 // GetSubscription godoc
 // @Summary Get subscription by ID
-// @Description Get subscription details by ID
+// @Description Get subscription details by ID. With expand=computed the response also carries a computed object (see domain.SubscriptionWithComputed).
 // @Tags subscriptions
 // @Accept json
 // @Produce json
 // @Param id path string true "Subscription ID (UUID)"
+// @Param fields query string false "Comma-separated list of fields to return"
+// @Param expand query string false "Set to computed to add a computed object with derived fields" Enums(computed)
+// @Param include_deleted query bool false "Admin only: also find soft-deleted subscriptions, marked by deleted_at"
 // @Success 200 {object} domain.Subscription
 // @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
 // @Router /subscriptions/{id} [get]
 func (h *SubscriptionHandler) GetSubscription(c *gin.Context) {
 	h.logger.Info("handler: get subscription request")
 
-	idStr := c.Param("id")
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		h.logger.Error("invalid subscription id", zap.String("id", idStr), zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subscription id"})
+	id, ok := h.parseIDParam(c)
+	if !ok {
 		return
 	}
 
-	subscription, err := h.service.GetByID(c.Request.Context(), id)
+	fields, err := parseFields(c.Query("fields"))
 	if err != nil {
-		h.logger.Error("failed to get subscription", zap.String("id", id.String()), zap.Error(err))
-		c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+		h.logger.Error("invalid fields parameter", zap.String("fields", c.Query("fields")), zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	expand := c.Query("expand")
+	if expand != "" && expand != domain.ExpandComputed {
+		h.logger.Error("invalid expand parameter", zap.String("expand", expand))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported expand value: " + expand})
+		return
+	}
+
+	var includeDeleted bool
+	if raw := c.Query("include_deleted"); raw != "" {
+		if includeDeleted, err = strconv.ParseBool(raw); err != nil {
+			h.logger.Error("invalid include_deleted parameter", zap.String("include_deleted", raw))
+			c.JSON(http.StatusBadRequest, gin.H{"error": "include_deleted must be a boolean"})
+			return
+		}
+	}
+	if includeDeleted && expand != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "include_deleted cannot be combined with expand"})
 		return
 	}
 
+	var subscription *domain.Subscription
+	var computed *domain.SubscriptionComputed
+	if expand == domain.ExpandComputed {
+		result, err := h.service.GetComputed(c.Request.Context(), id)
+		if err != nil {
+			h.logger.Error("failed to get subscription", zap.String("id", id.String()), zap.Error(err))
+			writeError(c, err)
+			return
+		}
+		subscription, computed = result.Subscription, &result.Computed
+	} else if includeDeleted {
+		subscription, err = h.service.GetByIDIncludingDeleted(c.Request.Context(), id)
+		if err != nil {
+			h.logger.Error("failed to get subscription", zap.String("id", id.String()), zap.Error(err))
+			writeError(c, err)
+			return
+		}
+	} else {
+		subscription, err = h.service.GetByID(c.Request.Context(), id)
+		if err != nil {
+			h.logger.Error("failed to get subscription", zap.String("id", id.String()), zap.Error(err))
+			writeError(c, err)
+			return
+		}
+	}
+
 	h.logger.Info("subscription retrieved successfully", zap.String("id", id.String()))
-	c.JSON(http.StatusOK, subscription)
+
+	if len(fields) == 0 {
+		if computed != nil {
+			c.JSON(http.StatusOK, domain.SubscriptionWithComputed{Subscription: subscription, Computed: *computed})
+			return
+		}
+		c.JSON(http.StatusOK, subscription)
+		return
+	}
+
+	projected, err := projectSubscription(subscription, fields)
+	if err != nil {
+		h.logger.Error("failed to project subscription", zap.String("id", id.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if computed != nil {
+		projected["computed"] = computed
+	}
+
+	c.JSON(http.StatusOK, projected)
 }
 
 // UpdateSubscription godoc
 // @Summary Update subscription
-// @Description Update subscription by ID
+// @Description Update subscription by ID; omitted fields are kept, and clear_end_date removes the end date. Send Content-Type application/vnd.subscription+json with a full subscription (as for create) to create-or-replace instead: a new subscription is created at the id, which must be a version 4 UUID, or the existing one of the same user is replaced, with omitted fields reset to their defaults. Send Content-Type application/json-patch+json with an RFC 6902 patch document to apply precise operations, e.g. removing /end_date.
 // @Tags subscriptions
-// @Accept json
+// @Accept json,application/vnd.subscription+json,application/json-patch+json
 // @Produce json
 // @Param id path string true "Subscription ID (UUID)"
-// @Param subscription body domain.UpdateSubscriptionRequest true "Subscription update data"
+// @Param subscription body domain.UpdateSubscriptionRequest true "Subscription update data, a full subscription, or a JSON Patch document"
 // @Success 200 {object} domain.Subscription
+// @Success 201 {object} domain.Subscription
 // @Failure 400 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
 // @Router /subscriptions/{id} [put]
 func (h *SubscriptionHandler) UpdateSubscription(c *gin.Context) {
 	h.logger.Info("handler: update subscription request")
 
-	idStr := c.Param("id")
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		h.logger.Error("invalid subscription id", zap.String("id", idStr), zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subscription id"})
+	id, ok := h.parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	switch c.ContentType() {
+	case jsonpatch.MediaType:
+		h.patchSubscription(c, id)
+		return
+	case domain.ReplaceMediaType:
+		h.putSubscription(c, id)
 		return
 	}
 
@@ -123,11 +233,7 @@ func (h *SubscriptionHandler) UpdateSubscription(c *gin.Context) {
 	subscription, err := h.service.Update(c.Request.Context(), id, &req)
 	if err != nil {
 		h.logger.Error("failed to update subscription", zap.String("id", id.String()), zap.Error(err))
-		if err.Error() == "subscription not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		writeError(c, err)
 		return
 	}
 
@@ -135,6 +241,50 @@ func (h *SubscriptionHandler) UpdateSubscription(c *gin.Context) {
 	c.JSON(http.StatusOK, subscription)
 }
 
+// putSubscription creates or replaces the subscription at id, answering 201
+// when it was created and 200 when it was replaced.
+func (h *SubscriptionHandler) putSubscription(c *gin.Context, id uuid.UUID) {
+	var req domain.CreateSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, created, err := h.service.Put(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.Error("failed to put subscription", zap.String("id", id.String()), zap.Error(err))
+		writeError(c, err)
+		return
+	}
+
+	h.logger.Info("subscription put successfully", zap.String("id", id.String()), zap.Bool("created", created))
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, subscription)
+}
+
+func (h *SubscriptionHandler) patchSubscription(c *gin.Context, id uuid.UUID) {
+	var patch []jsonpatch.Operation
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		h.logger.Error("failed to bind patch document", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.service.Patch(c.Request.Context(), id, patch)
+	if err != nil {
+		h.logger.Error("failed to patch subscription", zap.String("id", id.String()), zap.Error(err))
+		writeError(c, err)
+		return
+	}
+
+	h.logger.Info("subscription patched successfully", zap.String("id", id.String()))
+	c.JSON(http.StatusOK, subscription)
+}
+
 // DeleteSubscription godoc
 // @Summary Delete subscription
 // @Description Delete subscription by ID
@@ -146,25 +296,19 @@ func (h *SubscriptionHandler) UpdateSubscription(c *gin.Context) {
 // @Failure 400 {object} map[string]interface{}
 // @Failure 404 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
 // @Router /subscriptions/{id} [delete]
 func (h *SubscriptionHandler) DeleteSubscription(c *gin.Context) {
 	h.logger.Info("handler: delete subscription request")
 
-	idStr := c.Param("id")
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		h.logger.Error("invalid subscription id", zap.String("id", idStr), zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subscription id"})
+	id, ok := h.parseIDParam(c)
+	if !ok {
 		return
 	}
 
 	if err := h.service.Delete(c.Request.Context(), id); err != nil {
 		h.logger.Error("failed to delete subscription", zap.String("id", id.String()), zap.Error(err))
-		if err.Error() == "subscription not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		writeError(c, err)
 		return
 	}
 
@@ -172,19 +316,167 @@ func (h *SubscriptionHandler) DeleteSubscription(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// CloneSubscription godoc
+// @Summary Clone subscription
+// @Description Create a new subscription copied from an existing one, optionally overriding price and dates
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID (UUID)"
+// @Param overrides body domain.CloneSubscriptionRequest false "Fields to override"
+// @Success 201 {object} domain.Subscription
+// @Failure 400 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /subscriptions/{id}/clone [post]
+func (h *SubscriptionHandler) CloneSubscription(c *gin.Context) {
+	h.logger.Info("handler: clone subscription request")
+
+	id, ok := h.parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req domain.CloneSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.service.Clone(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.Error("failed to clone subscription", zap.String("id", id.String()), zap.Error(err))
+		writeError(c, err)
+		return
+	}
+
+	h.logger.Info("subscription cloned successfully", zap.String("source_id", id.String()), zap.String("id", subscription.ID.String()))
+	c.JSON(http.StatusCreated, subscription)
+}
+
+// AddPause godoc
+// @Summary Pause subscription billing
+// @Description Add an inclusive pause window; months whose billing date falls inside it are not counted in the total cost
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID (UUID)"
+// @Param pause body domain.CreatePauseRequest true "Pause window"
+// @Success 201 {object} domain.SubscriptionPause
+// @Failure 400 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /subscriptions/{id}/pauses [post]
+func (h *SubscriptionHandler) AddPause(c *gin.Context) {
+	h.logger.Info("handler: add pause request")
+
+	id, ok := h.parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req domain.CreatePauseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pause, err := h.service.AddPause(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.Error("failed to add pause", zap.String("id", id.String()), zap.Error(err))
+		writeError(c, err)
+		return
+	}
+
+	h.logger.Info("pause added successfully", zap.String("id", id.String()), zap.String("pause_id", pause.ID.String()))
+	c.JSON(http.StatusCreated, pause)
+}
+
+// RemovePause godoc
+// @Summary Remove a pause window
+// @Description Delete a pause window so the months it covered are billed again
+// @Tags subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID (UUID)"
+// @Param pause_id path string true "Pause ID (UUID)"
+// @Success 204
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /subscriptions/{id}/pauses/{pause_id} [delete]
+func (h *SubscriptionHandler) RemovePause(c *gin.Context) {
+	h.logger.Info("handler: remove pause request")
+
+	id, ok := h.parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	pauseIDStr := c.Param("pause_id")
+	pauseID, err := uuid.Parse(pauseIDStr)
+	if err != nil {
+		h.logger.Error("invalid pause id", zap.String("pause_id", pauseIDStr), zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid pause id",
+			"field": "pause_id",
+			"value": pauseIDStr,
+		})
+		return
+	}
+
+	if err := h.service.RemovePause(c.Request.Context(), id, pauseID); err != nil {
+		h.logger.Error("failed to remove pause", zap.String("id", id.String()), zap.String("pause_id", pauseIDStr), zap.Error(err))
+		writeError(c, err)
+		return
+	}
+
+	h.logger.Info("pause removed successfully", zap.String("id", id.String()), zap.String("pause_id", pauseIDStr))
+	c.Status(http.StatusNoContent)
+}
+
 // ListSubscriptions godoc
 // @Summary List subscriptions
 // @Description List subscriptions with optional filters
 // @Tags subscriptions
 // @Accept json
 // @Produce json
-// @Param user_id query string false "User ID filter"
-// @Param service_name query string false "Service name filter"
+// @Param user_id query []string false "User ID filter; repeat or comma-separate to match any of several users" collectionFormat(multi)
+// @Param service_name query []string false "Case-insensitive service name substring; repeat or comma-separate to match any of several" collectionFormat(multi)
+// @Param service_name_prefix query string false "Only service names starting with this, case-sensitive, e.g. aws: for aws:ec2 and aws:s3"
+// @Param min_price query int false "Minimum price (inclusive)"
+// @Param max_price query int false "Maximum price (inclusive)"
+// @Param active_from query string false "Only subscriptions active on or after this date (YYYY-MM-DD)"
+// @Param active_to query string false "Only subscriptions active on or before this date (YYYY-MM-DD)"
+// @Param updated_since query string false "Delta pull: only rows changed after this RFC 3339 timestamp; add include_deleted (admin only) to see deletions"
+// @Param updated_after_id query string false "Delta pull: with updated_since, also rows changed exactly then whose id sorts after this one; pass the previous X-Latest-Update-ID"
+// @Param tag query string false "Only subscriptions carrying this tag"
+// @Param open_ended query bool false "true for subscriptions without an end date, false for fixed-term ones"
+// @Param include_deleted query bool false "Admin only: include soft-deleted subscriptions, marked by deleted_at"
 // @Param limit query int false "Limit" default(20)
 // @Param offset query int false "Offset" default(0)
+// @Param fields query string false "Comma-separated list of fields to return"
+// @Param with_active_count query bool false "Include the number of currently active matching subscriptions"
+// @Param metadata.{key} query string false "Metadata filter, e.g. metadata.external_ref=abc"
 // @Success 200 {object} map[string]interface{}
+// @Header 200 {string} X-Latest-Update "Latest updated_at in the page, to pass as the next updated_since"
+// @Header 200 {string} X-Latest-Update-ID "Id of the latest changed row in the page, to pass as the next updated_after_id"
+// @Header 200 {int} X-Created-Count "Delta pulls only: rows in the page created after updated_since"
+// @Header 200 {int} X-Updated-Count "Delta pulls only: rows in the page changed but not created after updated_since"
+// @Header 200 {int} X-Deleted-Count "Delta pulls only: rows in the page deleted after updated_since"
 // @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
 // @Router /subscriptions [get]
 func (h *SubscriptionHandler) ListSubscriptions(c *gin.Context) {
 	h.logger.Info("handler: list subscriptions request")
@@ -195,36 +487,521 @@ func (h *SubscriptionHandler) ListSubscriptions(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	req.Metadata = metadataQuery(c)
+
+	fields, err := parseFields(c.Query("fields"))
+	if err != nil {
+		h.logger.Error("invalid fields parameter", zap.String("fields", c.Query("fields")), zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	subscriptions, total, err := h.service.List(c.Request.Context(), &req)
 	if err != nil {
 		h.logger.Error("failed to list subscriptions", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeError(c, err)
 		return
 	}
 
 	h.logger.Info("subscriptions listed successfully", zap.Int("count", len(subscriptions)), zap.Int64("total", total))
-	c.JSON(http.StatusOK, gin.H{
-		"data":   subscriptions,
+	setDeltaHeaders(c, &req, subscriptions)
+
+	var data interface{} = subscriptions
+	if len(fields) > 0 {
+		projected, err := projectSubscriptions(subscriptions, fields)
+		if err != nil {
+			h.logger.Error("failed to project subscriptions", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		data = projected
+	}
+
+	response := gin.H{
+		"data":   data,
 		"total":  total,
 		"limit":  req.Limit,
 		"offset": req.Offset,
+	}
+
+	if req.WithActiveCount {
+		activeCount, err := h.service.CountActive(c.Request.Context(), &req)
+		if err != nil {
+			h.logger.Error("failed to count active subscriptions", zap.Error(err))
+			writeError(c, err)
+			return
+		}
+		response["active_count"] = activeCount
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ListByBillingPeriod godoc
+// @Summary Count subscriptions per billing period
+// @Description Group the subscriptions matching the list filters by billing period, with their count and the sum of their per-period prices in each currency. total_cost is the RUB sum in whole units; totals has every currency. limit and offset are ignored.
+// @Tags subscriptions
+// @Produce json
+// @Param user_id query []string false "User ID filter; repeat or comma-separate to match any of several users" collectionFormat(multi)
+// @Param service_name query []string false "Case-insensitive service name substring; repeat or comma-separate to match any of several" collectionFormat(multi)
+// @Param service_name_prefix query string false "Only service names starting with this, case-sensitive, e.g. aws: for aws:ec2 and aws:s3"
+// @Param min_price query int false "Minimum price (inclusive)"
+// @Param max_price query int false "Maximum price (inclusive)"
+// @Param active_from query string false "Only subscriptions active on or after this date (YYYY-MM-DD)"
+// @Param active_to query string false "Only subscriptions active on or before this date (YYYY-MM-DD)"
+// @Param tag query string false "Only subscriptions carrying this tag"
+// @Param open_ended query bool false "true for subscriptions without an end date, false for fixed-term ones"
+// @Param include_deleted query bool false "Admin only: include soft-deleted subscriptions, marked by deleted_at"
+// @Param metadata.{key} query string false "Metadata filter, e.g. metadata.external_ref=abc"
+// @Success 200 {array} domain.BillingPeriodCount
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /subscriptions/by-period [get]
+func (h *SubscriptionHandler) ListByBillingPeriod(c *gin.Context) {
+	h.logger.Info("handler: count by billing period request")
+
+	var req domain.ListSubscriptionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("failed to bind query", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Metadata = metadataQuery(c)
+
+	periods, err := h.service.CountByBillingPeriod(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("failed to count subscriptions by billing period", zap.Error(err))
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, periods)
+}
+
+// SubscriptionTrend godoc
+// @Summary Active subscription count over time
+// @Description Count the subscriptions matching the list filters that are active at the end of each day, week or month between start and end. Intervals are calendar-aligned and zero-filled; the last one is counted as of end. limit and offset are ignored.
+// @Tags subscriptions
+// @Produce json
+// @Param start query string true "First day of the trend (YYYY-MM-DD)"
+// @Param end query string true "Last day of the trend (YYYY-MM-DD)"
+// @Param granularity query string false "day, week or month" default(month)
+// @Param user_id query []string false "User ID filter; repeat or comma-separate to match any of several users" collectionFormat(multi)
+// @Param service_name query []string false "Case-insensitive service name substring; repeat or comma-separate to match any of several" collectionFormat(multi)
+// @Param service_name_prefix query string false "Only service names starting with this, case-sensitive, e.g. aws: for aws:ec2 and aws:s3"
+// @Param min_price query int false "Minimum price (inclusive)"
+// @Param max_price query int false "Maximum price (inclusive)"
+// @Param tag query string false "Only subscriptions carrying this tag"
+// @Param open_ended query bool false "true for subscriptions without an end date, false for fixed-term ones"
+// @Param include_deleted query bool false "Admin only: include soft-deleted subscriptions, marked by deleted_at"
+// @Param metadata.{key} query string false "Metadata filter, e.g. metadata.external_ref=abc"
+// @Success 200 {object} domain.TrendResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /subscriptions/trend [get]
+func (h *SubscriptionHandler) SubscriptionTrend(c *gin.Context) {
+	h.logger.Info("handler: subscription trend request")
+
+	var req domain.TrendRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("failed to bind query", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Metadata = metadataQuery(c)
+
+	trend, err := h.service.Trend(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("failed to compute subscription trend", zap.Error(err))
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, trend)
+}
+
+// SubscriptionSchedule godoc
+// @Summary Upcoming billing, renewal and expiry events
+// @Description List the events of the subscriptions matching the list filters over the next within_days days, today included, in date order. Billing events fall on the start date and every billing period after it; auto-renewing subscriptions renew on their end date and every billing period after it, others expire on it. active_from and active_to are set to the window. Pauses are not taken into account.
+// @Tags subscriptions
+// @Produce json
+// @Param within_days query int false "Days to look ahead, today included" default(30)
+// @Param user_id query []string false "User ID filter; repeat or comma-separate to match any of several users" collectionFormat(multi)
+// @Param service_name query []string false "Case-insensitive service name substring; repeat or comma-separate to match any of several" collectionFormat(multi)
+// @Param service_name_prefix query string false "Only service names starting with this, case-sensitive, e.g. aws: for aws:ec2 and aws:s3"
+// @Param min_price query int false "Minimum price (inclusive)"
+// @Param max_price query int false "Maximum price (inclusive)"
+// @Param tag query string false "Only subscriptions carrying this tag"
+// @Param include_deleted query bool false "Admin only: include soft-deleted subscriptions, marked by deleted_at"
+// @Param metadata.{key} query string false "Metadata filter, e.g. metadata.external_ref=abc"
+// @Param limit query int false "Limit" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} domain.ScheduleResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /subscriptions/schedule [get]
+func (h *SubscriptionHandler) SubscriptionSchedule(c *gin.Context) {
+	h.logger.Info("handler: subscription schedule request")
+
+	var req domain.ScheduleRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("failed to bind query", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Metadata = metadataQuery(c)
+
+	schedule, err := h.service.Schedule(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("failed to list subscription schedule", zap.Error(err))
+		writeError(c, err)
+		return
+	}
+
+	h.logger.Info("subscription schedule listed successfully", zap.Int("count", len(schedule.Data)), zap.Int64("total", schedule.Total))
+	c.JSON(http.StatusOK, schedule)
+}
+
+// ListServiceSubscriptions godoc
+// @Summary List subscriptions for a service
+// @Description List subscriptions whose service name matches exactly, with subscriber count and monthly revenue across those active today, with quarterly and yearly prices spread over their months. revenue has the revenue of each currency; monthly_revenue is the RUB one in whole units
+// @Tags services
+// @Produce json
+// @Param name path string true "Service name (URL-encoded)"
+// @Param limit query int false "Limit" default(20)
+// @Param offset query int false "Offset" default(0)
+// @Success 200 {object} domain.ServiceSubscriptionsResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /services/{name}/subscriptions [get]
+func (h *SubscriptionHandler) ListServiceSubscriptions(c *gin.Context) {
+	h.logger.Info("handler: list service subscriptions request")
+
+	serviceName := c.Param("name")
+
+	var req domain.ServiceSubscriptionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("failed to bind query", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.service.ListByService(c.Request.Context(), serviceName, &req)
+	if err != nil {
+		h.logger.Error("failed to list service subscriptions", zap.String("service_name", serviceName), zap.Error(err))
+		writeError(c, err)
+		return
+	}
+
+	h.logger.Info("service subscriptions listed successfully", zap.String("service_name", serviceName), zap.Int64("total", response.Total))
+	c.JSON(http.StatusOK, response)
+}
+
+// ListServiceNames godoc
+// @Summary List service names
+// @Description List the distinct service names in use, for autocomplete
+// @Tags services
+// @Produce json
+// @Param prefix query string false "Only names starting with this prefix (case-insensitive)"
+// @Param limit query int false "Maximum number of names; 0 returns all" default(0)
+// @Success 200 {object} domain.ServiceNamesResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /services [get]
+func (h *SubscriptionHandler) ListServiceNames(c *gin.Context) {
+	h.logger.Info("handler: list service names request")
+
+	var req domain.ServiceNamesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("failed to bind query", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.service.ListServiceNames(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("failed to list service names", zap.Error(err))
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// BulkTagSubscriptions godoc
+// @Summary Add or remove tags in bulk
+// @Description Admin only. Add and remove tags on every subscription matching the filter in one transaction. Filters without ids or user_id require confirm=true.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param request body domain.BulkTagRequest true "Filter and tag changes"
+// @Success 200 {object} domain.BulkTagResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /subscriptions/tags [post]
+func (h *SubscriptionHandler) BulkTagSubscriptions(c *gin.Context) {
+	h.logger.Info("handler: bulk tag request")
+
+	var req domain.BulkTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.service.BulkTag(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("failed to bulk tag subscriptions", zap.Error(err))
+		writeError(c, err)
+		return
+	}
+
+	h.logger.Info("bulk tag finished", zap.Int64("affected", response.Affected))
+	c.JSON(http.StatusOK, response)
+}
+
+// ReassignSubscriptions godoc
+// @Summary Move a user's subscriptions to another user
+// @Description Admin only. Move every subscription of from_user_id to to_user_id in one transaction, recording a history entry for each
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param request body domain.ReassignUserRequest true "Source and target users"
+// @Success 200 {object} domain.ReassignUserResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /subscriptions/reassign [post]
+func (h *SubscriptionHandler) ReassignSubscriptions(c *gin.Context) {
+	h.logger.Info("handler: reassign subscriptions request")
+
+	var req domain.ReassignUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.service.ReassignUser(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("failed to reassign subscriptions", zap.Error(err))
+		writeError(c, err)
+		return
+	}
+
+	h.logger.Info("subscriptions reassigned", zap.Int64("moved", response.Moved))
+	c.JSON(http.StatusOK, response)
+}
+
+// ExportSubscriptions godoc
+// @Summary Export subscriptions
+// @Description Stream every subscription matching the filters as newline-delimited JSON. A failure after the first subscription is written ends the stream with a final {"error": ...} line, so an incomplete export is never mistaken for a complete one.
+// @Tags subscriptions
+// @Produce application/x-ndjson
+// @Param user_id query []string false "User ID filter; repeat or comma-separate to match any of several users" collectionFormat(multi)
+// @Param service_name query []string false "Case-insensitive service name substring; repeat or comma-separate to match any of several" collectionFormat(multi)
+// @Param service_name_prefix query string false "Only service names starting with this, case-sensitive, e.g. aws: for aws:ec2 and aws:s3"
+// @Param min_price query int false "Minimum price (inclusive)"
+// @Param max_price query int false "Maximum price (inclusive)"
+// @Param active_from query string false "Only subscriptions active on or after this date (YYYY-MM-DD)"
+// @Param active_to query string false "Only subscriptions active on or before this date (YYYY-MM-DD)"
+// @Param open_ended query bool false "true for subscriptions without an end date, false for fixed-term ones"
+// @Param include_deleted query bool false "Admin only: include soft-deleted subscriptions, marked by deleted_at"
+// @Param metadata.{key} query string false "Metadata filter, e.g. metadata.external_ref=abc"
+// @Param sort query string false "Sort column, prefix with - for descending" default(created_at)
+// @Success 200 {array} domain.Subscription
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /subscriptions/export [get]
+func (h *SubscriptionHandler) ExportSubscriptions(c *gin.Context) {
+	h.logger.Info("handler: export subscriptions request")
+
+	var req domain.ExportSubscriptionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Error("failed to bind query", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Metadata = metadataQuery(c)
+
+	encoder := json.NewEncoder(c.Writer)
+	exported := 0
+	err := h.service.Export(c.Request.Context(), &req, func(subscription *domain.Subscription) error {
+		if exported == 0 {
+			c.Header("Content-Type", "application/x-ndjson")
+			c.Status(http.StatusOK)
+		}
+		exported++
+		return encoder.Encode(subscription)
+	})
+	if err != nil {
+		h.logger.Error("failed to export subscriptions", zap.Int("exported", exported), zap.Error(err))
+		if exported > 0 {
+			// The 200 is already sent; the error line is what tells the
+			// client the rows before it are not all there is.
+			encoder.Encode(gin.H{"error": fmt.Sprintf("export failed after %d subscriptions", exported)})
+			return
+		}
+		writeError(c, err)
+		return
+	}
+
+	if exported == 0 {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Status(http.StatusOK)
+	}
+
+	h.logger.Info("subscriptions exported successfully", zap.Int("count", exported))
+}
+
+// ExportUserData godoc
+// @Summary Export a user's data
+// @Description Admin only. Stream every subscription the user has ever held, soft-deleted ones included, with its pauses and history as one JSON document for a data subject access request. A failure after the first subscription is written still ends the document, with an error field in place of exported_at, so an incomplete export is never mistaken for a complete one.
+// @Tags users
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {object} domain.UserExport
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /users/{user_id}/export [get]
+func (h *SubscriptionHandler) ExportUserData(c *gin.Context) {
+	h.logger.Info("handler: export user data request")
+
+	userID, ok := h.parseUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	// The document is written by hand around the streamed subscriptions so
+	// that no more than one of them is held in memory at a time.
+	started := false
+	start := func() {
+		started = true
+		c.Header("Content-Type", "application/json; charset=utf-8")
+		c.Status(http.StatusOK)
+		fmt.Fprintf(c.Writer, `{"user_id":%q,"subscriptions":[`, userID.String())
+	}
+
+	exported := 0
+	exportedAt, err := h.service.ExportUser(c.Request.Context(), userID, func(subscription *domain.UserExportSubscription) error {
+		data, err := json.Marshal(subscription)
+		if err != nil {
+			return err
+		}
+		if !started {
+			start()
+		} else if _, err := io.WriteString(c.Writer, ","); err != nil {
+			return err
+		}
+		exported++
+		_, err = c.Writer.Write(data)
+		return err
 	})
+	if err != nil {
+		h.logger.Error("failed to export user data", zap.String("user_id", userID.String()), zap.Int("exported", exported), zap.Error(err))
+		if started {
+			// The 200 is already sent; the error field is what tells the
+			// client the subscriptions before it are not all there is.
+			fmt.Fprintf(c.Writer, `],"error":"export failed after %d subscriptions"}`, exported)
+			return
+		}
+		writeError(c, err)
+		return
+	}
+
+	if !started {
+		start()
+	}
+	fmt.Fprintf(c.Writer, `],"exported_at":%q}`, exportedAt.Format(time.RFC3339Nano))
+
+	h.logger.Info("user data exported successfully", zap.String("user_id", userID.String()), zap.Int("count", exported))
+}
+
+// AnonymizeUser godoc
+// @Summary Anonymize a user's data
+// @Description Admin only. Detach every subscription of the user, soft-deleted ones included, from them in one transaction: user_id is replaced by a fresh tombstone id, metadata and history details are cleared, and an anonymized history entry is recorded. Prices, dates and services are kept so aggregates do not change.
+// @Tags users
+// @Produce json
+// @Param user_id path string true "User ID"
+// @Success 200 {object} domain.AnonymizeUserResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /users/{user_id}/anonymize [post]
+func (h *SubscriptionHandler) AnonymizeUser(c *gin.Context) {
+	h.logger.Info("handler: anonymize user request")
+
+	userID, ok := h.parseUserIDParam(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.service.AnonymizeUser(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("failed to anonymize user", zap.Error(err))
+		writeError(c, err)
+		return
+	}
+
+	h.logger.Info("user anonymized", zap.Int64("anonymized", response.Anonymized))
+	c.JSON(http.StatusOK, response)
 }
 
 // CalculateTotalCost godoc
 // @Summary Calculate total cost
-// @Description Calculate total cost of subscriptions for a period (considers overlapping periods and number of months)
+// @Description Calculate total cost of subscriptions in one currency for a period (considers overlapping periods and number of months). Quarterly and yearly prices are spread evenly over the months of their period. total_cost is in whole units of the currency rounded down, total_cost_minor in minor units and amount a decimal string
 // @Tags subscriptions
 // @Accept json
 // @Produce json
 // @Param user_id query string false "User ID filter"
 // @Param service_name query string false "Service name filter"
-// @Param start_date query string true "Start date (YYYY-MM-DD)"
-// @Param end_date query string true "End date (YYYY-MM-DD)"
+// @Param start_date query string false "Start date (YYYY-MM-DD), required unless period is set"
+// @Param end_date query string false "End date (YYYY-MM-DD), required unless period is set"
+// @Param period query string false "Relative window: this_month, last_month or an ISO 8601 duration such as P3M"
+// @Param currency query string false "ISO 4217 currency to total" default(RUB)
+// @Param group_by query string false "Add a breakdown grouped by service_name"
+// @Param order query string false "Breakdown order: cost_desc, cost_asc or service_name" default(cost_desc)
+// @Param limit query int false "Breakdown page size" default(20)
+// @Param offset query int false "Breakdown offset" default(0)
 // @Success 200 {object} domain.TotalCostResponse
 // @Failure 400 {object} map[string]interface{}
+// @Failure 422 {object} map[string]interface{}
 // @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
 // @Router /subscriptions/total-cost [get]
 func (h *SubscriptionHandler) CalculateTotalCost(c *gin.Context) {
 	h.logger.Info("handler: calculate total cost request")
@@ -239,14 +1016,131 @@ func (h *SubscriptionHandler) CalculateTotalCost(c *gin.Context) {
 	result, err := h.service.CalculateTotalCost(c.Request.Context(), &req)
 	if err != nil {
 		h.logger.Error("failed to calculate total cost", zap.Error(err))
-		if err.Error() == "date must be in YYYY-MM-DD format" || err.Error() == "invalid user_id format" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+		writeError(c, err)
 		return
 	}
 
 	h.logger.Info("total cost calculated successfully", zap.Int("total_cost", result.TotalCost))
 	c.JSON(http.StatusOK, result)
 }
+
+// ValidateSubscription godoc
+// @Summary Validate a subscription without saving it
+// @Description Run all create validations (dates, bounds, overlap) and return every problem found. Overlaps are problems while uniqueness is enforced and warnings otherwise
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param subscription body domain.CreateSubscriptionRequest true "Subscription data"
+// @Success 200 {object} domain.ValidateSubscriptionResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /subscriptions/validate [post]
+func (h *SubscriptionHandler) ValidateSubscription(c *gin.Context) {
+	h.logger.Info("handler: validate subscription request")
+
+	var req domain.CreateSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.service.Validate(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.Error("failed to validate subscription", zap.Error(err))
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ValidateSubscriptionUpdate godoc
+// @Summary Validate a subscription update without saving it
+// @Description Run all update validations against the existing subscription and return every problem found
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path string true "Subscription ID (UUID)"
+// @Param subscription body domain.UpdateSubscriptionRequest true "Subscription update data"
+// @Success 200 {object} domain.ValidateSubscriptionResponse
+// @Failure 400 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /subscriptions/{id}/validate [post]
+func (h *SubscriptionHandler) ValidateSubscriptionUpdate(c *gin.Context) {
+	h.logger.Info("handler: validate subscription update request")
+
+	id, ok := h.parseIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req domain.UpdateSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response, err := h.service.ValidateUpdate(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.Error("failed to validate subscription update", zap.String("id", id.String()), zap.Error(err))
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// setDeltaHeaders reports the change cursor for a list page. X-Latest-Update
+// and X-Latest-Update-ID are the greatest (updated_at, id) in the page,
+// falling back to the request's cursor when the page is empty so clients can
+// keep polling with the same one. The id is needed because several rows may
+// share an updated_at and a page can end among them. Delta pulls also break
+// the page down into created, updated and deleted rows.
+func setDeltaHeaders(c *gin.Context, req *domain.ListSubscriptionsRequest, subscriptions []*domain.Subscription) {
+	var latest *domain.Subscription
+	for _, subscription := range subscriptions {
+		if latest == nil || subscription.UpdatedAt.After(latest.UpdatedAt) ||
+			subscription.UpdatedAt.Equal(latest.UpdatedAt) && bytes.Compare(subscription.ID[:], latest.ID[:]) > 0 {
+			latest = subscription
+		}
+	}
+
+	if latest != nil {
+		c.Header("X-Latest-Update", latest.UpdatedAt.UTC().Format(time.RFC3339Nano))
+		c.Header("X-Latest-Update-ID", latest.ID.String())
+	} else if req.UpdatedSince != nil {
+		c.Header("X-Latest-Update", *req.UpdatedSince)
+		if req.UpdatedAfterID != nil {
+			c.Header("X-Latest-Update-ID", *req.UpdatedAfterID)
+		}
+	}
+
+	if req.UpdatedSince == nil {
+		return
+	}
+
+	since, err := time.Parse(time.RFC3339Nano, *req.UpdatedSince)
+	if err != nil {
+		return
+	}
+
+	var created, updated, deleted int
+	for _, subscription := range subscriptions {
+		switch {
+		case subscription.DeletedAt != nil:
+			deleted++
+		case subscription.CreatedAt.After(since):
+			created++
+		default:
+			updated++
+		}
+	}
+	c.Header("X-Created-Count", strconv.Itoa(created))
+	c.Header("X-Updated-Count", strconv.Itoa(updated))
+	c.Header("X-Deleted-Count", strconv.Itoa(deleted))
+}
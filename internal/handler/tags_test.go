@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestBulkTagSubscriptionsNeedsAdmin(t *testing.T) {
+	userID := uuid.New()
+	body := fmt.Sprintf(`{"filter":{"user_id":%q},"add":["work"]}`, userID)
+
+	tests := []struct {
+		name       string
+		admin      bool
+		wantStatus int
+		wantCalls  int
+	}{
+		{name: "without the admin token", wantStatus: http.StatusUnauthorized},
+		{name: "with the admin token", admin: true, wantStatus: http.StatusOK, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			router := newBatchRouter(&fakeSubscriptionService{
+				bulkTag: func(_ context.Context, req *domain.BulkTagRequest) (*domain.BulkTagResponse, error) {
+					calls++
+					if req.Filter.UserID == nil || *req.Filter.UserID != userID || !reflect.DeepEqual(req.Add, []string{"work"}) {
+						return nil, fmt.Errorf("bulk tag got %+v, want work added for %s", req, userID)
+					}
+					return &domain.BulkTagResponse{Affected: 2}, nil
+				},
+			})
+
+			rec := do(router, http.MethodPost, "/api/v1/subscriptions/tags", body, tt.admin)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("service called %d times, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response domain.BulkTagResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("decode %s: %v", rec.Body, err)
+			}
+			if response.Affected != 2 {
+				t.Errorf("affected = %d, want 2", response.Affected)
+			}
+		})
+	}
+}
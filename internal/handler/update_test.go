@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"subscription-service/internal/domain"
+	"subscription-service/internal/jsonpatch"
+
+	"github.com/google/uuid"
+)
+
+func TestUpdateSubscriptionMode(t *testing.T) {
+	id := uuid.MustParse("00000000-0000-4000-8000-000000000001")
+	fullBody := `{"service_name":"Netflix","price":400,"user_id":"60601fee-2bf1-4721-ae6f-7636e79a0cba","start_date":"2025-01-01"}`
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		created     bool
+		wantMode    string
+		wantStatus  int
+	}{
+		{name: "partial update", contentType: "application/json", body: `{"price":500}`, wantMode: "update", wantStatus: http.StatusOK},
+		{name: "user_id alone does not make a replace", contentType: "application/json", body: fullBody, wantMode: "update", wantStatus: http.StatusOK},
+		{name: "replace", contentType: domain.ReplaceMediaType, body: fullBody, wantMode: "put", wantStatus: http.StatusOK},
+		{name: "create at the id", contentType: domain.ReplaceMediaType, body: fullBody, created: true, wantMode: "put", wantStatus: http.StatusCreated},
+		{name: "replace media type with parameters", contentType: domain.ReplaceMediaType + "; charset=utf-8", body: fullBody, wantMode: "put", wantStatus: http.StatusOK},
+		{name: "JSON Patch", contentType: jsonpatch.MediaType, body: `[{"op":"replace","path":"/price","value":500}]`, wantMode: "patch", wantStatus: http.StatusOK},
+		{name: "malformed replace body", contentType: domain.ReplaceMediaType, body: `{"price":`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode := ""
+			saved := &domain.Subscription{ID: id}
+			h := newTestHandler(&fakeSubscriptionService{
+				update: func(context.Context, uuid.UUID, *domain.UpdateSubscriptionRequest) (*domain.Subscription, error) {
+					mode = "update"
+					return saved, nil
+				},
+				put: func(_ context.Context, _ uuid.UUID, req *domain.CreateSubscriptionRequest) (*domain.Subscription, bool, error) {
+					mode = "put"
+					if req.ServiceName != "Netflix" || req.Price != 400 {
+						t.Errorf("put request = %+v, want the full body", req)
+					}
+					return saved, tt.created, nil
+				},
+				patch: func(context.Context, uuid.UUID, []jsonpatch.Operation) (*domain.Subscription, error) {
+					mode = "patch"
+					return saved, nil
+				},
+			})
+
+			rec := serve(http.MethodPut, "/subscriptions/:id", "/subscriptions/"+id.String(), tt.body,
+				map[string]string{"Content-Type": tt.contentType}, h.UpdateSubscription)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d (%s), want %d", rec.Code, rec.Body, tt.wantStatus)
+			}
+			if mode != tt.wantMode {
+				t.Errorf("mode = %q, want %q", mode, tt.wantMode)
+			}
+		})
+	}
+}
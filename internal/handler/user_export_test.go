@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestExportUserData(t *testing.T) {
+	userID := uuid.New()
+	exportedAt := time.Date(2025, time.March, 15, 12, 0, 0, 0, time.UTC)
+	deletedAt := exportedAt.Add(-time.Hour)
+	stored := []*domain.UserExportSubscription{
+		{
+			Subscription: domain.Subscription{ID: uuid.New(), ServiceName: "Netflix", UserID: userID, Tags: []string{}},
+			Pauses:       []domain.SubscriptionPause{{ID: uuid.New(), PauseStart: "2025-02-01", PauseEnd: "2025-02-28"}},
+			History:      []domain.HistoryEntry{{ID: uuid.New(), Action: domain.HistoryActionRenewed}},
+		},
+		{
+			Subscription: domain.Subscription{ID: uuid.New(), ServiceName: "Spotify", UserID: userID, Tags: []string{}, DeletedAt: &deletedAt},
+			Pauses:       []domain.SubscriptionPause{},
+			History:      []domain.HistoryEntry{},
+		},
+	}
+
+	tests := []struct {
+		name string
+		// failAfter makes the export fail once that many subscriptions
+		// were handed over; negative means it succeeds.
+		failAfter  int
+		stored     []*domain.UserExportSubscription
+		wantStatus int
+		wantCount  int
+		wantError  bool
+	}{
+		{name: "every subscription with pauses and history", failAfter: -1, stored: stored, wantStatus: http.StatusOK, wantCount: 2},
+		{name: "user without subscriptions", failAfter: -1, wantStatus: http.StatusOK},
+		{name: "failure before anything is written", failAfter: 0, stored: stored, wantStatus: http.StatusServiceUnavailable},
+		{name: "failure mid-stream still ends the document", failAfter: 1, stored: stored, wantStatus: http.StatusOK, wantCount: 1, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newBatchRouter(&fakeSubscriptionService{
+				exportUser: func(_ context.Context, id uuid.UUID, fn func(*domain.UserExportSubscription) error) (time.Time, error) {
+					if id != userID {
+						return time.Time{}, fmt.Errorf("exported user %s, want %s", id, userID)
+					}
+					for i, subscription := range tt.stored {
+						if i == tt.failAfter {
+							return time.Time{}, fmt.Errorf("%w: connection lost", domain.ErrDatabaseUnavailable)
+						}
+						if err := fn(subscription); err != nil {
+							return time.Time{}, err
+						}
+					}
+					return exportedAt, nil
+				},
+			})
+
+			rec := do(router, http.MethodGet, "/api/v1/users/"+userID.String()+"/export", "", true)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			// Whatever happened, the body is one valid JSON document.
+			var export domain.UserExport
+			if err := json.Unmarshal(rec.Body.Bytes(), &export); err != nil {
+				t.Fatalf("decode %s: %v", rec.Body, err)
+			}
+			if export.UserID != userID {
+				t.Errorf("user_id = %s, want %s", export.UserID, userID)
+			}
+			if len(export.Subscriptions) != tt.wantCount {
+				t.Fatalf("subscriptions = %d, want %d", len(export.Subscriptions), tt.wantCount)
+			}
+			for i, got := range export.Subscriptions {
+				want := stored[i]
+				if got.ID != want.ID || len(got.Pauses) != len(want.Pauses) || len(got.History) != len(want.History) || (got.DeletedAt != nil) != (want.DeletedAt != nil) {
+					t.Errorf("subscriptions[%d] = %+v, want %+v", i, got, want)
+				}
+			}
+
+			if tt.wantError {
+				if export.Error == "" || export.ExportedAt != nil {
+					t.Errorf("error = %q, exported_at = %v, want an error and no exported_at", export.Error, export.ExportedAt)
+				}
+				return
+			}
+			if export.Error != "" || export.ExportedAt == nil || !export.ExportedAt.Equal(exportedAt) {
+				t.Errorf("error = %q, exported_at = %v, want no error and %v", export.Error, export.ExportedAt, exportedAt)
+			}
+		})
+	}
+}
+
+func TestExportUserDataNeedsAdmin(t *testing.T) {
+	// The admin check runs before the service is reached.
+	router := newBatchRouter(&fakeSubscriptionService{})
+
+	rec := do(router, http.MethodGet, "/api/v1/users/"+uuid.NewString()+"/export", "", false)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", rec.Code)
+	}
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestCreateSubscriptionWarnings(t *testing.T) {
+	id := uuid.New()
+	lowPrice := []domain.FieldError{{Field: "price", Message: "price 9.99 RUB is unusually low"}}
+	h := newTestHandler(&fakeSubscriptionService{
+		create: func(context.Context, *domain.CreateSubscriptionRequest) (*domain.Subscription, error) {
+			return &domain.Subscription{ID: id}, nil
+		},
+		createWarnings: func(*domain.CreateSubscriptionRequest) []domain.FieldError { return lowPrice },
+	})
+	body := `{"service_name":"Netflix","price_minor":999,"currency":"RUB","user_id":"` + uuid.NewString() + `","start_date":"2025-01-01"}`
+
+	t.Run("wrapped with warnings on request", func(t *testing.T) {
+		rec := serve(http.MethodPost, "/subscriptions", "/subscriptions?warnings=true", body, nil, h.CreateSubscription)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+		}
+		var got domain.SubscriptionWithWarnings
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if got.Subscription == nil || got.Subscription.ID != id {
+			t.Errorf("subscription = %+v, want id %s", got.Subscription, id)
+		}
+		if len(got.Warnings) != 1 || got.Warnings[0] != lowPrice[0] {
+			t.Errorf("warnings = %+v, want %+v", got.Warnings, lowPrice)
+		}
+	})
+
+	t.Run("plain subscription by default", func(t *testing.T) {
+		rec := serve(http.MethodPost, "/subscriptions", "/subscriptions", body, nil, h.CreateSubscription)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+		}
+		var got map[string]interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if _, wrapped := got["warnings"]; wrapped || got["id"] != id.String() {
+			t.Errorf("body = %s, want the bare subscription", rec.Body)
+		}
+	})
+
+	t.Run("malformed flag", func(t *testing.T) {
+		rec := serve(http.MethodPost, "/subscriptions", "/subscriptions?warnings=maybe", body, nil, h.CreateSubscription)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", rec.Code)
+		}
+	})
+}
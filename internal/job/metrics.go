@@ -0,0 +1,80 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"subscription-service/internal/metrics"
+	"subscription-service/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// MetricsJob refreshes the business KPI gauges, once at start and then every
+// interval. The interval doubles as the window for new and churned counts.
+type MetricsJob struct {
+	service  service.KPIService
+	gauges   *metrics.KPIGauges
+	interval time.Duration
+	logger   *zap.Logger
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func NewMetricsJob(service service.KPIService, gauges *metrics.KPIGauges, interval time.Duration, logger *zap.Logger) *MetricsJob {
+	return &MetricsJob{
+		service:  service,
+		gauges:   gauges,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+func (j *MetricsJob) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	j.cancel = cancel
+	j.done = make(chan struct{})
+
+	j.logger.Info("starting metrics job", zap.Duration("interval", j.interval))
+
+	go func() {
+		defer close(j.done)
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			j.collect(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// collect updates the gauges; on failure they keep their previous values.
+func (j *MetricsJob) collect(ctx context.Context) {
+	snapshot, err := j.service.Snapshot(ctx, j.interval)
+	if err != nil {
+		if ctx.Err() == nil {
+			j.logger.Error("metrics job run failed", zap.Error(err))
+		}
+		return
+	}
+	j.gauges.Set(snapshot)
+}
+
+func (j *MetricsJob) Stop(ctx context.Context) error {
+	j.logger.Info("stopping metrics job")
+	j.cancel()
+
+	select {
+	case <-j.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
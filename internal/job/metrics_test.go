@@ -0,0 +1,124 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"subscription-service/internal/domain"
+	"subscription-service/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+)
+
+// fakeKPIService answers Snapshot with snapshot or err and records the
+// window it was asked for.
+type fakeKPIService struct {
+	mu       sync.Mutex
+	snapshot *domain.KPISnapshot
+	err      error
+	windows  []time.Duration
+}
+
+func (s *fakeKPIService) Snapshot(_ context.Context, window time.Duration) (*domain.KPISnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.windows = append(s.windows, window)
+	return s.snapshot, s.err
+}
+
+func TestMetricsJobRefreshesGauges(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	gauges, err := metrics.NewKPIGauges(registry)
+	if err != nil {
+		t.Fatalf("NewKPIGauges: %v", err)
+	}
+	svc := &fakeKPIService{snapshot: &domain.KPISnapshot{
+		AsOf:                    time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC),
+		ActiveSubscriptions:     7,
+		MonthlyRecurringRevenue: map[string]int64{"RUB": 40000},
+		New:                     2,
+		Churned:                 1,
+	}}
+	j := NewMetricsJob(svc, gauges, 5*time.Minute, zap.NewNop())
+
+	gauge := func(name string) float64 {
+		t.Helper()
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("gather: %v", err)
+		}
+		for _, family := range families {
+			if family.GetName() == name {
+				return family.GetMetric()[0].GetGauge().GetValue()
+			}
+		}
+		t.Fatalf("metric %s not exported", name)
+		return 0
+	}
+	want := map[string]float64{
+		"subscriptions_active":                    7,
+		"subscriptions_monthly_recurring_revenue": 400,
+		"subscriptions_new":                       2,
+		"subscriptions_churned":                   1,
+	}
+
+	j.collect(context.Background())
+	for name, value := range want {
+		if got := gauge(name); got != value {
+			t.Errorf("%s = %v, want %v", name, got, value)
+		}
+	}
+	if len(svc.windows) != 1 || svc.windows[0] != 5*time.Minute {
+		t.Errorf("windows = %v, want one snapshot over the 5m interval", svc.windows)
+	}
+
+	// A failed refresh keeps the previous values rather than zeroing them.
+	svc.snapshot, svc.err = nil, errors.New("database unavailable")
+	j.collect(context.Background())
+	for name, value := range want {
+		if got := gauge(name); got != value {
+			t.Errorf("%s after a failed refresh = %v, want %v", name, got, value)
+		}
+	}
+}
+
+func TestMetricsJobCollectsOnStart(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	gauges, err := metrics.NewKPIGauges(registry)
+	if err != nil {
+		t.Fatalf("NewKPIGauges: %v", err)
+	}
+	svc := &fakeKPIService{snapshot: &domain.KPISnapshot{ActiveSubscriptions: 3}}
+	j := NewMetricsJob(svc, gauges, time.Hour, zap.NewNop())
+
+	j.Start()
+	deadline := time.Now().Add(time.Second)
+	for {
+		svc.mu.Lock()
+		collected := len(svc.windows)
+		svc.mu.Unlock()
+		if collected > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no collection after start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := j.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP subscriptions_active Subscriptions running today.
+# TYPE subscriptions_active gauge
+subscriptions_active 3
+`), "subscriptions_active"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,104 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"subscription-service/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// OutboxJob periodically relays pending outbox events to the publisher.
+type OutboxJob struct {
+	relay        service.OutboxRelay
+	interval     time.Duration
+	drainTimeout time.Duration
+	logger       *zap.Logger
+	ctx          context.Context
+	cancel       context.CancelFunc
+	stop         chan struct{}
+	done         chan struct{}
+}
+
+func NewOutboxJob(relay service.OutboxRelay, interval, drainTimeout time.Duration, logger *zap.Logger) *OutboxJob {
+	return &OutboxJob{
+		relay:        relay,
+		interval:     interval,
+		drainTimeout: drainTimeout,
+		logger:       logger,
+	}
+}
+
+func (j *OutboxJob) Start() {
+	j.ctx, j.cancel = context.WithCancel(context.Background())
+	j.stop = make(chan struct{})
+	j.done = make(chan struct{})
+
+	j.logger.Info("starting outbox job", zap.Duration("interval", j.interval))
+
+	go func() {
+		defer close(j.done)
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-j.stop:
+				return
+			case <-ticker.C:
+				if _, err := j.relay.PublishPending(j.ctx); err != nil {
+					j.logger.Error("outbox job run failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// Stop ends the periodic runs, letting a run in progress finish, and then
+// drains the outbox so events written by the last requests still go out.
+// Waiting for the run and draining each get up to the drain timeout, so a
+// delivery stuck past its budget does not leave the drain without one.
+// Events left over stay pending for the next start and are logged.
+func (j *OutboxJob) Stop(ctx context.Context) error {
+	j.logger.Info("stopping outbox job", zap.Duration("drain_timeout", j.drainTimeout))
+	defer j.cancel()
+
+	waitCtx, cancelWait := context.WithTimeout(ctx, j.drainTimeout)
+	defer cancelWait()
+
+	close(j.stop)
+	select {
+	case <-j.done:
+	case <-waitCtx.Done():
+		// Abort the delivery in flight; its events stay pending and the
+		// drain below picks them up.
+		j.logger.Warn("outbox run still in progress at shutdown, aborting it")
+		j.cancel()
+		<-j.done
+	}
+
+	drainCtx, cancelDrain := context.WithTimeout(ctx, j.drainTimeout)
+	defer cancelDrain()
+	published, err := j.relay.Drain(drainCtx)
+	if err != nil && drainCtx.Err() == nil {
+		j.logger.Error("failed to drain outbox", zap.Error(err))
+	}
+
+	// The drain may have used up ctx, so the count gets a context of its own.
+	countCtx, cancelCount := context.WithTimeout(context.WithoutCancel(ctx), time.Second)
+	defer cancelCount()
+	pending, countErr := j.relay.CountPending(countCtx)
+	if countErr != nil {
+		j.logger.Error("failed to count undelivered outbox events", zap.Error(countErr))
+		return nil
+	}
+
+	if pending > 0 {
+		j.logger.Warn("outbox events left undelivered at shutdown", zap.Int("drained", published), zap.Int64("pending", pending))
+	} else {
+		j.logger.Info("outbox drained", zap.Int("drained", published))
+	}
+	return nil
+}
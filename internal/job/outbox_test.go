@@ -0,0 +1,124 @@
+package job
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"subscription-service/internal/domain"
+	"subscription-service/internal/service"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// fakeOutbox is an in-memory outbox. Like a database call, Process fails
+// once its context is done. When hang is set the first Process blocks
+// until its context ends, standing in for a delivery that is stuck.
+type fakeOutbox struct {
+	mu        sync.Mutex
+	pending   []*domain.OutboxEvent
+	published []uuid.UUID
+	hang      bool
+	started   chan struct{}
+}
+
+func newFakeOutbox(events int, hang bool) *fakeOutbox {
+	o := &fakeOutbox{hang: hang, started: make(chan struct{})}
+	for i := 0; i < events; i++ {
+		o.pending = append(o.pending, &domain.OutboxEvent{ID: uuid.New()})
+	}
+	return o
+}
+
+func (o *fakeOutbox) ProcessPending(ctx context.Context, limit int, publish func(context.Context, *domain.OutboxEvent) error) (int, error) {
+	o.mu.Lock()
+	hang := o.hang
+	if hang {
+		o.hang = false
+		close(o.started)
+	}
+	o.mu.Unlock()
+
+	if hang {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	count := 0
+	for len(o.pending) > 0 && count < limit {
+		event := o.pending[0]
+		if err := publish(ctx, event); err != nil {
+			return count, err
+		}
+		o.pending = o.pending[1:]
+		o.published = append(o.published, event.ID)
+		count++
+	}
+	return count, nil
+}
+
+func (o *fakeOutbox) CountPending(ctx context.Context) (int64, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return int64(len(o.pending)), nil
+}
+
+type acceptingPublisher struct{}
+
+func (acceptingPublisher) Publish(context.Context, *domain.OutboxEvent) error { return nil }
+
+func TestOutboxJobStopDrainsPendingEvents(t *testing.T) {
+	const (
+		events       = 25
+		batchSize    = 10
+		drainTimeout = 200 * time.Millisecond
+	)
+
+	tests := []struct {
+		name     string
+		interval time.Duration
+		// stuck leaves a periodic run hanging until Stop gives up on it.
+		stuck bool
+	}{
+		{name: "idle job drains on stop", interval: time.Hour},
+		{name: "drain still runs after a stuck delivery is aborted", interval: time.Millisecond, stuck: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := newFakeOutbox(events, tt.stuck)
+			relay := service.NewOutboxRelay(outbox, acceptingPublisher{}, batchSize, zap.NewNop())
+			j := NewOutboxJob(relay, tt.interval, drainTimeout, zap.NewNop())
+
+			j.Start()
+			if tt.stuck {
+				select {
+				case <-outbox.started:
+				case <-time.After(time.Second):
+					t.Fatal("periodic run never started")
+				}
+			}
+
+			stopped := time.Now()
+			if err := j.Stop(context.Background()); err != nil {
+				t.Fatalf("Stop: %v", err)
+			}
+
+			outbox.mu.Lock()
+			defer outbox.mu.Unlock()
+			if len(outbox.published) != events || len(outbox.pending) != 0 {
+				t.Errorf("published %d and left %d pending, want all %d published", len(outbox.published), len(outbox.pending), events)
+			}
+			if elapsed := time.Since(stopped); elapsed > 2*drainTimeout+time.Second {
+				t.Errorf("Stop took %s, want it bounded by the drain timeouts", elapsed)
+			}
+		})
+	}
+}
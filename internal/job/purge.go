@@ -0,0 +1,68 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"subscription-service/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// PurgeJob periodically removes subscriptions that have been soft-deleted
+// for longer than the retention period.
+type PurgeJob struct {
+	service   service.PurgeService
+	interval  time.Duration
+	olderThan time.Duration
+	logger    *zap.Logger
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+func NewPurgeJob(service service.PurgeService, interval, olderThan time.Duration, logger *zap.Logger) *PurgeJob {
+	return &PurgeJob{
+		service:   service,
+		interval:  interval,
+		olderThan: olderThan,
+		logger:    logger,
+	}
+}
+
+func (j *PurgeJob) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	j.cancel = cancel
+	j.done = make(chan struct{})
+
+	j.logger.Info("starting purge job", zap.Duration("interval", j.interval), zap.Duration("older_than", j.olderThan))
+
+	go func() {
+		defer close(j.done)
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := j.service.Purge(ctx, j.olderThan); err != nil {
+					j.logger.Error("purge job run failed", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+func (j *PurgeJob) Stop(ctx context.Context) error {
+	j.logger.Info("stopping purge job")
+	j.cancel()
+
+	select {
+	case <-j.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,72 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"subscription-service/internal/domain"
+	"subscription-service/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// RecomputeJob keeps the derived status and next_renewal_date columns
+// current as dates pass, once at start and then every interval. Without it
+// a subscription whose end date has gone by would read as active until the
+// next write or admin recompute.
+type RecomputeJob struct {
+	service   service.RecomputeService
+	interval  time.Duration
+	batchSize int
+	logger    *zap.Logger
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+func NewRecomputeJob(service service.RecomputeService, interval time.Duration, batchSize int, logger *zap.Logger) *RecomputeJob {
+	return &RecomputeJob{
+		service:   service,
+		interval:  interval,
+		batchSize: batchSize,
+		logger:    logger,
+	}
+}
+
+func (j *RecomputeJob) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	j.cancel = cancel
+	j.done = make(chan struct{})
+
+	j.logger.Info("starting recompute job", zap.Duration("interval", j.interval), zap.Int("batch_size", j.batchSize))
+
+	go func() {
+		defer close(j.done)
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := j.service.Recompute(ctx, &domain.RecomputeRequest{BatchSize: j.batchSize}); err != nil && ctx.Err() == nil {
+				j.logger.Error("recompute job run failed", zap.Error(err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (j *RecomputeJob) Stop(ctx context.Context) error {
+	j.logger.Info("stopping recompute job")
+	j.cancel()
+
+	select {
+	case <-j.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
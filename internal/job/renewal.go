@@ -0,0 +1,68 @@
+package job
+
+import (
+	"context"
+	"time"
+
+	"subscription-service/internal/service"
+
+	"go.uber.org/zap"
+)
+
+// RenewalJob extends auto-renewing subscriptions that are due, once at
+// start so renewals missed while the service was down are not left waiting
+// a full interval, and then every interval.
+type RenewalJob struct {
+	service  service.RenewalService
+	interval time.Duration
+	logger   *zap.Logger
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func NewRenewalJob(service service.RenewalService, interval time.Duration, logger *zap.Logger) *RenewalJob {
+	return &RenewalJob{
+		service:  service,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+func (j *RenewalJob) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	j.cancel = cancel
+	j.done = make(chan struct{})
+
+	j.logger.Info("starting renewal job", zap.Duration("interval", j.interval))
+
+	go func() {
+		defer close(j.done)
+
+		ticker := time.NewTicker(j.interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := j.service.ProcessDueRenewals(ctx); err != nil && ctx.Err() == nil {
+				j.logger.Error("renewal job run failed", zap.Error(err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (j *RenewalJob) Stop(ctx context.Context) error {
+	j.logger.Info("stopping renewal job")
+	j.cancel()
+
+	select {
+	case <-j.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,364 @@
+// Package jsonpatch applies JSON Patch documents (RFC 6902) to JSON values.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const MediaType = "application/json-patch+json"
+
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+	OpMove    = "move"
+	OpCopy    = "copy"
+	OpTest    = "test"
+)
+
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty" swaggertype:"object"`
+}
+
+// Error reports the operation that could not be applied. Index is its
+// position in the patch document.
+type Error struct {
+	Index   int
+	Op      string
+	Path    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("operation %d (%s %s): %s", e.Index, e.Op, e.Path, e.Message)
+}
+
+// Apply applies patch to doc and returns the patched document. Operations
+// run in order and the patch is all or nothing: the first failing operation
+// aborts it and doc is left as it was.
+func Apply(doc []byte, patch []Operation) ([]byte, error) {
+	root, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, op := range patch {
+		fail := func(format string, args ...interface{}) error {
+			return &Error{Index: i, Op: op.Op, Path: op.Path, Message: fmt.Sprintf(format, args...)}
+		}
+
+		path, err := parsePointer(op.Path)
+		if err != nil {
+			return nil, fail("%v", err)
+		}
+
+		switch op.Op {
+		case OpAdd, OpReplace, OpTest:
+			if len(op.Value) == 0 {
+				return nil, fail("value is required")
+			}
+			value, err := decode(op.Value)
+			if err != nil {
+				return nil, fail("invalid value: %v", err)
+			}
+
+			switch op.Op {
+			case OpAdd:
+				root, err = add(root, path, value)
+			case OpReplace:
+				root, err = replace(root, path, value)
+			case OpTest:
+				var current interface{}
+				current, err = get(root, path)
+				if err == nil && !equal(current, value) {
+					err = fmt.Errorf("value does not match")
+				}
+			}
+			if err != nil {
+				return nil, fail("%v", err)
+			}
+		case OpRemove:
+			if root, _, err = remove(root, path); err != nil {
+				return nil, fail("%v", err)
+			}
+		case OpMove, OpCopy:
+			from, err := parsePointer(op.From)
+			if err != nil {
+				return nil, fail("from: %v", err)
+			}
+
+			var value interface{}
+			if op.Op == OpMove {
+				if isPrefix(from, path) && len(from) < len(path) {
+					return nil, fail("cannot move a value into one of its children")
+				}
+				root, value, err = remove(root, from)
+			} else {
+				value, err = get(root, from)
+				if err == nil {
+					value, err = deepCopy(value)
+				}
+			}
+			if err != nil {
+				return nil, fail("from: %v", err)
+			}
+
+			if root, err = add(root, path, value); err != nil {
+				return nil, fail("%v", err)
+			}
+		default:
+			return nil, fail("unsupported operation %q", op.Op)
+		}
+	}
+
+	return json.Marshal(root)
+}
+
+func decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// equal compares two decoded JSON values the way the test operation must:
+// numbers by value, so 1 and 1.0 match, objects regardless of member order
+// and arrays element by element.
+func equal(a, b interface{}) bool {
+	switch x := a.(type) {
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		rx, okx := new(big.Rat).SetString(x.String())
+		ry, oky := new(big.Rat).SetString(y.String())
+		return okx && oky && rx.Cmp(ry) == 0
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for key, value := range x {
+			other, ok := y[key]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		// Strings, booleans and null compare directly.
+		return a == b
+	}
+}
+
+func deepCopy(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return decode(data)
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into unescaped reference
+// tokens. The empty pointer refers to the whole document.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("path %q must start with /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func get(root interface{}, path []string) (interface{}, error) {
+	current := root
+	for _, token := range path {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("path not found: member %q does not exist", token)
+			}
+			current = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("path not found: %q is not inside an object or array", token)
+		}
+	}
+	return current, nil
+}
+
+// update replaces the container at the parent of path with the result of
+// fn, which receives the container and the last path token.
+func update(root interface{}, path []string, fn func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 0 {
+		return fn(nil, "")
+	}
+
+	parent, err := get(root, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	updated, err := fn(parent, path[len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	if len(path) == 1 {
+		return updated, nil
+	}
+
+	// Arrays may have been reallocated, so write the new container back.
+	return set(root, path[:len(path)-1], updated)
+}
+
+func set(root interface{}, path []string, value interface{}) (interface{}, error) {
+	return update(root, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			node[index] = value
+			return node, nil
+		default:
+			return value, nil
+		}
+	})
+}
+
+func add(root interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return update(root, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			if token == "-" {
+				return append(node, value), nil
+			}
+			index, err := arrayIndex(token, len(node))
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, nil)
+			copy(node[index+1:], node[index:])
+			node[index] = value
+			return node, nil
+		default:
+			return nil, fmt.Errorf("path not found: parent is not an object or array")
+		}
+	})
+}
+
+func replace(root interface{}, path []string, value interface{}) (interface{}, error) {
+	if _, err := get(root, path); err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return value, nil
+	}
+	return set(root, path, value)
+}
+
+// remove deletes the value at path and returns the new root along with the
+// removed value.
+func remove(root interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("cannot remove the whole document")
+	}
+
+	removed, err := get(root, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root, err = update(root, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			delete(node, token)
+			return node, nil
+		case []interface{}:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(node[:index], node[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("path not found: parent is not an object or array")
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return root, removed, nil
+}
+
+// arrayIndex parses an array reference token, accepting indexes up to max.
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if index > max {
+		return 0, fmt.Errorf("array index %d out of range", index)
+	}
+	return index, nil
+}
@@ -0,0 +1,134 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string
+		// wantIndex is the index of the operation expected to fail, or -1
+		// when the patch applies.
+		wantIndex int
+	}{
+		// add
+		{name: "add a member", doc: `{"a":1}`, patch: `[{"op":"add","path":"/b","value":2}]`, want: `{"a":1,"b":2}`, wantIndex: -1},
+		{name: "add over an existing member", doc: `{"a":1}`, patch: `[{"op":"add","path":"/a","value":[3]}]`, want: `{"a":[3]}`, wantIndex: -1},
+		{name: "add appends with -", doc: `{"a":[1,2]}`, patch: `[{"op":"add","path":"/a/-","value":3}]`, want: `{"a":[1,2,3]}`, wantIndex: -1},
+		{name: "add inserts before an index", doc: `{"a":[1,2]}`, patch: `[{"op":"add","path":"/a/1","value":9}]`, want: `{"a":[1,9,2]}`, wantIndex: -1},
+		{name: "add inserts at the front", doc: `{"a":[1,2]}`, patch: `[{"op":"add","path":"/a/0","value":9}]`, want: `{"a":[9,1,2]}`, wantIndex: -1},
+		{name: "add at the array length appends", doc: `{"a":[1,2]}`, patch: `[{"op":"add","path":"/a/2","value":9}]`, want: `{"a":[1,2,9]}`, wantIndex: -1},
+		{name: "add inside an array element", doc: `{"a":[{"b":1}]}`, patch: `[{"op":"add","path":"/a/0/c","value":2}]`, want: `{"a":[{"b":1,"c":2}]}`, wantIndex: -1},
+		{name: "add replaces the whole document", doc: `{"a":1}`, patch: `[{"op":"add","path":"","value":{"b":2}}]`, want: `{"b":2}`, wantIndex: -1},
+		{name: "add past the array length", doc: `{"a":[1,2]}`, patch: `[{"op":"add","path":"/a/3","value":9}]`, wantIndex: 0},
+		{name: "add under a missing parent", doc: `{"a":1}`, patch: `[{"op":"add","path":"/x/y","value":9}]`, wantIndex: 0},
+		{name: "add without a value", doc: `{"a":1}`, patch: `[{"op":"add","path":"/b"}]`, wantIndex: 0},
+
+		// remove and replace
+		{name: "remove an array element", doc: `{"a":[1,2,3]}`, patch: `[{"op":"remove","path":"/a/1"}]`, want: `{"a":[1,3]}`, wantIndex: -1},
+		{name: "remove past the last element", doc: `{"a":[1,2]}`, patch: `[{"op":"remove","path":"/a/2"}]`, wantIndex: 0},
+		{name: "remove with a leading zero index", doc: `{"a":[1,2]}`, patch: `[{"op":"remove","path":"/a/01"}]`, wantIndex: 0},
+		{name: "remove with a negative index", doc: `{"a":[1,2]}`, patch: `[{"op":"remove","path":"/a/-1"}]`, wantIndex: 0},
+		{name: "remove with - as the index", doc: `{"a":[1,2]}`, patch: `[{"op":"remove","path":"/a/-"}]`, wantIndex: 0},
+		{name: "remove the whole document", doc: `{"a":1}`, patch: `[{"op":"remove","path":""}]`, wantIndex: 0},
+		{name: "replace an array element", doc: `{"a":[1,2]}`, patch: `[{"op":"replace","path":"/a/1","value":5}]`, want: `{"a":[1,5]}`, wantIndex: -1},
+		{name: "replace a missing member", doc: `{"a":1}`, patch: `[{"op":"replace","path":"/b","value":5}]`, wantIndex: 0},
+		{name: "replace past the last element", doc: `{"a":[1,2]}`, patch: `[{"op":"replace","path":"/a/2","value":5}]`, wantIndex: 0},
+
+		// move
+		{name: "move a member", doc: `{"a":{"b":1},"c":{}}`, patch: `[{"op":"move","from":"/a/b","path":"/c/d"}]`, want: `{"a":{},"c":{"d":1}}`, wantIndex: -1},
+		{name: "move an element to the end", doc: `{"a":[1,2,3]}`, patch: `[{"op":"move","from":"/a/0","path":"/a/-"}]`, want: `{"a":[2,3,1]}`, wantIndex: -1},
+		{name: "move onto itself", doc: `{"a":{"b":1}}`, patch: `[{"op":"move","from":"/a","path":"/a"}]`, want: `{"a":{"b":1}}`, wantIndex: -1},
+		{name: "move into its own child", doc: `{"a":{"b":1}}`, patch: `[{"op":"move","from":"/a","path":"/a/b/c"}]`, wantIndex: 0},
+		{name: "move from a missing member", doc: `{"a":1}`, patch: `[{"op":"move","from":"/b","path":"/c"}]`, wantIndex: 0},
+		{name: "move from past the last element", doc: `{"a":[1]}`, patch: `[{"op":"move","from":"/a/1","path":"/b"}]`, wantIndex: 0},
+
+		// copy
+		{name: "copy a member", doc: `{"a":{"b":1}}`, patch: `[{"op":"copy","from":"/a","path":"/c"}]`, want: `{"a":{"b":1},"c":{"b":1}}`, wantIndex: -1},
+		{name: "copy into an array", doc: `{"a":[1,2],"b":3}`, patch: `[{"op":"copy","from":"/b","path":"/a/1"}]`, want: `{"a":[1,3,2],"b":3}`, wantIndex: -1},
+		{name: "copy is independent of its source", doc: `{"a":{"b":1}}`, patch: `[{"op":"copy","from":"/a","path":"/c"},{"op":"add","path":"/c/d","value":2}]`, want: `{"a":{"b":1},"c":{"b":1,"d":2}}`, wantIndex: -1},
+		{name: "copy from a missing member", doc: `{"a":1}`, patch: `[{"op":"copy","from":"/b","path":"/c"}]`, wantIndex: 0},
+
+		// test
+		{name: "test an equal string", doc: `{"a":"x"}`, patch: `[{"op":"test","path":"/a","value":"x"}]`, want: `{"a":"x"}`, wantIndex: -1},
+		{name: "test 1 against 1.0", doc: `{"a":1}`, patch: `[{"op":"test","path":"/a","value":1.0}]`, want: `{"a":1}`, wantIndex: -1},
+		{name: "test 100 against 1e2", doc: `{"a":100}`, patch: `[{"op":"test","path":"/a","value":1e2}]`, want: `{"a":100}`, wantIndex: -1},
+		{name: "test numbers nested in objects and arrays", doc: `{"a":{"x":[1,2.50],"y":null}}`, patch: `[{"op":"test","path":"/a","value":{"y":null,"x":[1.0,2.5]}}]`, want: `{"a":{"x":[1,2.50],"y":null}}`, wantIndex: -1},
+		{name: "test unequal numbers", doc: `{"a":1}`, patch: `[{"op":"test","path":"/a","value":1.5}]`, wantIndex: 0},
+		{name: "test a number against a string", doc: `{"a":1}`, patch: `[{"op":"test","path":"/a","value":"1"}]`, wantIndex: 0},
+		{name: "test arrays of different length", doc: `{"a":["x"]}`, patch: `[{"op":"test","path":"/a","value":["x","y"]}]`, wantIndex: 0},
+		{name: "test objects with an extra member", doc: `{"a":{"x":1}}`, patch: `[{"op":"test","path":"/a","value":{"x":1,"y":2}}]`, wantIndex: 0},
+		{name: "test a missing member", doc: `{"a":1}`, patch: `[{"op":"test","path":"/b","value":1}]`, wantIndex: 0},
+
+		// escaping
+		{name: "~1 stands for /", doc: `{"a/b":1}`, patch: `[{"op":"replace","path":"/a~1b","value":2}]`, want: `{"a/b":2}`, wantIndex: -1},
+		{name: "~0 stands for ~", doc: `{"m~n":1}`, patch: `[{"op":"remove","path":"/m~0n"}]`, want: `{}`, wantIndex: -1},
+		{name: "~01 is ~1, not /", doc: `{"~1":1,"/":2}`, patch: `[{"op":"remove","path":"/~01"}]`, want: `{"/":2}`, wantIndex: -1},
+		{name: "escaped from and path", doc: `{"a/b":{"c~d":1}}`, patch: `[{"op":"move","from":"/a~1b/c~0d","path":"/e~1f"}]`, want: `{"a/b":{},"e/f":1}`, wantIndex: -1},
+
+		// the patch as a whole
+		{name: "operations run in order", doc: `{"a":1}`, patch: `[{"op":"add","path":"/b","value":2},{"op":"test","path":"/b","value":2},{"op":"remove","path":"/a"}]`, want: `{"b":2}`, wantIndex: -1},
+		{name: "a later failure reports its index", doc: `{"a":1}`, patch: `[{"op":"add","path":"/b","value":2},{"op":"test","path":"/b","value":3}]`, wantIndex: 1},
+		{name: "unsupported operation", doc: `{"a":1}`, patch: `[{"op":"merge","path":"/a","value":2}]`, wantIndex: 0},
+		{name: "path without a leading slash", doc: `{"a":1}`, patch: `[{"op":"remove","path":"a"}]`, wantIndex: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch []Operation
+			if err := json.Unmarshal([]byte(tt.patch), &patch); err != nil {
+				t.Fatalf("decode patch: %v", err)
+			}
+
+			got, err := Apply([]byte(tt.doc), patch)
+			if tt.wantIndex >= 0 {
+				var patchErr *Error
+				if !errors.As(err, &patchErr) {
+					t.Fatalf("Apply = %s, %v, want an operation error", got, err)
+				}
+				if patchErr.Index != tt.wantIndex {
+					t.Errorf("failed operation = %d, want %d (%v)", patchErr.Index, tt.wantIndex, err)
+				}
+				if got != nil {
+					t.Errorf("failed patch returned %s, want nothing", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+
+			var gotValue, wantValue interface{}
+			if err := json.Unmarshal(got, &gotValue); err != nil {
+				t.Fatalf("decode result %s: %v", got, err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &wantValue); err != nil {
+				t.Fatalf("decode want: %v", err)
+			}
+			if !reflect.DeepEqual(gotValue, wantValue) {
+				t.Errorf("Apply = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyLeavesDocUnchanged(t *testing.T) {
+	doc := []byte(`{"a":[1,2,3]}`)
+	patch := []Operation{
+		{Op: OpRemove, Path: "/a/0"},
+		{Op: OpTest, Path: "/a/0", Value: json.RawMessage(`1`)},
+	}
+
+	if _, err := Apply(doc, patch); err == nil {
+		t.Fatal("Apply succeeded, want the test operation to fail")
+	}
+	if string(doc) != `{"a":[1,2,3]}` {
+		t.Errorf("doc = %s after a failed patch, want it untouched", doc)
+	}
+}
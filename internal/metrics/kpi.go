@@ -0,0 +1,72 @@
+// Package metrics holds the Prometheus collectors the service exports on
+// /metrics.
+package metrics
+
+import (
+	"subscription-service/internal/domain"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "subscriptions"
+
+// KPIGauges exports the business metrics. They are refreshed by the metrics
+// job rather than computed on scrape, so scrapes never hit the database.
+type KPIGauges struct {
+	active  prometheus.Gauge
+	mrr     *prometheus.GaugeVec
+	new     prometheus.Gauge
+	churned prometheus.Gauge
+	updated prometheus.Gauge
+}
+
+func NewKPIGauges(registerer prometheus.Registerer) (*KPIGauges, error) {
+	g := &KPIGauges{
+		active: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "active",
+			Help:      "Subscriptions running today.",
+		}),
+		mrr: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "monthly_recurring_revenue",
+			Help:      "Monthly recurring revenue of the subscriptions running today, in units of each currency.",
+		}, []string{"currency"}),
+		new: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "new",
+			Help:      "Subscriptions created during the last collection interval.",
+		}),
+		churned: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "churned",
+			Help:      "Subscriptions that ended or were deleted during the last collection interval.",
+		}),
+		updated: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "kpi_last_update_timestamp_seconds",
+			Help:      "When the subscription KPIs were last refreshed.",
+		}),
+	}
+
+	for _, collector := range []prometheus.Collector{g.active, g.mrr, g.new, g.churned, g.updated} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+func (g *KPIGauges) Set(snapshot *domain.KPISnapshot) {
+	g.active.Set(float64(snapshot.ActiveSubscriptions))
+	// Currencies that no longer have revenue would otherwise keep reporting
+	// their last value.
+	g.mrr.Reset()
+	for currency, minor := range snapshot.MonthlyRecurringRevenue {
+		scale, _ := domain.FromWholeUnits(1, currency)
+		g.mrr.WithLabelValues(currency).Set(float64(minor) / float64(scale))
+	}
+	g.new.Set(float64(snapshot.New))
+	g.churned.Set(float64(snapshot.Churned))
+	g.updated.Set(float64(snapshot.AsOf.Unix()))
+}
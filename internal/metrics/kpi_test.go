@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"subscription-service/internal/domain"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestKPIGaugesSet(t *testing.T) {
+	g, err := NewKPIGauges(prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("NewKPIGauges: %v", err)
+	}
+
+	asOf := time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+	g.Set(&domain.KPISnapshot{
+		AsOf:                    asOf,
+		ActiveSubscriptions:     12,
+		MonthlyRecurringRevenue: map[string]int64{"RUB": 123450, "JPY": 1500, "BHD": 2500},
+		New:                     3,
+		Churned:                 2,
+	})
+
+	tests := []struct {
+		name      string
+		collector prometheus.Collector
+		want      float64
+	}{
+		{name: "active", collector: g.active, want: 12},
+		{name: "new", collector: g.new, want: 3},
+		{name: "churned", collector: g.churned, want: 2},
+		{name: "last update", collector: g.updated, want: float64(asOf.Unix())},
+		{name: "revenue in roubles", collector: g.mrr.WithLabelValues("RUB"), want: 1234.5},
+		{name: "revenue in yen, which has no minor unit", collector: g.mrr.WithLabelValues("JPY"), want: 1500},
+		{name: "revenue in dinars, with three decimals", collector: g.mrr.WithLabelValues("BHD"), want: 2.5},
+	}
+	for _, tt := range tests {
+		if got := testutil.ToFloat64(tt.collector); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// A later refresh replaces every value, and a currency that no longer
+	// has revenue stops being reported.
+	g.Set(&domain.KPISnapshot{
+		AsOf:                    asOf.Add(time.Minute),
+		ActiveSubscriptions:     11,
+		MonthlyRecurringRevenue: map[string]int64{"RUB": 100000},
+	})
+	if got := testutil.ToFloat64(g.active); got != 11 {
+		t.Errorf("active after refresh = %v, want 11", got)
+	}
+	if got := testutil.ToFloat64(g.new); got != 0 {
+		t.Errorf("new after refresh = %v, want 0", got)
+	}
+	if got := testutil.CollectAndCount(g.mrr); got != 1 {
+		t.Errorf("revenue series after refresh = %d, want only RUB", got)
+	}
+	if got := testutil.ToFloat64(g.mrr.WithLabelValues("RUB")); got != 1000 {
+		t.Errorf("RUB revenue after refresh = %v, want 1000", got)
+	}
+}
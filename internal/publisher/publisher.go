@@ -0,0 +1,83 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"subscription-service/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+// Publisher delivers outbox events to the outside world. Delivery is at
+// least once, so receivers should deduplicate on the event id.
+type Publisher interface {
+	Publish(ctx context.Context, event *domain.OutboxEvent) error
+}
+
+type webhookPublisher struct {
+	url    string
+	client *http.Client
+	logger *zap.Logger
+}
+
+// NewWebhookPublisher posts each event as JSON to url. Any non-2xx response
+// counts as a failed delivery.
+func NewWebhookPublisher(url string, timeout time.Duration, logger *zap.Logger) Publisher {
+	return &webhookPublisher{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+		logger: logger,
+	}
+}
+
+func (p *webhookPublisher) Publish(ctx context.Context, event *domain.OutboxEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-ID", event.ID.String())
+	req.Header.Set("X-Event-Type", event.Type)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	p.logger.Debug("event delivered", zap.String("id", event.ID.String()), zap.String("type", event.Type))
+	return nil
+}
+
+type logPublisher struct {
+	logger *zap.Logger
+}
+
+// NewLogPublisher writes events to the log. It is used when no webhook is
+// configured so the outbox still drains.
+func NewLogPublisher(logger *zap.Logger) Publisher {
+	return &logPublisher{logger: logger}
+}
+
+func (p *logPublisher) Publish(ctx context.Context, event *domain.OutboxEvent) error {
+	p.logger.Info("event published",
+		zap.String("id", event.ID.String()),
+		zap.String("type", event.Type),
+		zap.String("aggregate_id", event.AggregateID.String()),
+	)
+	return nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+// AnonymizeUser detaches every subscription of userID, soft-deleted ones
+// included, from the user in one transaction and returns how many changed.
+// user_id becomes tombstone and metadata is emptied. The same transaction
+// clears the history details of those subscriptions and of every entry
+// naming the user as a reassignment's from or to user, and rewrites every
+// outbox event carrying the user, pending or published, to the tombstone
+// with empty metadata. Each subscription then gets an anonymized history
+// entry and an updated event. Prices, dates and services stay as they were,
+// so aggregates are unaffected.
+//
+// An event the relay claimed before the transaction may still be delivered
+// with its original payload.
+func (r *subscriptionRepository) AnonymizeUser(ctx context.Context, userID, tombstone uuid.UUID) (int64, error) {
+	r.logger.Info("anonymizing user", zap.String("user_id", userID.String()))
+
+	var anonymized int64
+	err := r.withTx(ctx, func(queries *sqlc.Queries) error {
+		anonymized = 0
+
+		subs, err := queries.AnonymizeUserSubscriptions(ctx, sqlc.AnonymizeUserSubscriptionsParams{
+			TombstoneUserID: pgtype.UUID{Bytes: tombstone, Valid: true},
+			UserID:          pgtype.UUID{Bytes: userID, Valid: true},
+		})
+		if err != nil {
+			r.logger.Error("failed to anonymize subscriptions", zap.Error(err))
+			return err
+		}
+
+		// History and events can name the user even when no subscription
+		// is theirs any more, such as after a reassignment.
+		ids := make([]pgtype.UUID, len(subs))
+		for i := range subs {
+			ids[i] = subs[i].ID
+		}
+		if err := queries.ScrubSubscriptionHistory(ctx, sqlc.ScrubSubscriptionHistoryParams{
+			SubscriptionIds: ids,
+			UserID:          userID.String(),
+		}); err != nil {
+			r.logger.Error("failed to scrub subscription history", zap.Error(err))
+			return err
+		}
+		scrubbed, err := queries.ScrubUserOutboxEvents(ctx, sqlc.ScrubUserOutboxEventsParams{
+			TombstoneUserID: tombstone.String(),
+			UserID:          userID.String(),
+		})
+		if err != nil {
+			r.logger.Error("failed to scrub outbox events", zap.Error(err))
+			return err
+		}
+		r.logger.Info("scrubbed outbox events", zap.Int64("events", scrubbed))
+
+		for i := range subs {
+			if err := queries.CreateHistoryEntry(ctx, sqlc.CreateHistoryEntryParams{
+				SubscriptionID: subs[i].ID,
+				Action:         domain.HistoryActionAnonymized,
+			}); err != nil {
+				r.logger.Error("failed to record anonymization history", zap.Error(err))
+				return err
+			}
+			if err := enqueueEvent(ctx, queries, domain.EventSubscriptionUpdated, subs[i].ID, r.convertToSubscription(&subs[i])); err != nil {
+				return err
+			}
+		}
+		anonymized = int64(len(subs))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	r.logger.Info("user anonymized successfully", zap.Int64("anonymized", anonymized))
+	return anonymized, nil
+}
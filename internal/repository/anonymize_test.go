@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestAnonymizeUserScrubsHistoryAndOutbox(t *testing.T) {
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, false)
+	ctx := context.Background()
+	userID, otherID, tombstone := uuid.New(), uuid.New(), uuid.New()
+
+	create := func(owner uuid.UUID, service string) {
+		t.Helper()
+		if _, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{
+			ServiceName: service,
+			PriceMinor:  400,
+			UserID:      owner,
+			StartDate:   "2025-01-01",
+			Metadata:    []byte(`{"email": "user@example.com"}`),
+		}); err != nil {
+			t.Fatalf("create %s: %v", service, err)
+		}
+	}
+
+	// The user's first subscription moves to another user, leaving history
+	// and events that name them on a subscription they no longer own. The
+	// events created so far are then published, so both published and
+	// pending events are covered.
+	create(userID, "Netflix")
+	if _, err := repo.ReassignUser(ctx, userID, otherID); err != nil {
+		t.Fatalf("reassign: %v", err)
+	}
+	if _, err := pool.Exec(ctx, "UPDATE outbox_events SET published_at = NOW()"); err != nil {
+		t.Fatalf("publish events: %v", err)
+	}
+	create(userID, "Spotify")
+
+	anonymized, err := repo.AnonymizeUser(ctx, userID, tombstone)
+	if err != nil {
+		t.Fatalf("AnonymizeUser: %v", err)
+	}
+	if anonymized != 1 {
+		t.Errorf("anonymized = %d, want 1", anonymized)
+	}
+
+	mentions := "%" + userID.String() + "%"
+	tests := []struct {
+		name  string
+		query string
+		args  []interface{}
+		want  int
+	}{
+		{name: "subscriptions", query: "SELECT COUNT(*) FROM subscriptions WHERE user_id = $1", args: []interface{}{userID}},
+		{name: "history details", query: "SELECT COUNT(*) FROM subscription_history WHERE details::TEXT LIKE $1", args: []interface{}{mentions}},
+		{name: "published events", query: "SELECT COUNT(*) FROM outbox_events WHERE published_at IS NOT NULL AND payload::TEXT LIKE $1", args: []interface{}{mentions}},
+		{name: "pending events", query: "SELECT COUNT(*) FROM outbox_events WHERE published_at IS NULL AND payload::TEXT LIKE $1", args: []interface{}{mentions}},
+		{name: "scrubbed event metadata", query: "SELECT COUNT(*) FROM outbox_events WHERE payload->>'user_id' = $1 AND payload->'metadata' <> '{}'::JSONB", args: []interface{}{tombstone.String()}},
+		{name: "events of the other user keep them", query: "SELECT COUNT(*) FROM outbox_events WHERE payload->>'user_id' = $1", args: []interface{}{otherID.String()}, want: 1},
+		{name: "scrubbed events carry the tombstone", query: "SELECT COUNT(*) FROM outbox_events WHERE payload->>'user_id' = $1", args: []interface{}{tombstone.String()}, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got int
+			if err := pool.QueryRow(ctx, tt.query, tt.args...).Scan(&got); err != nil {
+				t.Fatalf("query: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("count = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
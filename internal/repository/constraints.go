@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository/sqlc"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+const (
+	primaryKey = "subscriptions_pkey"
+
+	uniqueViolationCode = "23505"
+)
+
+// checkOverlap fails with domain.ErrDuplicateSubscription when the repository
+// enforces unique active subscriptions and sub, already written in the
+// transaction queries is bound to, overlaps another live subscription of
+// the same user to the same service. Dates are compared as in
+// FindOverlapping, with a missing end date running forever. Callers must run
+// in withCheckedTx so that a concurrent write cannot slip past the check.
+func (r *subscriptionRepository) checkOverlap(ctx context.Context, queries *sqlc.Queries, sub *sqlc.Subscription) error {
+	if !r.enforceUniqueActive {
+		return nil
+	}
+
+	ids, err := queries.ListOverlappingSubscriptionIDs(ctx, sqlc.ListOverlappingSubscriptionIDsParams{
+		UserID:      sub.UserID,
+		ServiceName: sub.ServiceName,
+		ExcludeID:   sub.ID,
+		EndDate:     sub.EndDate,
+		StartDate:   sub.StartDate,
+	})
+	if err != nil {
+		r.logger.Error("failed to check for overlapping subscriptions", zap.Error(err))
+		return err
+	}
+	if len(ids) > 0 {
+		r.logger.Warn("write would overlap an active subscription",
+			zap.String("service_name", sub.ServiceName),
+			zap.Int("conflicts", len(ids)),
+		)
+		return domain.ErrDuplicateSubscription
+	}
+	return nil
+}
+
+// mapConstraintError translates a clash on a client-chosen id, which a
+// concurrent create can still cause, into domain.ErrSubscriptionIDConflict.
+// Other errors are returned untouched.
+func mapConstraintError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != uniqueViolationCode {
+		return err
+	}
+	if pgErr.ConstraintName == primaryKey {
+		return domain.ErrSubscriptionIDConflict
+	}
+	return err
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestCreateUniqueActive(t *testing.T) {
+	pool := newTestPool(t)
+
+	tests := []struct {
+		name    string
+		enforce bool
+		first   *domain.CreateSubscriptionRequest
+		second  *domain.CreateSubscriptionRequest
+		wantErr error
+	}{
+		{
+			name:    "enforced rejects a second open-ended subscription",
+			enforce: true,
+			first:   &domain.CreateSubscriptionRequest{StartDate: "2025-01-01"},
+			second:  &domain.CreateSubscriptionRequest{StartDate: "2025-06-01"},
+			wantErr: domain.ErrDuplicateSubscription,
+		},
+		{
+			name:    "enforced rejects a dated term inside an existing one",
+			enforce: true,
+			first:   &domain.CreateSubscriptionRequest{StartDate: "2025-01-01", EndDate: strPtr("2025-12-31")},
+			second:  &domain.CreateSubscriptionRequest{StartDate: "2025-03-01", EndDate: strPtr("2025-04-30")},
+			wantErr: domain.ErrDuplicateSubscription,
+		},
+		{
+			name:    "enforced allows consecutive terms",
+			enforce: true,
+			first:   &domain.CreateSubscriptionRequest{StartDate: "2025-01-01", EndDate: strPtr("2025-06-30")},
+			second:  &domain.CreateSubscriptionRequest{StartDate: "2025-07-01"},
+		},
+		{
+			name:   "not enforced allows duplicates",
+			first:  &domain.CreateSubscriptionRequest{StartDate: "2025-01-01"},
+			second: &domain.CreateSubscriptionRequest{StartDate: "2025-01-01"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newTestRepository(t, pool, tt.enforce)
+			userID := uuid.New()
+
+			for _, req := range []*domain.CreateSubscriptionRequest{tt.first, tt.second} {
+				req.UserID = userID
+				req.ServiceName = "Netflix"
+				req.PriceMinor = 400
+			}
+
+			if _, err := repo.Create(ctx, tt.first); err != nil {
+				t.Fatalf("first create: %v", err)
+			}
+			_, err := repo.Create(ctx, tt.second)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("second create: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("second create: got %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateUniqueActiveConcurrent(t *testing.T) {
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, true)
+	userID := uuid.New()
+
+	const writers = 2
+	errs := make([]error, writers)
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = repo.Create(context.Background(), &domain.CreateSubscriptionRequest{
+				ServiceName: "Spotify",
+				PriceMinor:  200,
+				UserID:      userID,
+				StartDate:   "2025-01-01",
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	created := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, domain.ErrDuplicateSubscription):
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if created != 1 {
+		t.Fatalf("created %d subscriptions, want 1", created)
+	}
+}
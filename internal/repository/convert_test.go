@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"subscription-service/internal/repository/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+func TestConvertToSubscriptionTimestampsUTC(t *testing.T) {
+	repo := &subscriptionRepository{logger: zap.NewNop()}
+
+	// pgx hands back timestamptz values in the process's local zone; any
+	// zone other than UTC stands in for that here.
+	for _, zone := range []*time.Location{
+		time.FixedZone("MSK", 3*60*60),
+		time.FixedZone("PDT", -7*60*60),
+	} {
+		t.Run(zone.String(), func(t *testing.T) {
+			at := time.Date(2025, time.March, 15, 14, 30, 0, 0, zone)
+			sub := repo.convertToSubscription(&sqlc.Subscription{
+				ServiceName: "Netflix",
+				Currency:    "RUB",
+				CreatedAt:   pgtype.Timestamptz{Time: at, Valid: true},
+				UpdatedAt:   pgtype.Timestamptz{Time: at.Add(time.Hour), Valid: true},
+				DeletedAt:   pgtype.Timestamptz{Time: at.Add(2 * time.Hour), Valid: true},
+			})
+
+			for name, got := range map[string]time.Time{
+				"created_at": sub.CreatedAt,
+				"updated_at": sub.UpdatedAt,
+				"deleted_at": *sub.DeletedAt,
+			} {
+				if got.Location() != time.UTC {
+					t.Errorf("%s location = %v, want UTC", name, got.Location())
+				}
+			}
+			if !sub.CreatedAt.Equal(at) {
+				t.Errorf("created_at = %v, want the same instant as %v", sub.CreatedAt, at)
+			}
+
+			body, err := json.Marshal(sub)
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			var fields map[string]interface{}
+			if err := json.Unmarshal(body, &fields); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			want := at.UTC().Format(time.RFC3339)
+			if fields["created_at"] != want {
+				t.Errorf("created_at JSON = %v, want %s", fields["created_at"], want)
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestCalculateTotalCostBillingPeriods(t *testing.T) {
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, false)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	for _, sub := range []struct {
+		service  string
+		price    int
+		period   string
+		currency string
+	}{
+		{"Monthly", 100, domain.BillingPeriodMonthly, ""},
+		{"Quarterly", 300, domain.BillingPeriodQuarterly, ""},
+		{"Yearly", 1200, domain.BillingPeriodYearly, ""},
+		{"Yen", 1500, domain.BillingPeriodMonthly, "JPY"},
+	} {
+		if _, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{
+			ServiceName:   sub.service,
+			PriceMinor:    sub.price,
+			UserID:        userID,
+			StartDate:     "2025-01-01",
+			BillingPeriod: sub.period,
+			Currency:      sub.currency,
+		}); err != nil {
+			t.Fatalf("create %s: %v", sub.service, err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		service     *string
+		currency    string
+		start, end  string
+		wantTotal   int
+		wantByGroup []domain.ServiceCost
+	}{
+		{
+			name:      "a year costs the same on every period",
+			start:     "2025-01-01",
+			end:       "2025-12-01",
+			wantTotal: 3600,
+			wantByGroup: []domain.ServiceCost{
+				{ServiceName: "Monthly", TotalCost: 12, TotalCostMinor: 1200, Amount: "12.00"},
+				{ServiceName: "Quarterly", TotalCost: 12, TotalCostMinor: 1200, Amount: "12.00"},
+				{ServiceName: "Yearly", TotalCost: 12, TotalCostMinor: 1200, Amount: "12.00"},
+			},
+		},
+		{
+			name:      "a quarter spreads the yearly price",
+			start:     "2025-01-01",
+			end:       "2025-03-01",
+			wantTotal: 900,
+			wantByGroup: []domain.ServiceCost{
+				{ServiceName: "Monthly", TotalCost: 3, TotalCostMinor: 300, Amount: "3.00"},
+				{ServiceName: "Quarterly", TotalCost: 3, TotalCostMinor: 300, Amount: "3.00"},
+				{ServiceName: "Yearly", TotalCost: 3, TotalCostMinor: 300, Amount: "3.00"},
+			},
+		},
+		{
+			name:      "one month of a quarterly subscription",
+			service:   strPtr("Quarterly"),
+			start:     "2025-02-01",
+			end:       "2025-02-01",
+			wantTotal: 100,
+			wantByGroup: []domain.ServiceCost{
+				{ServiceName: "Quarterly", TotalCost: 1, TotalCostMinor: 100, Amount: "1.00"},
+			},
+		},
+		{
+			name:      "other currencies are summed apart",
+			currency:  "JPY",
+			start:     "2025-01-01",
+			end:       "2025-12-01",
+			wantTotal: 18000,
+			wantByGroup: []domain.ServiceCost{
+				{ServiceName: "Yen", TotalCost: 18000, TotalCostMinor: 18000, Amount: "18000"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			currency := tt.currency
+			if currency == "" {
+				currency = domain.DefaultCurrency
+			}
+			filter := TotalCostFilter{UserID: &userID, ServiceName: tt.service, Currency: currency, StartDate: tt.start, EndDate: tt.end}
+
+			total, err := repo.CalculateTotalCost(ctx, &filter)
+			if err != nil {
+				t.Fatalf("CalculateTotalCost: %v", err)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("total = %d, want %d", total, tt.wantTotal)
+			}
+
+			byService, _, err := repo.CalculateTotalCostByService(ctx, &TotalCostBreakdownFilter{TotalCostFilter: filter, Limit: 10})
+			if err != nil {
+				t.Fatalf("CalculateTotalCostByService: %v", err)
+			}
+			if !reflect.DeepEqual(byService, tt.wantByGroup) {
+				t.Errorf("by service = %v, want %v", byService, tt.wantByGroup)
+			}
+		})
+	}
+}
+
+func TestAggregatesPerCurrency(t *testing.T) {
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, false)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	for _, sub := range []struct {
+		service  string
+		price    int
+		period   string
+		currency string
+	}{
+		{"Netflix", 1999, domain.BillingPeriodMonthly, "RUB"},
+		{"Netflix", 12000, domain.BillingPeriodYearly, "RUB"},
+		{"Netflix", 1500, domain.BillingPeriodMonthly, "JPY"},
+		{"Spotify", 2500, domain.BillingPeriodMonthly, "BHD"},
+	} {
+		if _, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{
+			ServiceName:   sub.service,
+			PriceMinor:    sub.price,
+			UserID:        userID,
+			StartDate:     "2025-01-01",
+			BillingPeriod: sub.period,
+			Currency:      sub.currency,
+		}); err != nil {
+			t.Fatalf("create %s %s: %v", sub.service, sub.currency, err)
+		}
+	}
+
+	t.Run("billing periods", func(t *testing.T) {
+		periods, err := repo.CountByBillingPeriod(ctx, &ListSubscriptionsFilter{})
+		if err != nil {
+			t.Fatalf("CountByBillingPeriod: %v", err)
+		}
+		want := []domain.BillingPeriodCount{
+			{Period: domain.BillingPeriodMonthly, Count: 3, TotalCost: 19, Totals: []domain.CurrencyTotal{
+				domain.NewCurrencyTotal("BHD", 2500),
+				domain.NewCurrencyTotal("JPY", 1500),
+				domain.NewCurrencyTotal("RUB", 1999),
+			}},
+			{Period: domain.BillingPeriodYearly, Count: 1, TotalCost: 120, Totals: []domain.CurrencyTotal{
+				domain.NewCurrencyTotal("RUB", 12000),
+			}},
+		}
+		if !reflect.DeepEqual(periods, want) {
+			t.Errorf("periods = %+v, want %+v", periods, want)
+		}
+	})
+
+	t.Run("service revenue", func(t *testing.T) {
+		stats, err := repo.GetServiceStats(ctx, "Netflix", "2025-06-01")
+		if err != nil {
+			t.Fatalf("GetServiceStats: %v", err)
+		}
+		want := []domain.CurrencyTotal{
+			domain.NewCurrencyTotal("JPY", 1500),
+			domain.NewCurrencyTotal("RUB", 2999),
+		}
+		if stats.SubscriberCount != 1 || !reflect.DeepEqual(stats.MonthlyRevenue, want) {
+			t.Errorf("stats = %+v, want 1 subscriber and revenue %+v", stats, want)
+		}
+	})
+
+	t.Run("recurring revenue", func(t *testing.T) {
+		asOf := time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+		kpis, err := repo.GetKPIs(ctx, asOf, asOf.AddDate(0, -1, 0))
+		if err != nil {
+			t.Fatalf("GetKPIs: %v", err)
+		}
+		want := map[string]int64{"BHD": 2500, "JPY": 1500, "RUB": 2999}
+		if !reflect.DeepEqual(kpis.MonthlyRecurringRevenue, want) {
+			t.Errorf("recurring revenue = %v, want %v", kpis.MonthlyRecurringRevenue, want)
+		}
+	})
+
+	t.Run("price bounds are whole units", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			min, max   *int
+			wantPrices []int
+		}{
+			{name: "minimum", min: intPtr(15), wantPrices: []int{1500, 1999, 12000}},
+			{name: "maximum", max: intPtr(19), wantPrices: []int{1999, 2500}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				subscriptions, _, err := repo.List(ctx, &ListSubscriptionsFilter{MinPrice: tt.min, MaxPrice: tt.max, Limit: 10})
+				if err != nil {
+					t.Fatalf("List: %v", err)
+				}
+				prices := []int{}
+				for _, sub := range subscriptions {
+					prices = append(prices, sub.PriceMinor)
+				}
+				sort.Ints(prices)
+				if !reflect.DeepEqual(prices, tt.wantPrices) {
+					t.Errorf("prices = %v, want %v", prices, tt.wantPrices)
+				}
+			})
+		}
+	})
+}
+
+func TestCalculateTotalCostByServiceTopN(t *testing.T) {
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, false)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	for _, sub := range []struct {
+		service string
+		price   int
+	}{
+		{"Alpha", 100}, {"Bravo", 700}, {"Charlie", 300}, {"Delta", 500},
+		{"Echo", 500}, {"Foxtrot", 900}, {"Golf", 200},
+	} {
+		if _, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{
+			ServiceName: sub.service,
+			PriceMinor:  sub.price,
+			UserID:      userID,
+			StartDate:   "2025-01-01",
+		}); err != nil {
+			t.Fatalf("create %s: %v", sub.service, err)
+		}
+	}
+
+	// Delta and Echo cost the same; ties fall back to the service name.
+	tests := []struct {
+		name       string
+		order      string
+		limit      int
+		offset     int
+		want       []string
+		wantGroups int64
+	}{
+		{name: "top five by cost", order: domain.TotalCostOrderCostDesc, limit: 5, want: []string{"Foxtrot", "Bravo", "Delta", "Echo", "Charlie"}, wantGroups: 7},
+		{name: "bottom five by cost", order: domain.TotalCostOrderCostAsc, limit: 5, want: []string{"Alpha", "Golf", "Charlie", "Delta", "Echo"}, wantGroups: 7},
+		{name: "first five by name", order: domain.TotalCostOrderServiceName, limit: 5, want: []string{"Alpha", "Bravo", "Charlie", "Delta", "Echo"}, wantGroups: 7},
+		{name: "second page by cost", order: domain.TotalCostOrderCostDesc, limit: 5, offset: 5, want: []string{"Golf", "Alpha"}, wantGroups: 7},
+		{name: "past the last group", order: domain.TotalCostOrderCostDesc, limit: 5, offset: 10, want: []string{}, wantGroups: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			costs, groups, err := repo.CalculateTotalCostByService(ctx, &TotalCostBreakdownFilter{
+				TotalCostFilter: TotalCostFilter{UserID: &userID, Currency: domain.DefaultCurrency, StartDate: "2025-01-01", EndDate: "2025-01-01"},
+				Order:           tt.order,
+				Limit:           tt.limit,
+				Offset:          tt.offset,
+			})
+			if err != nil {
+				t.Fatalf("CalculateTotalCostByService: %v", err)
+			}
+
+			got := make([]string, len(costs))
+			for i, cost := range costs {
+				got[i] = cost.ServiceName
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("services = %v, want %v", got, tt.want)
+			}
+			if groups != tt.wantGroups {
+				t.Errorf("groups = %d, want %d", groups, tt.wantGroups)
+			}
+		})
+	}
+}
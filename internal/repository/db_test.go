@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// testDatabaseURLEnv names a Postgres database with the migrations applied.
+// Tests that need one are skipped when it is unset. The tables are emptied
+// before each such test, so never point it at a database you care about.
+const testDatabaseURLEnv = "TEST_DATABASE_URL"
+
+func newTestPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	url := os.Getenv(testDatabaseURLEnv)
+	if url == "" {
+		t.Skipf("%s is not set", testDatabaseURLEnv)
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	if _, err := pool.Exec(ctx, "TRUNCATE subscriptions, subscription_history, subscription_pauses, outbox_events"); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	return pool
+}
+
+func newTestRepository(t *testing.T, pool *pgxpool.Pool, enforceUniqueActive bool) *subscriptionRepository {
+	t.Helper()
+	tx := TxConfig{IsolationLevel: pgx.RepeatableRead, MaxRetries: 3}
+	return NewSubscriptionRepository(pool, tx, enforceUniqueActive, zap.NewNop()).(*subscriptionRepository)
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// TestListDeltaPullSharedTimestamp pages a delta pull in steps of two over
+// three rows that share one updated_at, so the first page ends inside the
+// group. Following the (updated_at, id) cursor must return every row once.
+func TestListDeltaPullSharedTimestamp(t *testing.T) {
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, false)
+	ctx := context.Background()
+
+	want := map[uuid.UUID]bool{}
+	for i := 0; i < 3; i++ {
+		sub, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{
+			ServiceName: "Netflix",
+			PriceMinor:  400,
+			UserID:      uuid.New(),
+			StartDate:   "2025-01-01",
+		})
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		want[sub.ID] = true
+	}
+
+	changedAt := time.Date(2025, time.March, 1, 10, 0, 0, 0, time.UTC)
+	if _, err := pool.Exec(ctx, "UPDATE subscriptions SET updated_at = $1", changedAt); err != nil {
+		t.Fatalf("set updated_at: %v", err)
+	}
+
+	since := changedAt.Add(-time.Second)
+	var afterID *uuid.UUID
+	seen := map[uuid.UUID]bool{}
+	for page := 0; page < 3; page++ {
+		subs, _, err := repo.List(ctx, &ListSubscriptionsFilter{UpdatedSince: &since, UpdatedAfterID: afterID, Limit: 2})
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		if len(subs) == 0 {
+			break
+		}
+		for _, sub := range subs {
+			if seen[sub.ID] {
+				t.Fatalf("subscription %s returned twice", sub.ID)
+			}
+			seen[sub.ID] = true
+		}
+		last := subs[len(subs)-1]
+		since = last.UpdatedAt
+		afterID = &last.ID
+	}
+
+	if len(seen) != len(want) {
+		t.Fatalf("delta pull returned %d subscriptions, want %d", len(seen), len(want))
+	}
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"subscription-service/internal/domain"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// filterPredicate is a WHERE clause under construction together with its
+// positional arguments.
+type filterPredicate struct {
+	conditions []string
+	args       []interface{}
+}
+
+// add appends a condition whose %d verbs are replaced, in order, by the
+// positions of args.
+func (p *filterPredicate) add(condition string, args ...interface{}) {
+	positions := make([]interface{}, len(args))
+	for i, arg := range args {
+		p.args = append(p.args, arg)
+		positions[i] = len(p.args)
+	}
+	p.conditions = append(p.conditions, fmt.Sprintf(condition, positions...))
+}
+
+func (p *filterPredicate) where() string {
+	if len(p.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(p.conditions, " AND ")
+}
+
+// minorUnitsPerUnit is the number of minor units in a whole unit of each
+// row's currency. The price filters are in whole units, like the price field
+// of the API, while the column holds minor units, and a whole-unit price
+// p covers the stored prices from p to p+1 units exclusive.
+var minorUnitsPerUnit = func() string {
+	var b strings.Builder
+	b.WriteString("CASE currency")
+	for _, code := range domain.Currencies() {
+		scale, _ := domain.FromWholeUnits(1, code)
+		fmt.Fprintf(&b, " WHEN '%s' THEN %d", code, scale)
+	}
+	scale, _ := domain.FromWholeUnits(1, domain.DefaultCurrency)
+	fmt.Fprintf(&b, " ELSE %d END", scale)
+	return b.String()
+}()
+
+// buildFilterPredicate translates the list filters into SQL. List, Count,
+// CountActive and StreamAll all start from it so a filter added here applies
+// to every one of them. Limit and Offset are left to the caller.
+func buildFilterPredicate(filter *ListSubscriptionsFilter) (*filterPredicate, error) {
+	p := &filterPredicate{}
+
+	if !filter.IncludeDeleted {
+		p.conditions = append(p.conditions, "deleted_at IS NULL")
+	}
+	if filter.UpdatedSince != nil && filter.UpdatedAfterID != nil {
+		p.add("(updated_at, id) > ($%d, $%d)", *filter.UpdatedSince, pgtype.UUID{Bytes: *filter.UpdatedAfterID, Valid: true})
+	} else if filter.UpdatedSince != nil {
+		p.add("updated_at > $%d", *filter.UpdatedSince)
+	}
+
+	if filter.UserID != nil {
+		p.add("user_id = $%d", pgtype.UUID{Bytes: *filter.UserID, Valid: true})
+	}
+	if len(filter.UserIDs) > 0 {
+		userIDs := make([]pgtype.UUID, len(filter.UserIDs))
+		for i, userID := range filter.UserIDs {
+			userIDs[i] = pgtype.UUID{Bytes: userID, Valid: true}
+		}
+		p.add("user_id = ANY($%d::UUID[])", userIDs)
+	}
+	if len(filter.ServiceNames) > 0 {
+		patterns := make([]string, len(filter.ServiceNames))
+		for i, name := range filter.ServiceNames {
+			patterns[i] = "%" + name + "%"
+		}
+		p.add("service_name ILIKE ANY($%d::TEXT[])", patterns)
+	}
+	if filter.ExactServiceName != nil {
+		p.add("service_name = $%d", *filter.ExactServiceName)
+	}
+	if filter.ServiceNamePrefix != nil {
+		p.add(`service_name LIKE ($%d || '%%') ESCAPE '\'`, escapeLike(*filter.ServiceNamePrefix))
+	}
+	if len(filter.Metadata) > 0 {
+		metadata, err := metadataFilter(filter.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		p.add("metadata @> $%d::JSONB", metadata)
+	}
+	if filter.Tag != nil {
+		p.add("tags @> ARRAY[$%d::TEXT]", *filter.Tag)
+	}
+	if filter.OpenEnded != nil {
+		if *filter.OpenEnded {
+			p.conditions = append(p.conditions, "end_date IS NULL")
+		} else {
+			p.conditions = append(p.conditions, "end_date IS NOT NULL")
+		}
+	}
+	if filter.MinPrice != nil {
+		p.add("price >= $%d::BIGINT * "+minorUnitsPerUnit, int64(*filter.MinPrice))
+	}
+	if filter.MaxPrice != nil {
+		p.add("price < ($%d::BIGINT + 1) * "+minorUnitsPerUnit, int64(*filter.MaxPrice))
+	}
+	if filter.ActiveTo != nil {
+		activeTo := pgtype.Date{}
+		if err := activeTo.Scan(*filter.ActiveTo); err != nil {
+			return nil, err
+		}
+		p.add("start_date <= $%d", activeTo)
+	}
+	if filter.ActiveFrom != nil {
+		activeFrom := pgtype.Date{}
+		if err := activeFrom.Scan(*filter.ActiveFrom); err != nil {
+			return nil, err
+		}
+		p.add("(end_date IS NULL OR end_date >= $%d)", activeFrom)
+	}
+
+	return p, nil
+}
+
+// likeEscaper escapes the LIKE metacharacters with backslash. Patterns built
+// with it name backslash in an explicit ESCAPE clause rather than relying on
+// the default.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLike makes value match itself literally in a LIKE pattern.
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+// addActiveAsOf restricts the predicate to subscriptions running on asOf.
+func (p *filterPredicate) addActiveAsOf(asOf pgtype.Date) {
+	p.add("start_date <= $%d", asOf)
+	p.conditions = append(p.conditions, fmt.Sprintf("(end_date IS NULL OR end_date >= $%d)", len(p.args)))
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestBuildFilterPredicate(t *testing.T) {
+	since := time.Date(2025, time.March, 1, 10, 0, 0, 0, time.UTC)
+	afterID := uuid.MustParse("7d444840-9dc0-11d1-b245-5ffdce74fad2")
+
+	tests := []struct {
+		name     string
+		filter   ListSubscriptionsFilter
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:    "live rows by default",
+			wantSQL: " WHERE deleted_at IS NULL",
+		},
+		{
+			name:    "deleted rows on request",
+			filter:  ListSubscriptionsFilter{IncludeDeleted: true},
+			wantSQL: "",
+		},
+		{
+			name:     "delta pull still hides deleted rows",
+			filter:   ListSubscriptionsFilter{UpdatedSince: &since},
+			wantSQL:  " WHERE deleted_at IS NULL AND updated_at > $1",
+			wantArgs: []interface{}{since},
+		},
+		{
+			name:     "delta pull by composite cursor",
+			filter:   ListSubscriptionsFilter{UpdatedSince: &since, UpdatedAfterID: &afterID},
+			wantSQL:  " WHERE deleted_at IS NULL AND (updated_at, id) > ($1, $2)",
+			wantArgs: []interface{}{since, pgtype.UUID{Bytes: afterID, Valid: true}},
+		},
+		{
+			name:     "delta pull with deletions",
+			filter:   ListSubscriptionsFilter{UpdatedSince: &since, IncludeDeleted: true},
+			wantSQL:  " WHERE updated_at > $1",
+			wantArgs: []interface{}{since},
+		},
+		{
+			name:     "price bounds in whole units of each currency",
+			filter:   ListSubscriptionsFilter{MinPrice: intPtr(10), MaxPrice: intPtr(20)},
+			wantSQL:  " WHERE deleted_at IS NULL AND price >= $1::BIGINT * " + minorUnitsPerUnit + " AND price < ($2::BIGINT + 1) * " + minorUnitsPerUnit,
+			wantArgs: []interface{}{int64(10), int64(20)},
+		},
+		{
+			name:     "lower price bound only",
+			filter:   ListSubscriptionsFilter{MinPrice: intPtr(0)},
+			wantSQL:  " WHERE deleted_at IS NULL AND price >= $1::BIGINT * " + minorUnitsPerUnit,
+			wantArgs: []interface{}{int64(0)},
+		},
+		{
+			name:     "upper price bound only",
+			filter:   ListSubscriptionsFilter{MaxPrice: intPtr(20)},
+			wantSQL:  " WHERE deleted_at IS NULL AND price < ($1::BIGINT + 1) * " + minorUnitsPerUnit,
+			wantArgs: []interface{}{int64(20)},
+		},
+		{
+			name:     "active window overlaps, open-ended rows included",
+			filter:   ListSubscriptionsFilter{ActiveFrom: strPtr("2025-03-01"), ActiveTo: strPtr("2025-06-30")},
+			wantSQL:  " WHERE deleted_at IS NULL AND start_date <= $1 AND (end_date IS NULL OR end_date >= $2)",
+			wantArgs: []interface{}{testDate(2025, time.June, 30), testDate(2025, time.March, 1)},
+		},
+		{
+			name:     "one service name is a substring match",
+			filter:   ListSubscriptionsFilter{ServiceNames: []string{"flix"}},
+			wantSQL:  " WHERE deleted_at IS NULL AND service_name ILIKE ANY($1::TEXT[])",
+			wantArgs: []interface{}{[]string{"%flix%"}},
+		},
+		{
+			name:     "several service names match by the same rule",
+			filter:   ListSubscriptionsFilter{ServiceNames: []string{"flix", "Spot"}},
+			wantSQL:  " WHERE deleted_at IS NULL AND service_name ILIKE ANY($1::TEXT[])",
+			wantArgs: []interface{}{[]string{"%flix%", "%Spot%"}},
+		},
+		{
+			name:     "service name prefix matches LIKE metacharacters literally",
+			filter:   ListSubscriptionsFilter{ServiceNamePrefix: strPtr(`100%_off\`)},
+			wantSQL:  ` WHERE deleted_at IS NULL AND service_name LIKE ($1 || '%') ESCAPE '\'`,
+			wantArgs: []interface{}{`100\%\_off\\`},
+		},
+		{
+			name:     "metadata by containment",
+			filter:   ListSubscriptionsFilter{Metadata: map[string]interface{}{"team": "infra", "seats": float64(4)}},
+			wantSQL:  " WHERE deleted_at IS NULL AND metadata @> $1::JSONB",
+			wantArgs: []interface{}{[]byte(`{"seats":4,"team":"infra"}`)},
+		},
+		{
+			name:    "open-ended rows",
+			filter:  ListSubscriptionsFilter{OpenEnded: boolPtr(true)},
+			wantSQL: " WHERE deleted_at IS NULL AND end_date IS NULL",
+		},
+		{
+			name:    "fixed-term rows",
+			filter:  ListSubscriptionsFilter{OpenEnded: boolPtr(false)},
+			wantSQL: " WHERE deleted_at IS NULL AND end_date IS NOT NULL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := buildFilterPredicate(&tt.filter)
+			if err != nil {
+				t.Fatalf("buildFilterPredicate: %v", err)
+			}
+			if got := p.where(); got != tt.wantSQL {
+				t.Errorf("where = %q, want %q", got, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(p.args, tt.wantArgs) {
+				t.Errorf("args = %#v, want %#v", p.args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func intPtr(i int) *int { return &i }
+
+func boolPtr(b bool) *bool { return &b }
+
+func testDate(year int, month time.Month, day int) pgtype.Date {
+	return pgtype.Date{Time: time.Date(year, month, day, 0, 0, 0, 0, time.UTC), Valid: true}
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+// ListHistory returns the history of a subscription, oldest entry first.
+// It does not check that the subscription exists or is live.
+func (r *subscriptionRepository) ListHistory(ctx context.Context, subscriptionID uuid.UUID) ([]domain.HistoryEntry, error) {
+	entries, err := r.queries.ListHistoryEntries(ctx, pgtype.UUID{Bytes: subscriptionID, Valid: true})
+	if err != nil {
+		r.logger.Error("failed to list history", zap.String("subscription_id", subscriptionID.String()), zap.Error(err))
+		return nil, err
+	}
+
+	result := make([]domain.HistoryEntry, len(entries))
+	for i := range entries {
+		result[i] = convertToHistoryEntry(&entries[i])
+	}
+	return result, nil
+}
+
+func convertToHistoryEntry(entry *sqlc.SubscriptionHistory) domain.HistoryEntry {
+	result := domain.HistoryEntry{
+		ID:             uuid.UUID(entry.ID.Bytes),
+		SubscriptionID: uuid.UUID(entry.SubscriptionID.Bytes),
+		Action:         entry.Action,
+		Details:        entry.Details,
+	}
+	if entry.CreatedAt.Valid {
+		result.CreatedAt = entry.CreatedAt.Time.UTC()
+	}
+	return result
+}
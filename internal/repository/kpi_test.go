@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestGetKPIs(t *testing.T) {
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, false)
+	ctx := context.Background()
+
+	asOf := time.Now().UTC()
+	since := asOf.AddDate(0, 0, -7)
+	date := func(days int) *string {
+		d := asOf.AddDate(0, 0, days).Format("2006-01-02")
+		return &d
+	}
+
+	create := func(service string, price int, currency, period string, end *string) uuid.UUID {
+		t.Helper()
+		sub, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{
+			ServiceName:   service,
+			PriceMinor:    price,
+			Currency:      currency,
+			BillingPeriod: period,
+			UserID:        uuid.New(),
+			StartDate:     *date(-60),
+			EndDate:       end,
+		})
+		if err != nil {
+			t.Fatalf("create %s: %v", service, err)
+		}
+		return sub.ID
+	}
+
+	// Running today, created within the window.
+	create("Netflix", 40000, "RUB", domain.BillingPeriodMonthly, nil)
+	create("GitHub", 12000, "USD", domain.BillingPeriodYearly, date(30))
+	// Ended within the window: churned, not active.
+	create("Spotify", 20000, "RUB", domain.BillingPeriodMonthly, date(-3))
+	// Deleted within the window: churned, not active.
+	deleted := create("Hulu", 30000, "RUB", domain.BillingPeriodMonthly, nil)
+	if err := repo.Delete(ctx, deleted); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	// Created and ended before the window: none of new, churned or active.
+	old := create("Disney", 50000, "RUB", domain.BillingPeriodMonthly, date(-30))
+	if _, err := pool.Exec(ctx, "UPDATE subscriptions SET created_at = $1 WHERE id = $2", asOf.AddDate(0, 0, -60), old); err != nil {
+		t.Fatalf("backdate: %v", err)
+	}
+
+	kpis, err := repo.GetKPIs(ctx, asOf, since)
+	if err != nil {
+		t.Fatalf("GetKPIs: %v", err)
+	}
+
+	want := &domain.KPISnapshot{
+		AsOf:                    asOf,
+		ActiveSubscriptions:     2,
+		MonthlyRecurringRevenue: map[string]int64{"RUB": 40000, "USD": 1000},
+		New:                     4,
+		Churned:                 2,
+	}
+	if !reflect.DeepEqual(kpis, want) {
+		t.Errorf("kpis = %+v, want %+v", kpis, want)
+	}
+}
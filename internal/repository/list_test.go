@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// listServiceNames runs List with filter and returns the service names of
+// the page, sorted, after checking the reported total agrees with it.
+func listServiceNames(t *testing.T, repo *subscriptionRepository, filter ListSubscriptionsFilter) []string {
+	t.Helper()
+
+	filter.Limit = 100
+	subscriptions, total, err := repo.List(context.Background(), &filter)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if total != int64(len(subscriptions)) {
+		t.Errorf("total = %d, but the page has %d rows", total, len(subscriptions))
+	}
+
+	names := make([]string, 0, len(subscriptions))
+	for _, sub := range subscriptions {
+		names = append(names, sub.ServiceName)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestListPriceBounds(t *testing.T) {
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, false)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	for _, sub := range []struct {
+		service  string
+		price    int
+		currency string
+	}{
+		{"RUB 199.99", 19999, "RUB"},
+		{"RUB 200", 20000, "RUB"},
+		{"RUB 300", 30000, "RUB"},
+		{"JPY 200", 200, "JPY"},
+		{"BHD 200.5", 200500, "BHD"},
+		{"USD 300.01", 30001, "USD"},
+	} {
+		if _, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{
+			ServiceName: sub.service,
+			PriceMinor:  sub.price,
+			UserID:      userID,
+			StartDate:   "2025-01-01",
+			Currency:    sub.currency,
+		}); err != nil {
+			t.Fatalf("create %s: %v", sub.service, err)
+		}
+	}
+
+	// Bounds are inclusive and in whole units of each row's currency, the
+	// price the API reports, which rounds down.
+	tests := []struct {
+		name     string
+		min, max *int
+		want     []string
+	}{
+		{name: "one whole unit in every currency", min: intPtr(200), max: intPtr(200), want: []string{"BHD 200.5", "JPY 200", "RUB 200"}},
+		{name: "upper bound keeps the fraction below it", max: intPtr(199), want: []string{"RUB 199.99"}},
+		{name: "lower bound only", min: intPtr(300), want: []string{"RUB 300", "USD 300.01"}},
+		{name: "both bounds", min: intPtr(200), max: intPtr(300), want: []string{"BHD 200.5", "JPY 200", "RUB 200", "RUB 300", "USD 300.01"}},
+		{name: "empty band", min: intPtr(250), max: intPtr(250), want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := listServiceNames(t, repo, ListSubscriptionsFilter{UserID: &userID, MinPrice: tt.min, MaxPrice: tt.max})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListActiveWindow(t *testing.T) {
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, false)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	for _, sub := range []struct {
+		service string
+		start   string
+		end     *string
+	}{
+		{"Inside", "2025-04-01", strPtr("2025-05-01")},
+		{"Ends on from", "2025-01-01", strPtr("2025-03-01")},
+		{"Starts on to", "2025-06-30", strPtr("2025-12-01")},
+		{"Before", "2025-01-01", strPtr("2025-02-28")},
+		{"After, open-ended", "2025-07-01", nil},
+		{"Open-ended", "2024-01-01", nil},
+		{"Spans", "2024-01-01", strPtr("2026-01-01")},
+	} {
+		if _, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{
+			ServiceName: sub.service,
+			PriceMinor:  100,
+			UserID:      userID,
+			StartDate:   sub.start,
+			EndDate:     sub.end,
+		}); err != nil {
+			t.Fatalf("create %s: %v", sub.service, err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		from, to *string
+		want     []string
+	}{
+		{
+			name: "inside, overlapping and open-ended, with inclusive ends",
+			from: strPtr("2025-03-01"), to: strPtr("2025-06-30"),
+			want: []string{"Ends on from", "Inside", "Open-ended", "Spans", "Starts on to"},
+		},
+		{
+			name: "from only",
+			from: strPtr("2025-07-01"),
+			want: []string{"After, open-ended", "Open-ended", "Spans", "Starts on to"},
+		},
+		{
+			name: "to only",
+			to:   strPtr("2025-02-28"),
+			want: []string{"Before", "Ends on from", "Open-ended", "Spans"},
+		},
+		{
+			name: "a later window only open-ended rows reach",
+			from: strPtr("2026-06-01"), to: strPtr("2026-06-30"),
+			want: []string{"After, open-ended", "Open-ended"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := listServiceNames(t, repo, ListSubscriptionsFilter{UserID: &userID, ActiveFrom: tt.from, ActiveTo: tt.to})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListOpenEnded(t *testing.T) {
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, false)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	for _, sub := range []struct {
+		service string
+		end     *string
+	}{
+		{"Netflix", nil},
+		{"Spotify", nil},
+		{"GitHub", strPtr("2025-12-01")},
+	} {
+		if _, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{
+			ServiceName: sub.service,
+			PriceMinor:  100,
+			UserID:      userID,
+			StartDate:   "2025-01-01",
+			EndDate:     sub.end,
+		}); err != nil {
+			t.Fatalf("create %s: %v", sub.service, err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		openEnded *bool
+		want      []string
+	}{
+		{name: "open-ended", openEnded: boolPtr(true), want: []string{"Netflix", "Spotify"}},
+		{name: "fixed-term", openEnded: boolPtr(false), want: []string{"GitHub"}},
+		{name: "unfiltered", want: []string{"GitHub", "Netflix", "Spotify"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := listServiceNames(t, repo, ListSubscriptionsFilter{UserID: &userID, OpenEnded: tt.openEnded})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListServiceNamePrefix(t *testing.T) {
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, false)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	for _, service := range []string{"aws:ec2", "aws:s3", "AWS:rds", "a_b", "axb", "100%_off", "100 percent off", `back\slash`, "backslash"} {
+		if _, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{
+			ServiceName: service,
+			PriceMinor:  100,
+			UserID:      userID,
+			StartDate:   "2025-01-01",
+		}); err != nil {
+			t.Fatalf("create %s: %v", service, err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		prefix string
+		want   []string
+	}{
+		{name: "case-sensitive prefix", prefix: "aws:", want: []string{"aws:ec2", "aws:s3"}},
+		{name: "underscore is literal", prefix: "a_", want: []string{"a_b"}},
+		{name: "percent is literal", prefix: "100%", want: []string{"100%_off"}},
+		{name: "backslash is literal", prefix: `back\`, want: []string{`back\slash`}},
+		{name: "no match", prefix: "gcp:", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := listServiceNames(t, repo, ListSubscriptionsFilter{UserID: &userID, ServiceNamePrefix: &tt.prefix})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+type OutboxRepository interface {
+	// ProcessPending claims up to limit due events, oldest first, and hands
+	// each to publish outside any transaction. Events publish accepts are
+	// marked sent; failures are retried with backoff and dead-lettered after
+	// the configured number of attempts. Claimed events are hidden from
+	// concurrent relays, so several instances can run side by side.
+	ProcessPending(ctx context.Context, limit int, publish func(context.Context, *domain.OutboxEvent) error) (int, error)
+	CountPending(ctx context.Context) (int64, error)
+}
+
+// OutboxRetry controls redelivery of outbox events.
+type OutboxRetry struct {
+	// MaxAttempts is how many failed publishes dead-letter an event.
+	MaxAttempts int
+	// Backoff is the delay before the first retry. It doubles with every
+	// further failure, up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// ClaimTimeout is how long a claimed event stays hidden from other
+	// relays. An event whose relay dies before marking it, say between
+	// claiming and publishing, becomes due again once it runs out.
+	ClaimTimeout time.Duration
+}
+
+// delay is how long to wait before retrying an event that has failed
+// attempts times, this failure included.
+func (r OutboxRetry) delay(attempts int) time.Duration {
+	delay := r.Backoff
+	for i := 1; i < attempts && delay < r.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, r.MaxBackoff)
+}
+
+type outboxRepository struct {
+	db      DB
+	queries *sqlc.Queries
+	retry   OutboxRetry
+	logger  *zap.Logger
+}
+
+func NewOutboxRepository(db DB, retry OutboxRetry, logger *zap.Logger) OutboxRepository {
+	return &outboxRepository{
+		db:      db,
+		queries: sqlc.New(db),
+		retry:   retry,
+		logger:  logger,
+	}
+}
+
+// ProcessPending claims the batch in a statement of its own, which commits
+// before anything is published, so no row lock or transaction is held while
+// the publisher is called. Delivery is at least once: a crash after publish
+// and before the event is marked sends it again once the claim runs out.
+func (r *outboxRepository) ProcessPending(ctx context.Context, limit int, publish func(context.Context, *domain.OutboxEvent) error) (int, error) {
+	events, err := r.queries.ClaimDueOutboxEvents(ctx, sqlc.ClaimDueOutboxEventsParams{
+		ClaimSeconds: r.retry.ClaimTimeout.Seconds(),
+		BatchSize:    int32(limit),
+	})
+	if err != nil {
+		r.logger.Error("failed to claim outbox events", zap.Error(err))
+		return 0, err
+	}
+	sort.Slice(events, func(i, j int) bool {
+		a, b := events[i].CreatedAt.Time, events[j].CreatedAt.Time
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return bytes.Compare(events[i].ID.Bytes[:], events[j].ID.Bytes[:]) < 0
+	})
+
+	published := 0
+	for i := range events {
+		event := convertToOutboxEvent(&events[i])
+
+		if publishErr := publish(ctx, event); publishErr != nil {
+			if err := r.recordFailure(ctx, event, publishErr); err != nil {
+				return published, err
+			}
+			continue
+		}
+
+		if err := r.queries.MarkOutboxEventPublished(ctx, events[i].ID); err != nil {
+			r.logger.Error("failed to mark outbox event published", zap.String("id", event.ID.String()), zap.Error(err))
+			return published, err
+		}
+		published++
+	}
+
+	return published, nil
+}
+
+func (r *outboxRepository) recordFailure(ctx context.Context, event *domain.OutboxEvent, publishErr error) error {
+	attempts := event.Attempts + 1
+	delay := r.retry.delay(attempts)
+	r.logger.Warn("failed to publish outbox event",
+		zap.String("id", event.ID.String()),
+		zap.String("type", event.Type),
+		zap.Int("attempts", attempts),
+		zap.Duration("retry_in", delay),
+		zap.Error(publishErr),
+	)
+
+	deadLettered, err := r.queries.RecordOutboxEventFailure(ctx, sqlc.RecordOutboxEventFailureParams{
+		LastError:    pgtype.Text{String: publishErr.Error(), Valid: true},
+		RetrySeconds: delay.Seconds(),
+		MaxAttempts:  int32(r.retry.MaxAttempts),
+		ID:           pgtype.UUID{Bytes: event.ID, Valid: true},
+	})
+	if err != nil {
+		r.logger.Error("failed to record outbox event failure", zap.String("id", event.ID.String()), zap.Error(err))
+		return err
+	}
+	if deadLettered {
+		r.logger.Error("outbox event dead-lettered",
+			zap.String("id", event.ID.String()),
+			zap.String("type", event.Type),
+			zap.Int("attempts", attempts),
+			zap.Error(publishErr),
+		)
+	}
+	return nil
+}
+
+func (r *outboxRepository) CountPending(ctx context.Context) (int64, error) {
+	count, err := r.queries.CountPendingOutboxEvents(ctx)
+	if err != nil {
+		r.logger.Error("failed to count pending outbox events", zap.Error(err))
+		return 0, err
+	}
+	return count, nil
+}
+
+// enqueueEvent records an event with queries, which should be bound to the
+// transaction making the change the event describes.
+func enqueueEvent(ctx context.Context, queries *sqlc.Queries, eventType string, aggregateID pgtype.UUID, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return queries.CreateOutboxEvent(ctx, sqlc.CreateOutboxEventParams{
+		EventType:   eventType,
+		AggregateID: aggregateID,
+		Payload:     data,
+	})
+}
+
+func convertToOutboxEvent(event *sqlc.OutboxEvent) *domain.OutboxEvent {
+	result := &domain.OutboxEvent{
+		ID:          uuid.UUID(event.ID.Bytes),
+		Type:        event.EventType,
+		AggregateID: uuid.UUID(event.AggregateID.Bytes),
+		Payload:     event.Payload,
+		Attempts:    int(event.Attempts),
+	}
+	if event.CreatedAt.Valid {
+		result.CreatedAt = event.CreatedAt.Time.UTC()
+	}
+	return result
+}
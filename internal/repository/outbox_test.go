@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+func TestOutboxRetryDelay(t *testing.T) {
+	retry := OutboxRetry{Backoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{100, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := retry.delay(tt.attempts); got != tt.want {
+			t.Errorf("delay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func newTestOutbox(t *testing.T, retry OutboxRetry) (*outboxRepository, *sqlc.Queries) {
+	t.Helper()
+	pool := newTestPool(t)
+	queries := sqlc.New(pool)
+	if err := enqueueEvent(context.Background(), queries, domain.EventSubscriptionCreated, pgtype.UUID{Bytes: uuid.New(), Valid: true}, map[string]string{}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	return NewOutboxRepository(pool, retry, zap.NewNop()).(*outboxRepository), queries
+}
+
+func TestOutboxCrashBetweenClaimAndPublish(t *testing.T) {
+	const claimTimeout = 200 * time.Millisecond
+	repo, queries := newTestOutbox(t, OutboxRetry{MaxAttempts: 3, Backoff: time.Second, MaxBackoff: time.Second, ClaimTimeout: claimTimeout})
+	ctx := context.Background()
+
+	// A relay claims the event and dies before publishing it.
+	claimed, err := queries.ClaimDueOutboxEvents(ctx, sqlc.ClaimDueOutboxEventsParams{ClaimSeconds: claimTimeout.Seconds(), BatchSize: 10})
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("claim: %d events, %v", len(claimed), err)
+	}
+
+	var sent []uuid.UUID
+	publish := func(ctx context.Context, event *domain.OutboxEvent) error {
+		sent = append(sent, event.ID)
+		return nil
+	}
+
+	if n, err := repo.ProcessPending(ctx, 10, publish); err != nil || n != 0 {
+		t.Fatalf("while claimed: published %d, %v; want 0", n, err)
+	}
+
+	time.Sleep(2 * claimTimeout)
+	if n, err := repo.ProcessPending(ctx, 10, publish); err != nil || n != 1 {
+		t.Fatalf("after claim timeout: published %d, %v; want 1", n, err)
+	}
+	if len(sent) != 1 || sent[0] != uuid.UUID(claimed[0].ID.Bytes) {
+		t.Fatalf("sent %v, want the claimed event", sent)
+	}
+
+	pending, err := repo.CountPending(ctx)
+	if err != nil || pending != 0 {
+		t.Fatalf("pending = %d, %v; want 0", pending, err)
+	}
+}
+
+func TestOutboxFailures(t *testing.T) {
+	failing := func(ctx context.Context, event *domain.OutboxEvent) error {
+		return errors.New("webhook down")
+	}
+
+	tests := []struct {
+		name        string
+		maxAttempts int
+		wantPending int64
+	}{
+		{name: "failed event waits for its retry", maxAttempts: 3, wantPending: 1},
+		{name: "last attempt dead-letters the event", maxAttempts: 1, wantPending: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _ := newTestOutbox(t, OutboxRetry{MaxAttempts: tt.maxAttempts, Backoff: time.Hour, MaxBackoff: time.Hour, ClaimTimeout: time.Minute})
+			ctx := context.Background()
+
+			if n, err := repo.ProcessPending(ctx, 10, failing); err != nil || n != 0 {
+				t.Fatalf("published %d, %v; want 0", n, err)
+			}
+
+			// The retry is an hour away, so nothing is due now.
+			calls := 0
+			if _, err := repo.ProcessPending(ctx, 10, func(ctx context.Context, event *domain.OutboxEvent) error {
+				calls++
+				return nil
+			}); err != nil || calls != 0 {
+				t.Fatalf("second run published %d events, %v; want none due", calls, err)
+			}
+
+			pending, err := repo.CountPending(ctx)
+			if err != nil || pending != tt.wantPending {
+				t.Fatalf("pending = %d, %v; want %d", pending, err, tt.wantPending)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestCalculateTotalCostSkipsPausedMonths(t *testing.T) {
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, false)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	// A month is skipped when its billing date, the first of the month in
+	// the window, falls inside a pause, ends included.
+	tests := []struct {
+		name    string
+		service string
+		pauses  []domain.CreatePauseRequest
+		removed bool
+		want    int
+	}{
+		{name: "no pause", service: "Alpha", want: 12000},
+		{name: "one whole month", service: "Bravo", pauses: []domain.CreatePauseRequest{{PauseStart: "2025-03-01", PauseEnd: "2025-03-31"}}, want: 11000},
+		{name: "across a month boundary", service: "Charlie", pauses: []domain.CreatePauseRequest{{PauseStart: "2025-03-15", PauseEnd: "2025-05-10"}}, want: 10000},
+		{name: "inside a month, missing its billing date", service: "Delta", pauses: []domain.CreatePauseRequest{{PauseStart: "2025-06-02", PauseEnd: "2025-06-28"}}, want: 12000},
+		{name: "several pauses, one past the window", service: "Echo", pauses: []domain.CreatePauseRequest{
+			{PauseStart: "2025-02-01", PauseEnd: "2025-02-01"},
+			{PauseStart: "2025-11-15", PauseEnd: "2026-02-01"},
+		}, want: 10000},
+		{name: "removed pause", service: "Foxtrot", pauses: []domain.CreatePauseRequest{{PauseStart: "2025-03-01", PauseEnd: "2025-03-31"}}, removed: true, want: 12000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{
+				ServiceName: tt.service,
+				PriceMinor:  1000,
+				UserID:      userID,
+				StartDate:   "2025-01-01",
+			})
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			for _, pause := range tt.pauses {
+				created, err := repo.CreatePause(ctx, sub.ID, &pause)
+				if err != nil {
+					t.Fatalf("CreatePause: %v", err)
+				}
+				if tt.removed {
+					if err := repo.DeletePause(ctx, sub.ID, created.ID); err != nil {
+						t.Fatalf("DeletePause: %v", err)
+					}
+				}
+			}
+
+			filter := TotalCostFilter{UserID: &userID, ServiceName: &tt.service, Currency: domain.DefaultCurrency, StartDate: "2025-01-01", EndDate: "2025-12-01"}
+			total, err := repo.CalculateTotalCost(ctx, &filter)
+			if err != nil {
+				t.Fatalf("CalculateTotalCost: %v", err)
+			}
+			if total != tt.want {
+				t.Errorf("total = %d, want %d", total, tt.want)
+			}
+
+			byService, _, err := repo.CalculateTotalCostByService(ctx, &TotalCostBreakdownFilter{TotalCostFilter: filter, Limit: 10})
+			if err != nil {
+				t.Fatalf("CalculateTotalCostByService: %v", err)
+			}
+			if len(byService) != 1 || byService[0].TotalCostMinor != int64(tt.want) {
+				t.Errorf("by service = %v, want one group costing %d", byService, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// newSaturatedPool returns a one-connection pool whose only connection is
+// held until the test ends.
+func newSaturatedPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	url := os.Getenv(testDatabaseURLEnv)
+	if url == "" {
+		t.Skipf("%s is not set", testDatabaseURLEnv)
+	}
+
+	ctx := context.Background()
+	poolConfig, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("parse %s: %v", testDatabaseURLEnv, err)
+	}
+	poolConfig.MaxConns = 1
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	held, err := pool.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	t.Cleanup(held.Release)
+	return pool
+}
+
+func TestAcquireTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		callerLimit time.Duration
+		wantErr     error
+	}{
+		{name: "saturated pool is unavailable", callerLimit: time.Minute, wantErr: domain.ErrDatabaseUnavailable},
+		{name: "caller deadline first is not saturation", callerLimit: 10 * time.Millisecond, wantErr: context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := WithAcquireTimeout(newSaturatedPool(t), 100*time.Millisecond)
+			repo := NewSubscriptionRepository(db, TxConfig{}, false, zap.NewNop())
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.callerLimit)
+			defer cancel()
+
+			operations := map[string]func() error{
+				"query row": func() error { _, err := repo.GetByID(ctx, uuid.New()); return err },
+				"transaction": func() error {
+					_, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{ServiceName: "Netflix", PriceMinor: 400, UserID: uuid.New(), StartDate: "2025-01-01"})
+					return err
+				},
+			}
+			for name, operation := range operations {
+				err := operation()
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("%s error = %v, want %v", name, err, tt.wantErr)
+				}
+				if tt.wantErr != domain.ErrDatabaseUnavailable && errors.Is(err, domain.ErrDatabaseUnavailable) {
+					t.Errorf("%s reported the caller's own deadline as saturation", name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"subscription-service/internal/repository/sqlc"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+// PurgeDeleted permanently removes up to batchSize subscriptions that were
+// soft-deleted before cutoff, oldest deletion first, and returns how many
+// it removed. Their history and pauses go with them.
+func (r *subscriptionRepository) PurgeDeleted(ctx context.Context, cutoff time.Time, batchSize int) (int64, error) {
+	r.logger.Info("purging deleted subscriptions", zap.Time("cutoff", cutoff), zap.Int("batch_size", batchSize))
+
+	purged, err := r.queries.PurgeDeletedSubscriptions(ctx, sqlc.PurgeDeletedSubscriptionsParams{
+		Cutoff:    pgtype.Timestamptz{Time: cutoff, Valid: true},
+		BatchSize: int32(batchSize),
+	})
+	if err != nil {
+		r.logger.Error("failed to purge deleted subscriptions", zap.Error(err))
+		return 0, err
+	}
+
+	r.logger.Info("deleted subscriptions purged", zap.Int64("purged", purged))
+	return purged, nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestPurgeDeleted(t *testing.T) {
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, false)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	cutoff := now.AddDate(0, 0, -90)
+
+	// deletedAgo is nil for a live row; otherwise the row is soft-deleted
+	// and its deleted_at moved that far back.
+	rows := []struct {
+		service    string
+		deletedAgo *time.Duration
+		wantPurged bool
+	}{
+		{service: "Live"},
+		{service: "Deleted today", deletedAgo: durationPtr(0)},
+		{service: "Deleted just after the cutoff", deletedAgo: durationPtr(89 * 24 * time.Hour)},
+		{service: "Deleted just before the cutoff", deletedAgo: durationPtr(91 * 24 * time.Hour), wantPurged: true},
+		{service: "Deleted long ago", deletedAgo: durationPtr(400 * 24 * time.Hour), wantPurged: true},
+		{service: "Deleted a year ago", deletedAgo: durationPtr(365 * 24 * time.Hour), wantPurged: true},
+	}
+
+	ids := make([]uuid.UUID, len(rows))
+	for i, row := range rows {
+		sub, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{
+			ServiceName: row.service,
+			PriceMinor:  400,
+			UserID:      uuid.New(),
+			StartDate:   "2024-01-01",
+		})
+		if err != nil {
+			t.Fatalf("create %s: %v", row.service, err)
+		}
+		ids[i] = sub.ID
+		if _, err := pool.Exec(ctx, "INSERT INTO subscription_history (subscription_id, action) VALUES ($1, $2)", sub.ID, domain.HistoryActionRenewed); err != nil {
+			t.Fatalf("history %s: %v", row.service, err)
+		}
+		if _, err := repo.CreatePause(ctx, sub.ID, &domain.CreatePauseRequest{PauseStart: "2024-03-01", PauseEnd: "2024-03-31"}); err != nil {
+			t.Fatalf("pause %s: %v", row.service, err)
+		}
+		if row.deletedAgo == nil {
+			continue
+		}
+		if err := repo.Delete(ctx, sub.ID); err != nil {
+			t.Fatalf("delete %s: %v", row.service, err)
+		}
+		if _, err := pool.Exec(ctx, "UPDATE subscriptions SET deleted_at = $1 WHERE id = $2", now.Add(-*row.deletedAgo), sub.ID); err != nil {
+			t.Fatalf("backdate %s: %v", row.service, err)
+		}
+	}
+
+	// Batches of two take the three old rows in two passes.
+	for i, want := range []int64{2, 1, 0} {
+		purged, err := repo.PurgeDeleted(ctx, cutoff, 2)
+		if err != nil {
+			t.Fatalf("batch %d: PurgeDeleted: %v", i, err)
+		}
+		if purged != want {
+			t.Errorf("batch %d purged %d, want %d", i, purged, want)
+		}
+	}
+
+	count := func(query string, id uuid.UUID) int {
+		t.Helper()
+		var n int
+		if err := pool.QueryRow(ctx, query, id).Scan(&n); err != nil {
+			t.Fatalf("count: %v", err)
+		}
+		return n
+	}
+	for i, row := range rows {
+		for table, query := range map[string]string{
+			"subscriptions":        "SELECT COUNT(*) FROM subscriptions WHERE id = $1",
+			"subscription_history": "SELECT COUNT(*) FROM subscription_history WHERE subscription_id = $1",
+			"subscription_pauses":  "SELECT COUNT(*) FROM subscription_pauses WHERE subscription_id = $1",
+		} {
+			remaining := count(query, ids[i])
+			if row.wantPurged && remaining != 0 {
+				t.Errorf("%s: %d rows left in %s, want none", row.service, remaining, table)
+			}
+			if !row.wantPurged && remaining == 0 {
+				t.Errorf("%s: nothing left in %s, want it kept", row.service, table)
+			}
+		}
+	}
+}
+
+func durationPtr(d time.Duration) *time.Duration { return &d }
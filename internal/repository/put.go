@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+// Put creates the subscription described by req under id, or replaces every
+// field of the existing one when id is already in use by the same user. It
+// reports whether the subscription was created. An id that belongs to
+// another user, or to a deleted subscription, is never reused and yields
+// domain.ErrSubscriptionIDConflict.
+func (r *subscriptionRepository) Put(ctx context.Context, id uuid.UUID, req *domain.CreateSubscriptionRequest) (*domain.Subscription, bool, error) {
+	r.logger.Info("putting subscription", zap.String("id", id.String()), zap.String("user_id", req.UserID.String()))
+
+	params, err := r.createParams(req)
+	if err != nil {
+		return nil, false, err
+	}
+	idPgtype := pgtype.UUID{Bytes: id, Valid: true}
+
+	var result *domain.Subscription
+	var created bool
+	err = r.withCheckedTx(ctx, func(queries *sqlc.Queries) error {
+		owner, err := queries.GetSubscriptionOwner(ctx, idPgtype)
+		if errors.Is(err, pgx.ErrNoRows) {
+			created = true
+			result, err = r.createWithID(ctx, queries, idPgtype, params)
+			return err
+		}
+		if err != nil {
+			r.logger.Error("failed to look up subscription owner", zap.String("id", id.String()), zap.Error(err))
+			return err
+		}
+
+		created = false
+		if owner.UserID != params.UserID || owner.DeletedAt.Valid {
+			r.logger.Warn("subscription id already taken", zap.String("id", id.String()), zap.Bool("deleted", owner.DeletedAt.Valid))
+			return domain.ErrSubscriptionIDConflict
+		}
+
+		sub, err := queries.UpdateSubscription(ctx, sqlc.UpdateSubscriptionParams{
+			ID:            idPgtype,
+			ServiceName:   params.ServiceName,
+			Price:         params.Price,
+			StartDate:     params.StartDate,
+			EndDate:       params.EndDate,
+			AutoRenew:     params.AutoRenew,
+			Metadata:      params.Metadata,
+			Tags:          params.Tags,
+			BillingPeriod: params.BillingPeriod,
+			Currency:      params.Currency,
+		})
+		if err != nil {
+			r.logger.Error("failed to replace subscription", zap.String("id", id.String()), zap.Error(err))
+			return mapConstraintError(err)
+		}
+		if err := r.checkOverlap(ctx, queries, &sub); err != nil {
+			return err
+		}
+		if err := refreshDerivedFields(ctx, queries, &sub); err != nil {
+			return err
+		}
+
+		result = r.convertToSubscription(&sub)
+		return enqueueEvent(ctx, queries, domain.EventSubscriptionUpdated, sub.ID, result)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	r.logger.Info("subscription put successfully", zap.String("id", id.String()), zap.Bool("created", created))
+	return result, created, nil
+}
+
+func (r *subscriptionRepository) createWithID(ctx context.Context, queries *sqlc.Queries, id pgtype.UUID, params sqlc.CreateSubscriptionParams) (*domain.Subscription, error) {
+	sub, err := queries.CreateSubscriptionWithID(ctx, sqlc.CreateSubscriptionWithIDParams{
+		ID:            id,
+		ServiceName:   params.ServiceName,
+		Price:         params.Price,
+		UserID:        params.UserID,
+		StartDate:     params.StartDate,
+		EndDate:       params.EndDate,
+		AutoRenew:     params.AutoRenew,
+		Metadata:      params.Metadata,
+		Tags:          params.Tags,
+		BillingPeriod: params.BillingPeriod,
+		Currency:      params.Currency,
+	})
+	if err != nil {
+		r.logger.Error("failed to create subscription", zap.Error(err))
+		return nil, mapConstraintError(err)
+	}
+	if err := r.checkOverlap(ctx, queries, &sub); err != nil {
+		return nil, err
+	}
+	if err := refreshDerivedFields(ctx, queries, &sub); err != nil {
+		return nil, err
+	}
+
+	result := r.convertToSubscription(&sub)
+	if err := enqueueEvent(ctx, queries, domain.EventSubscriptionCreated, sub.ID, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
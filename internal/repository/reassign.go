@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+// ReassignUser moves every live subscription of from to to in one
+// transaction and returns how many moved. Each moved subscription gets a
+// history entry and an updated event. If the move would give to two
+// overlapping subscriptions to the same service while that is enforced,
+// nothing moves and domain.ErrDuplicateSubscription is returned.
+func (r *subscriptionRepository) ReassignUser(ctx context.Context, from, to uuid.UUID) (int64, error) {
+	r.logger.Info("reassigning subscriptions", zap.String("from_user_id", from.String()), zap.String("to_user_id", to.String()))
+
+	details, err := json.Marshal(map[string]interface{}{
+		"from_user_id": from,
+		"to_user_id":   to,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var moved int64
+	err = r.withCheckedTx(ctx, func(queries *sqlc.Queries) error {
+		moved = 0
+
+		subs, err := queries.ReassignUserSubscriptions(ctx, sqlc.ReassignUserSubscriptionsParams{
+			FromUserID: pgtype.UUID{Bytes: from, Valid: true},
+			ToUserID:   pgtype.UUID{Bytes: to, Valid: true},
+		})
+		if err != nil {
+			r.logger.Error("failed to reassign subscriptions", zap.Error(err))
+			return mapConstraintError(err)
+		}
+
+		for i := range subs {
+			if err := r.checkOverlap(ctx, queries, &subs[i]); err != nil {
+				return err
+			}
+			if err := queries.CreateHistoryEntry(ctx, sqlc.CreateHistoryEntryParams{
+				SubscriptionID: subs[i].ID,
+				Action:         domain.HistoryActionReassigned,
+				Details:        details,
+			}); err != nil {
+				r.logger.Error("failed to record reassignment history", zap.Error(err))
+				return err
+			}
+			if err := enqueueEvent(ctx, queries, domain.EventSubscriptionUpdated, subs[i].ID, r.convertToSubscription(&subs[i])); err != nil {
+				return err
+			}
+		}
+		moved = int64(len(subs))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	r.logger.Info("subscriptions reassigned successfully", zap.Int64("moved", moved))
+	return moved, nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestReassignUser(t *testing.T) {
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, false)
+	ctx := context.Background()
+	from, to, bystander := uuid.New(), uuid.New(), uuid.New()
+
+	create := func(owner uuid.UUID, service string) uuid.UUID {
+		t.Helper()
+		sub, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{
+			ServiceName: service,
+			PriceMinor:  400,
+			UserID:      owner,
+			StartDate:   "2025-01-01",
+		})
+		if err != nil {
+			t.Fatalf("create %s: %v", service, err)
+		}
+		return sub.ID
+	}
+	moving := []uuid.UUID{create(from, "Netflix"), create(from, "Spotify"), create(from, "GitHub")}
+	deleted := create(from, "Hulu")
+	if err := repo.Delete(ctx, deleted); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	create(bystander, "Netflix")
+
+	moved, err := repo.ReassignUser(ctx, from, to)
+	if err != nil {
+		t.Fatalf("ReassignUser: %v", err)
+	}
+	if moved != int64(len(moving)) {
+		t.Errorf("moved = %d, want %d", moved, len(moving))
+	}
+
+	movingIDs := make([]pgtype.UUID, len(moving))
+	for i, id := range moving {
+		movingIDs[i] = pgtype.UUID{Bytes: id, Valid: true}
+	}
+	count := func(query string, args ...interface{}) int {
+		t.Helper()
+		var n int
+		if err := pool.QueryRow(ctx, query, args...).Scan(&n); err != nil {
+			t.Fatalf("count: %v", err)
+		}
+		return n
+	}
+	tests := []struct {
+		name  string
+		query string
+		args  []interface{}
+		want  int
+	}{
+		{name: "no live rows left under the old user", query: "SELECT COUNT(*) FROM subscriptions WHERE user_id = $1 AND deleted_at IS NULL", args: []interface{}{from}},
+		{name: "deleted rows stay with the old user", query: "SELECT COUNT(*) FROM subscriptions WHERE user_id = $1 AND deleted_at IS NOT NULL", args: []interface{}{from}, want: 1},
+		{name: "live rows now under the new user", query: "SELECT COUNT(*) FROM subscriptions WHERE user_id = $1 AND id = ANY($2::UUID[])", args: []interface{}{to, movingIDs}, want: len(moving)},
+		{name: "other users untouched", query: "SELECT COUNT(*) FROM subscriptions WHERE user_id = $1", args: []interface{}{bystander}, want: 1},
+		{name: "a history entry per moved row", query: "SELECT COUNT(*) FROM subscription_history WHERE action = $1 AND subscription_id = ANY($2::UUID[])", args: []interface{}{domain.HistoryActionReassigned, movingIDs}, want: len(moving)},
+		{name: "no history for the deleted row", query: "SELECT COUNT(*) FROM subscription_history WHERE subscription_id = $1", args: []interface{}{deleted}},
+		{name: "an updated event per moved row", query: "SELECT COUNT(*) FROM outbox_events WHERE event_type = $1 AND payload->>'user_id' = $2", args: []interface{}{domain.EventSubscriptionUpdated, to.String()}, want: len(moving)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := count(tt.query, tt.args...); got != tt.want {
+				t.Errorf("count = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("repeating moves nothing", func(t *testing.T) {
+		moved, err := repo.ReassignUser(ctx, from, to)
+		if err != nil {
+			t.Fatalf("ReassignUser: %v", err)
+		}
+		if moved != 0 {
+			t.Errorf("moved = %d, want 0", moved)
+		}
+	})
+}
+
+func TestReassignUserOverlapMovesNothing(t *testing.T) {
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, true)
+	ctx := context.Background()
+	from, to := uuid.New(), uuid.New()
+
+	for _, sub := range []struct {
+		owner   uuid.UUID
+		service string
+	}{
+		{from, "Spotify"},
+		{from, "Netflix"},
+		{to, "Netflix"},
+	} {
+		if _, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{
+			ServiceName: sub.service,
+			PriceMinor:  400,
+			UserID:      sub.owner,
+			StartDate:   "2025-01-01",
+		}); err != nil {
+			t.Fatalf("create %s: %v", sub.service, err)
+		}
+	}
+
+	if _, err := repo.ReassignUser(ctx, from, to); !errors.Is(err, domain.ErrDuplicateSubscription) {
+		t.Fatalf("ReassignUser error = %v, want %v", err, domain.ErrDuplicateSubscription)
+	}
+
+	var remaining int
+	if err := pool.QueryRow(ctx, "SELECT COUNT(*) FROM subscriptions WHERE user_id = $1", from).Scan(&remaining); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if remaining != 2 {
+		t.Errorf("%d rows left under the old user, want both after the rollback", remaining)
+	}
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"subscription-service/internal/repository/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+// RecomputeFilter selects the next batch of subscriptions to recompute:
+// up to Limit rows matching the embedded filter with an id above AfterID,
+// in id order. Offset of the embedded filter is ignored.
+type RecomputeFilter struct {
+	ListSubscriptionsFilter
+	AfterID *uuid.UUID
+	AsOf    string
+}
+
+// RecomputeBatch reports one recompute batch. LastID is the cursor to pass
+// as AfterID for the next batch and is nil when the batch was empty.
+type RecomputeBatch struct {
+	Processed int
+	Updated   int
+	LastID    *uuid.UUID
+}
+
+// RecomputeDerivedFields recalculates status and next_renewal_date for one
+// batch inside a single transaction. Rows that already hold the right
+// values are left untouched, so running it again is harmless; rows that
+// change get a new updated_at so delta pulls pick them up.
+func (r *subscriptionRepository) RecomputeDerivedFields(ctx context.Context, filter *RecomputeFilter) (*RecomputeBatch, error) {
+	predicate, err := buildFilterPredicate(&filter.ListSubscriptionsFilter)
+	if err != nil {
+		return nil, err
+	}
+	if filter.AfterID != nil {
+		predicate.add("id > $%d", *filter.AfterID)
+	}
+
+	asOf := pgtype.Date{}
+	if err := asOf.Scan(filter.AsOf); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("SELECT id FROM subscriptions%s ORDER BY id LIMIT %d FOR UPDATE", predicate.where(), filter.Limit)
+
+	var batch *RecomputeBatch
+	err = r.withRawTx(ctx, func(tx pgx.Tx, queries *sqlc.Queries) error {
+		rows, err := tx.Query(ctx, query, predicate.args...)
+		if err != nil {
+			r.logger.Error("failed to select subscriptions to recompute", zap.Error(err))
+			return err
+		}
+		var ids []pgtype.UUID
+		for rows.Next() {
+			var id pgtype.UUID
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		batch = &RecomputeBatch{Processed: len(ids)}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		updated, err := queries.RecomputeDerivedFields(ctx, sqlc.RecomputeDerivedFieldsParams{
+			Ids:  ids,
+			AsOf: asOf,
+		})
+		if err != nil {
+			r.logger.Error("failed to recompute derived fields", zap.Error(err))
+			return err
+		}
+
+		lastID := uuid.UUID(ids[len(ids)-1].Bytes)
+		batch.Updated = len(updated)
+		batch.LastID = &lastID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// refreshDerivedFields brings the derived columns of sub up to date as of
+// today, within the caller's transaction.
+func refreshDerivedFields(ctx context.Context, queries *sqlc.Queries, sub *sqlc.Subscription) error {
+	rows, err := queries.RecomputeDerivedFields(ctx, sqlc.RecomputeDerivedFieldsParams{
+		Ids: []pgtype.UUID{sub.ID},
+	})
+	if err != nil {
+		return err
+	}
+	if len(rows) == 1 {
+		*sub = rows[0]
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestRecomputeDerivedFields(t *testing.T) {
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, false)
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		asOf          string
+		wantStatus    string
+		wantUpdatedAt bool
+	}{
+		{name: "past end date expires", asOf: "2025-03-01", wantStatus: domain.SubscriptionStatusExpired, wantUpdatedAt: true},
+		{name: "inside the term stays active", asOf: "2024-06-01", wantStatus: domain.SubscriptionStatusActive},
+		{name: "before the start is scheduled", asOf: "2023-06-01", wantStatus: domain.SubscriptionStatusScheduled, wantUpdatedAt: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New()
+			sub, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{
+				ServiceName: "Netflix",
+				PriceMinor:  400,
+				UserID:      userID,
+				StartDate:   "2024-01-01",
+				EndDate:     strPtr("2024-12-31"),
+				AutoRenew:   true,
+			})
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+
+			// Pretend the row was last refreshed while the term was running.
+			staleAt := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+			if _, err := pool.Exec(ctx, "UPDATE subscriptions SET status = $1, updated_at = $2 WHERE id = $3",
+				domain.SubscriptionStatusActive, staleAt, sub.ID); err != nil {
+				t.Fatalf("make stale: %v", err)
+			}
+
+			batch, err := repo.RecomputeDerivedFields(ctx, &RecomputeFilter{
+				ListSubscriptionsFilter: ListSubscriptionsFilter{UserID: &userID, Limit: 10},
+				AsOf:                    tt.asOf,
+			})
+			if err != nil {
+				t.Fatalf("recompute: %v", err)
+			}
+			if batch.Processed != 1 {
+				t.Fatalf("processed = %d, want 1", batch.Processed)
+			}
+
+			got, err := repo.GetByID(ctx, sub.ID)
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			if got.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", got.Status, tt.wantStatus)
+			}
+			if bumped := got.UpdatedAt.After(staleAt); bumped != tt.wantUpdatedAt {
+				t.Errorf("updated_at bumped = %v, want %v", bumped, tt.wantUpdatedAt)
+			}
+			if got.NextRenewalDate == nil || *got.NextRenewalDate != "2024-12-31" {
+				t.Errorf("next_renewal_date = %v, want 2024-12-31", got.NextRenewalDate)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestRenewExtendsByBillingPeriod(t *testing.T) {
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, false)
+	ctx := context.Background()
+
+	tests := []struct {
+		period  string
+		wantEnd string
+	}{
+		{domain.BillingPeriodMonthly, "2025-02-28"},
+		{domain.BillingPeriodQuarterly, "2025-04-30"},
+		{domain.BillingPeriodYearly, "2026-01-31"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			sub, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{
+				ServiceName:   "Netflix",
+				PriceMinor:    400,
+				UserID:        uuid.New(),
+				StartDate:     "2024-01-31",
+				EndDate:       strPtr("2025-01-31"),
+				AutoRenew:     true,
+				BillingPeriod: tt.period,
+			})
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+
+			renewed, err := repo.Renew(ctx, sub.ID, "2025-01-31")
+			if err != nil {
+				t.Fatalf("renew: %v", err)
+			}
+			if renewed == nil || renewed.EndDate == nil || *renewed.EndDate != tt.wantEnd {
+				t.Fatalf("renewed end date = %v, want %s", renewed, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestRenewUniqueActive(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		enforce     bool
+		nextStart   string
+		wantErr     error
+		wantEnd     string
+		wantHistory int
+	}{
+		{name: "enforced skips a renewal into a later term", enforce: true, nextStart: "2025-02-15", wantErr: domain.ErrDuplicateSubscription, wantEnd: "2025-01-31", wantHistory: 0},
+		{name: "enforced renews up to the day before a later term", enforce: true, nextStart: "2025-03-01", wantEnd: "2025-02-28", wantHistory: 1},
+		{name: "not enforced renews regardless", nextStart: "2025-02-15", wantEnd: "2025-02-28", wantHistory: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRepository(t, pool, tt.enforce)
+			userID := uuid.New()
+
+			sub, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{
+				ServiceName: "Netflix",
+				PriceMinor:  400,
+				UserID:      userID,
+				StartDate:   "2024-01-31",
+				EndDate:     strPtr("2025-01-31"),
+				AutoRenew:   true,
+			})
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+			if _, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{
+				ServiceName: "Netflix",
+				PriceMinor:  400,
+				UserID:      userID,
+				StartDate:   tt.nextStart,
+			}); err != nil {
+				t.Fatalf("create later term: %v", err)
+			}
+
+			renewed, err := repo.Renew(ctx, sub.ID, "2025-01-31")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("renew: got %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && renewed != nil {
+				t.Errorf("renew returned %v alongside %v", renewed, err)
+			}
+
+			got, err := repo.GetByID(ctx, sub.ID)
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			if got.EndDate == nil || *got.EndDate != tt.wantEnd {
+				t.Errorf("end date = %v, want %s", got.EndDate, tt.wantEnd)
+			}
+
+			var history int
+			if err := pool.QueryRow(ctx,
+				"SELECT COUNT(*) FROM subscription_history WHERE subscription_id = $1 AND action = $2",
+				sub.ID, domain.HistoryActionRenewed,
+			).Scan(&history); err != nil {
+				t.Fatalf("count history: %v", err)
+			}
+			if history != tt.wantHistory {
+				t.Errorf("renewal history entries = %d, want %d", history, tt.wantHistory)
+			}
+		})
+	}
+}
@@ -8,13 +8,50 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type OutboxEvent struct {
+	ID             pgtype.UUID
+	EventType      string
+	AggregateID    pgtype.UUID
+	Payload        []byte
+	CreatedAt      pgtype.Timestamptz
+	PublishedAt    pgtype.Timestamptz
+	Attempts       int32
+	LastError      pgtype.Text
+	NextAttemptAt  pgtype.Timestamptz
+	DeadLetteredAt pgtype.Timestamptz
+}
+
 type Subscription struct {
-	ID          pgtype.UUID
-	ServiceName string
-	Price       int32
-	UserID      pgtype.UUID
-	StartDate   pgtype.Date
-	EndDate     pgtype.Date
-	CreatedAt   pgtype.Timestamptz
-	UpdatedAt   pgtype.Timestamptz
+	ID              pgtype.UUID
+	ServiceName     string
+	Price           int32
+	UserID          pgtype.UUID
+	StartDate       pgtype.Date
+	EndDate         pgtype.Date
+	CreatedAt       pgtype.Timestamptz
+	UpdatedAt       pgtype.Timestamptz
+	AutoRenew       bool
+	Metadata        []byte
+	Status          string
+	NextRenewalDate pgtype.Date
+	DeletedAt       pgtype.Timestamptz
+	Tags            []string
+	BillingPeriod   string
+	Currency        string
+}
+
+type SubscriptionHistory struct {
+	ID             pgtype.UUID
+	SubscriptionID pgtype.UUID
+	Action         string
+	Details        []byte
+	CreatedAt      pgtype.Timestamptz
+}
+
+type SubscriptionPause struct {
+	ID             pgtype.UUID
+	SubscriptionID pgtype.UUID
+	PauseStart     pgtype.Date
+	PauseEnd       pgtype.Date
+	CreatedAt      pgtype.Timestamptz
 }
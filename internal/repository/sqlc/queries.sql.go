@@ -11,6 +11,55 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const anonymizeUserSubscriptions = `-- name: AnonymizeUserSubscriptions :many
+UPDATE subscriptions
+SET user_id = $1, metadata = '{}'::JSONB, updated_at = NOW()
+WHERE user_id = $2
+RETURNING id, service_name, price, user_id, start_date, end_date, created_at, updated_at, auto_renew, metadata, status, next_renewal_date, deleted_at, tags, billing_period, currency
+`
+
+type AnonymizeUserSubscriptionsParams struct {
+	TombstoneUserID pgtype.UUID
+	UserID          pgtype.UUID
+}
+
+func (q *Queries) AnonymizeUserSubscriptions(ctx context.Context, arg AnonymizeUserSubscriptionsParams) ([]Subscription, error) {
+	rows, err := q.db.Query(ctx, anonymizeUserSubscriptions, arg.TombstoneUserID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Subscription
+	for rows.Next() {
+		var i Subscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.ServiceName,
+			&i.Price,
+			&i.UserID,
+			&i.StartDate,
+			&i.EndDate,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.AutoRenew,
+			&i.Metadata,
+			&i.Status,
+			&i.NextRenewalDate,
+			&i.DeletedAt,
+			&i.Tags,
+			&i.BillingPeriod,
+			&i.Currency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const calculateTotalCost = `-- name: CalculateTotalCost :one
 WITH date_range AS (
     SELECT 
@@ -19,18 +68,28 @@ WITH date_range AS (
 subscription_costs AS (
     SELECT 
         s.id as subscription_id,
-        s.price,
+        CASE s.billing_period
+            WHEN 'yearly' THEN s.price / 12.0
+            WHEN 'quarterly' THEN s.price / 3.0
+            ELSE s.price
+        END AS monthly_price,
         COUNT(DISTINCT dr.month_start) as months_count
     FROM subscriptions s
     CROSS JOIN date_range dr
     WHERE 
         ($3::UUID IS NULL OR s.user_id = $3) AND
         ($4::VARCHAR IS NULL OR s.service_name ILIKE '%' || $4 || '%') AND
+        s.currency = $5 AND
         (s.start_date <= dr.month_start) AND
-        (s.end_date IS NULL OR s.end_date >= dr.month_start)
-    GROUP BY s.id, s.price
+        (s.end_date IS NULL OR s.end_date >= dr.month_start) AND
+        s.deleted_at IS NULL AND
+        NOT EXISTS (
+            SELECT 1 FROM subscription_pauses p
+            WHERE p.subscription_id = s.id AND dr.month_start BETWEEN p.pause_start AND p.pause_end
+        )
+    GROUP BY s.id, s.price, s.billing_period
 )
-SELECT COALESCE(SUM(price * months_count), 0)::BIGINT as total_cost
+SELECT COALESCE(ROUND(SUM(monthly_price * months_count)), 0)::BIGINT as total_cost
 FROM subscription_costs
 `
 
@@ -39,6 +98,7 @@ type CalculateTotalCostParams struct {
 	EndDate     pgtype.Date
 	UserID      pgtype.UUID
 	ServiceName pgtype.Text
+	Currency    string
 }
 
 func (q *Queries) CalculateTotalCost(ctx context.Context, arg CalculateTotalCostParams) (int64, error) {
@@ -47,43 +107,236 @@ func (q *Queries) CalculateTotalCost(ctx context.Context, arg CalculateTotalCost
 		arg.EndDate,
 		arg.UserID,
 		arg.ServiceName,
+		arg.Currency,
 	)
 	var total_cost int64
 	err := row.Scan(&total_cost)
 	return total_cost, err
 }
 
-const countSubscriptions = `-- name: CountSubscriptions :one
-SELECT COUNT(*) FROM subscriptions
-WHERE 
-    ($1::UUID IS NULL OR user_id = $1) AND
-    ($2::VARCHAR IS NULL OR service_name ILIKE '%' || $2 || '%')
+const calculateTotalCostByService = `-- name: CalculateTotalCostByService :many
+WITH date_range AS (
+    SELECT 
+        generate_series($1::DATE, $2::DATE, '1 month'::interval)::DATE AS month_start
+),
+subscription_costs AS (
+    SELECT 
+        s.id as subscription_id,
+        s.service_name,
+        CASE s.billing_period
+            WHEN 'yearly' THEN s.price / 12.0
+            WHEN 'quarterly' THEN s.price / 3.0
+            ELSE s.price
+        END AS monthly_price,
+        COUNT(DISTINCT dr.month_start) as months_count
+    FROM subscriptions s
+    CROSS JOIN date_range dr
+    WHERE 
+        ($3::UUID IS NULL OR s.user_id = $3) AND
+        ($4::VARCHAR IS NULL OR s.service_name ILIKE '%' || $4 || '%') AND
+        s.currency = $5 AND
+        (s.start_date <= dr.month_start) AND
+        (s.end_date IS NULL OR s.end_date >= dr.month_start) AND
+        s.deleted_at IS NULL AND
+        NOT EXISTS (
+            SELECT 1 FROM subscription_pauses p
+            WHERE p.subscription_id = s.id AND dr.month_start BETWEEN p.pause_start AND p.pause_end
+        )
+    GROUP BY s.id, s.service_name, s.price, s.billing_period
+)
+SELECT
+    service_name,
+    ROUND(SUM(monthly_price * months_count))::BIGINT AS total_cost,
+    COUNT(*) OVER ()::BIGINT AS group_count
+FROM subscription_costs
+GROUP BY service_name
+ORDER BY
+    CASE WHEN $6::TEXT = 'cost_asc' THEN SUM(monthly_price * months_count) END ASC,
+    CASE WHEN $6::TEXT = 'cost_desc' THEN SUM(monthly_price * months_count) END DESC,
+    service_name ASC
+LIMIT $8 OFFSET $7
 `
 
-type CountSubscriptionsParams struct {
+type CalculateTotalCostByServiceParams struct {
+	StartDate   pgtype.Date
+	EndDate     pgtype.Date
 	UserID      pgtype.UUID
 	ServiceName pgtype.Text
+	Currency    string
+	SortOrder   string
+	Offset      int32
+	Limit       int32
 }
 
-func (q *Queries) CountSubscriptions(ctx context.Context, arg CountSubscriptionsParams) (int64, error) {
-	row := q.db.QueryRow(ctx, countSubscriptions, arg.UserID, arg.ServiceName)
+type CalculateTotalCostByServiceRow struct {
+	ServiceName string
+	TotalCost   int64
+	GroupCount  int64
+}
+
+func (q *Queries) CalculateTotalCostByService(ctx context.Context, arg CalculateTotalCostByServiceParams) ([]CalculateTotalCostByServiceRow, error) {
+	rows, err := q.db.Query(ctx, calculateTotalCostByService,
+		arg.StartDate,
+		arg.EndDate,
+		arg.UserID,
+		arg.ServiceName,
+		arg.Currency,
+		arg.SortOrder,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CalculateTotalCostByServiceRow
+	for rows.Next() {
+		var i CalculateTotalCostByServiceRow
+		if err := rows.Scan(&i.ServiceName, &i.TotalCost, &i.GroupCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const claimDueOutboxEvents = `-- name: ClaimDueOutboxEvents :many
+UPDATE outbox_events
+SET next_attempt_at = NOW() + $1::FLOAT8 * INTERVAL '1 second'
+WHERE id IN (
+    SELECT id FROM outbox_events
+    WHERE published_at IS NULL AND dead_lettered_at IS NULL AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at, created_at, id
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, event_type, aggregate_id, payload, created_at, published_at, attempts, last_error, next_attempt_at, dead_lettered_at
+`
+
+type ClaimDueOutboxEventsParams struct {
+	ClaimSeconds float64
+	BatchSize    int32
+}
+
+func (q *Queries) ClaimDueOutboxEvents(ctx context.Context, arg ClaimDueOutboxEventsParams) ([]OutboxEvent, error) {
+	rows, err := q.db.Query(ctx, claimDueOutboxEvents, arg.ClaimSeconds, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OutboxEvent
+	for rows.Next() {
+		var i OutboxEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventType,
+			&i.AggregateID,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.PublishedAt,
+			&i.Attempts,
+			&i.LastError,
+			&i.NextAttemptAt,
+			&i.DeadLetteredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countPendingOutboxEvents = `-- name: CountPendingOutboxEvents :one
+SELECT COUNT(*) FROM outbox_events WHERE published_at IS NULL AND dead_lettered_at IS NULL
+`
+
+func (q *Queries) CountPendingOutboxEvents(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countPendingOutboxEvents)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
 }
 
+const createHistoryEntry = `-- name: CreateHistoryEntry :exec
+INSERT INTO subscription_history (subscription_id, action, details)
+VALUES ($1, $2, $3)
+`
+
+type CreateHistoryEntryParams struct {
+	SubscriptionID pgtype.UUID
+	Action         string
+	Details        []byte
+}
+
+func (q *Queries) CreateHistoryEntry(ctx context.Context, arg CreateHistoryEntryParams) error {
+	_, err := q.db.Exec(ctx, createHistoryEntry, arg.SubscriptionID, arg.Action, arg.Details)
+	return err
+}
+
+const createOutboxEvent = `-- name: CreateOutboxEvent :exec
+INSERT INTO outbox_events (event_type, aggregate_id, payload)
+VALUES ($1, $2, $3)
+`
+
+type CreateOutboxEventParams struct {
+	EventType   string
+	AggregateID pgtype.UUID
+	Payload     []byte
+}
+
+func (q *Queries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) error {
+	_, err := q.db.Exec(ctx, createOutboxEvent, arg.EventType, arg.AggregateID, arg.Payload)
+	return err
+}
+
+const createPause = `-- name: CreatePause :one
+INSERT INTO subscription_pauses (subscription_id, pause_start, pause_end)
+VALUES ($1, $2, $3)
+RETURNING id, subscription_id, pause_start, pause_end, created_at
+`
+
+type CreatePauseParams struct {
+	SubscriptionID pgtype.UUID
+	PauseStart     pgtype.Date
+	PauseEnd       pgtype.Date
+}
+
+func (q *Queries) CreatePause(ctx context.Context, arg CreatePauseParams) (SubscriptionPause, error) {
+	row := q.db.QueryRow(ctx, createPause, arg.SubscriptionID, arg.PauseStart, arg.PauseEnd)
+	var i SubscriptionPause
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.PauseStart,
+		&i.PauseEnd,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const createSubscription = `-- name: CreateSubscription :one
-INSERT INTO subscriptions (service_name, price, user_id, start_date, end_date)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, service_name, price, user_id, start_date, end_date, created_at, updated_at
+INSERT INTO subscriptions (service_name, price, user_id, start_date, end_date, auto_renew, metadata, tags, billing_period, currency)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, service_name, price, user_id, start_date, end_date, created_at, updated_at, auto_renew, metadata, status, next_renewal_date, deleted_at, tags, billing_period, currency
 `
 
 type CreateSubscriptionParams struct {
-	ServiceName string
-	Price       int32
-	UserID      pgtype.UUID
-	StartDate   pgtype.Date
-	EndDate     pgtype.Date
+	ServiceName   string
+	Price         int32
+	UserID        pgtype.UUID
+	StartDate     pgtype.Date
+	EndDate       pgtype.Date
+	AutoRenew     bool
+	Metadata      []byte
+	Tags          []string
+	BillingPeriod string
+	Currency      string
 }
 
 func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscriptionParams) (Subscription, error) {
@@ -93,6 +346,67 @@ func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscription
 		arg.UserID,
 		arg.StartDate,
 		arg.EndDate,
+		arg.AutoRenew,
+		arg.Metadata,
+		arg.Tags,
+		arg.BillingPeriod,
+		arg.Currency,
+	)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.ServiceName,
+		&i.Price,
+		&i.UserID,
+		&i.StartDate,
+		&i.EndDate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.AutoRenew,
+		&i.Metadata,
+		&i.Status,
+		&i.NextRenewalDate,
+		&i.DeletedAt,
+		&i.Tags,
+		&i.BillingPeriod,
+		&i.Currency,
+	)
+	return i, err
+}
+
+const createSubscriptionWithID = `-- name: CreateSubscriptionWithID :one
+INSERT INTO subscriptions (id, service_name, price, user_id, start_date, end_date, auto_renew, metadata, tags, billing_period, currency)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+RETURNING id, service_name, price, user_id, start_date, end_date, created_at, updated_at, auto_renew, metadata, status, next_renewal_date, deleted_at, tags, billing_period, currency
+`
+
+type CreateSubscriptionWithIDParams struct {
+	ID            pgtype.UUID
+	ServiceName   string
+	Price         int32
+	UserID        pgtype.UUID
+	StartDate     pgtype.Date
+	EndDate       pgtype.Date
+	AutoRenew     bool
+	Metadata      []byte
+	Tags          []string
+	BillingPeriod string
+	Currency      string
+}
+
+func (q *Queries) CreateSubscriptionWithID(ctx context.Context, arg CreateSubscriptionWithIDParams) (Subscription, error) {
+	row := q.db.QueryRow(ctx, createSubscriptionWithID,
+		arg.ID,
+		arg.ServiceName,
+		arg.Price,
+		arg.UserID,
+		arg.StartDate,
+		arg.EndDate,
+		arg.AutoRenew,
+		arg.Metadata,
+		arg.Tags,
+		arg.BillingPeriod,
+		arg.Currency,
 	)
 	var i Subscription
 	err := row.Scan(
@@ -104,12 +418,39 @@ func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscription
 		&i.EndDate,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.AutoRenew,
+		&i.Metadata,
+		&i.Status,
+		&i.NextRenewalDate,
+		&i.DeletedAt,
+		&i.Tags,
+		&i.BillingPeriod,
+		&i.Currency,
 	)
 	return i, err
 }
 
+const deletePause = `-- name: DeletePause :execrows
+DELETE FROM subscription_pauses WHERE id = $1 AND subscription_id = $2
+`
+
+type DeletePauseParams struct {
+	ID             pgtype.UUID
+	SubscriptionID pgtype.UUID
+}
+
+func (q *Queries) DeletePause(ctx context.Context, arg DeletePauseParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deletePause, arg.ID, arg.SubscriptionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const deleteSubscription = `-- name: DeleteSubscription :execrows
-DELETE FROM subscriptions WHERE id = $1
+UPDATE subscriptions
+SET deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) DeleteSubscription(ctx context.Context, id pgtype.UUID) (int64, error) {
@@ -120,8 +461,124 @@ func (q *Queries) DeleteSubscription(ctx context.Context, id pgtype.UUID) (int64
 	return result.RowsAffected(), nil
 }
 
+const getMonthlyRecurringRevenue = `-- name: GetMonthlyRecurringRevenue :many
+SELECT
+    currency,
+    ROUND(SUM(
+        CASE billing_period
+            WHEN 'yearly' THEN price / 12.0
+            WHEN 'quarterly' THEN price / 3.0
+            ELSE price
+        END
+    ))::BIGINT AS monthly_recurring_revenue
+FROM subscriptions
+WHERE
+    deleted_at IS NULL AND
+    start_date <= $1::DATE AND
+    (end_date IS NULL OR end_date >= $1::DATE)
+GROUP BY currency
+ORDER BY currency
+`
+
+type GetMonthlyRecurringRevenueRow struct {
+	Currency                string
+	MonthlyRecurringRevenue int64
+}
+
+func (q *Queries) GetMonthlyRecurringRevenue(ctx context.Context, asOf pgtype.Date) ([]GetMonthlyRecurringRevenueRow, error) {
+	rows, err := q.db.Query(ctx, getMonthlyRecurringRevenue, asOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMonthlyRecurringRevenueRow
+	for rows.Next() {
+		var i GetMonthlyRecurringRevenueRow
+		if err := rows.Scan(&i.Currency, &i.MonthlyRecurringRevenue); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getServiceRevenue = `-- name: GetServiceRevenue :many
+SELECT
+    currency,
+    ROUND(SUM(
+        CASE billing_period
+            WHEN 'yearly' THEN price / 12.0
+            WHEN 'quarterly' THEN price / 3.0
+            ELSE price
+        END
+    ))::BIGINT AS monthly_revenue
+FROM subscriptions
+WHERE
+    service_name = $1 AND
+    start_date <= $2::DATE AND
+    (end_date IS NULL OR end_date >= $2::DATE) AND
+    deleted_at IS NULL
+GROUP BY currency
+ORDER BY currency
+`
+
+type GetServiceRevenueParams struct {
+	ServiceName string
+	AsOf        pgtype.Date
+}
+
+type GetServiceRevenueRow struct {
+	Currency       string
+	MonthlyRevenue int64
+}
+
+func (q *Queries) GetServiceRevenue(ctx context.Context, arg GetServiceRevenueParams) ([]GetServiceRevenueRow, error) {
+	rows, err := q.db.Query(ctx, getServiceRevenue, arg.ServiceName, arg.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetServiceRevenueRow
+	for rows.Next() {
+		var i GetServiceRevenueRow
+		if err := rows.Scan(&i.Currency, &i.MonthlyRevenue); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getServiceStats = `-- name: GetServiceStats :one
+SELECT COUNT(DISTINCT user_id)::BIGINT AS subscriber_count
+FROM subscriptions
+WHERE
+    service_name = $1 AND
+    start_date <= $2::DATE AND
+    (end_date IS NULL OR end_date >= $2::DATE) AND
+    deleted_at IS NULL
+`
+
+type GetServiceStatsParams struct {
+	ServiceName string
+	AsOf        pgtype.Date
+}
+
+func (q *Queries) GetServiceStats(ctx context.Context, arg GetServiceStatsParams) (int64, error) {
+	row := q.db.QueryRow(ctx, getServiceStats, arg.ServiceName, arg.AsOf)
+	var subscriber_count int64
+	err := row.Scan(&subscriber_count)
+	return subscriber_count, err
+}
+
 const getSubscription = `-- name: GetSubscription :one
-SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at FROM subscriptions WHERE id = $1
+SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at, auto_renew, metadata, status, next_renewal_date, deleted_at, tags, billing_period, currency FROM subscriptions WHERE id = $1 AND deleted_at IS NULL
 `
 
 func (q *Queries) GetSubscription(ctx context.Context, id pgtype.UUID) (Subscription, error) {
@@ -136,37 +593,379 @@ func (q *Queries) GetSubscription(ctx context.Context, id pgtype.UUID) (Subscrip
 		&i.EndDate,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.AutoRenew,
+		&i.Metadata,
+		&i.Status,
+		&i.NextRenewalDate,
+		&i.DeletedAt,
+		&i.Tags,
+		&i.BillingPeriod,
+		&i.Currency,
 	)
 	return i, err
 }
 
-const listSubscriptions = `-- name: ListSubscriptions :many
-SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at FROM subscriptions
-WHERE 
-    ($1::UUID IS NULL OR user_id = $1) AND
-    ($2::VARCHAR IS NULL OR service_name ILIKE '%' || $2 || '%')
-ORDER BY created_at DESC
-LIMIT $4 OFFSET $3
+const getSubscriptionForUpdate = `-- name: GetSubscriptionForUpdate :one
+SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at, auto_renew, metadata, status, next_renewal_date, deleted_at, tags, billing_period, currency FROM subscriptions WHERE id = $1 AND deleted_at IS NULL FOR UPDATE
 `
 
-type ListSubscriptionsParams struct {
+func (q *Queries) GetSubscriptionForUpdate(ctx context.Context, id pgtype.UUID) (Subscription, error) {
+	row := q.db.QueryRow(ctx, getSubscriptionForUpdate, id)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.ServiceName,
+		&i.Price,
+		&i.UserID,
+		&i.StartDate,
+		&i.EndDate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.AutoRenew,
+		&i.Metadata,
+		&i.Status,
+		&i.NextRenewalDate,
+		&i.DeletedAt,
+		&i.Tags,
+		&i.BillingPeriod,
+		&i.Currency,
+	)
+	return i, err
+}
+
+const getSubscriptionIncludingDeleted = `-- name: GetSubscriptionIncludingDeleted :one
+SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at, auto_renew, metadata, status, next_renewal_date, deleted_at, tags, billing_period, currency FROM subscriptions WHERE id = $1
+`
+
+func (q *Queries) GetSubscriptionIncludingDeleted(ctx context.Context, id pgtype.UUID) (Subscription, error) {
+	row := q.db.QueryRow(ctx, getSubscriptionIncludingDeleted, id)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.ServiceName,
+		&i.Price,
+		&i.UserID,
+		&i.StartDate,
+		&i.EndDate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.AutoRenew,
+		&i.Metadata,
+		&i.Status,
+		&i.NextRenewalDate,
+		&i.DeletedAt,
+		&i.Tags,
+		&i.BillingPeriod,
+		&i.Currency,
+	)
+	return i, err
+}
+
+const getSubscriptionKPIs = `-- name: GetSubscriptionKPIs :one
+SELECT
+    COUNT(*) FILTER (WHERE
+        deleted_at IS NULL AND
+        start_date <= $1::DATE AND
+        (end_date IS NULL OR end_date >= $1::DATE)
+    )::BIGINT AS active_count,
+    COUNT(*) FILTER (WHERE created_at >= $2::TIMESTAMPTZ)::BIGINT AS new_count,
+    COUNT(*) FILTER (WHERE
+        deleted_at >= $2::TIMESTAMPTZ OR
+        (deleted_at IS NULL AND end_date >= $2::DATE AND end_date < $1::DATE)
+    )::BIGINT AS churned_count
+FROM subscriptions
+`
+
+type GetSubscriptionKPIsParams struct {
+	AsOf  pgtype.Date
+	Since pgtype.Timestamptz
+}
+
+type GetSubscriptionKPIsRow struct {
+	ActiveCount  int64
+	NewCount     int64
+	ChurnedCount int64
+}
+
+func (q *Queries) GetSubscriptionKPIs(ctx context.Context, arg GetSubscriptionKPIsParams) (GetSubscriptionKPIsRow, error) {
+	row := q.db.QueryRow(ctx, getSubscriptionKPIs, arg.AsOf, arg.Since)
+	var i GetSubscriptionKPIsRow
+	err := row.Scan(
+		&i.ActiveCount,
+		&i.NewCount,
+		&i.ChurnedCount,
+	)
+	return i, err
+}
+
+const getSubscriptionOwner = `-- name: GetSubscriptionOwner :one
+SELECT user_id, deleted_at FROM subscriptions WHERE id = $1 FOR UPDATE
+`
+
+type GetSubscriptionOwnerRow struct {
+	UserID    pgtype.UUID
+	DeletedAt pgtype.Timestamptz
+}
+
+func (q *Queries) GetSubscriptionOwner(ctx context.Context, id pgtype.UUID) (GetSubscriptionOwnerRow, error) {
+	row := q.db.QueryRow(ctx, getSubscriptionOwner, id)
+	var i GetSubscriptionOwnerRow
+	err := row.Scan(&i.UserID, &i.DeletedAt)
+	return i, err
+}
+
+const listHistoryEntries = `-- name: ListHistoryEntries :many
+SELECT id, subscription_id, action, details, created_at FROM subscription_history
+WHERE subscription_id = $1
+ORDER BY created_at, id
+`
+
+func (q *Queries) ListHistoryEntries(ctx context.Context, subscriptionID pgtype.UUID) ([]SubscriptionHistory, error) {
+	rows, err := q.db.Query(ctx, listHistoryEntries, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SubscriptionHistory
+	for rows.Next() {
+		var i SubscriptionHistory
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.Action,
+			&i.Details,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOverlappingSubscriptionIDs = `-- name: ListOverlappingSubscriptionIDs :many
+SELECT id FROM subscriptions
+WHERE
+    user_id = $1 AND
+    service_name = $2 AND
+    ($3::UUID IS NULL OR id <> $3) AND
+    start_date <= COALESCE($4::DATE, 'infinity'::DATE) AND
+    (end_date IS NULL OR end_date >= $5::DATE) AND
+    deleted_at IS NULL
+ORDER BY start_date, id
+`
+
+type ListOverlappingSubscriptionIDsParams struct {
 	UserID      pgtype.UUID
-	ServiceName pgtype.Text
-	Offset      int32
-	Limit       int32
+	ServiceName string
+	ExcludeID   pgtype.UUID
+	EndDate     pgtype.Date
+	StartDate   pgtype.Date
 }
 
-func (q *Queries) ListSubscriptions(ctx context.Context, arg ListSubscriptionsParams) ([]Subscription, error) {
-	rows, err := q.db.Query(ctx, listSubscriptions,
+func (q *Queries) ListOverlappingSubscriptionIDs(ctx context.Context, arg ListOverlappingSubscriptionIDsParams) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, listOverlappingSubscriptionIDs,
 		arg.UserID,
 		arg.ServiceName,
-		arg.Offset,
-		arg.Limit,
+		arg.ExcludeID,
+		arg.EndDate,
+		arg.StartDate,
 	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPauses = `-- name: ListPauses :many
+SELECT id, subscription_id, pause_start, pause_end, created_at FROM subscription_pauses
+WHERE subscription_id = $1
+ORDER BY pause_start, id
+`
+
+func (q *Queries) ListPauses(ctx context.Context, subscriptionID pgtype.UUID) ([]SubscriptionPause, error) {
+	rows, err := q.db.Query(ctx, listPauses, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SubscriptionPause
+	for rows.Next() {
+		var i SubscriptionPause
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.PauseStart,
+			&i.PauseEnd,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listServiceNames = `-- name: ListServiceNames :many
+SELECT DISTINCT service_name FROM subscriptions
+WHERE deleted_at IS NULL
+ORDER BY service_name
+`
+
+func (q *Queries) ListServiceNames(ctx context.Context) ([]string, error) {
+	rows, err := q.db.Query(ctx, listServiceNames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var service_name string
+		if err := rows.Scan(&service_name); err != nil {
+			return nil, err
+		}
+		items = append(items, service_name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markOutboxEventPublished = `-- name: MarkOutboxEventPublished :exec
+UPDATE outbox_events
+SET published_at = NOW(), attempts = attempts + 1, last_error = NULL
+WHERE id = $1
+`
+
+func (q *Queries) MarkOutboxEventPublished(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, markOutboxEventPublished, id)
+	return err
+}
+
+const purgeDeletedSubscriptions = `-- name: PurgeDeletedSubscriptions :execrows
+DELETE FROM subscriptions
+WHERE id IN (
+    SELECT id FROM subscriptions
+    WHERE deleted_at < $1
+    ORDER BY deleted_at
+    LIMIT $2
+)
+`
+
+type PurgeDeletedSubscriptionsParams struct {
+	Cutoff    pgtype.Timestamptz
+	BatchSize int32
+}
+
+func (q *Queries) PurgeDeletedSubscriptions(ctx context.Context, arg PurgeDeletedSubscriptionsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeDeletedSubscriptions, arg.Cutoff, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const reassignUserSubscriptions = `-- name: ReassignUserSubscriptions :many
+UPDATE subscriptions
+SET user_id = $1, updated_at = NOW()
+WHERE user_id = $2 AND deleted_at IS NULL
+RETURNING id, service_name, price, user_id, start_date, end_date, created_at, updated_at, auto_renew, metadata, status, next_renewal_date, deleted_at, tags, billing_period, currency
+`
+
+type ReassignUserSubscriptionsParams struct {
+	ToUserID   pgtype.UUID
+	FromUserID pgtype.UUID
+}
+
+func (q *Queries) ReassignUserSubscriptions(ctx context.Context, arg ReassignUserSubscriptionsParams) ([]Subscription, error) {
+	rows, err := q.db.Query(ctx, reassignUserSubscriptions, arg.ToUserID, arg.FromUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Subscription
+	for rows.Next() {
+		var i Subscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.ServiceName,
+			&i.Price,
+			&i.UserID,
+			&i.StartDate,
+			&i.EndDate,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.AutoRenew,
+			&i.Metadata,
+			&i.Status,
+			&i.NextRenewalDate,
+			&i.DeletedAt,
+			&i.Tags,
+			&i.BillingPeriod,
+			&i.Currency,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recomputeDerivedFields = `-- name: RecomputeDerivedFields :many
+UPDATE subscriptions
+SET
+    status = CASE
+        WHEN end_date IS NOT NULL AND end_date < COALESCE($2::DATE, CURRENT_DATE) THEN 'expired'
+        WHEN start_date > COALESCE($2::DATE, CURRENT_DATE) THEN 'scheduled'
+        ELSE 'active'
+    END,
+    next_renewal_date = CASE WHEN auto_renew THEN end_date END,
+    updated_at = NOW()
+WHERE
+    id = ANY($1::UUID[]) AND (
+        status IS DISTINCT FROM (CASE
+            WHEN end_date IS NOT NULL AND end_date < COALESCE($2::DATE, CURRENT_DATE) THEN 'expired'
+            WHEN start_date > COALESCE($2::DATE, CURRENT_DATE) THEN 'scheduled'
+            ELSE 'active'
+        END) OR
+        next_renewal_date IS DISTINCT FROM (CASE WHEN auto_renew THEN end_date END)
+    )
+RETURNING id, service_name, price, user_id, start_date, end_date, created_at, updated_at, auto_renew, metadata, status, next_renewal_date, deleted_at, tags, billing_period, currency
+`
+
+type RecomputeDerivedFieldsParams struct {
+	Ids  []pgtype.UUID
+	AsOf pgtype.Date
+}
+
+func (q *Queries) RecomputeDerivedFields(ctx context.Context, arg RecomputeDerivedFieldsParams) ([]Subscription, error) {
+	rows, err := q.db.Query(ctx, recomputeDerivedFields, arg.Ids, arg.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 	var items []Subscription
 	for rows.Next() {
 		var i Subscription
@@ -179,6 +978,14 @@ func (q *Queries) ListSubscriptions(ctx context.Context, arg ListSubscriptionsPa
 			&i.EndDate,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.AutoRenew,
+			&i.Metadata,
+			&i.Status,
+			&i.NextRenewalDate,
+			&i.DeletedAt,
+			&i.Tags,
+			&i.BillingPeriod,
+			&i.Currency,
 		); err != nil {
 			return nil, err
 		}
@@ -190,24 +997,142 @@ func (q *Queries) ListSubscriptions(ctx context.Context, arg ListSubscriptionsPa
 	return items, nil
 }
 
+const recordOutboxEventFailure = `-- name: RecordOutboxEventFailure :one
+UPDATE outbox_events
+SET
+    attempts = attempts + 1,
+    last_error = $1,
+    next_attempt_at = NOW() + $2::FLOAT8 * INTERVAL '1 second',
+    dead_lettered_at = CASE WHEN attempts + 1 >= $3::INT THEN NOW() END
+WHERE id = $4
+RETURNING dead_lettered_at IS NOT NULL AS dead_lettered
+`
+
+type RecordOutboxEventFailureParams struct {
+	LastError    pgtype.Text
+	RetrySeconds float64
+	MaxAttempts  int32
+	ID           pgtype.UUID
+}
+
+func (q *Queries) RecordOutboxEventFailure(ctx context.Context, arg RecordOutboxEventFailureParams) (bool, error) {
+	row := q.db.QueryRow(ctx, recordOutboxEventFailure,
+		arg.LastError,
+		arg.RetrySeconds,
+		arg.MaxAttempts,
+		arg.ID,
+	)
+	var dead_lettered bool
+	err := row.Scan(&dead_lettered)
+	return dead_lettered, err
+}
+
+const renewSubscription = `-- name: RenewSubscription :one
+UPDATE subscriptions
+SET
+    end_date = (end_date + CASE billing_period
+        WHEN 'yearly' THEN INTERVAL '1 year'
+        WHEN 'quarterly' THEN INTERVAL '3 months'
+        ELSE INTERVAL '1 month'
+    END)::DATE,
+    updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL AND auto_renew AND end_date = $2::DATE
+RETURNING id, service_name, price, user_id, start_date, end_date, created_at, updated_at, auto_renew, metadata, status, next_renewal_date, deleted_at, tags, billing_period, currency
+`
+
+type RenewSubscriptionParams struct {
+	ID             pgtype.UUID
+	CurrentEndDate pgtype.Date
+}
+
+func (q *Queries) RenewSubscription(ctx context.Context, arg RenewSubscriptionParams) (Subscription, error) {
+	row := q.db.QueryRow(ctx, renewSubscription, arg.ID, arg.CurrentEndDate)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.ServiceName,
+		&i.Price,
+		&i.UserID,
+		&i.StartDate,
+		&i.EndDate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.AutoRenew,
+		&i.Metadata,
+		&i.Status,
+		&i.NextRenewalDate,
+		&i.DeletedAt,
+		&i.Tags,
+		&i.BillingPeriod,
+		&i.Currency,
+	)
+	return i, err
+}
+
+const scrubSubscriptionHistory = `-- name: ScrubSubscriptionHistory :exec
+UPDATE subscription_history SET details = NULL
+WHERE subscription_id = ANY($1::UUID[])
+    OR details->>'from_user_id' = $2::TEXT
+    OR details->>'to_user_id' = $2::TEXT
+`
+
+type ScrubSubscriptionHistoryParams struct {
+	SubscriptionIds []pgtype.UUID
+	UserID          string
+}
+
+func (q *Queries) ScrubSubscriptionHistory(ctx context.Context, arg ScrubSubscriptionHistoryParams) error {
+	_, err := q.db.Exec(ctx, scrubSubscriptionHistory, arg.SubscriptionIds, arg.UserID)
+	return err
+}
+
+const scrubUserOutboxEvents = `-- name: ScrubUserOutboxEvents :execrows
+UPDATE outbox_events
+SET payload = jsonb_set(jsonb_set(payload, '{user_id}', to_jsonb($1::TEXT)), '{metadata}', '{}'::JSONB)
+WHERE payload->>'user_id' = $2::TEXT
+`
+
+type ScrubUserOutboxEventsParams struct {
+	TombstoneUserID string
+	UserID          string
+}
+
+func (q *Queries) ScrubUserOutboxEvents(ctx context.Context, arg ScrubUserOutboxEventsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, scrubUserOutboxEvents, arg.TombstoneUserID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateSubscription = `-- name: UpdateSubscription :one
 UPDATE subscriptions 
 SET 
     service_name = COALESCE($2, service_name),
     price = COALESCE($3, price),
     start_date = COALESCE($4, start_date),
-    end_date = COALESCE($5, end_date),
+    end_date = $5,
+    auto_renew = COALESCE($6, auto_renew),
+    metadata = COALESCE($7, metadata),
+    tags = COALESCE($8, tags),
+    billing_period = COALESCE($9, billing_period),
+    currency = COALESCE($10, currency),
     updated_at = NOW()
-WHERE id = $1
-RETURNING id, service_name, price, user_id, start_date, end_date, created_at, updated_at
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING id, service_name, price, user_id, start_date, end_date, created_at, updated_at, auto_renew, metadata, status, next_renewal_date, deleted_at, tags, billing_period, currency
 `
 
 type UpdateSubscriptionParams struct {
-	ID          pgtype.UUID
-	ServiceName string
-	Price       int32
-	StartDate   pgtype.Date
-	EndDate     pgtype.Date
+	ID            pgtype.UUID
+	ServiceName   string
+	Price         int32
+	StartDate     pgtype.Date
+	EndDate       pgtype.Date
+	AutoRenew     bool
+	Metadata      []byte
+	Tags          []string
+	BillingPeriod string
+	Currency      string
 }
 
 func (q *Queries) UpdateSubscription(ctx context.Context, arg UpdateSubscriptionParams) (Subscription, error) {
@@ -217,6 +1142,11 @@ func (q *Queries) UpdateSubscription(ctx context.Context, arg UpdateSubscription
 		arg.Price,
 		arg.StartDate,
 		arg.EndDate,
+		arg.AutoRenew,
+		arg.Metadata,
+		arg.Tags,
+		arg.BillingPeriod,
+		arg.Currency,
 	)
 	var i Subscription
 	err := row.Scan(
@@ -228,6 +1158,14 @@ func (q *Queries) UpdateSubscription(ctx context.Context, arg UpdateSubscription
 		&i.EndDate,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.AutoRenew,
+		&i.Metadata,
+		&i.Status,
+		&i.NextRenewalDate,
+		&i.DeletedAt,
+		&i.Tags,
+		&i.BillingPeriod,
+		&i.Currency,
 	)
 	return i, err
 }
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository/sqlc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+const streamBatchSize = 500
+
+const subscriptionColumns = "id, service_name, price, user_id, start_date, end_date, created_at, updated_at, auto_renew, metadata, status, next_renewal_date, deleted_at, tags, billing_period, currency"
+
+// SortOrder orders streamed rows by Column, with id as the tiebreaker so the
+// order is total and stable across runs.
+type SortOrder struct {
+	Column string
+	Desc   bool
+}
+
+// DefaultStreamSort is the order used when no sort is requested.
+var DefaultStreamSort = SortOrder{Column: "created_at"}
+
+// sortableColumns lists the non-nullable columns usable for keyset
+// pagination.
+var sortableColumns = map[string]struct{}{
+	"id":           {},
+	"created_at":   {},
+	"updated_at":   {},
+	"start_date":   {},
+	"service_name": {},
+	"price":        {},
+}
+
+func IsSortableColumn(column string) bool {
+	_, ok := sortableColumns[column]
+	return ok
+}
+
+// StreamFilter narrows StreamAll. Limit and Offset of the embedded list
+// filter are ignored; every matching row is visited.
+type StreamFilter struct {
+	ListSubscriptionsFilter
+	AutoRenew *bool
+	EndsBy    *string
+	Sort      *SortOrder
+}
+
+// StreamAll visits every subscription matching filter in a deterministic
+// order, fetching streamBatchSize rows at a time with keyset pagination so
+// memory stays bounded. No connection is held while fn runs. Iteration stops
+// at the first error from fn or when ctx is cancelled.
+func (r *subscriptionRepository) StreamAll(ctx context.Context, filter *StreamFilter, fn func(*domain.Subscription) error) error {
+	sort := DefaultStreamSort
+	if filter.Sort != nil {
+		sort = *filter.Sort
+	}
+	if !IsSortableColumn(sort.Column) {
+		return fmt.Errorf("unsupported sort column %q", sort.Column)
+	}
+
+	r.logger.Info("streaming subscriptions", zap.String("sort", sort.Column), zap.Bool("desc", sort.Desc))
+
+	where, args, err := streamPredicate(filter)
+	if err != nil {
+		return err
+	}
+
+	direction, comparison := "ASC", ">"
+	if sort.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	var last *sqlc.Subscription
+	visited := 0
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		conditions := where
+		queryArgs := args
+		if last != nil {
+			queryArgs = append(append([]interface{}{}, args...), keysetValue(last, sort.Column), last.ID)
+			conditions = append(append([]string{}, where...), fmt.Sprintf("(%s, id) %s ($%d, $%d)", sort.Column, comparison, len(queryArgs)-1, len(queryArgs)))
+		}
+
+		query := "SELECT " + subscriptionColumns + " FROM subscriptions"
+		if len(conditions) > 0 {
+			query += " WHERE " + strings.Join(conditions, " AND ")
+		}
+		query += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT %d", sort.Column, direction, direction, streamBatchSize)
+
+		subs, err := r.querySubscriptions(ctx, query, queryArgs...)
+		if err != nil {
+			r.logger.Error("failed to stream subscriptions", zap.Error(err))
+			return err
+		}
+
+		for i := range subs {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(r.convertToSubscription(&subs[i])); err != nil {
+				return err
+			}
+		}
+		visited += len(subs)
+
+		if len(subs) < streamBatchSize {
+			break
+		}
+		last = &subs[len(subs)-1]
+	}
+
+	r.logger.Info("subscriptions streamed successfully", zap.Int("count", visited))
+	return nil
+}
+
+func streamPredicate(filter *StreamFilter) ([]string, []interface{}, error) {
+	predicate, err := buildFilterPredicate(&filter.ListSubscriptionsFilter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if filter.AutoRenew != nil {
+		predicate.add("auto_renew = $%d", *filter.AutoRenew)
+	}
+	if filter.EndsBy != nil {
+		endsBy := pgtype.Date{}
+		if err := endsBy.Scan(*filter.EndsBy); err != nil {
+			return nil, nil, err
+		}
+		predicate.add("end_date <= $%d", endsBy)
+	}
+
+	return predicate.conditions, predicate.args, nil
+}
+
+func keysetValue(sub *sqlc.Subscription, column string) interface{} {
+	switch column {
+	case "created_at":
+		return sub.CreatedAt
+	case "updated_at":
+		return sub.UpdatedAt
+	case "start_date":
+		return sub.StartDate
+	case "service_name":
+		return sub.ServiceName
+	case "price":
+		return sub.Price
+	default:
+		return sub.ID
+	}
+}
+
+func (r *subscriptionRepository) querySubscriptions(ctx context.Context, query string, args ...interface{}) ([]sqlc.Subscription, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []sqlc.Subscription
+	for rows.Next() {
+		i, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func scanSubscription(row pgx.Row) (sqlc.Subscription, error) {
+	var i sqlc.Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.ServiceName,
+		&i.Price,
+		&i.UserID,
+		&i.StartDate,
+		&i.EndDate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.AutoRenew,
+		&i.Metadata,
+		&i.Status,
+		&i.NextRenewalDate,
+		&i.DeletedAt,
+		&i.Tags,
+		&i.BillingPeriod,
+		&i.Currency,
+	)
+	return i, err
+}
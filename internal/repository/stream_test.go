@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sort"
+	"testing"
+
+	"subscription-service/internal/domain"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// seedSubscriptions inserts count live subscriptions directly, each carrying
+// metadataBytes of metadata so a test can tell rows held in memory from rows
+// streamed past. All rows share one created_at, leaving the id tiebreaker to
+// order them.
+func seedSubscriptions(t *testing.T, pool *pgxpool.Pool, count, metadataBytes int) {
+	t.Helper()
+	_, err := pool.Exec(context.Background(), `
+		INSERT INTO subscriptions (service_name, price, user_id, start_date, metadata, created_at)
+		SELECT 'service-' || (g % 7), 100 + g % 50, gen_random_uuid(), DATE '2025-01-01' + (g % 28),
+		       jsonb_build_object('padding', repeat('x', $2::INT)), TIMESTAMPTZ '2025-01-01 00:00:00+00'
+		FROM generate_series(1, $1::INT) AS g`,
+		count, metadataBytes)
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+}
+
+func TestStreamAll(t *testing.T) {
+	const rows = 3 * streamBatchSize
+
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, false)
+	seedSubscriptions(t, pool, rows, 16)
+
+	tests := []struct {
+		name      string
+		filter    StreamFilter
+		wantCount int
+		less      func(a, b *domain.Subscription) bool
+	}{
+		{
+			name:      "default order visits every row once",
+			wantCount: rows,
+			less: func(a, b *domain.Subscription) bool {
+				return a.ID.String() < b.ID.String()
+			},
+		},
+		{
+			name:      "descending price with id tiebreaker",
+			filter:    StreamFilter{Sort: &SortOrder{Column: "price", Desc: true}},
+			wantCount: rows,
+			less: func(a, b *domain.Subscription) bool {
+				if a.PriceMinor != b.PriceMinor {
+					return a.PriceMinor > b.PriceMinor
+				}
+				return a.ID.String() > b.ID.String()
+			},
+		},
+		{
+			name:      "filters apply to every batch",
+			filter:    StreamFilter{ListSubscriptionsFilter: ListSubscriptionsFilter{ExactServiceName: strPtr("service-3")}},
+			wantCount: rows / 7,
+			less: func(a, b *domain.Subscription) bool {
+				return a.ID.String() < b.ID.String()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var visited []*domain.Subscription
+			err := repo.StreamAll(context.Background(), &tt.filter, func(subscription *domain.Subscription) error {
+				visited = append(visited, subscription)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("StreamAll: %v", err)
+			}
+
+			if len(visited) != tt.wantCount {
+				t.Errorf("visited %d rows, want %d", len(visited), tt.wantCount)
+			}
+			if !sort.SliceIsSorted(visited, func(i, j int) bool { return tt.less(visited[i], visited[j]) }) {
+				t.Error("rows were not visited in order")
+			}
+			seen := make(map[string]struct{}, len(visited))
+			for _, subscription := range visited {
+				if _, ok := seen[subscription.ID.String()]; ok {
+					t.Fatalf("row %s visited twice", subscription.ID)
+				}
+				seen[subscription.ID.String()] = struct{}{}
+			}
+		})
+	}
+}
+
+func TestStreamAllBoundedMemory(t *testing.T) {
+	const (
+		rows          = 4000
+		metadataBytes = 4096
+	)
+
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, false)
+	seedSubscriptions(t, pool, rows, metadataBytes)
+
+	var stats runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&stats)
+	baseline := stats.HeapAlloc
+
+	// Sample the live heap as rows go by; rows the callback has finished
+	// with are garbage, so only what StreamAll itself retains shows up.
+	var peak uint64
+	visited := 0
+	err := repo.StreamAll(context.Background(), &StreamFilter{}, func(*domain.Subscription) error {
+		visited++
+		if visited%250 == 0 {
+			runtime.GC()
+			runtime.ReadMemStats(&stats)
+			if stats.HeapAlloc > baseline && stats.HeapAlloc-baseline > peak {
+				peak = stats.HeapAlloc - baseline
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamAll: %v", err)
+	}
+
+	if visited != rows {
+		t.Errorf("visited %d rows, want %d", visited, rows)
+	}
+	// Holding every row would take rows*metadataBytes; a batch at a time
+	// stays well under half of that.
+	if limit := uint64(rows * metadataBytes / 2); peak > limit {
+		t.Errorf("live heap grew by %d bytes while streaming, want at most %d", peak, limit)
+	}
+}
+
+func TestStreamAllStopsWhenCancelled(t *testing.T) {
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, false)
+	seedSubscriptions(t, pool, 2*streamBatchSize, 16)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const stopAfter = 10
+	visited := 0
+	err := repo.StreamAll(ctx, &StreamFilter{}, func(*domain.Subscription) error {
+		visited++
+		if visited == stopAfter {
+			cancel()
+		}
+		return nil
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("StreamAll error = %v, want %v", err, context.Canceled)
+	}
+	if visited != stopAfter {
+		t.Errorf("visited %d rows after cancelling, want %d", visited, stopAfter)
+	}
+}
@@ -2,104 +2,257 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
 
 	"subscription-service/internal/domain"
 	"subscription-service/internal/repository/sqlc"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
-	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
 )
 
 type ListSubscriptionsFilter struct {
-	UserID      *uuid.UUID
-	ServiceName *string
-	Limit       int
-	Offset      int
+	UserID *uuid.UUID
+	// UserIDs matches subscriptions of any of the users.
+	UserIDs []uuid.UUID
+	// ServiceNames matches names containing any of the values,
+	// case-insensitively.
+	ServiceNames []string
+	// ExactServiceName matches the service name exactly.
+	ExactServiceName *string
+	// ServiceNamePrefix matches names starting with it, case-sensitively.
+	// LIKE metacharacters in it match literally.
+	ServiceNamePrefix *string
+	Metadata          map[string]interface{}
+	MinPrice          *int
+	MaxPrice          *int
+	ActiveFrom        *string
+	ActiveTo          *string
+	// UpdatedSince restricts the filter to rows changed after it. Deletions
+	// are only among them with IncludeDeleted.
+	UpdatedSince *time.Time
+	// UpdatedAfterID, with UpdatedSince, makes the cursor the pair
+	// (updated_at, id) so rows sharing the cursor's updated_at are split
+	// by id instead of skipped.
+	UpdatedAfterID *uuid.UUID
+	// IncludeDeleted keeps soft-deleted rows in the results.
+	IncludeDeleted bool
+	// Tag matches subscriptions carrying the tag.
+	Tag *string
+	// OpenEnded matches rows without an end date when true and rows with
+	// one when false.
+	OpenEnded *bool
+	Limit     int
+	Offset    int
+}
+
+type ServiceStats struct {
+	SubscriberCount int64
+	// MonthlyRevenue has one total per currency, in currency order. It
+	// spreads quarterly and yearly prices evenly over the months of their
+	// period.
+	MonthlyRevenue []domain.CurrencyTotal
 }
 
 type TotalCostFilter struct {
 	UserID      *uuid.UUID
 	ServiceName *string
+	// Currency selects the subscriptions to sum; amounts in different
+	// currencies are never added together.
+	Currency  string
+	StartDate string
+	EndDate   string
+}
+
+// TotalCostBreakdownFilter pages through per-service costs for the window
+// described by the embedded filter.
+type TotalCostBreakdownFilter struct {
+	TotalCostFilter
+	Order  string
+	Limit  int
+	Offset int
+}
+
+type OverlapFilter struct {
+	UserID      uuid.UUID
+	ServiceName string
 	StartDate   string
-	EndDate     string
+	EndDate     *string
+	ExcludeID   *uuid.UUID
 }
 
 type SubscriptionRepository interface {
 	Create(ctx context.Context, req *domain.CreateSubscriptionRequest) (*domain.Subscription, error)
+	Put(ctx context.Context, id uuid.UUID, req *domain.CreateSubscriptionRequest) (*domain.Subscription, bool, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)
+	GetByIDIncludingDeleted(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)
 	Update(ctx context.Context, id uuid.UUID, req *domain.UpdateSubscriptionRequest) (*domain.Subscription, error)
+	// UpdateIfUnmodified applies req only while the subscription's
+	// updated_at still equals updatedAt and returns
+	// domain.ErrSubscriptionModified otherwise.
+	UpdateIfUnmodified(ctx context.Context, id uuid.UUID, updatedAt time.Time, req *domain.UpdateSubscriptionRequest) (*domain.Subscription, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 	List(ctx context.Context, filter *ListSubscriptionsFilter) ([]*domain.Subscription, int64, error)
 	CalculateTotalCost(ctx context.Context, filter *TotalCostFilter) (int, error)
+	CalculateTotalCostByService(ctx context.Context, filter *TotalCostBreakdownFilter) ([]domain.ServiceCost, int64, error)
+	StreamAll(ctx context.Context, filter *StreamFilter, fn func(*domain.Subscription) error) error
+	Renew(ctx context.Context, id uuid.UUID, currentEndDate string) (*domain.Subscription, error)
+	FindOverlapping(ctx context.Context, filter *OverlapFilter) ([]uuid.UUID, error)
+	CountActive(ctx context.Context, filter *ListSubscriptionsFilter, asOf string) (int64, error)
+	CountByBillingPeriod(ctx context.Context, filter *ListSubscriptionsFilter) ([]domain.BillingPeriodCount, error)
+	CountActiveTrend(ctx context.Context, filter *TrendFilter) ([]domain.TrendPoint, error)
+	GetServiceStats(ctx context.Context, serviceName string, asOf string) (*ServiceStats, error)
+	GetKPIs(ctx context.Context, asOf time.Time, since time.Time) (*domain.KPISnapshot, error)
+	ListServiceNames(ctx context.Context) ([]string, error)
+	CreatePause(ctx context.Context, subscriptionID uuid.UUID, req *domain.CreatePauseRequest) (*domain.SubscriptionPause, error)
+	ListPauses(ctx context.Context, subscriptionID uuid.UUID) ([]domain.SubscriptionPause, error)
+	DeletePause(ctx context.Context, subscriptionID, pauseID uuid.UUID) error
+	ListHistory(ctx context.Context, subscriptionID uuid.UUID) ([]domain.HistoryEntry, error)
+	RecomputeDerivedFields(ctx context.Context, filter *RecomputeFilter) (*RecomputeBatch, error)
+	PurgeDeleted(ctx context.Context, cutoff time.Time, batchSize int) (int64, error)
+	BulkTag(ctx context.Context, filter *BulkTagFilter, add, remove []string) (int64, error)
+	ReassignUser(ctx context.Context, from, to uuid.UUID) (int64, error)
+	AnonymizeUser(ctx context.Context, userID, tombstone uuid.UUID) (int64, error)
 }
 
 type subscriptionRepository struct {
-	db      *pgxpool.Pool
-	queries *sqlc.Queries
-	logger  *zap.Logger
+	db                  DB
+	queries             *sqlc.Queries
+	tx                  TxConfig
+	enforceUniqueActive bool
+	logger              *zap.Logger
 }
 
-func NewSubscriptionRepository(db *pgxpool.Pool, logger *zap.Logger) SubscriptionRepository {
+// NewSubscriptionRepository builds the repository. With enforceUniqueActive
+// set, creates, updates, puts and reassignments that would leave a user with
+// two overlapping live subscriptions to one service fail with
+// domain.ErrDuplicateSubscription.
+func NewSubscriptionRepository(db DB, tx TxConfig, enforceUniqueActive bool, logger *zap.Logger) SubscriptionRepository {
 	return &subscriptionRepository{
-		db:      db,
-		queries: sqlc.New(db),
-		logger:  logger,
+		db:                  db,
+		queries:             sqlc.New(db),
+		tx:                  tx,
+		enforceUniqueActive: enforceUniqueActive,
+		logger:              logger,
 	}
 }
 
 func (r *subscriptionRepository) Create(ctx context.Context, req *domain.CreateSubscriptionRequest) (*domain.Subscription, error) {
 	r.logger.Info("creating subscription", zap.String("service_name", req.ServiceName), zap.String("user_id", req.UserID.String()))
 
+	params, err := r.createParams(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *domain.Subscription
+	err = r.withCheckedTx(ctx, func(queries *sqlc.Queries) error {
+		sub, err := queries.CreateSubscription(ctx, params)
+		if err != nil {
+			r.logger.Error("failed to create subscription", zap.Error(err))
+			return mapConstraintError(err)
+		}
+		if err := r.checkOverlap(ctx, queries, &sub); err != nil {
+			return err
+		}
+		if err := refreshDerivedFields(ctx, queries, &sub); err != nil {
+			return err
+		}
+
+		result = r.convertToSubscription(&sub)
+		return enqueueEvent(ctx, queries, domain.EventSubscriptionCreated, sub.ID, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.logger.Info("subscription created successfully", zap.String("id", result.ID.String()))
+	return result, nil
+}
+
+func (r *subscriptionRepository) createParams(req *domain.CreateSubscriptionRequest) (sqlc.CreateSubscriptionParams, error) {
 	userIDPgtype := pgtype.UUID{}
 	if err := userIDPgtype.Scan(req.UserID.String()); err != nil {
 		r.logger.Error("failed to convert user_id", zap.Error(err))
-		return nil, err
+		return sqlc.CreateSubscriptionParams{}, err
 	}
 
 	startDate := pgtype.Date{}
 	if err := startDate.Scan(req.StartDate); err != nil {
 		r.logger.Error("failed to parse start date", zap.Error(err))
-		return nil, err
+		return sqlc.CreateSubscriptionParams{}, err
 	}
 
 	endDate := pgtype.Date{}
 	if req.EndDate != nil {
 		if err := endDate.Scan(*req.EndDate); err != nil {
 			r.logger.Error("failed to parse end date", zap.Error(err))
-			return nil, err
+			return sqlc.CreateSubscriptionParams{}, err
 		}
 	}
 
-	params := sqlc.CreateSubscriptionParams{
-		ServiceName: req.ServiceName,
-		Price:       int32(req.Price),
-		UserID:      userIDPgtype,
-		StartDate:   startDate,
-		EndDate:     endDate,
+	metadata := []byte(req.Metadata)
+	if len(metadata) == 0 {
+		metadata = []byte("{}")
 	}
 
-	sub, err := r.queries.CreateSubscription(ctx, params)
-	if err != nil {
-		r.logger.Error("failed to create subscription", zap.Error(err))
-		return nil, err
+	tags := req.Tags
+	if tags == nil {
+		tags = []string{}
 	}
 
-	result := r.convertToSubscription(&sub)
-	r.logger.Info("subscription created successfully", zap.String("id", result.ID.String()))
-	return result, nil
+	billingPeriod := req.BillingPeriod
+	if billingPeriod == "" {
+		billingPeriod = domain.DefaultBillingPeriod
+	}
+
+	currency := req.Currency
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+
+	return sqlc.CreateSubscriptionParams{
+		ServiceName:   req.ServiceName,
+		Price:         int32(req.PriceMinor),
+		UserID:        userIDPgtype,
+		StartDate:     startDate,
+		EndDate:       endDate,
+		AutoRenew:     req.AutoRenew,
+		Metadata:      metadata,
+		Tags:          tags,
+		BillingPeriod: billingPeriod,
+		Currency:      currency,
+	}, nil
 }
 
 func (r *subscriptionRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
 	r.logger.Info("getting subscription by id", zap.String("id", id.String()))
+	return r.getByID(ctx, id, r.queries.GetSubscription)
+}
 
+// GetByIDIncludingDeleted is GetByID for admin reads that should also see
+// soft-deleted subscriptions.
+func (r *subscriptionRepository) GetByIDIncludingDeleted(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	r.logger.Info("getting subscription by id, including deleted", zap.String("id", id.String()))
+	return r.getByID(ctx, id, r.queries.GetSubscriptionIncludingDeleted)
+}
+
+func (r *subscriptionRepository) getByID(ctx context.Context, id uuid.UUID, get func(context.Context, pgtype.UUID) (sqlc.Subscription, error)) (*domain.Subscription, error) {
 	idPgtype := pgtype.UUID{}
 	if err := idPgtype.Scan(id.String()); err != nil {
 		return nil, err
 	}
 
-	sub, err := r.queries.GetSubscription(ctx, idPgtype)
+	sub, err := get(ctx, idPgtype)
+	if errors.Is(err, pgx.ErrNoRows) {
+		r.logger.Warn("subscription not found", zap.String("id", id.String()))
+		return nil, domain.ErrSubscriptionNotFound
+	}
 	if err != nil {
 		r.logger.Error("failed to get subscription", zap.String("id", id.String()), zap.Error(err))
 		return nil, err
@@ -111,6 +264,16 @@ func (r *subscriptionRepository) GetByID(ctx context.Context, id uuid.UUID) (*do
 }
 
 func (r *subscriptionRepository) Update(ctx context.Context, id uuid.UUID, req *domain.UpdateSubscriptionRequest) (*domain.Subscription, error) {
+	return r.update(ctx, id, nil, req)
+}
+
+func (r *subscriptionRepository) UpdateIfUnmodified(ctx context.Context, id uuid.UUID, updatedAt time.Time, req *domain.UpdateSubscriptionRequest) (*domain.Subscription, error) {
+	return r.update(ctx, id, &updatedAt, req)
+}
+
+// update applies req, first checking the stored updated_at against
+// updatedAt when it is set.
+func (r *subscriptionRepository) update(ctx context.Context, id uuid.UUID, updatedAt *time.Time, req *domain.UpdateSubscriptionRequest) (*domain.Subscription, error) {
 	r.logger.Info("updating subscription", zap.String("id", id.String()))
 
 	idPgtype := pgtype.UUID{}
@@ -118,19 +281,59 @@ func (r *subscriptionRepository) Update(ctx context.Context, id uuid.UUID, req *
 		return nil, err
 	}
 
-	current, err := r.queries.GetSubscription(ctx, idPgtype)
+	// The current row is read and locked inside the transaction, so a
+	// concurrent update either waits for this one or, under repeatable read
+	// or serializable, makes it retry instead of silently overwriting it.
+	var result *domain.Subscription
+	err := r.withCheckedTx(ctx, func(queries *sqlc.Queries) error {
+		current, err := queries.GetSubscriptionForUpdate(ctx, idPgtype)
+		if err != nil {
+			return err
+		}
+		if updatedAt != nil && !current.UpdatedAt.Time.Equal(*updatedAt) {
+			r.logger.Warn("subscription modified since it was read", zap.String("id", id.String()))
+			return domain.ErrSubscriptionModified
+		}
+
+		params, err := r.updateParams(&current, req)
+		if err != nil {
+			return err
+		}
+
+		sub, err := queries.UpdateSubscription(ctx, params)
+		if err != nil {
+			r.logger.Error("failed to update subscription", zap.String("id", id.String()), zap.Error(err))
+			return mapConstraintError(err)
+		}
+		if err := r.checkOverlap(ctx, queries, &sub); err != nil {
+			return err
+		}
+		if err := refreshDerivedFields(ctx, queries, &sub); err != nil {
+			return err
+		}
+
+		result = r.convertToSubscription(&sub)
+		return enqueueEvent(ctx, queries, domain.EventSubscriptionUpdated, sub.ID, result)
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	r.logger.Info("subscription updated successfully", zap.String("id", id.String()))
+	return result, nil
+}
+
+// updateParams merges req into current, keeping the stored value of every
+// field the request leaves out.
+func (r *subscriptionRepository) updateParams(current *sqlc.Subscription, req *domain.UpdateSubscriptionRequest) (sqlc.UpdateSubscriptionParams, error) {
 	serviceName := current.ServiceName
 	if req.ServiceName != nil {
 		serviceName = *req.ServiceName
 	}
 
 	price := current.Price
-	if req.Price != nil {
-		price = int32(*req.Price)
+	if req.PriceMinor != nil {
+		price = int32(*req.PriceMinor)
 	}
 
 	startDate := current.StartDate
@@ -138,7 +341,7 @@ func (r *subscriptionRepository) Update(ctx context.Context, id uuid.UUID, req *
 		newStartDate := pgtype.Date{}
 		if err := newStartDate.Scan(*req.StartDate); err != nil {
 			r.logger.Error("failed to parse start date", zap.Error(err))
-			return nil, err
+			return sqlc.UpdateSubscriptionParams{}, err
 		}
 		startDate = newStartDate
 	}
@@ -148,28 +351,46 @@ func (r *subscriptionRepository) Update(ctx context.Context, id uuid.UUID, req *
 		newEndDate := pgtype.Date{}
 		if err := newEndDate.Scan(*req.EndDate); err != nil {
 			r.logger.Error("failed to parse end date", zap.Error(err))
-			return nil, err
+			return sqlc.UpdateSubscriptionParams{}, err
 		}
 		endDate = newEndDate
 	}
+	if req.ClearEndDate {
+		endDate = pgtype.Date{}
+	}
 
-	params := sqlc.UpdateSubscriptionParams{
-		ID:          idPgtype,
-		ServiceName: serviceName,
-		Price:       price,
-		StartDate:   startDate,
-		EndDate:     endDate,
+	autoRenew := current.AutoRenew
+	if req.AutoRenew != nil {
+		autoRenew = *req.AutoRenew
 	}
 
-	sub, err := r.queries.UpdateSubscription(ctx, params)
-	if err != nil {
-		r.logger.Error("failed to update subscription", zap.String("id", id.String()), zap.Error(err))
-		return nil, err
+	metadata := current.Metadata
+	if len(req.Metadata) > 0 {
+		metadata = req.Metadata
 	}
 
-	result := r.convertToSubscription(&sub)
-	r.logger.Info("subscription updated successfully", zap.String("id", id.String()))
-	return result, nil
+	billingPeriod := current.BillingPeriod
+	if req.BillingPeriod != nil {
+		billingPeriod = *req.BillingPeriod
+	}
+
+	currency := current.Currency
+	if req.Currency != nil {
+		currency = *req.Currency
+	}
+
+	return sqlc.UpdateSubscriptionParams{
+		ID:            current.ID,
+		ServiceName:   serviceName,
+		Price:         price,
+		StartDate:     startDate,
+		EndDate:       endDate,
+		AutoRenew:     autoRenew,
+		Metadata:      metadata,
+		Tags:          req.Tags,
+		BillingPeriod: billingPeriod,
+		Currency:      currency,
+	}, nil
 }
 
 func (r *subscriptionRepository) Delete(ctx context.Context, id uuid.UUID) error {
@@ -180,17 +401,24 @@ func (r *subscriptionRepository) Delete(ctx context.Context, id uuid.UUID) error
 		return err
 	}
 
-	rowsAffected, err := r.queries.DeleteSubscription(ctx, idPgtype)
+	err := r.withTx(ctx, func(queries *sqlc.Queries) error {
+		rowsAffected, err := queries.DeleteSubscription(ctx, idPgtype)
+		if err != nil {
+			r.logger.Error("failed to delete subscription", zap.String("id", id.String()), zap.Error(err))
+			return err
+		}
+
+		if rowsAffected == 0 {
+			r.logger.Warn("subscription not found for deletion", zap.String("id", id.String()))
+			return nil
+		}
+
+		return enqueueEvent(ctx, queries, domain.EventSubscriptionDeleted, idPgtype, map[string]string{"id": id.String()})
+	})
 	if err != nil {
-		r.logger.Error("failed to delete subscription", zap.String("id", id.String()), zap.Error(err))
 		return err
 	}
 
-	if rowsAffected == 0 {
-		r.logger.Warn("subscription not found for deletion", zap.String("id", id.String()))
-		return nil
-	}
-
 	r.logger.Info("subscription deleted successfully", zap.String("id", id.String()))
 	return nil
 }
@@ -201,39 +429,31 @@ func (r *subscriptionRepository) List(ctx context.Context, filter *ListSubscript
 		zap.Int("offset", filter.Offset),
 	)
 
-	var userID pgtype.UUID
-	if filter.UserID != nil {
-		if err := userID.Scan(filter.UserID.String()); err != nil {
-			return nil, 0, err
-		}
-	}
-
-	serviceName := ""
-	if filter.ServiceName != nil {
-		serviceName = *filter.ServiceName
-	}
-
-	listParams := sqlc.ListSubscriptionsParams{
-		UserID:      userID,
-		ServiceName: pgtype.Text{String: serviceName, Valid: serviceName != ""},
-		Limit:       int32(filter.Limit),
-		Offset:      int32(filter.Offset),
+	predicate, err := buildFilterPredicate(filter)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	subs, err := r.queries.ListSubscriptions(ctx, listParams)
-	if err != nil {
-		r.logger.Error("failed to list subscriptions", zap.Error(err))
+	var count int64
+	if err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM subscriptions"+predicate.where(), predicate.args...).Scan(&count); err != nil {
+		r.logger.Error("failed to count subscriptions", zap.Error(err))
 		return nil, 0, err
 	}
 
-	countParams := sqlc.CountSubscriptionsParams{
-		UserID:      userID,
-		ServiceName: pgtype.Text{String: serviceName, Valid: serviceName != ""},
+	// Delta pulls page in (updated_at, id) order so the last row seen is a
+	// safe cursor for the next pull.
+	order := "created_at DESC"
+	if filter.UpdatedSince != nil {
+		order = "updated_at, id"
 	}
 
-	count, err := r.queries.CountSubscriptions(ctx, countParams)
+	args := append(append([]interface{}{}, predicate.args...), int32(filter.Limit), int32(filter.Offset))
+	query := "SELECT " + subscriptionColumns + " FROM subscriptions" + predicate.where() +
+		fmt.Sprintf(" ORDER BY %s LIMIT $%d OFFSET $%d", order, len(args)-1, len(args))
+
+	subs, err := r.querySubscriptions(ctx, query, args...)
 	if err != nil {
-		r.logger.Error("failed to count subscriptions", zap.Error(err))
+		r.logger.Error("failed to list subscriptions", zap.Error(err))
 		return nil, 0, err
 	}
 
@@ -246,6 +466,166 @@ func (r *subscriptionRepository) List(ctx context.Context, filter *ListSubscript
 	return result, count, nil
 }
 
+func (r *subscriptionRepository) CountActive(ctx context.Context, filter *ListSubscriptionsFilter, asOf string) (int64, error) {
+	r.logger.Info("counting active subscriptions", zap.String("as_of", asOf))
+
+	asOfDate := pgtype.Date{}
+	if err := asOfDate.Scan(asOf); err != nil {
+		r.logger.Error("failed to parse as of date", zap.Error(err))
+		return 0, err
+	}
+
+	predicate, err := buildFilterPredicate(filter)
+	if err != nil {
+		return 0, err
+	}
+	predicate.addActiveAsOf(asOfDate)
+
+	var count int64
+	if err := r.db.QueryRow(ctx, "SELECT COUNT(*) FROM subscriptions"+predicate.where(), predicate.args...).Scan(&count); err != nil {
+		r.logger.Error("failed to count active subscriptions", zap.Error(err))
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// CountByBillingPeriod groups the subscriptions matching filter by billing
+// period, totalling their prices per currency. Limit and Offset are ignored.
+func (r *subscriptionRepository) CountByBillingPeriod(ctx context.Context, filter *ListSubscriptionsFilter) ([]domain.BillingPeriodCount, error) {
+	r.logger.Info("counting subscriptions by billing period")
+
+	predicate, err := buildFilterPredicate(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Blank periods are not expected, but are treated like the default that
+	// legacy rows were given.
+	predicate.args = append(predicate.args, domain.DefaultBillingPeriod)
+	query := fmt.Sprintf(`SELECT COALESCE(NULLIF(billing_period, ''), $%d) AS period, currency, COUNT(*), SUM(price)
+FROM subscriptions%s
+GROUP BY period, currency
+ORDER BY period, currency`, len(predicate.args), predicate.where())
+
+	rows, err := r.db.Query(ctx, query, predicate.args...)
+	if err != nil {
+		r.logger.Error("failed to count subscriptions by billing period", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	periods := []domain.BillingPeriodCount{}
+	for rows.Next() {
+		var period, currency string
+		var count, total int64
+		if err := rows.Scan(&period, &currency, &count, &total); err != nil {
+			return nil, err
+		}
+		if len(periods) == 0 || periods[len(periods)-1].Period != period {
+			periods = append(periods, domain.BillingPeriodCount{Period: period, Totals: []domain.CurrencyTotal{}})
+		}
+		last := &periods[len(periods)-1]
+		last.Count += count
+		last.Totals = append(last.Totals, domain.NewCurrencyTotal(currency, total))
+		if currency == domain.DefaultCurrency {
+			last.TotalCost = domain.WholeUnits(total, currency)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return periods, nil
+}
+
+func (r *subscriptionRepository) GetServiceStats(ctx context.Context, serviceName string, asOf string) (*ServiceStats, error) {
+	r.logger.Info("getting service stats", zap.String("service_name", serviceName), zap.String("as_of", asOf))
+
+	asOfDate := pgtype.Date{}
+	if err := asOfDate.Scan(asOf); err != nil {
+		r.logger.Error("failed to parse as of date", zap.Error(err))
+		return nil, err
+	}
+
+	subscribers, err := r.queries.GetServiceStats(ctx, sqlc.GetServiceStatsParams{
+		ServiceName: serviceName,
+		AsOf:        asOfDate,
+	})
+	if err != nil {
+		r.logger.Error("failed to get service stats", zap.Error(err))
+		return nil, err
+	}
+
+	rows, err := r.queries.GetServiceRevenue(ctx, sqlc.GetServiceRevenueParams{
+		ServiceName: serviceName,
+		AsOf:        asOfDate,
+	})
+	if err != nil {
+		r.logger.Error("failed to get service revenue", zap.Error(err))
+		return nil, err
+	}
+
+	revenue := make([]domain.CurrencyTotal, len(rows))
+	for i, row := range rows {
+		revenue[i] = domain.NewCurrencyTotal(row.Currency, row.MonthlyRevenue)
+	}
+	return &ServiceStats{
+		SubscriberCount: subscribers,
+		MonthlyRevenue:  revenue,
+	}, nil
+}
+
+// GetKPIs computes the business metrics as of asOf, counting new and churned
+// subscriptions from since. Yearly and quarterly prices are spread over
+// their months for the recurring revenue, which is summed per currency.
+func (r *subscriptionRepository) GetKPIs(ctx context.Context, asOf time.Time, since time.Time) (*domain.KPISnapshot, error) {
+	r.logger.Debug("getting subscription kpis", zap.Time("as_of", asOf), zap.Time("since", since))
+
+	asOfDate := pgtype.Date{Time: asOf, Valid: true}
+	row, err := r.queries.GetSubscriptionKPIs(ctx, sqlc.GetSubscriptionKPIsParams{
+		AsOf:  asOfDate,
+		Since: pgtype.Timestamptz{Time: since, Valid: true},
+	})
+	if err != nil {
+		r.logger.Error("failed to get subscription kpis", zap.Error(err))
+		return nil, err
+	}
+
+	revenueRows, err := r.queries.GetMonthlyRecurringRevenue(ctx, asOfDate)
+	if err != nil {
+		r.logger.Error("failed to get monthly recurring revenue", zap.Error(err))
+		return nil, err
+	}
+	revenue := make(map[string]int64, len(revenueRows))
+	for _, revenueRow := range revenueRows {
+		revenue[revenueRow.Currency] = revenueRow.MonthlyRecurringRevenue
+	}
+
+	return &domain.KPISnapshot{
+		AsOf:                    asOf,
+		ActiveSubscriptions:     row.ActiveCount,
+		MonthlyRecurringRevenue: revenue,
+		New:                     row.NewCount,
+		Churned:                 row.ChurnedCount,
+	}, nil
+}
+
+func (r *subscriptionRepository) ListServiceNames(ctx context.Context) ([]string, error) {
+	r.logger.Info("listing service names")
+
+	names, err := r.queries.ListServiceNames(ctx)
+	if err != nil {
+		r.logger.Error("failed to list service names", zap.Error(err))
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// CalculateTotalCost sums the cost of every month in the window that the
+// matching subscriptions are active and not paused in. A quarterly or yearly
+// price is spread evenly over the months of its period, and the total is
+// rounded to the nearest minor unit of filter.Currency.
 func (r *subscriptionRepository) CalculateTotalCost(ctx context.Context, filter *TotalCostFilter) (int, error) {
 	r.logger.Info("calculating total cost",
 		zap.String("start_date", filter.StartDate),
@@ -279,6 +659,7 @@ func (r *subscriptionRepository) CalculateTotalCost(ctx context.Context, filter
 	params := sqlc.CalculateTotalCostParams{
 		UserID:      userID,
 		ServiceName: pgtype.Text{String: serviceName, Valid: serviceName != ""},
+		Currency:    filter.Currency,
 		StartDate:   startDate,
 		EndDate:     endDate,
 	}
@@ -294,6 +675,286 @@ func (r *subscriptionRepository) CalculateTotalCost(ctx context.Context, filter
 	return result, nil
 }
 
+func (r *subscriptionRepository) CalculateTotalCostByService(ctx context.Context, filter *TotalCostBreakdownFilter) ([]domain.ServiceCost, int64, error) {
+	r.logger.Info("calculating total cost by service",
+		zap.String("start_date", filter.StartDate),
+		zap.String("end_date", filter.EndDate),
+		zap.String("order", filter.Order),
+		zap.Int("limit", filter.Limit),
+		zap.Int("offset", filter.Offset),
+	)
+
+	var userID pgtype.UUID
+	if filter.UserID != nil {
+		userID = pgtype.UUID{Bytes: *filter.UserID, Valid: true}
+	}
+
+	serviceName := ""
+	if filter.ServiceName != nil {
+		serviceName = *filter.ServiceName
+	}
+
+	startDate := pgtype.Date{}
+	if err := startDate.Scan(filter.StartDate); err != nil {
+		r.logger.Error("failed to parse start date", zap.Error(err))
+		return nil, 0, err
+	}
+
+	endDate := pgtype.Date{}
+	if err := endDate.Scan(filter.EndDate); err != nil {
+		r.logger.Error("failed to parse end date", zap.Error(err))
+		return nil, 0, err
+	}
+
+	rows, err := r.queries.CalculateTotalCostByService(ctx, sqlc.CalculateTotalCostByServiceParams{
+		StartDate:   startDate,
+		EndDate:     endDate,
+		UserID:      userID,
+		ServiceName: pgtype.Text{String: serviceName, Valid: serviceName != ""},
+		Currency:    filter.Currency,
+		SortOrder:   filter.Order,
+		Offset:      int32(filter.Offset),
+		Limit:       int32(filter.Limit),
+	})
+	if err != nil {
+		r.logger.Error("failed to calculate total cost by service", zap.Error(err))
+		return nil, 0, err
+	}
+
+	// The group count rides along on every row, so a page past the last
+	// group reports zero groups.
+	var groups int64
+	costs := make([]domain.ServiceCost, len(rows))
+	for i, row := range rows {
+		costs[i] = domain.ServiceCost{
+			ServiceName:    row.ServiceName,
+			TotalCost:      domain.WholeUnits(row.TotalCost, filter.Currency),
+			TotalCostMinor: row.TotalCost,
+			Amount:         domain.FormatAmount(row.TotalCost, filter.Currency),
+		}
+		groups = row.GroupCount
+	}
+
+	return costs, groups, nil
+}
+
+// Renew extends the subscription's end date by one billing period (a month,
+// three months or a year) and records a history entry in the same
+// transaction. The update is conditional on the end date still matching
+// currentEndDate, so a renewal that was already applied returns (nil, nil)
+// instead of extending twice. A renewal whose extended term would overlap
+// another live subscription of the same user to the same service fails with
+// domain.ErrDuplicateSubscription and writes nothing.
+func (r *subscriptionRepository) Renew(ctx context.Context, id uuid.UUID, currentEndDate string) (*domain.Subscription, error) {
+	r.logger.Info("renewing subscription", zap.String("id", id.String()), zap.String("end_date", currentEndDate))
+
+	idPgtype := pgtype.UUID{}
+	if err := idPgtype.Scan(id.String()); err != nil {
+		return nil, err
+	}
+
+	endDate := pgtype.Date{}
+	if err := endDate.Scan(currentEndDate); err != nil {
+		r.logger.Error("failed to parse end date", zap.Error(err))
+		return nil, err
+	}
+
+	var result *domain.Subscription
+	err := r.withCheckedTx(ctx, func(queries *sqlc.Queries) error {
+		result = nil
+
+		sub, err := queries.RenewSubscription(ctx, sqlc.RenewSubscriptionParams{
+			ID:             idPgtype,
+			CurrentEndDate: endDate,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			r.logger.Error("failed to renew subscription", zap.String("id", id.String()), zap.Error(err))
+			return err
+		}
+		if err := r.checkOverlap(ctx, queries, &sub); err != nil {
+			return err
+		}
+		if err := refreshDerivedFields(ctx, queries, &sub); err != nil {
+			r.logger.Error("failed to refresh derived fields", zap.String("id", id.String()), zap.Error(err))
+			return err
+		}
+
+		renewed := r.convertToSubscription(&sub)
+
+		details, err := json.Marshal(map[string]interface{}{
+			"previous_end_date": currentEndDate,
+			"end_date":          renewed.EndDate,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := queries.CreateHistoryEntry(ctx, sqlc.CreateHistoryEntryParams{
+			SubscriptionID: idPgtype,
+			Action:         domain.HistoryActionRenewed,
+			Details:        details,
+		}); err != nil {
+			r.logger.Error("failed to record renewal history", zap.String("id", id.String()), zap.Error(err))
+			return err
+		}
+
+		if err := enqueueEvent(ctx, queries, domain.EventSubscriptionRenewed, idPgtype, renewed); err != nil {
+			r.logger.Error("failed to enqueue renewal event", zap.String("id", id.String()), zap.Error(err))
+			return err
+		}
+
+		result = renewed
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		r.logger.Info("subscription already renewed", zap.String("id", id.String()))
+		return nil, nil
+	}
+
+	r.logger.Info("subscription renewed successfully", zap.String("id", id.String()))
+	return result, nil
+}
+
+func (r *subscriptionRepository) FindOverlapping(ctx context.Context, filter *OverlapFilter) ([]uuid.UUID, error) {
+	r.logger.Info("finding overlapping subscriptions",
+		zap.String("user_id", filter.UserID.String()),
+		zap.String("service_name", filter.ServiceName),
+	)
+
+	userID := pgtype.UUID{}
+	if err := userID.Scan(filter.UserID.String()); err != nil {
+		return nil, err
+	}
+
+	var excludeID pgtype.UUID
+	if filter.ExcludeID != nil {
+		if err := excludeID.Scan(filter.ExcludeID.String()); err != nil {
+			return nil, err
+		}
+	}
+
+	startDate := pgtype.Date{}
+	if err := startDate.Scan(filter.StartDate); err != nil {
+		r.logger.Error("failed to parse start date", zap.Error(err))
+		return nil, err
+	}
+
+	endDate := pgtype.Date{}
+	if filter.EndDate != nil {
+		if err := endDate.Scan(*filter.EndDate); err != nil {
+			r.logger.Error("failed to parse end date", zap.Error(err))
+			return nil, err
+		}
+	}
+
+	ids, err := r.queries.ListOverlappingSubscriptionIDs(ctx, sqlc.ListOverlappingSubscriptionIDsParams{
+		UserID:      userID,
+		ServiceName: filter.ServiceName,
+		ExcludeID:   excludeID,
+		EndDate:     endDate,
+		StartDate:   startDate,
+	})
+	if err != nil {
+		r.logger.Error("failed to find overlapping subscriptions", zap.Error(err))
+		return nil, err
+	}
+
+	result := make([]uuid.UUID, len(ids))
+	for i, id := range ids {
+		result[i] = uuid.UUID(id.Bytes)
+	}
+
+	return result, nil
+}
+
+func (r *subscriptionRepository) CreatePause(ctx context.Context, subscriptionID uuid.UUID, req *domain.CreatePauseRequest) (*domain.SubscriptionPause, error) {
+	r.logger.Info("creating pause",
+		zap.String("subscription_id", subscriptionID.String()),
+		zap.String("pause_start", req.PauseStart),
+		zap.String("pause_end", req.PauseEnd),
+	)
+
+	pauseStart := pgtype.Date{}
+	if err := pauseStart.Scan(req.PauseStart); err != nil {
+		r.logger.Error("failed to parse pause start", zap.Error(err))
+		return nil, err
+	}
+
+	pauseEnd := pgtype.Date{}
+	if err := pauseEnd.Scan(req.PauseEnd); err != nil {
+		r.logger.Error("failed to parse pause end", zap.Error(err))
+		return nil, err
+	}
+
+	pause, err := r.queries.CreatePause(ctx, sqlc.CreatePauseParams{
+		SubscriptionID: pgtype.UUID{Bytes: subscriptionID, Valid: true},
+		PauseStart:     pauseStart,
+		PauseEnd:       pauseEnd,
+	})
+	if err != nil {
+		r.logger.Error("failed to create pause", zap.String("subscription_id", subscriptionID.String()), zap.Error(err))
+		return nil, err
+	}
+
+	result := convertToPause(&pause)
+
+	r.logger.Info("pause created successfully", zap.String("id", result.ID.String()))
+	return result, nil
+}
+
+func (r *subscriptionRepository) ListPauses(ctx context.Context, subscriptionID uuid.UUID) ([]domain.SubscriptionPause, error) {
+	pauses, err := r.queries.ListPauses(ctx, pgtype.UUID{Bytes: subscriptionID, Valid: true})
+	if err != nil {
+		r.logger.Error("failed to list pauses", zap.String("subscription_id", subscriptionID.String()), zap.Error(err))
+		return nil, err
+	}
+
+	result := make([]domain.SubscriptionPause, len(pauses))
+	for i := range pauses {
+		result[i] = *convertToPause(&pauses[i])
+	}
+	return result, nil
+}
+
+func (r *subscriptionRepository) DeletePause(ctx context.Context, subscriptionID, pauseID uuid.UUID) error {
+	r.logger.Info("deleting pause", zap.String("subscription_id", subscriptionID.String()), zap.String("id", pauseID.String()))
+
+	rowsAffected, err := r.queries.DeletePause(ctx, sqlc.DeletePauseParams{
+		ID:             pgtype.UUID{Bytes: pauseID, Valid: true},
+		SubscriptionID: pgtype.UUID{Bytes: subscriptionID, Valid: true},
+	})
+	if err != nil {
+		r.logger.Error("failed to delete pause", zap.String("id", pauseID.String()), zap.Error(err))
+		return err
+	}
+
+	if rowsAffected == 0 {
+		r.logger.Warn("pause not found for deletion", zap.String("id", pauseID.String()))
+		return domain.ErrPauseNotFound
+	}
+
+	return nil
+}
+
+func convertToPause(pause *sqlc.SubscriptionPause) *domain.SubscriptionPause {
+	result := &domain.SubscriptionPause{
+		ID:             uuid.UUID(pause.ID.Bytes),
+		SubscriptionID: uuid.UUID(pause.SubscriptionID.Bytes),
+		PauseStart:     pause.PauseStart.Time.Format("2006-01-02"),
+		PauseEnd:       pause.PauseEnd.Time.Format("2006-01-02"),
+	}
+	if pause.CreatedAt.Valid {
+		result.CreatedAt = pause.CreatedAt.Time.UTC()
+	}
+	return result
+}
+
 func (r *subscriptionRepository) convertToSubscription(sub *sqlc.Subscription) *domain.Subscription {
 	userID := uuid.UUID{}
 	if sub.UserID.Valid {
@@ -311,11 +972,21 @@ func (r *subscriptionRepository) convertToSubscription(sub *sqlc.Subscription) *
 	}
 
 	result := &domain.Subscription{
-		ID:          id,
-		ServiceName: sub.ServiceName,
-		Price:       int(sub.Price),
-		UserID:      userID,
-		StartDate:   startDateStr,
+		ID:            id,
+		ServiceName:   sub.ServiceName,
+		Price:         int(domain.WholeUnits(int64(sub.Price), sub.Currency)),
+		PriceMinor:    int(sub.Price),
+		UserID:        userID,
+		StartDate:     startDateStr,
+		AutoRenew:     sub.AutoRenew,
+		Status:        sub.Status,
+		Tags:          sub.Tags,
+		BillingPeriod: sub.BillingPeriod,
+		Currency:      sub.Currency,
+		Amount:        domain.FormatAmount(int64(sub.Price), sub.Currency),
+	}
+	if result.Tags == nil {
+		result.Tags = []string{}
 	}
 
 	if sub.EndDate.Valid {
@@ -323,13 +994,40 @@ func (r *subscriptionRepository) convertToSubscription(sub *sqlc.Subscription) *
 		result.EndDate = &endDateStr
 	}
 
+	if sub.NextRenewalDate.Valid {
+		nextRenewalStr := sub.NextRenewalDate.Time.Format("2006-01-02")
+		result.NextRenewalDate = &nextRenewalStr
+	}
+
+	if len(sub.Metadata) > 0 {
+		if err := json.Unmarshal(sub.Metadata, &result.Metadata); err != nil {
+			r.logger.Warn("failed to decode subscription metadata", zap.String("id", id.String()), zap.Error(err))
+		}
+	}
+
+	// pgx returns timestamptz values in the server's local zone; responses
+	// always carry UTC so they read the same whatever TZ the service runs in.
 	if sub.CreatedAt.Valid {
-		result.CreatedAt = sub.CreatedAt.Time
+		result.CreatedAt = sub.CreatedAt.Time.UTC()
 	}
 
 	if sub.UpdatedAt.Valid {
-		result.UpdatedAt = sub.UpdatedAt.Time
+		result.UpdatedAt = sub.UpdatedAt.Time.UTC()
+	}
+
+	if sub.DeletedAt.Valid {
+		deletedAt := sub.DeletedAt.Time.UTC()
+		result.DeletedAt = &deletedAt
 	}
 
 	return result
 }
+
+// metadataFilter encodes metadata filters as a JSONB containment document,
+// or nil when no metadata filter is set.
+func metadataFilter(metadata map[string]interface{}) ([]byte, error) {
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(metadata)
+}
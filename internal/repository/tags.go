@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+// BulkTagFilter selects the subscriptions BulkTag changes. Soft-deleted rows
+// are never touched.
+type BulkTagFilter struct {
+	IDs              []uuid.UUID
+	UserID           *uuid.UUID
+	ExactServiceName *string
+	Tag              *string
+}
+
+// BulkTag adds and removes tags on every matching subscription in a single
+// statement, so either all of them change or none do. Rows whose tags would
+// not change are skipped and not counted. Each changed row gets an
+// updated event, written in the same transaction.
+func (r *subscriptionRepository) BulkTag(ctx context.Context, filter *BulkTagFilter, add, remove []string) (int64, error) {
+	r.logger.Info("bulk tagging subscriptions", zap.Strings("add", add), zap.Strings("remove", remove))
+
+	if add == nil {
+		add = []string{}
+	}
+	if remove == nil {
+		remove = []string{}
+	}
+
+	predicate, err := buildFilterPredicate(&ListSubscriptionsFilter{
+		UserID:           filter.UserID,
+		ExactServiceName: filter.ExactServiceName,
+		Tag:              filter.Tag,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(filter.IDs) > 0 {
+		ids := make([]pgtype.UUID, len(filter.IDs))
+		for i, id := range filter.IDs {
+			ids[i] = pgtype.UUID{Bytes: id, Valid: true}
+		}
+		predicate.add("id = ANY($%d::UUID[])", ids)
+	}
+
+	predicate.args = append(predicate.args, add, remove)
+	addArg, removeArg := len(predicate.args)-1, len(predicate.args)
+	predicate.conditions = append(predicate.conditions,
+		fmt.Sprintf("(NOT (tags @> $%d::TEXT[]) OR tags && $%d::TEXT[])", addArg, removeArg))
+
+	query := fmt.Sprintf(`UPDATE subscriptions
+SET tags = ARRAY(
+        SELECT DISTINCT t FROM unnest(tags || $%d::TEXT[]) AS t
+        WHERE NOT (t = ANY($%d::TEXT[]))
+        ORDER BY t
+    ),
+    updated_at = NOW()%s
+RETURNING %s`, addArg, removeArg, predicate.where(), subscriptionColumns)
+
+	var affected int64
+	err = r.withRawTx(ctx, func(tx pgx.Tx, queries *sqlc.Queries) error {
+		affected = 0
+
+		rows, err := tx.Query(ctx, query, predicate.args...)
+		if err != nil {
+			r.logger.Error("failed to bulk tag subscriptions", zap.Error(err))
+			return err
+		}
+		var changed []sqlc.Subscription
+		for rows.Next() {
+			sub, err := scanSubscription(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			changed = append(changed, sub)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for i := range changed {
+			if err := enqueueEvent(ctx, queries, domain.EventSubscriptionUpdated, changed[i].ID, r.convertToSubscription(&changed[i])); err != nil {
+				return err
+			}
+		}
+		affected = int64(len(changed))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	r.logger.Info("subscriptions tagged successfully", zap.Int64("affected", affected))
+	return affected, nil
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestBulkTagIdempotent(t *testing.T) {
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, false)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	ids := make(map[string]uuid.UUID)
+	for service, tags := range map[string][]string{
+		"Netflix": {"video"},
+		"Spotify": {"music", "video"},
+		"GitHub":  nil,
+	} {
+		sub, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{
+			ServiceName: service,
+			PriceMinor:  400,
+			UserID:      userID,
+			StartDate:   "2025-01-01",
+			Tags:        tags,
+		})
+		if err != nil {
+			t.Fatalf("create %s: %v", service, err)
+		}
+		ids[service] = sub.ID
+	}
+
+	tagsOf := func() map[string][]string {
+		t.Helper()
+		got := make(map[string][]string)
+		for service, id := range ids {
+			sub, err := repo.GetByID(ctx, id)
+			if err != nil {
+				t.Fatalf("get %s: %v", service, err)
+			}
+			got[service] = append([]string{}, sub.Tags...)
+		}
+		return got
+	}
+	updatedEvents := func() int {
+		t.Helper()
+		var n int
+		if err := pool.QueryRow(ctx,
+			"SELECT COUNT(*) FROM outbox_events WHERE event_type = $1 AND payload->>'user_id' = $2",
+			domain.EventSubscriptionUpdated, userID.String(),
+		).Scan(&n); err != nil {
+			t.Fatalf("count events: %v", err)
+		}
+		return n
+	}
+
+	tests := []struct {
+		name   string
+		add    []string
+		remove []string
+		want   int64
+		tags   map[string][]string
+	}{
+		{
+			name: "add skips rows that already have the tag",
+			add:  []string{"video"},
+			want: 1,
+			tags: map[string][]string{"Netflix": {"video"}, "Spotify": {"music", "video"}, "GitHub": {"video"}},
+		},
+		{
+			name:   "remove skips rows without the tag",
+			remove: []string{"music"},
+			want:   1,
+			tags:   map[string][]string{"Netflix": {"video"}, "Spotify": {"video"}, "GitHub": {"video"}},
+		},
+		{
+			name:   "add and remove together",
+			add:    []string{"work"},
+			remove: []string{"video"},
+			want:   3,
+			tags:   map[string][]string{"Netflix": {"work"}, "Spotify": {"work"}, "GitHub": {"work"}},
+		},
+	}
+
+	filter := &BulkTagFilter{UserID: &userID}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := updatedEvents()
+			affected, err := repo.BulkTag(ctx, filter, tt.add, tt.remove)
+			if err != nil {
+				t.Fatalf("first BulkTag: %v", err)
+			}
+			if affected != tt.want {
+				t.Errorf("first affected = %d, want %d", affected, tt.want)
+			}
+			if got := updatedEvents() - before; got != int(tt.want) {
+				t.Errorf("first call wrote %d updated events, want %d", got, tt.want)
+			}
+			if got := tagsOf(); !reflect.DeepEqual(got, tt.tags) {
+				t.Errorf("tags after first call = %v, want %v", got, tt.tags)
+			}
+
+			// Repeating the same request changes nothing and emits nothing.
+			before = updatedEvents()
+			affected, err = repo.BulkTag(ctx, filter, tt.add, tt.remove)
+			if err != nil {
+				t.Fatalf("second BulkTag: %v", err)
+			}
+			if affected != 0 {
+				t.Errorf("second affected = %d, want 0", affected)
+			}
+			if got := updatedEvents() - before; got != 0 {
+				t.Errorf("second call wrote %d updated events, want 0", got)
+			}
+			if got := tagsOf(); !reflect.DeepEqual(got, tt.tags) {
+				t.Errorf("tags after second call = %v, want %v", got, tt.tags)
+			}
+		})
+	}
+}
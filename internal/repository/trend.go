@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"subscription-service/internal/domain"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+)
+
+// TrendFilter describes the intervals CountActiveTrend reports on and the
+// subscriptions it counts. Limit and Offset of the embedded filter are
+// ignored.
+type TrendFilter struct {
+	ListSubscriptionsFilter
+	Start string
+	End   string
+	// Granularity is one of the domain.TrendGranularity values.
+	Granularity string
+}
+
+var trendIntervals = map[string]string{
+	domain.TrendGranularityDay:   "1 day",
+	domain.TrendGranularityWeek:  "1 week",
+	domain.TrendGranularityMonth: "1 month",
+}
+
+// CountActiveTrend counts, for every interval between Start and End, the
+// matching subscriptions active on the interval's last day, or on End for
+// the final interval. Intervals are aligned to the calendar, so the first
+// one may begin before Start. Every interval is reported, with zero when
+// nothing was active.
+func (r *subscriptionRepository) CountActiveTrend(ctx context.Context, filter *TrendFilter) ([]domain.TrendPoint, error) {
+	r.logger.Info("counting subscription trend", zap.String("start", filter.Start), zap.String("end", filter.End), zap.String("granularity", filter.Granularity))
+
+	interval, ok := trendIntervals[filter.Granularity]
+	if !ok {
+		return nil, fmt.Errorf("unsupported trend granularity %q", filter.Granularity)
+	}
+
+	start, end := pgtype.Date{}, pgtype.Date{}
+	if err := start.Scan(filter.Start); err != nil {
+		return nil, err
+	}
+	if err := end.Scan(filter.End); err != nil {
+		return nil, err
+	}
+
+	predicate, err := buildFilterPredicate(&filter.ListSubscriptionsFilter)
+	if err != nil {
+		return nil, err
+	}
+	predicate.args = append(predicate.args, start, end)
+	startArg, endArg := len(predicate.args)-1, len(predicate.args)
+
+	query := fmt.Sprintf(`WITH matching AS (
+    SELECT start_date, end_date FROM subscriptions%s
+),
+intervals AS (
+    SELECT bucket::DATE AS interval_start,
+           LEAST(bucket + INTERVAL '%s' - INTERVAL '1 day', $%d::DATE)::DATE AS as_of
+    FROM generate_series(date_trunc('%s', $%d::DATE::TIMESTAMP), $%d::DATE::TIMESTAMP, INTERVAL '%s') AS bucket
+)
+SELECT i.interval_start, COUNT(m.start_date)
+FROM intervals i
+LEFT JOIN matching m ON m.start_date <= i.as_of AND (m.end_date IS NULL OR m.end_date >= i.as_of)
+GROUP BY i.interval_start
+ORDER BY i.interval_start`, predicate.where(), interval, endArg, filter.Granularity, startArg, endArg, interval)
+
+	rows, err := r.db.Query(ctx, query, predicate.args...)
+	if err != nil {
+		r.logger.Error("failed to count subscription trend", zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []domain.TrendPoint{}
+	for rows.Next() {
+		var intervalStart pgtype.Date
+		var point domain.TrendPoint
+		if err := rows.Scan(&intervalStart, &point.Count); err != nil {
+			return nil, err
+		}
+		point.Date = intervalStart.Time.Format("2006-01-02")
+		points = append(points, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
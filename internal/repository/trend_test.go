@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestCountActiveTrend(t *testing.T) {
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, false)
+	ctx := context.Background()
+	userID, otherID := uuid.New(), uuid.New()
+
+	for _, sub := range []struct {
+		service string
+		user    uuid.UUID
+		start   string
+		end     *string
+	}{
+		{"Netflix", userID, "2025-01-10", nil},
+		{"Spotify", userID, "2025-02-01", strPtr("2025-02-28")},
+		{"Netflix", userID, "2025-03-20", strPtr("2025-04-10")},
+		{"Hulu", otherID, "2024-06-01", nil},
+	} {
+		if _, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{
+			ServiceName: sub.service,
+			PriceMinor:  100,
+			UserID:      sub.user,
+			StartDate:   sub.start,
+			EndDate:     sub.end,
+		}); err != nil {
+			t.Fatalf("create %s from %s: %v", sub.service, sub.start, err)
+		}
+	}
+
+	mine := ListSubscriptionsFilter{UserID: &userID}
+	netflix := "Netflix"
+	tests := []struct {
+		name        string
+		filter      ListSubscriptionsFilter
+		start, end  string
+		granularity string
+		want        []domain.TrendPoint
+	}{
+		{
+			name:   "monthly, counted on each month's last day and on end for the last",
+			filter: mine,
+			start:  "2025-01-01", end: "2025-04-15", granularity: domain.TrendGranularityMonth,
+			want: []domain.TrendPoint{{Date: "2025-01-01", Count: 1}, {Date: "2025-02-01", Count: 2}, {Date: "2025-03-01", Count: 2}, {Date: "2025-04-01", Count: 1}},
+		},
+		{
+			name:   "months before any of the user's subscriptions are zero-filled",
+			filter: mine,
+			start:  "2024-11-01", end: "2025-01-31", granularity: domain.TrendGranularityMonth,
+			want: []domain.TrendPoint{{Date: "2024-11-01", Count: 0}, {Date: "2024-12-01", Count: 0}, {Date: "2025-01-01", Count: 1}},
+		},
+		{
+			name:   "weekly, aligned to the Monday before start",
+			filter: mine,
+			start:  "2025-02-19", end: "2025-03-02", granularity: domain.TrendGranularityWeek,
+			want: []domain.TrendPoint{{Date: "2025-02-17", Count: 2}, {Date: "2025-02-24", Count: 1}},
+		},
+		{
+			name:   "daily across an end date",
+			filter: mine,
+			start:  "2025-02-27", end: "2025-03-01", granularity: domain.TrendGranularityDay,
+			want: []domain.TrendPoint{{Date: "2025-02-27", Count: 2}, {Date: "2025-02-28", Count: 2}, {Date: "2025-03-01", Count: 1}},
+		},
+		{
+			name:   "filters narrow the subscriptions counted",
+			filter: ListSubscriptionsFilter{UserID: &userID, ExactServiceName: &netflix},
+			start:  "2025-01-01", end: "2025-04-15", granularity: domain.TrendGranularityMonth,
+			want: []domain.TrendPoint{{Date: "2025-01-01", Count: 1}, {Date: "2025-02-01", Count: 1}, {Date: "2025-03-01", Count: 2}, {Date: "2025-04-01", Count: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.CountActiveTrend(ctx, &TrendFilter{
+				ListSubscriptionsFilter: tt.filter,
+				Start:                   tt.start,
+				End:                     tt.end,
+				Granularity:             tt.granularity,
+			})
+			if err != nil {
+				t.Fatalf("CountActiveTrend: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
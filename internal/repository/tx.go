@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository/sqlc"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+)
+
+const (
+	serializationFailureCode = "40001"
+	deadlockDetectedCode     = "40P01"
+
+	txRetryBaseDelay = 10 * time.Millisecond
+	defaultTxRetries = 3
+)
+
+// TxConfig controls write transactions: the isolation level they run at and
+// how often one that lost a serialization conflict is retried.
+type TxConfig struct {
+	IsolationLevel pgx.TxIsoLevel
+	MaxRetries     int
+}
+
+// NewTxConfig translates the database config, defaulting to repeatable read
+// so that a read-modify-write inside one transaction cannot lose updates,
+// and to defaultTxRetries retries.
+func NewTxConfig(cfg config.DatabaseConfig) TxConfig {
+	level := pgx.RepeatableRead
+	switch cfg.IsolationLevel {
+	case config.IsolationReadCommitted:
+		level = pgx.ReadCommitted
+	case config.IsolationSerializable:
+		level = pgx.Serializable
+	}
+	retries := cfg.TxRetries
+	if retries <= 0 {
+		retries = defaultTxRetries
+	}
+	return TxConfig{IsolationLevel: level, MaxRetries: retries}
+}
+
+// isRetryableTxError reports whether err aborted a transaction that may
+// succeed when run again from the start.
+func isRetryableTxError(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == serializationFailureCode || pgErr.Code == deadlockDetectedCode
+}
+
+// withTx runs fn with queries bound to a new transaction, committing when fn
+// succeeds and rolling back otherwise. A transaction that fails with a
+// serialization failure or deadlock is rerun from scratch up to MaxRetries
+// times, so fn must not keep state between attempts other than its result;
+// one that still conflicts fails with domain.ErrWriteConflict.
+func (r *subscriptionRepository) withTx(ctx context.Context, fn func(*sqlc.Queries) error) error {
+	return r.withRawTx(ctx, func(_ pgx.Tx, queries *sqlc.Queries) error {
+		return fn(queries)
+	})
+}
+
+// withCheckedTx is withTx for writes that can leave a user with overlapping
+// subscriptions to one service. While that is enforced they run serializable
+// whatever the configured level, so two concurrent writes cannot both pass
+// checkOverlap: one of them fails with a serialization failure and its retry
+// sees the other's row.
+func (r *subscriptionRepository) withCheckedTx(ctx context.Context, fn func(*sqlc.Queries) error) error {
+	level := r.tx.IsolationLevel
+	if r.enforceUniqueActive {
+		level = pgx.Serializable
+	}
+	return r.retryTx(ctx, level, func(_ pgx.Tx, queries *sqlc.Queries) error {
+		return fn(queries)
+	})
+}
+
+// withRawTx is withTx for callers that also run hand-built statements on the
+// transaction itself.
+func (r *subscriptionRepository) withRawTx(ctx context.Context, fn func(pgx.Tx, *sqlc.Queries) error) error {
+	return r.retryTx(ctx, r.tx.IsolationLevel, fn)
+}
+
+func (r *subscriptionRepository) retryTx(ctx context.Context, level pgx.TxIsoLevel, fn func(pgx.Tx, *sqlc.Queries) error) error {
+	for attempt := 0; ; attempt++ {
+		err := r.runTx(ctx, level, fn)
+		if err == nil || !isRetryableTxError(err) {
+			return err
+		}
+		if attempt >= r.tx.MaxRetries {
+			r.logger.Error("transaction conflicted on every attempt", zap.Int("attempts", attempt+1), zap.Error(err))
+			return fmt.Errorf("%w: %w", domain.ErrWriteConflict, err)
+		}
+
+		delay := txRetryBaseDelay << attempt
+		r.logger.Warn("retrying transaction after conflict",
+			zap.Int("attempt", attempt+1),
+			zap.Duration("delay", delay),
+			zap.Error(err),
+		)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (r *subscriptionRepository) runTx(ctx context.Context, level pgx.TxIsoLevel, fn func(pgx.Tx, *sqlc.Queries) error) error {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: level})
+	if err != nil {
+		r.logger.Error("failed to begin transaction", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err := fn(tx, r.queries.WithTx(tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		r.logger.Error("failed to commit transaction", zap.Error(err))
+		return err
+	}
+	return nil
+}
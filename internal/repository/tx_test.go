@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository/sqlc"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+func TestNewTxConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.DatabaseConfig
+		wantLevel   pgx.TxIsoLevel
+		wantRetries int
+	}{
+		{name: "defaults", wantLevel: pgx.RepeatableRead, wantRetries: defaultTxRetries},
+		{name: "zero retries take the default", cfg: config.DatabaseConfig{IsolationLevel: config.IsolationSerializable, TxRetries: 0}, wantLevel: pgx.Serializable, wantRetries: defaultTxRetries},
+		{name: "configured", cfg: config.DatabaseConfig{IsolationLevel: config.IsolationReadCommitted, TxRetries: 5}, wantLevel: pgx.ReadCommitted, wantRetries: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewTxConfig(tt.cfg)
+			if got.IsolationLevel != tt.wantLevel || got.MaxRetries != tt.wantRetries {
+				t.Errorf("NewTxConfig = %+v, want level %s and %d retries", got, tt.wantLevel, tt.wantRetries)
+			}
+		})
+	}
+}
+
+func TestRetryTxConflict(t *testing.T) {
+	pool := newTestPool(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		maxRetries   int
+		conflicts    int
+		wantErr      error
+		wantAttempts int
+	}{
+		{name: "conflict is retried and succeeds", maxRetries: 3, conflicts: 1, wantAttempts: 2},
+		{name: "several conflicts within the budget", maxRetries: 3, conflicts: 3, wantAttempts: 4},
+		{name: "conflicts past the budget give up", maxRetries: 1, conflicts: 5, wantErr: domain.ErrWriteConflict, wantAttempts: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewSubscriptionRepository(pool, TxConfig{IsolationLevel: pgx.RepeatableRead, MaxRetries: tt.maxRetries}, false, zap.NewNop()).(*subscriptionRepository)
+			sub, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{ServiceName: "Netflix", PriceMinor: 400, UserID: uuid.New(), StartDate: "2025-01-01"})
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+
+			// Each conflicting attempt reads the row, lets another
+			// transaction change it, then writes it too, which repeatable
+			// read refuses.
+			attempts := 0
+			err = repo.withRawTx(ctx, func(tx pgx.Tx, _ *sqlc.Queries) error {
+				attempts++
+				var price int
+				if err := tx.QueryRow(ctx, "SELECT price FROM subscriptions WHERE id = $1", sub.ID).Scan(&price); err != nil {
+					return err
+				}
+				if attempts <= tt.conflicts {
+					if _, err := pool.Exec(ctx, "UPDATE subscriptions SET price = price + 1 WHERE id = $1", sub.ID); err != nil {
+						return fmt.Errorf("concurrent update: %w", err)
+					}
+				}
+				_, err := tx.Exec(ctx, "UPDATE subscriptions SET price = $1 WHERE id = $2", price+100, sub.ID)
+				return err
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("withRawTx error = %v, want %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("ran %d attempts, want %d", attempts, tt.wantAttempts)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			var price int
+			if err := pool.QueryRow(ctx, "SELECT price FROM subscriptions WHERE id = $1", sub.ID).Scan(&price); err != nil {
+				t.Fatalf("read back: %v", err)
+			}
+			if want := 400 + tt.conflicts + 100; price != want {
+				t.Errorf("price = %d, want %d from the retried write on top of the concurrent ones", price, want)
+			}
+		})
+	}
+}
+
+// TestCheckedTxConcurrentCreates runs creates for one user in parallel while
+// uniqueness is enforced: the serializable checks conflict with each other,
+// and the retries must let every non-overlapping create through.
+func TestCheckedTxConcurrentCreates(t *testing.T) {
+	pool := newTestPool(t)
+	repo := NewSubscriptionRepository(pool, TxConfig{IsolationLevel: pgx.ReadCommitted, MaxRetries: 10}, true, zap.NewNop())
+	userID := uuid.New()
+
+	const writers = 6
+	errs := make([]error, writers)
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = repo.Create(context.Background(), &domain.CreateSubscriptionRequest{
+				ServiceName: fmt.Sprintf("service-%d", i),
+				PriceMinor:  200,
+				UserID:      userID,
+				StartDate:   "2025-01-01",
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("create %d: %v", i, err)
+		}
+	}
+}
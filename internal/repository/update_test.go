@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestUpdateEndDate(t *testing.T) {
+	pool := newTestPool(t)
+	repo := newTestRepository(t, pool, false)
+	ctx := context.Background()
+
+	sub, err := repo.Create(ctx, &domain.CreateSubscriptionRequest{
+		ServiceName: "Netflix",
+		PriceMinor:  400,
+		UserID:      uuid.New(),
+		StartDate:   "2025-01-01",
+		EndDate:     strPtr("2025-06-01"),
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// The steps run in order against the same subscription.
+	steps := []struct {
+		name    string
+		req     domain.UpdateSubscriptionRequest
+		wantEnd *string
+	}{
+		{name: "other fields keep it", req: domain.UpdateSubscriptionRequest{ServiceName: strPtr("Netflix Premium")}, wantEnd: strPtr("2025-06-01")},
+		{name: "end_date changes it", req: domain.UpdateSubscriptionRequest{EndDate: strPtr("2025-09-01")}, wantEnd: strPtr("2025-09-01")},
+		{name: "clear_end_date removes it", req: domain.UpdateSubscriptionRequest{ClearEndDate: true}},
+		{name: "an open-ended subscription stays open-ended", req: domain.UpdateSubscriptionRequest{AutoRenew: boolPtr(true)}},
+		{name: "end_date sets it again", req: domain.UpdateSubscriptionRequest{EndDate: strPtr("2025-12-01")}, wantEnd: strPtr("2025-12-01")},
+	}
+
+	for _, step := range steps {
+		updated, err := repo.Update(ctx, sub.ID, &step.req)
+		if err != nil {
+			t.Fatalf("%s: Update: %v", step.name, err)
+		}
+		stored, err := repo.GetByID(ctx, sub.ID)
+		if err != nil {
+			t.Fatalf("%s: GetByID: %v", step.name, err)
+		}
+		for source, got := range map[string]*string{"returned": updated.EndDate, "stored": stored.EndDate} {
+			if !equalDates(got, step.wantEnd) {
+				t.Errorf("%s: %s end_date = %v, want %v", step.name, source, deref(got), deref(step.wantEnd))
+			}
+		}
+	}
+}
+
+func equalDates(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return "<nil>"
+	}
+	return *s
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// WarmUp opens the pool's minimum number of connections, at least one and
+// never more than its maximum, so the first requests after a cold start do
+// not pay for connection setup. All of them are held at once, which forces
+// the pool to establish each one, and pinged; with query set each also runs
+// SELECT 1. They are released back to the pool afterwards.
+func WarmUp(ctx context.Context, db *pgxpool.Pool, query bool, logger *zap.Logger) error {
+	poolConfig := db.Config()
+	conns := int(max(poolConfig.MinConns, 1))
+	conns = min(conns, int(poolConfig.MaxConns))
+
+	logger.Info("warming up database connections", zap.Int("conns", conns), zap.Bool("query", query))
+
+	acquired := make([]*pgxpool.Conn, 0, conns)
+	defer func() {
+		for _, conn := range acquired {
+			conn.Release()
+		}
+	}()
+
+	for i := 0; i < conns; i++ {
+		conn, err := db.Acquire(ctx)
+		if err != nil {
+			return fmt.Errorf("warm up connection %d of %d: %w", i+1, conns, err)
+		}
+		acquired = append(acquired, conn)
+
+		if err := conn.Ping(ctx); err != nil {
+			return fmt.Errorf("warm up connection %d of %d: %w", i+1, conns, err)
+		}
+		if query {
+			var one int
+			if err := conn.QueryRow(ctx, "SELECT 1").Scan(&one); err != nil {
+				return fmt.Errorf("warm up connection %d of %d: %w", i+1, conns, err)
+			}
+		}
+	}
+
+	logger.Info("database connections warmed up", zap.Int("conns", conns), zap.Int32("total_conns", db.Stat().TotalConns()))
+	return nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+func TestWarmUp(t *testing.T) {
+	url := os.Getenv(testDatabaseURLEnv)
+	if url == "" {
+		t.Skipf("%s is not set", testDatabaseURLEnv)
+	}
+
+	tests := []struct {
+		name      string
+		minConns  int32
+		maxConns  int32
+		query     bool
+		wantConns int32
+	}{
+		{name: "no minimum opens one connection", minConns: 0, maxConns: 4, wantConns: 1},
+		{name: "opens the minimum", minConns: 3, maxConns: 3, wantConns: 3},
+		{name: "opens the minimum with a query", minConns: 2, maxConns: 2, query: true, wantConns: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			poolConfig, err := pgxpool.ParseConfig(url)
+			if err != nil {
+				t.Fatalf("parse %s: %v", testDatabaseURLEnv, err)
+			}
+			poolConfig.MinConns = tt.minConns
+			poolConfig.MaxConns = tt.maxConns
+
+			pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+			if err != nil {
+				t.Fatalf("connect: %v", err)
+			}
+			t.Cleanup(pool.Close)
+
+			if err := WarmUp(ctx, pool, tt.query, zap.NewNop()); err != nil {
+				t.Fatalf("WarmUp: %v", err)
+			}
+
+			stat := pool.Stat()
+			if stat.TotalConns() != tt.wantConns {
+				t.Errorf("pool has %d connections, want %d", stat.TotalConns(), tt.wantConns)
+			}
+			if stat.IdleConns() != tt.wantConns {
+				t.Errorf("%d connections are idle, want all %d released", stat.IdleConns(), tt.wantConns)
+			}
+		})
+	}
+}
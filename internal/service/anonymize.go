@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// AnonymizeUser erases a user from their subscriptions on request. The rows
+// are kept under a fresh tombstone user id, shared by all of them and
+// unrelated to any real user, so totals and counts do not change.
+func (s *subscriptionService) AnonymizeUser(ctx context.Context, userID uuid.UUID) (*domain.AnonymizeUserResponse, error) {
+	s.logger.Info("service: anonymizing user", zap.String("user_id", userID.String()))
+
+	if userID == uuid.Nil {
+		problems := domain.ValidationErrors{{Field: "user_id", Message: "user_id is required"}}
+		s.logger.Error("invalid anonymize request", zap.Error(problems))
+		return nil, problems
+	}
+
+	anonymized, err := s.repo.AnonymizeUser(ctx, userID, uuid.New())
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.AnonymizeUserResponse{Anonymized: anonymized}, nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"subscription-service/internal/clock"
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// BatchService applies bulk operations item by item through
+// SubscriptionService, so every item gets the same validation as a single
+// request and one failure does not stop the rest.
+type BatchService interface {
+	Create(ctx context.Context, req *domain.BatchCreateRequest) *domain.BatchResponse
+	CreateIdempotent(ctx context.Context, key string, req *domain.BatchCreateRequest) (*domain.BatchResponse, bool, error)
+	Update(ctx context.Context, req *domain.BatchUpdateRequest) *domain.BatchResponse
+	Delete(ctx context.Context, req *domain.BatchDeleteRequest) *domain.BatchResponse
+}
+
+type batchService struct {
+	subscriptions SubscriptionService
+	idempotency   *batchIdempotency
+	logger        *zap.Logger
+}
+
+func NewBatchService(subscriptions SubscriptionService, idempotencyTTL time.Duration, idempotencyMaxKeys int, clock clock.Clock, logger *zap.Logger) BatchService {
+	return &batchService{
+		subscriptions: subscriptions,
+		idempotency:   newBatchIdempotency(idempotencyTTL, idempotencyMaxKeys, clock),
+		logger:        logger,
+	}
+}
+
+func (s *batchService) Create(ctx context.Context, req *domain.BatchCreateRequest) *domain.BatchResponse {
+	s.logger.Info("service: batch create", zap.Int("items", len(req.Items)))
+
+	results := make([]domain.BatchResult, len(req.Items))
+	for i := range req.Items {
+		// Create fills in defaults and normalizes the price on the request
+		// it is given; a copy leaves req as the caller sent it, so its
+		// idempotency fingerprint does not change.
+		item := req.Items[i]
+		subscription, err := s.subscriptions.Create(ctx, &item)
+		if err != nil {
+			results[i] = failedResult(i, nil, err)
+			continue
+		}
+		results[i] = domain.BatchResult{Index: i, ID: &subscription.ID, Status: domain.BatchStatusCreated}
+	}
+
+	return domain.NewBatchResponse(results)
+}
+
+// CreateIdempotent runs Create once per key and returns the stored response
+// when the same key and request are seen again, reporting true for such a
+// replay. The response is stored whatever the item outcomes were, so a
+// replay never creates anything. Retrying just the failed items takes a new
+// key; items that did succeed are then caught by create deduplication when
+// it is enabled.
+func (s *batchService) CreateIdempotent(ctx context.Context, key string, req *domain.BatchCreateRequest) (*domain.BatchResponse, bool, error) {
+	fingerprint, err := batchFingerprint(req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	response, replayed, err := s.idempotency.claim(ctx, key, fingerprint)
+	if err != nil || replayed {
+		if replayed {
+			s.logger.Info("service: replaying batch create", zap.String("idempotency_key", key))
+		}
+		return response, replayed, err
+	}
+
+	// The claim is released however Create ends. If it panics, no response
+	// is stored and the key is freed for the retry.
+	var created *domain.BatchResponse
+	defer func() { s.idempotency.finish(key, created) }()
+
+	created = s.Create(ctx, req)
+	return created, false, nil
+}
+
+func (s *batchService) Update(ctx context.Context, req *domain.BatchUpdateRequest) *domain.BatchResponse {
+	s.logger.Info("service: batch update", zap.Int("items", len(req.Items)))
+
+	results := make([]domain.BatchResult, len(req.Items))
+	for i := range req.Items {
+		id := req.Items[i].ID
+		if _, err := s.subscriptions.Update(ctx, id, &req.Items[i].UpdateSubscriptionRequest); err != nil {
+			results[i] = failedResult(i, &id, err)
+			continue
+		}
+		results[i] = domain.BatchResult{Index: i, ID: &id, Status: domain.BatchStatusUpdated}
+	}
+
+	return domain.NewBatchResponse(results)
+}
+
+func (s *batchService) Delete(ctx context.Context, req *domain.BatchDeleteRequest) *domain.BatchResponse {
+	s.logger.Info("service: batch delete", zap.Int("items", len(req.IDs)))
+
+	results := make([]domain.BatchResult, len(req.IDs))
+	for i := range req.IDs {
+		id := req.IDs[i]
+		if err := s.subscriptions.Delete(ctx, id); err != nil {
+			results[i] = failedResult(i, &id, err)
+			continue
+		}
+		results[i] = domain.BatchResult{Index: i, ID: &id, Status: domain.BatchStatusDeleted}
+	}
+
+	return domain.NewBatchResponse(results)
+}
+
+func failedResult(index int, id *uuid.UUID, err error) domain.BatchResult {
+	return domain.BatchResult{Index: index, ID: id, Status: domain.BatchStatusFailed, Error: err.Error()}
+}
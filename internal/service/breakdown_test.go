@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository"
+)
+
+func TestCalculateTotalCostBreakdownPaging(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       domain.TotalCostRequest
+		wantOrder string
+		wantLimit int
+	}{
+		{name: "biggest spend first by default", wantOrder: domain.TotalCostOrderCostDesc, wantLimit: 20},
+		{name: "top five", req: domain.TotalCostRequest{Limit: 5}, wantOrder: domain.TotalCostOrderCostDesc, wantLimit: 5},
+		{name: "requested order", req: domain.TotalCostRequest{Order: domain.TotalCostOrderCostAsc}, wantOrder: domain.TotalCostOrderCostAsc, wantLimit: 20},
+		{name: "limit is capped", req: domain.TotalCostRequest{Limit: 1000}, wantOrder: domain.TotalCostOrderCostDesc, wantLimit: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *repository.TotalCostBreakdownFilter
+			repo := &fakeRepository{
+				calculateTotalCost: func(context.Context, *repository.TotalCostFilter) (int, error) { return 0, nil },
+				costByService: func(_ context.Context, filter *repository.TotalCostBreakdownFilter) ([]domain.ServiceCost, int64, error) {
+					got = filter
+					return nil, 7, nil
+				},
+			}
+			svc := newTestService(repo, config.SubscriptionConfig{}, newFakeClock(testToday))
+
+			req := tt.req
+			req.StartDate, req.EndDate, req.GroupBy = "2025-01-01", "2025-12-01", domain.TotalCostGroupByService
+			resp, err := svc.CalculateTotalCost(context.Background(), &req)
+			if err != nil {
+				t.Fatalf("CalculateTotalCost: %v", err)
+			}
+
+			if got.Order != tt.wantOrder || got.Limit != tt.wantLimit {
+				t.Errorf("breakdown query order %q limit %d, want %q limit %d", got.Order, got.Limit, tt.wantOrder, tt.wantLimit)
+			}
+			if resp.Breakdown.Limit != tt.wantLimit || resp.Breakdown.Total != 7 {
+				t.Errorf("breakdown = %+v, want limit %d of 7 groups", resp.Breakdown, tt.wantLimit)
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"subscription-service/internal/clock"
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+	"subscription-service/internal/jsonpatch"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultCacheTTL     = 30 * time.Second
+	defaultCacheTimeout = 5 * time.Second
+)
+
+// cachedSubscriptionService caches GetByID and List results in memory with
+// stale-while-revalidate semantics: entries younger than the TTL are served
+// as is, entries within the stale window after that are served immediately
+// while a background refresh runs, and older entries are reloaded inline.
+// A failed refresh keeps the stale entry so the next read retries it.
+//
+// Every load is bounded by the configured timeout. When an inline reload
+// fails because the backend is down or slow, the expired entry is served
+// instead of the error, however old it is; answers such as "not found" are
+// passed on.
+//
+// Writes made through this service drop the affected entries. Changes made
+// elsewhere, such as by the renewal job, show up once the entry expires.
+type cachedSubscriptionService struct {
+	SubscriptionService
+
+	ttl         time.Duration
+	staleWindow time.Duration
+	timeout     time.Duration
+	clock       clock.Clock
+	logger      *zap.Logger
+
+	mu         sync.Mutex
+	entries    map[string]*cacheEntry
+	generation uint64
+}
+
+type cacheEntry struct {
+	value      interface{}
+	fetchedAt  time.Time
+	refreshing bool
+}
+
+type cachedList struct {
+	subscriptions []*domain.Subscription
+	total         int64
+	req           domain.ListSubscriptionsRequest
+}
+
+func NewCachedSubscriptionService(next SubscriptionService, cfg config.CacheConfig, clock clock.Clock, logger *zap.Logger) SubscriptionService {
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultCacheTimeout
+	}
+
+	return &cachedSubscriptionService{
+		SubscriptionService: next,
+		ttl:                 ttl,
+		staleWindow:         cfg.StaleWindow,
+		timeout:             timeout,
+		clock:               clock,
+		logger:              logger,
+		entries:             make(map[string]*cacheEntry),
+	}
+}
+
+func (s *cachedSubscriptionService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	value, err := s.get(ctx, "subscription:"+id.String(), func(ctx context.Context) (interface{}, error) {
+		return s.SubscriptionService.GetByID(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*domain.Subscription), nil
+}
+
+func (s *cachedSubscriptionService) List(ctx context.Context, req *domain.ListSubscriptionsRequest) ([]*domain.Subscription, int64, error) {
+	// Marshalling cannot fail: the request holds only strings, numbers,
+	// bools, string slices and a string map, whose keys encoding/json sorts.
+	key, _ := json.Marshal(req)
+
+	// The loader works on its own copy so a background refresh never touches
+	// the caller's request, which the handler reads after List returns.
+	template := *req
+	value, err := s.get(ctx, "list:"+string(key), func(ctx context.Context) (interface{}, error) {
+		listReq := template
+		subscriptions, total, err := s.SubscriptionService.List(ctx, &listReq)
+		if err != nil {
+			return nil, err
+		}
+		return &cachedList{subscriptions: subscriptions, total: total, req: listReq}, nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	list := value.(*cachedList)
+	*req = list.req
+	return list.subscriptions, list.total, nil
+}
+
+func (s *cachedSubscriptionService) Create(ctx context.Context, req *domain.CreateSubscriptionRequest) (*domain.Subscription, error) {
+	subscription, err := s.SubscriptionService.Create(ctx, req)
+	if err == nil {
+		s.invalidate(subscription.ID)
+	}
+	return subscription, err
+}
+
+func (s *cachedSubscriptionService) Update(ctx context.Context, id uuid.UUID, req *domain.UpdateSubscriptionRequest) (*domain.Subscription, error) {
+	subscription, err := s.SubscriptionService.Update(ctx, id, req)
+	s.invalidate(id)
+	return subscription, err
+}
+
+func (s *cachedSubscriptionService) Put(ctx context.Context, id uuid.UUID, req *domain.CreateSubscriptionRequest) (*domain.Subscription, bool, error) {
+	subscription, created, err := s.SubscriptionService.Put(ctx, id, req)
+	s.invalidate(id)
+	return subscription, created, err
+}
+
+func (s *cachedSubscriptionService) Patch(ctx context.Context, id uuid.UUID, patch []jsonpatch.Operation) (*domain.Subscription, error) {
+	subscription, err := s.SubscriptionService.Patch(ctx, id, patch)
+	s.invalidate(id)
+	return subscription, err
+}
+
+func (s *cachedSubscriptionService) Delete(ctx context.Context, id uuid.UUID) error {
+	err := s.SubscriptionService.Delete(ctx, id)
+	s.invalidate(id)
+	return err
+}
+
+func (s *cachedSubscriptionService) Clone(ctx context.Context, id uuid.UUID, req *domain.CloneSubscriptionRequest) (*domain.Subscription, error) {
+	subscription, err := s.SubscriptionService.Clone(ctx, id, req)
+	if err == nil {
+		s.invalidate(subscription.ID)
+	}
+	return subscription, err
+}
+
+func (s *cachedSubscriptionService) BulkTag(ctx context.Context, req *domain.BulkTagRequest) (*domain.BulkTagResponse, error) {
+	resp, err := s.SubscriptionService.BulkTag(ctx, req)
+	s.invalidateAll()
+	return resp, err
+}
+
+func (s *cachedSubscriptionService) ReassignUser(ctx context.Context, req *domain.ReassignUserRequest) (*domain.ReassignUserResponse, error) {
+	resp, err := s.SubscriptionService.ReassignUser(ctx, req)
+	s.invalidateAll()
+	return resp, err
+}
+
+func (s *cachedSubscriptionService) AnonymizeUser(ctx context.Context, userID uuid.UUID) (*domain.AnonymizeUserResponse, error) {
+	resp, err := s.SubscriptionService.AnonymizeUser(ctx, userID)
+	s.invalidateAll()
+	return resp, err
+}
+
+// get returns the cached value for key, loading it with load when the entry
+// is missing or past the stale window. An entry past the stale window is
+// still served when reloading it fails with a backend failure.
+func (s *cachedSubscriptionService) get(ctx context.Context, key string, load func(context.Context) (interface{}, error)) (interface{}, error) {
+	now := s.clock.Now()
+
+	s.mu.Lock()
+	entry, ok := s.entries[key]
+	var expired interface{}
+	var expiredAge time.Duration
+	if ok {
+		age := now.Sub(entry.fetchedAt)
+		if age < s.ttl {
+			s.mu.Unlock()
+			return entry.value, nil
+		}
+		if age < s.ttl+s.staleWindow {
+			if !entry.refreshing {
+				entry.refreshing = true
+				go s.refresh(context.WithoutCancel(ctx), key, s.generation, load)
+			}
+			s.mu.Unlock()
+			s.logger.Debug("serving stale cache entry", zap.String("key", key), zap.Duration("age", age))
+			return entry.value, nil
+		}
+		expired, expiredAge = entry.value, age
+	}
+	generation := s.generation
+	s.mu.Unlock()
+
+	value, err := s.load(ctx, load)
+	if err != nil {
+		if ok && isBackendFailure(err) {
+			s.logger.Warn("failed to reload cache entry, serving expired entry", zap.String("key", key), zap.Duration("age", expiredAge), zap.Error(err))
+			return expired, nil
+		}
+		return nil, err
+	}
+
+	s.store(key, generation, value)
+	return value, nil
+}
+
+func (s *cachedSubscriptionService) refresh(ctx context.Context, key string, generation uint64, load func(context.Context) (interface{}, error)) {
+	value, err := s.load(ctx, load)
+	if err != nil {
+		s.logger.Warn("failed to refresh cache entry", zap.String("key", key), zap.Error(err))
+		s.mu.Lock()
+		if entry, ok := s.entries[key]; ok {
+			entry.refreshing = false
+		}
+		s.mu.Unlock()
+		return
+	}
+
+	s.store(key, generation, value)
+}
+
+// load runs load under the cache timeout.
+func (s *cachedSubscriptionService) load(ctx context.Context, load func(context.Context) (interface{}, error)) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return load(ctx)
+}
+
+// isBackendFailure reports whether err means the wrapped service could not
+// answer, as opposed to an answer such as not found or invalid input.
+func isBackendFailure(err error) bool {
+	var problems domain.ValidationErrors
+	return !errors.Is(err, domain.ErrSubscriptionNotFound) && !errors.As(err, &problems)
+}
+
+// store saves value unless a write invalidated the cache after the load
+// started, in which case the value may already be out of date.
+func (s *cachedSubscriptionService) store(key string, generation uint64, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if generation != s.generation {
+		if entry, ok := s.entries[key]; ok {
+			entry.refreshing = false
+		}
+		return
+	}
+	s.entries[key] = &cacheEntry{value: value, fetchedAt: s.clock.Now()}
+}
+
+// invalidate drops the entry for id and every cached list, since any write
+// can change list membership and totals.
+func (s *cachedSubscriptionService) invalidate(id uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.generation++
+	delete(s.entries, "subscription:"+id.String())
+	for key := range s.entries {
+		if strings.HasPrefix(key, "list:") {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// invalidateAll drops every entry, for writes that may touch any number of
+// subscriptions.
+func (s *cachedSubscriptionService) invalidateAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.generation++
+	s.entries = make(map[string]*cacheEntry)
+}
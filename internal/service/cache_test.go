@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// fakeReadService answers GetByID with getByID and counts the calls; every
+// other method panics on the nil embedded interface.
+type fakeReadService struct {
+	SubscriptionService
+
+	mu      sync.Mutex
+	calls   int
+	getByID func(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)
+}
+
+func (s *fakeReadService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	s.mu.Lock()
+	s.calls++
+	getByID := s.getByID
+	s.mu.Unlock()
+	return getByID(ctx, id)
+}
+
+func (s *fakeReadService) setGetByID(getByID func(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.getByID = getByID
+}
+
+func (s *fakeReadService) callCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func returnsSubscription(name string) func(context.Context, uuid.UUID) (*domain.Subscription, error) {
+	return func(_ context.Context, id uuid.UUID) (*domain.Subscription, error) {
+		return &domain.Subscription{ID: id, ServiceName: name}, nil
+	}
+}
+
+func returnsError(err error) func(context.Context, uuid.UUID) (*domain.Subscription, error) {
+	return func(context.Context, uuid.UUID) (*domain.Subscription, error) {
+		return nil, err
+	}
+}
+
+// hangs never answers, like a database that accepts connections but does
+// not respond; only the context ends the call.
+func hangs(ctx context.Context, _ uuid.UUID) (*domain.Subscription, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+var testCacheConfig = config.CacheConfig{
+	Enabled:     true,
+	TTL:         30 * time.Second,
+	StaleWindow: time.Minute,
+	Timeout:     50 * time.Millisecond,
+}
+
+func TestCachedGetByIDBackendFailures(t *testing.T) {
+	tests := []struct {
+		name      string
+		primed    bool
+		advance   time.Duration
+		backend   func(context.Context, uuid.UUID) (*domain.Subscription, error)
+		wantName  string
+		wantErr   error
+		wantCalls int
+	}{
+		{
+			name:      "fresh entry does not reach the backend",
+			primed:    true,
+			advance:   10 * time.Second,
+			backend:   returnsError(domain.ErrDatabaseUnavailable),
+			wantName:  "cached",
+			wantCalls: 1,
+		},
+		{
+			name:      "expired entry is reloaded",
+			primed:    true,
+			advance:   2 * time.Minute,
+			backend:   returnsSubscription("reloaded"),
+			wantName:  "reloaded",
+			wantCalls: 2,
+		},
+		{
+			name:      "dead backend without an entry fails the read",
+			backend:   returnsError(domain.ErrDatabaseUnavailable),
+			wantErr:   domain.ErrDatabaseUnavailable,
+			wantCalls: 1,
+		},
+		{
+			name:      "dead backend serves the expired entry",
+			primed:    true,
+			advance:   time.Hour,
+			backend:   returnsError(domain.ErrDatabaseUnavailable),
+			wantName:  "cached",
+			wantCalls: 2,
+		},
+		{
+			name:      "hanging backend without an entry times out",
+			backend:   hangs,
+			wantErr:   context.DeadlineExceeded,
+			wantCalls: 1,
+		},
+		{
+			name:      "hanging backend serves the expired entry",
+			primed:    true,
+			advance:   time.Hour,
+			backend:   hangs,
+			wantName:  "cached",
+			wantCalls: 2,
+		},
+		{
+			name:      "not found on reload is passed on",
+			primed:    true,
+			advance:   time.Hour,
+			backend:   returnsError(domain.ErrSubscriptionNotFound),
+			wantErr:   domain.ErrSubscriptionNotFound,
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock(testToday)
+			next := &fakeReadService{getByID: returnsSubscription("cached")}
+			cache := NewCachedSubscriptionService(next, testCacheConfig, clock, zap.NewNop())
+			ctx := context.Background()
+			id := uuid.New()
+
+			if tt.primed {
+				if _, err := cache.GetByID(ctx, id); err != nil {
+					t.Fatalf("priming GetByID: %v", err)
+				}
+			}
+			clock.Advance(tt.advance)
+			next.setGetByID(tt.backend)
+
+			started := time.Now()
+			got, err := cache.GetByID(ctx, id)
+			if elapsed := time.Since(started); elapsed > time.Second {
+				t.Errorf("GetByID took %v, want it bounded by the cache timeout", elapsed)
+			}
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("GetByID error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("GetByID: %v", err)
+			} else if got.ServiceName != tt.wantName {
+				t.Errorf("GetByID = %q, want %q", got.ServiceName, tt.wantName)
+			}
+			if calls := next.callCount(); calls != tt.wantCalls {
+				t.Errorf("backend calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestCachedGetByIDServesStaleThenRefreshes(t *testing.T) {
+	clock := newFakeClock(testToday)
+	next := &fakeReadService{getByID: returnsSubscription("v1")}
+	cache := NewCachedSubscriptionService(next, testCacheConfig, clock, zap.NewNop())
+	ctx := context.Background()
+	id := uuid.New()
+
+	if _, err := cache.GetByID(ctx, id); err != nil {
+		t.Fatalf("priming GetByID: %v", err)
+	}
+
+	refreshed := make(chan struct{})
+	next.setGetByID(func(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+		defer close(refreshed)
+		return returnsSubscription("v2")(ctx, id)
+	})
+	clock.Advance(testCacheConfig.TTL + time.Second)
+
+	got, err := cache.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("stale GetByID: %v", err)
+	}
+	if got.ServiceName != "v1" {
+		t.Fatalf("stale GetByID = %q, want the stale v1 served without waiting", got.ServiceName)
+	}
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("background refresh did not run")
+	}
+
+	// The refresh stores its result right after the backend returns; the
+	// refreshed entry is fresh by the fake clock, so reading it loads
+	// nothing.
+	deadline := time.Now().Add(time.Second)
+	for {
+		got, err = cache.GetByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetByID after refresh: %v", err)
+		}
+		if got.ServiceName == "v2" || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got.ServiceName != "v2" {
+		t.Errorf("GetByID after refresh = %q, want v2", got.ServiceName)
+	}
+	if calls := next.callCount(); calls != 2 {
+		t.Errorf("backend calls = %d, want 2: one load and one refresh", calls)
+	}
+}
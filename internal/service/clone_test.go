@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// storeFullCreates makes the store's creates keep everything the request
+// would store, so a clone can be compared field by field with its source.
+func storeFullCreates(store *createStore, repo *fakeRepository) {
+	repo.create = func(_ context.Context, req *domain.CreateSubscriptionRequest) (*domain.Subscription, error) {
+		metadata := map[string]interface{}{}
+		if len(req.Metadata) > 0 {
+			if err := json.Unmarshal(req.Metadata, &metadata); err != nil {
+				return nil, err
+			}
+		}
+		subscription := &domain.Subscription{
+			ID:            uuid.New(),
+			ServiceName:   req.ServiceName,
+			PriceMinor:    req.PriceMinor,
+			UserID:        req.UserID,
+			StartDate:     req.StartDate,
+			EndDate:       req.EndDate,
+			AutoRenew:     req.AutoRenew,
+			Metadata:      metadata,
+			Tags:          req.Tags,
+			BillingPeriod: req.BillingPeriod,
+			Currency:      req.Currency,
+		}
+		store.mu.Lock()
+		defer store.mu.Unlock()
+		store.created[subscription.ID] = subscription
+		return subscription, nil
+	}
+}
+
+func TestClone(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    domain.CloneSubscriptionRequest
+		change func(want *domain.Subscription)
+	}{
+		{name: "no overrides", change: func(*domain.Subscription) {}},
+		{name: "price in whole units", req: domain.CloneSubscriptionRequest{Price: intPtr(550)}, change: func(want *domain.Subscription) {
+			want.PriceMinor = 55000
+		}},
+		{name: "price in minor units", req: domain.CloneSubscriptionRequest{PriceMinor: intPtr(1999)}, change: func(want *domain.Subscription) {
+			want.PriceMinor = 1999
+		}},
+		{name: "dates", req: domain.CloneSubscriptionRequest{StartDate: strPtr("2025-06-01"), EndDate: strPtr("2025-12-01")}, change: func(want *domain.Subscription) {
+			want.StartDate = "2025-06-01"
+			want.EndDate = strPtr("2025-12-01")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, repo := newCreateStore()
+			storeFullCreates(store, repo)
+			svc := newTestService(repo, config.SubscriptionConfig{}, newFakeClock(testToday))
+
+			sourceReq := testCreateRequest(uuid.New())
+			sourceReq.Currency = domain.DefaultCurrency
+			sourceReq.BillingPeriod = domain.BillingPeriodYearly
+			sourceReq.AutoRenew = true
+			source, err := svc.Create(context.Background(), &sourceReq)
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			before := *source
+
+			clone, err := svc.Clone(context.Background(), source.ID, &tt.req)
+			if err != nil {
+				t.Fatalf("Clone: %v", err)
+			}
+
+			if clone.ID == source.ID {
+				t.Fatalf("clone kept the source id %s", source.ID)
+			}
+			want := before
+			want.ID = clone.ID
+			tt.change(&want)
+			if !reflect.DeepEqual(*clone, want) {
+				t.Errorf("clone = %+v, want %+v", *clone, want)
+			}
+			if !reflect.DeepEqual(*store.created[source.ID], before) {
+				t.Errorf("source changed to %+v, want %+v", *store.created[source.ID], before)
+			}
+		})
+	}
+}
+
+func TestCloneDedup(t *testing.T) {
+	tests := []struct {
+		name        string
+		req         domain.CloneSubscriptionRequest
+		wantCreates int
+	}{
+		{name: "no overrides returns the source", wantCreates: 1},
+		{name: "an override creates a new subscription", req: domain.CloneSubscriptionRequest{StartDate: strPtr("2025-06-01")}, wantCreates: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, repo := newCreateStore()
+			storeFullCreates(store, repo)
+			svc := newTestService(repo, config.SubscriptionConfig{DedupWindow: time.Minute}, newFakeClock(testToday))
+
+			sourceReq := testCreateRequest(uuid.New())
+			source, err := svc.Create(context.Background(), &sourceReq)
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+
+			clone, err := svc.Clone(context.Background(), source.ID, &tt.req)
+			if err != nil {
+				t.Fatalf("Clone: %v", err)
+			}
+
+			if got := store.count(); got != tt.wantCreates {
+				t.Errorf("created %d subscriptions, want %d", got, tt.wantCreates)
+			}
+			if deduped := clone.ID == source.ID; deduped != (tt.wantCreates == 1) {
+				t.Errorf("clone returned the source = %v, want %v", deduped, tt.wantCreates == 1)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// GetComputed returns the subscription together with fields derived from it
+// as of today according to the service clock.
+func (s *subscriptionService) GetComputed(ctx context.Context, id uuid.UUID) (*domain.SubscriptionWithComputed, error) {
+	s.logger.Info("service: getting subscription with computed fields", zap.String("id", id.String()))
+
+	subscription, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	pauses, err := s.repo.ListPauses(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	computed, err := computeFields(subscription, pauses, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.SubscriptionWithComputed{Subscription: subscription, Computed: *computed}, nil
+}
+
+func computeFields(subscription *domain.Subscription, pauses []domain.SubscriptionPause, now time.Time) (*domain.SubscriptionComputed, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	start, err := time.Parse(dateLayout, subscription.StartDate)
+	if err != nil {
+		return nil, err
+	}
+
+	var end *time.Time
+	if subscription.EndDate != nil {
+		parsed, err := time.Parse(dateLayout, *subscription.EndDate)
+		if err != nil {
+			return nil, err
+		}
+		end = &parsed
+	}
+
+	computed := &domain.SubscriptionComputed{
+		AsOf:     today.Format(dateLayout),
+		IsActive: !start.After(today) && (end == nil || !end.Before(today)),
+	}
+
+	if subscription.AutoRenew && end != nil && !end.Before(today) {
+		days := int(end.Sub(today).Hours() / 24)
+		computed.DaysUntilRenewal = &days
+	}
+
+	last := today
+	if end != nil && end.Before(last) {
+		last = *end
+	}
+
+	month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if month.Before(start) {
+		month = month.AddDate(0, 1, 0)
+	}
+	for ; !month.After(last); month = month.AddDate(0, 1, 0) {
+		if !monthPaused(month, pauses) {
+			computed.MonthsBilled++
+		}
+	}
+	computed.TotalPaidToDateMinor = computed.MonthsBilled * subscription.PriceMinor
+	computed.TotalPaidToDate = int(domain.WholeUnits(int64(computed.TotalPaidToDateMinor), subscription.Currency))
+
+	return computed, nil
+}
+
+func monthPaused(month time.Time, pauses []domain.SubscriptionPause) bool {
+	day := month.Format(dateLayout)
+	for _, pause := range pauses {
+		if pause.PauseStart <= day && day <= pause.PauseEnd {
+			return true
+		}
+	}
+	return false
+}
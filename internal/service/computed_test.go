@@ -0,0 +1,57 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"subscription-service/internal/domain"
+)
+
+func TestComputeFields(t *testing.T) {
+	// testToday is 2025-03-15.
+	tests := []struct {
+		name         string
+		subscription domain.Subscription
+		pauses       []domain.SubscriptionPause
+		want         domain.SubscriptionComputed
+	}{
+		{
+			name:         "active open-ended",
+			subscription: domain.Subscription{StartDate: "2025-01-01", PriceMinor: 40000, Currency: "RUB"},
+			want:         domain.SubscriptionComputed{AsOf: "2025-03-15", IsActive: true, MonthsBilled: 3, TotalPaidToDate: 1200, TotalPaidToDateMinor: 120000},
+		},
+		{
+			name:         "active, renewing at its end date",
+			subscription: domain.Subscription{StartDate: "2025-01-01", EndDate: strPtr("2025-03-31"), AutoRenew: true, PriceMinor: 1999, Currency: "USD"},
+			want:         domain.SubscriptionComputed{AsOf: "2025-03-15", IsActive: true, DaysUntilRenewal: intPtr(16), MonthsBilled: 3, TotalPaidToDate: 59, TotalPaidToDateMinor: 5997},
+		},
+		{
+			name:         "expired, started mid-month",
+			subscription: domain.Subscription{StartDate: "2024-01-15", EndDate: strPtr("2024-06-30"), AutoRenew: true, PriceMinor: 500, Currency: "JPY"},
+			want:         domain.SubscriptionComputed{AsOf: "2025-03-15", MonthsBilled: 5, TotalPaidToDate: 2500, TotalPaidToDateMinor: 2500},
+		},
+		{
+			name:         "paused month is not paid",
+			subscription: domain.Subscription{StartDate: "2025-01-01", PriceMinor: 40000, Currency: "RUB"},
+			pauses:       []domain.SubscriptionPause{{PauseStart: "2025-02-01", PauseEnd: "2025-02-28"}},
+			want:         domain.SubscriptionComputed{AsOf: "2025-03-15", IsActive: true, MonthsBilled: 2, TotalPaidToDate: 800, TotalPaidToDateMinor: 80000},
+		},
+		{
+			name:         "not started yet",
+			subscription: domain.Subscription{StartDate: "2025-04-01", PriceMinor: 40000, Currency: "RUB"},
+			want:         domain.SubscriptionComputed{AsOf: "2025-03-15"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := computeFields(&tt.subscription, tt.pauses, testToday)
+			if err != nil {
+				t.Fatalf("computeFields: %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("computed = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestCreateDefaultUserID(t *testing.T) {
+	defaultUser := uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba")
+	givenUser := uuid.New()
+
+	tests := []struct {
+		name         string
+		defaultUser  string
+		userID       uuid.UUID
+		wantUserID   uuid.UUID
+		wantProblems []string
+	}{
+		{name: "default applied when user_id is omitted", defaultUser: defaultUser.String(), wantUserID: defaultUser},
+		{name: "given user_id wins over the default", defaultUser: defaultUser.String(), userID: givenUser, wantUserID: givenUser},
+		{name: "user_id required without a default", wantProblems: []string{"user_id"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stored uuid.UUID
+			repo := &fakeRepository{
+				create: func(_ context.Context, req *domain.CreateSubscriptionRequest) (*domain.Subscription, error) {
+					stored = req.UserID
+					return &domain.Subscription{ID: uuid.New(), UserID: req.UserID}, nil
+				},
+			}
+			svc := newTestService(repo, config.SubscriptionConfig{DefaultUserID: tt.defaultUser}, newFakeClock(testToday))
+
+			_, err := svc.Create(context.Background(), &domain.CreateSubscriptionRequest{
+				ServiceName: "Netflix",
+				Price:       400,
+				UserID:      tt.userID,
+				StartDate:   "2025-01-01",
+			})
+
+			if tt.wantProblems != nil {
+				var problems domain.ValidationErrors
+				if !errors.As(err, &problems) {
+					t.Fatalf("Create error = %v, want validation errors", err)
+				}
+				if got := fields(problems); !reflect.DeepEqual(got, tt.wantProblems) {
+					t.Errorf("problems = %v, want %v", got, tt.wantProblems)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if stored != tt.wantUserID {
+				t.Errorf("stored user_id = %s, want %s", stored, tt.wantUserID)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+func TestCreateAmount(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		price        int
+		priceMinor   int
+		amount       string
+		currency     string
+		wantPrice    int
+		wantCurrency string
+		wantProblems []string
+	}{
+		{name: "price in whole units", price: 19, wantPrice: 1900, wantCurrency: "RUB"},
+		{name: "price in whole units of a zero-decimal currency", price: 19, currency: "JPY", wantPrice: 19, wantCurrency: "JPY"},
+		{name: "price in whole units of a three-decimal currency", price: 19, currency: "BHD", wantPrice: 19000, wantCurrency: "BHD"},
+		{name: "price too large for minor units", price: 30000000, wantProblems: []string{"price"}},
+		{name: "price in minor units", priceMinor: 1999, wantPrice: 1999, wantCurrency: "RUB"},
+		{name: "amount in the default currency", amount: "19.99", wantPrice: 1999, wantCurrency: "RUB"},
+		{name: "amount in a zero-decimal currency", amount: "1999", currency: "JPY", wantPrice: 1999, wantCurrency: "JPY"},
+		{name: "amount in a three-decimal currency", amount: "1.999", currency: "BHD", wantPrice: 1999, wantCurrency: "BHD"},
+		{name: "fraction in a zero-decimal currency", amount: "19.99", currency: "JPY", wantProblems: []string{"amount"}},
+		{name: "too many decimals for a three-decimal currency", amount: "1.9999", currency: "BHD", wantProblems: []string{"amount"}},
+		{name: "zero amount", amount: "0.00", wantProblems: []string{"amount"}},
+		{name: "amount and price together", price: 19, amount: "19.99", wantProblems: []string{"amount"}},
+		{name: "price in both units", price: 19, priceMinor: 1999, wantProblems: []string{"price_minor"}},
+		{name: "no price", wantProblems: []string{"price"}},
+		{name: "unsupported currency", amount: "19.99", currency: "XYZ", wantProblems: []string{"currency"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var saved *domain.CreateSubscriptionRequest
+			repo := &fakeRepository{
+				create: func(_ context.Context, req *domain.CreateSubscriptionRequest) (*domain.Subscription, error) {
+					saved = req
+					return &domain.Subscription{ID: uuid.New(), ServiceName: req.ServiceName}, nil
+				},
+			}
+			svc := newTestService(repo, config.SubscriptionConfig{}, newFakeClock(testToday))
+
+			_, err := svc.Create(context.Background(), &domain.CreateSubscriptionRequest{
+				ServiceName: "Netflix",
+				Price:       tt.price,
+				PriceMinor:  tt.priceMinor,
+				Amount:      tt.amount,
+				Currency:    tt.currency,
+				UserID:      userID,
+				StartDate:   "2025-01-01",
+			})
+
+			if tt.wantProblems != nil {
+				var problems domain.ValidationErrors
+				if !errors.As(err, &problems) {
+					t.Fatalf("Create error = %v, want validation errors", err)
+				}
+				if got := fields(problems); !reflect.DeepEqual(got, tt.wantProblems) {
+					t.Errorf("problems = %v, want %v", got, tt.wantProblems)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if saved.PriceMinor != tt.wantPrice || saved.Price != 0 || saved.Amount != "" {
+				t.Errorf("saved price_minor = %d, price = %d, amount = %q, want %d and neither other form", saved.PriceMinor, saved.Price, saved.Amount, tt.wantPrice)
+			}
+			if got := createCurrency(saved); got != tt.wantCurrency {
+				t.Errorf("saved currency = %s, want %s", got, tt.wantCurrency)
+			}
+		})
+	}
+}
+
+func TestUpdateAmount(t *testing.T) {
+	id := uuid.New()
+
+	tests := []struct {
+		name         string
+		current      string
+		req          domain.UpdateSubscriptionRequest
+		wantPrice    *int
+		wantProblems []string
+	}{
+		{name: "amount in the stored zero-decimal currency", current: "JPY", req: domain.UpdateSubscriptionRequest{Amount: strPtr("500")}, wantPrice: intPtr(500)},
+		{name: "amount in the stored three-decimal currency", current: "BHD", req: domain.UpdateSubscriptionRequest{Amount: strPtr("0.5")}, wantPrice: intPtr(500)},
+		{name: "amount in the new currency", current: "RUB", req: domain.UpdateSubscriptionRequest{Amount: strPtr("1.250"), Currency: strPtr("KWD")}, wantPrice: intPtr(1250)},
+		{name: "price in whole units of the stored currency", current: "BHD", req: domain.UpdateSubscriptionRequest{Price: intPtr(2)}, wantPrice: intPtr(2000)},
+		{name: "price in whole units of the new currency", current: "RUB", req: domain.UpdateSubscriptionRequest{Price: intPtr(2), Currency: strPtr("JPY")}, wantPrice: intPtr(2)},
+		{name: "price in minor units", current: "RUB", req: domain.UpdateSubscriptionRequest{PriceMinor: intPtr(1999)}, wantPrice: intPtr(1999)},
+		{name: "price and amount together", current: "RUB", req: domain.UpdateSubscriptionRequest{Price: intPtr(19), Amount: strPtr("19.99")}, wantProblems: []string{"amount"}},
+		{name: "fraction in the stored zero-decimal currency", current: "JPY", req: domain.UpdateSubscriptionRequest{Amount: strPtr("5.5")}, wantProblems: []string{"amount"}},
+		{name: "currency without a price", current: "RUB", req: domain.UpdateSubscriptionRequest{Currency: strPtr("USD")}, wantProblems: []string{"currency"}},
+		{name: "other fields leave the price alone", current: "JPY", req: domain.UpdateSubscriptionRequest{ServiceName: strPtr("Hulu")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := &domain.Subscription{ID: id, ServiceName: "Netflix", PriceMinor: 1000, Currency: tt.current, UserID: uuid.New(), StartDate: "2025-01-01"}
+			var saved *domain.UpdateSubscriptionRequest
+			repo := &fakeRepository{
+				getByID: func(context.Context, uuid.UUID) (*domain.Subscription, error) { return current, nil },
+				update: func(_ context.Context, _ uuid.UUID, req *domain.UpdateSubscriptionRequest) (*domain.Subscription, error) {
+					saved = req
+					return current, nil
+				},
+			}
+			svc := newTestService(repo, config.SubscriptionConfig{}, newFakeClock(testToday))
+
+			_, err := svc.Update(context.Background(), id, &tt.req)
+
+			if tt.wantProblems != nil {
+				var problems domain.ValidationErrors
+				if !errors.As(err, &problems) {
+					t.Fatalf("Update error = %v, want validation errors", err)
+				}
+				if got := fields(problems); !reflect.DeepEqual(got, tt.wantProblems) {
+					t.Errorf("problems = %v, want %v", got, tt.wantProblems)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+			if saved.Amount != nil || saved.Price != nil {
+				t.Errorf("saved amount = %v, price = %v, want them replaced by price_minor", saved.Amount, saved.Price)
+			}
+			if !reflect.DeepEqual(saved.PriceMinor, tt.wantPrice) {
+				t.Errorf("saved price_minor = %v, want %v", saved.PriceMinor, tt.wantPrice)
+			}
+		})
+	}
+}
+
+func TestCalculateTotalCostCurrency(t *testing.T) {
+	tests := []struct {
+		name         string
+		currency     *string
+		total        int
+		wantCurrency string
+		wantWhole    int
+		wantAmount   string
+		wantProblems []string
+	}{
+		{name: "default currency", total: 123456, wantCurrency: "RUB", wantWhole: 1234, wantAmount: "1234.56"},
+		{name: "zero-decimal currency", currency: strPtr("JPY"), total: 123456, wantCurrency: "JPY", wantWhole: 123456, wantAmount: "123456"},
+		{name: "three-decimal currency", currency: strPtr("BHD"), total: 123456, wantCurrency: "BHD", wantWhole: 123, wantAmount: "123.456"},
+		{name: "unsupported currency", currency: strPtr("XYZ"), wantProblems: []string{"currency"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var filterCurrency string
+			repo := &fakeRepository{
+				calculateTotalCost: func(_ context.Context, filter *repository.TotalCostFilter) (int, error) {
+					filterCurrency = filter.Currency
+					return tt.total, nil
+				},
+			}
+			svc := newTestService(repo, config.SubscriptionConfig{}, newFakeClock(testToday))
+
+			resp, err := svc.CalculateTotalCost(context.Background(), &domain.TotalCostRequest{
+				StartDate: "2025-01-01",
+				EndDate:   "2025-12-01",
+				Currency:  tt.currency,
+			})
+
+			if tt.wantProblems != nil {
+				var problems domain.ValidationErrors
+				if !errors.As(err, &problems) {
+					t.Fatalf("CalculateTotalCost error = %v, want validation errors", err)
+				}
+				if got := fields(problems); !reflect.DeepEqual(got, tt.wantProblems) {
+					t.Errorf("problems = %v, want %v", got, tt.wantProblems)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CalculateTotalCost: %v", err)
+			}
+			if filterCurrency != tt.wantCurrency {
+				t.Errorf("summed currency = %s, want %s", filterCurrency, tt.wantCurrency)
+			}
+			if resp.Currency != tt.wantCurrency || resp.Amount != tt.wantAmount || resp.TotalCostMinor != tt.total || resp.TotalCost != tt.wantWhole {
+				t.Errorf("response = %+v, want total %d (%d minor) %s (%s)", resp, tt.wantWhole, tt.total, tt.wantCurrency, tt.wantAmount)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"subscription-service/internal/clock"
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// createDeduper remembers recent creates by their content so that an
+// identical create arriving within window returns the subscription made by
+// the first one. A create that is still in flight blocks identical ones
+// until it finishes, so near-simultaneous double submits collapse too.
+//
+// Finished creates are kept at most maxEntries at a time; past that the
+// oldest is dropped first. Entries expire window after their create and a
+// dedup hit does not extend that, so the oldest entry is also the least
+// useful one. In-flight creates are never dropped, which means the map can
+// briefly exceed maxEntries by the number of creates running at once.
+type createDeduper struct {
+	window     time.Duration
+	maxEntries int
+	clock      clock.Clock
+
+	mu      sync.Mutex
+	entries map[string]*recentCreate
+	// finished holds the keys of completed creates, oldest first.
+	finished *list.List
+}
+
+type recentCreate struct {
+	id        uuid.UUID
+	createdAt time.Time
+	done      chan struct{}
+	element   *list.Element
+}
+
+const defaultDedupMaxEntries = 10000
+
+func newCreateDeduper(window time.Duration, maxEntries int, clock clock.Clock) *createDeduper {
+	if maxEntries <= 0 {
+		maxEntries = defaultDedupMaxEntries
+	}
+
+	return &createDeduper{
+		window:     window,
+		maxEntries: maxEntries,
+		clock:      clock,
+		entries:    make(map[string]*recentCreate),
+		finished:   list.New(),
+	}
+}
+
+// createFingerprint identifies a create by everything it would store, so two
+// requests share a fingerprint only when they would create the same
+// subscription. Defaults are filled in, tags are compared as a set and
+// metadata by value, so spelling the same request differently still
+// matches.
+func createFingerprint(req *domain.CreateSubscriptionRequest) string {
+	billingPeriod := req.BillingPeriod
+	if billingPeriod == "" {
+		billingPeriod = domain.DefaultBillingPeriod
+	}
+	currency := req.Currency
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+
+	tags := append([]string{}, req.Tags...)
+	sort.Strings(tags)
+
+	// Validation has already rejected metadata that is not a JSON object;
+	// re-encoding it sorts the keys and drops insignificant whitespace.
+	metadata := map[string]interface{}{}
+	if len(req.Metadata) > 0 {
+		_ = json.Unmarshal(req.Metadata, &metadata)
+	}
+
+	key, _ := json.Marshal(struct {
+		UserID        uuid.UUID              `json:"user_id"`
+		ServiceName   string                 `json:"service_name"`
+		PriceMinor    int                    `json:"price_minor"`
+		Currency      string                 `json:"currency"`
+		BillingPeriod string                 `json:"billing_period"`
+		StartDate     string                 `json:"start_date"`
+		EndDate       *string                `json:"end_date"`
+		AutoRenew     bool                   `json:"auto_renew"`
+		Tags          []string               `json:"tags"`
+		Metadata      map[string]interface{} `json:"metadata"`
+	}{
+		UserID:        req.UserID,
+		ServiceName:   req.ServiceName,
+		PriceMinor:    req.PriceMinor,
+		Currency:      currency,
+		BillingPeriod: billingPeriod,
+		StartDate:     req.StartDate,
+		EndDate:       req.EndDate,
+		AutoRenew:     req.AutoRenew,
+		Tags:          tags,
+		Metadata:      metadata,
+	})
+	return string(key)
+}
+
+// claim returns the id of a recent identical create, or reserves key for the
+// caller, who must then call finish with the outcome.
+func (d *createDeduper) claim(ctx context.Context, key string) (uuid.UUID, bool, error) {
+	for {
+		d.mu.Lock()
+		d.evictExpiredLocked()
+
+		entry, ok := d.entries[key]
+		if !ok {
+			for len(d.entries) >= d.maxEntries && d.finished.Len() > 0 {
+				d.removeLocked(d.finished.Front().Value.(string))
+			}
+			d.entries[key] = &recentCreate{done: make(chan struct{})}
+			d.mu.Unlock()
+			return uuid.UUID{}, false, nil
+		}
+		d.mu.Unlock()
+
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return uuid.UUID{}, false, ctx.Err()
+		}
+
+		d.mu.Lock()
+		current, ok := d.entries[key]
+		d.mu.Unlock()
+		if ok && current == entry {
+			return entry.id, true, nil
+		}
+		// The earlier create failed and released the key; try to claim it.
+	}
+}
+
+// finish records the subscription created for key, or releases the claim
+// when the create failed so a retry is not deduplicated against nothing.
+func (d *createDeduper) finish(key string, id *uuid.UUID) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry, ok := d.entries[key]
+	if !ok {
+		return
+	}
+
+	if id == nil {
+		delete(d.entries, key)
+	} else {
+		entry.id = *id
+		entry.createdAt = d.clock.Now()
+		entry.element = d.finished.PushBack(key)
+	}
+	close(entry.done)
+}
+
+// forget drops key, used when the remembered subscription no longer exists.
+func (d *createDeduper) forget(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.removeLocked(key)
+}
+
+func (d *createDeduper) removeLocked(key string) {
+	entry, ok := d.entries[key]
+	if !ok {
+		return
+	}
+	if entry.element != nil {
+		d.finished.Remove(entry.element)
+	}
+	delete(d.entries, key)
+}
+
+// evictExpiredLocked drops finished entries older than the window. They are
+// kept oldest first, so it stops at the first one still fresh.
+func (d *createDeduper) evictExpiredLocked() {
+	cutoff := d.clock.Now().Add(-d.window)
+	for front := d.finished.Front(); front != nil; front = d.finished.Front() {
+		key := front.Value.(string)
+		if !d.entries[key].createdAt.Before(cutoff) {
+			return
+		}
+		d.removeLocked(key)
+	}
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// createStore is a fakeRepository backing that keeps every created
+// subscription, so dedup hits can be read back by id.
+type createStore struct {
+	mu      sync.Mutex
+	created map[uuid.UUID]*domain.Subscription
+}
+
+func newCreateStore() (*createStore, *fakeRepository) {
+	store := &createStore{created: make(map[uuid.UUID]*domain.Subscription)}
+	return store, &fakeRepository{
+		create: func(_ context.Context, req *domain.CreateSubscriptionRequest) (*domain.Subscription, error) {
+			store.mu.Lock()
+			defer store.mu.Unlock()
+			subscription := &domain.Subscription{ID: uuid.New(), ServiceName: req.ServiceName, UserID: req.UserID}
+			store.created[subscription.ID] = subscription
+			return subscription, nil
+		},
+		getByID: func(_ context.Context, id uuid.UUID) (*domain.Subscription, error) {
+			store.mu.Lock()
+			defer store.mu.Unlock()
+			if subscription, ok := store.created[id]; ok {
+				return subscription, nil
+			}
+			return nil, domain.ErrSubscriptionNotFound
+		},
+	}
+}
+
+func (s *createStore) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.created)
+}
+
+func testCreateRequest(userID uuid.UUID) domain.CreateSubscriptionRequest {
+	return domain.CreateSubscriptionRequest{
+		ServiceName: "Netflix",
+		PriceMinor:  40000,
+		UserID:      userID,
+		StartDate:   "2025-01-01",
+		Tags:        []string{"family", "video"},
+		Metadata:    json.RawMessage(`{"plan":"premium","seats":4}`),
+	}
+}
+
+func TestCreateFingerprint(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   func(req *domain.CreateSubscriptionRequest)
+		wantSame bool
+	}{
+		{name: "identical", change: func(*domain.CreateSubscriptionRequest) {}, wantSame: true},
+		{name: "default billing period spelled out", change: func(req *domain.CreateSubscriptionRequest) { req.BillingPeriod = domain.DefaultBillingPeriod }, wantSame: true},
+		{name: "default currency spelled out", change: func(req *domain.CreateSubscriptionRequest) { req.Currency = domain.DefaultCurrency }, wantSame: true},
+		{name: "tags in another order", change: func(req *domain.CreateSubscriptionRequest) { req.Tags = []string{"video", "family"} }, wantSame: true},
+		{name: "metadata keys in another order", change: func(req *domain.CreateSubscriptionRequest) {
+			req.Metadata = json.RawMessage(`{ "seats": 4, "plan": "premium" }`)
+		}, wantSame: true},
+		{name: "price", change: func(req *domain.CreateSubscriptionRequest) { req.PriceMinor = 500 }},
+		{name: "currency", change: func(req *domain.CreateSubscriptionRequest) { req.Currency = "USD" }},
+		{name: "billing period", change: func(req *domain.CreateSubscriptionRequest) { req.BillingPeriod = domain.BillingPeriodYearly }},
+		{name: "end date", change: func(req *domain.CreateSubscriptionRequest) { req.EndDate = strPtr("2025-12-01") }},
+		{name: "auto renew", change: func(req *domain.CreateSubscriptionRequest) { req.AutoRenew = true }},
+		{name: "tags", change: func(req *domain.CreateSubscriptionRequest) { req.Tags = []string{"family"} }},
+		{name: "metadata", change: func(req *domain.CreateSubscriptionRequest) {
+			req.Metadata = json.RawMessage(`{"plan":"basic","seats":4}`)
+		}},
+		{name: "no metadata", change: func(req *domain.CreateSubscriptionRequest) { req.Metadata = nil }},
+	}
+
+	userID := uuid.New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, other := testCreateRequest(userID), testCreateRequest(userID)
+			tt.change(&other)
+
+			if same := createFingerprint(&base) == createFingerprint(&other); same != tt.wantSame {
+				t.Errorf("same fingerprint = %v, want %v", same, tt.wantSame)
+			}
+		})
+	}
+}
+
+func TestCreateDedupWindow(t *testing.T) {
+	const window = time.Minute
+
+	tests := []struct {
+		name        string
+		after       time.Duration
+		change      func(req *domain.CreateSubscriptionRequest)
+		wantCreates int
+	}{
+		{name: "repeat inside the window", after: window / 2, wantCreates: 1},
+		{name: "repeat at the end of the window", after: window, wantCreates: 1},
+		{name: "repeat after the window", after: window + time.Second, wantCreates: 2},
+		{name: "different billing period inside the window", after: time.Second, change: func(req *domain.CreateSubscriptionRequest) {
+			req.BillingPeriod = domain.BillingPeriodYearly
+		}, wantCreates: 2},
+		{name: "different metadata inside the window", after: time.Second, change: func(req *domain.CreateSubscriptionRequest) {
+			req.Metadata = json.RawMessage(`{"plan":"basic"}`)
+		}, wantCreates: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, repo := newCreateStore()
+			clock := newFakeClock(testToday)
+			svc := newTestService(repo, config.SubscriptionConfig{DedupWindow: window}, clock)
+			userID := uuid.New()
+
+			first := testCreateRequest(userID)
+			created, err := svc.Create(context.Background(), &first)
+			if err != nil {
+				t.Fatalf("first Create: %v", err)
+			}
+
+			clock.Advance(tt.after)
+			second := testCreateRequest(userID)
+			if tt.change != nil {
+				tt.change(&second)
+			}
+			repeated, err := svc.Create(context.Background(), &second)
+			if err != nil {
+				t.Fatalf("second Create: %v", err)
+			}
+
+			if got := store.count(); got != tt.wantCreates {
+				t.Errorf("created %d subscriptions, want %d", got, tt.wantCreates)
+			}
+			if deduped := repeated.ID == created.ID; deduped != (tt.wantCreates == 1) {
+				t.Errorf("second create returned the first subscription = %v, want %v", deduped, tt.wantCreates == 1)
+			}
+		})
+	}
+}
+
+func TestCreateDeduperConcurrent(t *testing.T) {
+	const (
+		maxEntries = 50
+		workers    = 16
+		perWorker  = 500
+	)
+
+	tests := []struct {
+		name string
+		// keys is how many distinct keys the workers cycle through.
+		keys int
+	}{
+		{name: "few keys, mostly dedup hits", keys: 10},
+		{name: "more keys than fit", keys: 2000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock(testToday)
+			d := newCreateDeduper(time.Hour, maxEntries, clock)
+
+			// issued maps every id handed out to the key it was created for; a key
+			// evicted and claimed again gets a new id.
+			var (
+				mu     sync.Mutex
+				issued = make(map[uuid.UUID]string)
+				wg     sync.WaitGroup
+			)
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := 0; i < perWorker; i++ {
+						key := fmt.Sprintf("key-%d", (w*perWorker+i)%tt.keys)
+						id, found, err := d.claim(context.Background(), key)
+						if err != nil {
+							t.Errorf("claim(%s): %v", key, err)
+							return
+						}
+						if found {
+							mu.Lock()
+							issuedFor := issued[id]
+							mu.Unlock()
+							if issuedFor != key {
+								t.Errorf("claim(%s) = %s, which was created for %q", key, id, issuedFor)
+							}
+							continue
+						}
+
+						id = uuid.New()
+						mu.Lock()
+						issued[id] = key
+						mu.Unlock()
+						d.finish(key, &id)
+
+						d.mu.Lock()
+						size := len(d.entries)
+						d.mu.Unlock()
+						// Each worker holds at most one claim at a time.
+						if size > maxEntries+workers {
+							t.Errorf("dedup map holds %d entries, want at most %d", size, maxEntries+workers)
+						}
+					}
+				}(w)
+			}
+			wg.Wait()
+
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			if len(d.entries) > maxEntries {
+				t.Errorf("dedup map holds %d entries after the creates finished, want at most %d", len(d.entries), maxEntries)
+			}
+			if d.finished.Len() != len(d.entries) {
+				t.Errorf("eviction list has %d keys for %d entries", d.finished.Len(), len(d.entries))
+			}
+		})
+	}
+}
+
+func TestCreateDeduperCollapsesSimultaneousCreates(t *testing.T) {
+	const callers = 32
+
+	d := newCreateDeduper(time.Hour, 0, newFakeClock(testToday))
+	created := uuid.New()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		claims  int
+		results = make([]uuid.UUID, callers)
+		start   = make(chan struct{})
+	)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			id, found, err := d.claim(context.Background(), "key")
+			if err != nil {
+				t.Errorf("claim: %v", err)
+				return
+			}
+			if !found {
+				mu.Lock()
+				claims++
+				mu.Unlock()
+				// Hold the claim briefly so the others queue behind it.
+				time.Sleep(10 * time.Millisecond)
+				id = created
+				d.finish("key", &id)
+			}
+			results[i] = id
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	if claims != 1 {
+		t.Errorf("%d callers created, want 1", claims)
+	}
+	for i, id := range results {
+		if id != created {
+			t.Errorf("caller %d got %s, want %s", i, id, created)
+		}
+	}
+}
+
+func TestCreateDedupForgetsDeletedSubscription(t *testing.T) {
+	store, repo := newCreateStore()
+	svc := newTestService(repo, config.SubscriptionConfig{DedupWindow: time.Minute}, newFakeClock(testToday))
+	userID := uuid.New()
+
+	first := testCreateRequest(userID)
+	deleted, err := svc.Create(context.Background(), &first)
+	if err != nil {
+		t.Fatalf("first Create: %v", err)
+	}
+	store.mu.Lock()
+	delete(store.created, deleted.ID)
+	store.mu.Unlock()
+
+	second := testCreateRequest(userID)
+	recreated, err := svc.Create(context.Background(), &second)
+	if err != nil {
+		t.Fatalf("second Create: %v", err)
+	}
+	if recreated.ID == deleted.ID {
+		t.Fatal("second create returned the deleted subscription")
+	}
+
+	// The new subscription is remembered in place of the deleted one.
+	third := testCreateRequest(userID)
+	repeated, err := svc.Create(context.Background(), &third)
+	if err != nil {
+		t.Fatalf("third Create: %v", err)
+	}
+	if repeated.ID != recreated.ID {
+		t.Errorf("third create returned %s, want the recreated %s", repeated.ID, recreated.ID)
+	}
+	if got := store.count(); got != 1 {
+		t.Errorf("store holds %d subscriptions, want 1", got)
+	}
+}
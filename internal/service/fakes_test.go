@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// fakeClock is a clock.Clock that only moves when told to.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// fakeRepository implements the repository methods a test sets; calling any
+// other method panics on the nil embedded interface.
+type fakeRepository struct {
+	repository.SubscriptionRepository
+
+	create             func(ctx context.Context, req *domain.CreateSubscriptionRequest) (*domain.Subscription, error)
+	getByID            func(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)
+	update             func(ctx context.Context, id uuid.UUID, req *domain.UpdateSubscriptionRequest) (*domain.Subscription, error)
+	updateIfUnmodified func(ctx context.Context, id uuid.UUID, updatedAt time.Time, req *domain.UpdateSubscriptionRequest) (*domain.Subscription, error)
+	findOverlapping    func(ctx context.Context, filter *repository.OverlapFilter) ([]uuid.UUID, error)
+	calculateTotalCost func(ctx context.Context, filter *repository.TotalCostFilter) (int, error)
+	costByService      func(ctx context.Context, filter *repository.TotalCostBreakdownFilter) ([]domain.ServiceCost, int64, error)
+	list               func(ctx context.Context, filter *repository.ListSubscriptionsFilter) ([]*domain.Subscription, int64, error)
+	countActive        func(ctx context.Context, filter *repository.ListSubscriptionsFilter, asOf string) (int64, error)
+	delete             func(ctx context.Context, id uuid.UUID) error
+	listServiceNames   func(ctx context.Context) ([]string, error)
+	purgeDeleted       func(ctx context.Context, cutoff time.Time, batchSize int) (int64, error)
+	streamAll          func(ctx context.Context, filter *repository.StreamFilter, fn func(*domain.Subscription) error) error
+	renew              func(ctx context.Context, id uuid.UUID, currentEndDate string) (*domain.Subscription, error)
+}
+
+func (r *fakeRepository) Create(ctx context.Context, req *domain.CreateSubscriptionRequest) (*domain.Subscription, error) {
+	return r.create(ctx, req)
+}
+
+func (r *fakeRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	return r.getByID(ctx, id)
+}
+
+func (r *fakeRepository) Update(ctx context.Context, id uuid.UUID, req *domain.UpdateSubscriptionRequest) (*domain.Subscription, error) {
+	return r.update(ctx, id, req)
+}
+
+func (r *fakeRepository) UpdateIfUnmodified(ctx context.Context, id uuid.UUID, updatedAt time.Time, req *domain.UpdateSubscriptionRequest) (*domain.Subscription, error) {
+	return r.updateIfUnmodified(ctx, id, updatedAt, req)
+}
+
+func (r *fakeRepository) CalculateTotalCost(ctx context.Context, filter *repository.TotalCostFilter) (int, error) {
+	return r.calculateTotalCost(ctx, filter)
+}
+
+func (r *fakeRepository) CalculateTotalCostByService(ctx context.Context, filter *repository.TotalCostBreakdownFilter) ([]domain.ServiceCost, int64, error) {
+	return r.costByService(ctx, filter)
+}
+
+func (r *fakeRepository) List(ctx context.Context, filter *repository.ListSubscriptionsFilter) ([]*domain.Subscription, int64, error) {
+	return r.list(ctx, filter)
+}
+
+func (r *fakeRepository) CountActive(ctx context.Context, filter *repository.ListSubscriptionsFilter, asOf string) (int64, error) {
+	return r.countActive(ctx, filter, asOf)
+}
+
+func (r *fakeRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.delete(ctx, id)
+}
+
+func (r *fakeRepository) ListServiceNames(ctx context.Context) ([]string, error) {
+	return r.listServiceNames(ctx)
+}
+
+func (r *fakeRepository) PurgeDeleted(ctx context.Context, cutoff time.Time, batchSize int) (int64, error) {
+	return r.purgeDeleted(ctx, cutoff, batchSize)
+}
+
+func (r *fakeRepository) StreamAll(ctx context.Context, filter *repository.StreamFilter, fn func(*domain.Subscription) error) error {
+	return r.streamAll(ctx, filter, fn)
+}
+
+func (r *fakeRepository) Renew(ctx context.Context, id uuid.UUID, currentEndDate string) (*domain.Subscription, error) {
+	return r.renew(ctx, id, currentEndDate)
+}
+
+func (r *fakeRepository) FindOverlapping(ctx context.Context, filter *repository.OverlapFilter) ([]uuid.UUID, error) {
+	if r.findOverlapping == nil {
+		return nil, nil
+	}
+	return r.findOverlapping(ctx, filter)
+}
+
+var testToday = time.Date(2025, time.March, 15, 12, 0, 0, 0, time.UTC)
+
+func newTestService(repo repository.SubscriptionRepository, cfg config.SubscriptionConfig, clock *fakeClock) *subscriptionService {
+	logger := zap.NewNop()
+	validator := NewSubscriptionValidator(repo, cfg, logger)
+	return NewSubscriptionService(repo, validator, cfg, clock, logger).(*subscriptionService)
+}
+
+func strPtr(s string) *string { return &s }
+
+func intPtr(i int) *int { return &i }
+
+// fields lists the field of every error, for comparing problem lists
+// without depending on message wording.
+func fields(errs []domain.FieldError) []string {
+	out := make([]string, len(errs))
+	for i, e := range errs {
+		out[i] = e.Field
+	}
+	return out
+}
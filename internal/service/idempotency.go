@@ -0,0 +1,166 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"subscription-service/internal/clock"
+	"subscription-service/internal/domain"
+)
+
+const (
+	defaultBatchIdempotencyTTL     = 24 * time.Hour
+	defaultBatchIdempotencyMaxKeys = 1000
+)
+
+// batchIdempotency remembers bulk create responses by Idempotency-Key so a
+// retried batch gets the original response instead of running again. Each
+// key is bound to the fingerprint of the request it was first used with;
+// reusing it for a different request is an error rather than a replay. A
+// batch still in flight blocks retries with its key until it finishes.
+//
+// At most maxKeys keys are held at a time. Like createDeduper, finished
+// entries are dropped oldest first to make room and expire ttl after they
+// were stored. In-flight entries are never dropped, so once every key held
+// is in flight a new one is refused with domain.ErrIdempotencyKeysExhausted.
+type batchIdempotency struct {
+	ttl     time.Duration
+	maxKeys int
+	clock   clock.Clock
+
+	mu      sync.Mutex
+	entries map[string]*batchReplay
+	// finished holds the keys of completed batches, oldest first.
+	finished *list.List
+}
+
+type batchReplay struct {
+	fingerprint string
+	response    *domain.BatchResponse
+	storedAt    time.Time
+	done        chan struct{}
+	element     *list.Element
+}
+
+func newBatchIdempotency(ttl time.Duration, maxKeys int, clock clock.Clock) *batchIdempotency {
+	if ttl <= 0 {
+		ttl = defaultBatchIdempotencyTTL
+	}
+	if maxKeys <= 0 {
+		maxKeys = defaultBatchIdempotencyMaxKeys
+	}
+
+	return &batchIdempotency{
+		ttl:      ttl,
+		maxKeys:  maxKeys,
+		clock:    clock,
+		entries:  make(map[string]*batchReplay),
+		finished: list.New(),
+	}
+}
+
+func batchFingerprint(req *domain.BatchCreateRequest) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// claim returns the stored response for key, or reserves key for the caller,
+// who must then call finish, whether or not a response was produced. A
+// caller waiting on a batch that ends without one claims the key afresh.
+func (b *batchIdempotency) claim(ctx context.Context, key, fingerprint string) (*domain.BatchResponse, bool, error) {
+	for {
+		response, found, err := b.tryClaim(ctx, key, fingerprint)
+		if err != nil || !found || response != nil {
+			return response, found, err
+		}
+	}
+}
+
+// tryClaim is one round of claim. It reports found with a nil response when
+// the batch it waited on was abandoned.
+func (b *batchIdempotency) tryClaim(ctx context.Context, key, fingerprint string) (*domain.BatchResponse, bool, error) {
+	b.mu.Lock()
+	b.evictExpiredLocked()
+
+	entry, ok := b.entries[key]
+	if !ok {
+		for len(b.entries) >= b.maxKeys && b.finished.Len() > 0 {
+			b.removeLocked(b.finished.Front().Value.(string))
+		}
+		if len(b.entries) >= b.maxKeys {
+			b.mu.Unlock()
+			return nil, false, domain.ErrIdempotencyKeysExhausted
+		}
+		b.entries[key] = &batchReplay{fingerprint: fingerprint, done: make(chan struct{})}
+		b.mu.Unlock()
+		return nil, false, nil
+	}
+	b.mu.Unlock()
+
+	if entry.fingerprint != fingerprint {
+		return nil, false, domain.ErrIdempotencyKeyReused
+	}
+
+	select {
+	case <-entry.done:
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+	return entry.response, true, nil
+}
+
+// finish stores the response for a claimed key and releases anyone waiting
+// on it. A nil response abandons the claim: nothing is stored and the key is
+// free to be claimed again.
+func (b *batchIdempotency) finish(key string, response *domain.BatchResponse) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry, ok := b.entries[key]
+	if !ok {
+		return
+	}
+	if response == nil {
+		delete(b.entries, key)
+		close(entry.done)
+		return
+	}
+
+	entry.response = response
+	entry.storedAt = b.clock.Now()
+	entry.element = b.finished.PushBack(key)
+	close(entry.done)
+}
+
+func (b *batchIdempotency) removeLocked(key string) {
+	entry, ok := b.entries[key]
+	if !ok {
+		return
+	}
+	if entry.element != nil {
+		b.finished.Remove(entry.element)
+	}
+	delete(b.entries, key)
+}
+
+// evictExpiredLocked drops finished entries older than the ttl, stopping at
+// the first one still fresh.
+func (b *batchIdempotency) evictExpiredLocked() {
+	cutoff := b.clock.Now().Add(-b.ttl)
+	for front := b.finished.Front(); front != nil; front = b.finished.Front() {
+		key := front.Value.(string)
+		if !b.entries[key].storedAt.Before(cutoff) {
+			return
+		}
+		b.removeLocked(key)
+	}
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestBatchIdempotencyConcurrent(t *testing.T) {
+	const (
+		workers   = 16
+		perWorker = 300
+	)
+
+	b := newBatchIdempotency(0, 0, newFakeClock(testToday))
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				// Neighbouring workers share keys, so some claims replay.
+				key := fmt.Sprintf("key-%d", (w/2)*perWorker+i)
+				response, found, err := b.claim(context.Background(), key, "fingerprint")
+				if err != nil {
+					t.Errorf("claim(%s): %v", key, err)
+					return
+				}
+				if found {
+					if response == nil || response.Succeeded != 1 {
+						t.Errorf("claim(%s) replayed %+v, want the stored response", key, response)
+					}
+					continue
+				}
+				b.finish(key, &domain.BatchResponse{Succeeded: 1})
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.entries) > b.maxKeys {
+		t.Errorf("idempotency map holds %d keys, want at most %d", len(b.entries), b.maxKeys)
+	}
+	if b.finished.Len() != len(b.entries) {
+		t.Errorf("eviction list has %d keys for %d entries", b.finished.Len(), len(b.entries))
+	}
+}
+
+func TestBatchIdempotencyCapsInFlightKeys(t *testing.T) {
+	b := newBatchIdempotency(0, 2, newFakeClock(testToday))
+	ctx := context.Background()
+
+	for _, key := range []string{"a", "b"} {
+		if _, _, err := b.claim(ctx, key, "fingerprint"); err != nil {
+			t.Fatalf("claim(%s): %v", key, err)
+		}
+	}
+
+	// Both keys are in flight, so there is nothing to evict.
+	if _, _, err := b.claim(ctx, "c", "fingerprint"); !errors.Is(err, domain.ErrIdempotencyKeysExhausted) {
+		t.Fatalf("claim past the cap = %v, want %v", err, domain.ErrIdempotencyKeysExhausted)
+	}
+
+	// A finished batch makes room: its key is the one dropped.
+	b.finish("a", &domain.BatchResponse{Succeeded: 1})
+	if _, found, err := b.claim(ctx, "c", "fingerprint"); err != nil || found {
+		t.Fatalf("claim after a batch finished = found %v, %v", found, err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.entries["a"]; ok || len(b.entries) != 2 {
+		t.Errorf("entries = %v, want b and c", b.entries)
+	}
+}
+
+func TestBatchIdempotencyAbandonedClaim(t *testing.T) {
+	b := newBatchIdempotency(0, 0, newFakeClock(testToday))
+	ctx := context.Background()
+
+	if _, _, err := b.claim(ctx, "key", "fingerprint"); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+
+	// A retry waiting on the key takes it over once the claim is abandoned.
+	type result struct {
+		found bool
+		err   error
+	}
+	retried := make(chan result)
+	go func() {
+		_, found, err := b.claim(ctx, "key", "fingerprint")
+		retried <- result{found, err}
+	}()
+
+	b.finish("key", nil)
+	if got := <-retried; got.err != nil || got.found {
+		t.Fatalf("retry after an abandoned claim = found %v, %v, want a fresh claim", got.found, got.err)
+	}
+
+	b.finish("key", &domain.BatchResponse{Succeeded: 1})
+	response, found, err := b.claim(ctx, "key", "fingerprint")
+	if err != nil || !found || response.Succeeded != 1 {
+		t.Errorf("claim after the retry finished = %+v, found %v, %v, want its response", response, found, err)
+	}
+}
+
+func TestCreateIdempotentReleasesKeyOnPanic(t *testing.T) {
+	store, repo := newCreateStore()
+	create := repo.create
+	panicked := false
+	repo.create = func(ctx context.Context, req *domain.CreateSubscriptionRequest) (*domain.Subscription, error) {
+		if !panicked {
+			panicked = true
+			panic("driver bug")
+		}
+		return create(ctx, req)
+	}
+
+	clock := newFakeClock(testToday)
+	s := NewBatchService(newTestService(repo, config.SubscriptionConfig{}, clock), 0, 0, clock, zap.NewNop())
+	req := &domain.BatchCreateRequest{Items: []domain.CreateSubscriptionRequest{testCreateRequest(uuid.New())}}
+
+	func() {
+		// Stands in for the recovery middleware keeping the server up.
+		defer func() {
+			if recover() == nil {
+				t.Fatal("first CreateIdempotent did not panic")
+			}
+		}()
+		s.CreateIdempotent(context.Background(), "key", req)
+	}()
+
+	response, replayed, err := s.CreateIdempotent(context.Background(), "key", req)
+	if err != nil || replayed {
+		t.Fatalf("retry after a panic = replayed %v, %v, want a fresh run", replayed, err)
+	}
+	if response.Succeeded != 1 || store.count() != 1 {
+		t.Errorf("retry succeeded %d items and created %d subscriptions, want 1 and 1", response.Succeeded, store.count())
+	}
+}
+
+func TestCreateIdempotentReplay(t *testing.T) {
+	userID := uuid.New()
+	batch := func(services ...string) *domain.BatchCreateRequest {
+		req := &domain.BatchCreateRequest{}
+		for _, service := range services {
+			item := testCreateRequest(userID)
+			item.ServiceName = service
+			req.Items = append(req.Items, item)
+		}
+		return req
+	}
+
+	tests := []struct {
+		name         string
+		dedupWindow  time.Duration
+		firstKey     string
+		first        *domain.BatchCreateRequest
+		secondKey    string
+		second       *domain.BatchCreateRequest
+		wantReplayed bool
+		wantErr      error
+		wantCreates  int
+	}{
+		{
+			name:         "same key and batch replays",
+			firstKey:     "k1",
+			first:        batch("Netflix", "Spotify"),
+			secondKey:    "k1",
+			second:       batch("Netflix", "Spotify"),
+			wantReplayed: true,
+			wantCreates:  2,
+		},
+		{
+			name:         "replay keeps failed items failed",
+			firstKey:     "k1",
+			first:        batch("Netflix", ""),
+			secondKey:    "k1",
+			second:       batch("Netflix", ""),
+			wantReplayed: true,
+			wantCreates:  1,
+		},
+		{
+			name:        "same key with another batch is refused",
+			firstKey:    "k1",
+			first:       batch("Netflix"),
+			secondKey:   "k1",
+			second:      batch("Spotify"),
+			wantErr:     domain.ErrIdempotencyKeyReused,
+			wantCreates: 1,
+		},
+		{
+			name:        "new key runs the batch again",
+			firstKey:    "k1",
+			first:       batch("Netflix"),
+			secondKey:   "k2",
+			second:      batch("Netflix"),
+			wantCreates: 2,
+		},
+		{
+			name:        "new key with create dedup returns the first subscriptions",
+			dedupWindow: time.Minute,
+			firstKey:    "k1",
+			first:       batch("Netflix", "Spotify"),
+			secondKey:   "k2",
+			second:      batch("Netflix", "Spotify"),
+			wantCreates: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, repo := newCreateStore()
+			clock := newFakeClock(testToday)
+			subscriptions := newTestService(repo, config.SubscriptionConfig{DedupWindow: tt.dedupWindow}, clock)
+			s := NewBatchService(subscriptions, 0, 0, clock, zap.NewNop())
+
+			first, replayed, err := s.CreateIdempotent(context.Background(), tt.firstKey, tt.first)
+			if err != nil || replayed {
+				t.Fatalf("first CreateIdempotent = replayed %v, %v", replayed, err)
+			}
+
+			second, replayed, err := s.CreateIdempotent(context.Background(), tt.secondKey, tt.second)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("second CreateIdempotent error = %v, want %v", err, tt.wantErr)
+			}
+			if replayed != tt.wantReplayed {
+				t.Errorf("replayed = %v, want %v", replayed, tt.wantReplayed)
+			}
+			if tt.wantReplayed && !reflect.DeepEqual(second, first) {
+				t.Errorf("replay = %+v, want the original response %+v", second, first)
+			}
+			if got := store.count(); got != tt.wantCreates {
+				t.Errorf("created %d subscriptions, want %d", got, tt.wantCreates)
+			}
+		})
+	}
+}
+
+func TestCreateIdempotentConcurrentReplays(t *testing.T) {
+	const retries = 16
+
+	store, repo := newCreateStore()
+	clock := newFakeClock(testToday)
+	s := NewBatchService(newTestService(repo, config.SubscriptionConfig{}, clock), 0, 0, clock, zap.NewNop())
+
+	userID := uuid.New()
+	req := &domain.BatchCreateRequest{Items: []domain.CreateSubscriptionRequest{testCreateRequest(userID), testCreateRequest(uuid.New())}}
+
+	var (
+		wg        sync.WaitGroup
+		responses = make([]*domain.BatchResponse, retries)
+		start     = make(chan struct{})
+	)
+	for i := 0; i < retries; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			response, _, err := s.CreateIdempotent(context.Background(), "key", req)
+			if err != nil {
+				t.Errorf("CreateIdempotent: %v", err)
+				return
+			}
+			responses[i] = response
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	if got := store.count(); got != len(req.Items) {
+		t.Errorf("created %d subscriptions, want %d", got, len(req.Items))
+	}
+	for i, response := range responses {
+		if !reflect.DeepEqual(response, responses[0]) {
+			t.Errorf("retry %d got %+v, want %+v", i, response, responses[0])
+		}
+	}
+}
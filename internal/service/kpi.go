@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"subscription-service/internal/clock"
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+type KPIService interface {
+	// Snapshot computes the business metrics as of now, counting new and
+	// churned subscriptions over the window that ends now.
+	Snapshot(ctx context.Context, window time.Duration) (*domain.KPISnapshot, error)
+}
+
+type kpiService struct {
+	repo   repository.SubscriptionRepository
+	clock  clock.Clock
+	logger *zap.Logger
+}
+
+func NewKPIService(repo repository.SubscriptionRepository, clock clock.Clock, logger *zap.Logger) KPIService {
+	return &kpiService{
+		repo:   repo,
+		clock:  clock,
+		logger: logger,
+	}
+}
+
+func (s *kpiService) Snapshot(ctx context.Context, window time.Duration) (*domain.KPISnapshot, error) {
+	now := s.clock.Now().UTC()
+	return s.repo.GetKPIs(ctx, now, now.Add(-window))
+}
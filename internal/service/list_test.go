@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository"
+)
+
+func TestCountActiveUsesListFilters(t *testing.T) {
+	var listed, counted *repository.ListSubscriptionsFilter
+	var countedAsOf string
+	repo := &fakeRepository{
+		list: func(_ context.Context, filter *repository.ListSubscriptionsFilter) ([]*domain.Subscription, int64, error) {
+			listed = filter
+			return nil, 0, nil
+		},
+		countActive: func(_ context.Context, filter *repository.ListSubscriptionsFilter, asOf string) (int64, error) {
+			counted = filter
+			countedAsOf = asOf
+			return 0, nil
+		},
+	}
+	svc := newTestService(repo, config.SubscriptionConfig{}, newFakeClock(testToday))
+
+	openEnded := true
+	req := domain.ListSubscriptionsRequest{
+		UserID:            []string{"60601fee-2bf1-4721-ae6f-7636e79a0cba", "7f3b5c1e-9d2a-4e8b-b6c4-2a1d0e9f8c7b"},
+		ServiceName:       []string{"netflix", "spotify"},
+		ServiceNamePrefix: strPtr("aws:"),
+		MinPrice:          intPtr(100),
+		MaxPrice:          intPtr(900),
+		ActiveFrom:        strPtr("2025-01-01"),
+		ActiveTo:          strPtr("2025-12-31"),
+		Tag:               strPtr("work"),
+		OpenEnded:         &openEnded,
+		Metadata:          map[string]string{"team": "infra"},
+		Limit:             5,
+		Offset:            10,
+		WithActiveCount:   true,
+	}
+
+	listReq, countReq := req, req
+	if _, _, err := svc.List(context.Background(), &listReq); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if _, err := svc.CountActive(context.Background(), &countReq); err != nil {
+		t.Fatalf("CountActive: %v", err)
+	}
+
+	// The count covers every page, so paging is the only difference
+	// allowed between the two filters.
+	want := *listed
+	want.Limit, want.Offset = counted.Limit, counted.Offset
+	if !reflect.DeepEqual(*counted, want) {
+		t.Errorf("CountActive filter = %+v, want the List filter %+v", *counted, *listed)
+	}
+	if countedAsOf != "2025-03-15" {
+		t.Errorf("asOf = %q, want 2025-03-15", countedAsOf)
+	}
+}
+
+func TestMetadataFilterValues(t *testing.T) {
+	got := metadataFilterValues(map[string]string{
+		"seats":   "4",
+		"trial":   "true",
+		"team":    "infra",
+		"ref":     "007",
+		"quoted":  `"4"`,
+		"nothing": "null",
+	})
+	want := map[string]interface{}{
+		"seats":   float64(4),
+		"trial":   true,
+		"team":    "infra",
+		"ref":     "007",
+		"quoted":  `"4"`,
+		"nothing": "null",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("metadataFilterValues = %#v, want %#v", got, want)
+	}
+}
+
+func TestListPageDepth(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxOffset    int
+		limit        int
+		offset       int
+		wantLimit    int
+		wantMax      int
+		wantProblems []string
+	}{
+		{name: "just under the configured maximum", maxOffset: 1000, offset: 999, wantLimit: 20},
+		{name: "at the configured maximum", maxOffset: 1000, offset: 1000, wantLimit: 20},
+		{name: "just over the configured maximum", maxOffset: 1000, offset: 1001, wantMax: 1000},
+		{name: "at the default maximum", offset: defaultMaxOffset, wantLimit: 20},
+		{name: "over the default maximum", offset: defaultMaxOffset + 1, wantMax: defaultMaxOffset},
+		{name: "limit is capped", limit: 1000, wantLimit: 100},
+		{name: "negative limit", limit: -1, wantProblems: []string{"limit"}},
+		{name: "negative offset", offset: -1, wantProblems: []string{"offset"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var listed *repository.ListSubscriptionsFilter
+			repo := &fakeRepository{
+				list: func(_ context.Context, filter *repository.ListSubscriptionsFilter) ([]*domain.Subscription, int64, error) {
+					listed = filter
+					return nil, 0, nil
+				},
+			}
+			svc := newTestService(repo, config.SubscriptionConfig{MaxOffset: tt.maxOffset}, newFakeClock(testToday))
+
+			_, _, err := svc.List(context.Background(), &domain.ListSubscriptionsRequest{Limit: tt.limit, Offset: tt.offset})
+
+			var tooLarge *domain.OffsetTooLargeError
+			var problems domain.ValidationErrors
+			switch {
+			case tt.wantMax != 0:
+				if !errors.As(err, &tooLarge) || tooLarge.Max != tt.wantMax {
+					t.Fatalf("List error = %v, want offset past %d", err, tt.wantMax)
+				}
+				if listed != nil {
+					t.Error("the repository was queried past the maximum offset")
+				}
+			case tt.wantProblems != nil:
+				if !errors.As(err, &problems) {
+					t.Fatalf("List error = %v, want validation errors", err)
+				}
+				if got := fields(problems); !reflect.DeepEqual(got, tt.wantProblems) {
+					t.Errorf("problems = %v, want %v", got, tt.wantProblems)
+				}
+			default:
+				if err != nil {
+					t.Fatalf("List: %v", err)
+				}
+				if listed.Limit != tt.wantLimit || listed.Offset != tt.offset {
+					t.Errorf("listed limit %d offset %d, want limit %d offset %d", listed.Limit, listed.Offset, tt.wantLimit, tt.offset)
+				}
+			}
+		})
+	}
+}
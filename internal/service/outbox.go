@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+
+	"subscription-service/internal/publisher"
+	"subscription-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+type OutboxRelay interface {
+	PublishPending(ctx context.Context) (int, error)
+	// Drain publishes batches until one publishes nothing, so events that
+	// keep failing do not hold it up, and returns how many were published.
+	// Events whose retry is not yet due are left for later.
+	Drain(ctx context.Context) (int, error)
+	CountPending(ctx context.Context) (int64, error)
+}
+
+type outboxRelay struct {
+	repo      repository.OutboxRepository
+	publisher publisher.Publisher
+	batchSize int
+	logger    *zap.Logger
+}
+
+func NewOutboxRelay(repo repository.OutboxRepository, publisher publisher.Publisher, batchSize int, logger *zap.Logger) OutboxRelay {
+	return &outboxRelay{
+		repo:      repo,
+		publisher: publisher,
+		batchSize: batchSize,
+		logger:    logger,
+	}
+}
+
+// PublishPending publishes one batch of due outbox events. An event is only
+// marked sent after the publisher accepts it, so a crash at any point leaves
+// it pending and it is sent again once its claim times out.
+func (r *outboxRelay) PublishPending(ctx context.Context) (int, error) {
+	published, err := r.repo.ProcessPending(ctx, r.batchSize, r.publisher.Publish)
+	if err != nil {
+		r.logger.Error("failed to process outbox batch", zap.Error(err))
+		return published, err
+	}
+
+	if published > 0 {
+		r.logger.Info("outbox events published", zap.Int("count", published))
+	}
+	return published, nil
+}
+
+func (r *outboxRelay) Drain(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		published, err := r.PublishPending(ctx)
+		total += published
+		if err != nil || published == 0 {
+			return total, err
+		}
+	}
+}
+
+func (r *outboxRelay) CountPending(ctx context.Context) (int64, error) {
+	return r.repo.CountPending(ctx)
+}
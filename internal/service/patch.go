@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"subscription-service/internal/domain"
+	"subscription-service/internal/jsonpatch"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// patchableFields are the subscription JSON members a JSON Patch may change.
+// Every other member is read-only.
+var patchableFields = map[string]struct{}{
+	"service_name":   {},
+	"price":          {},
+	"price_minor":    {},
+	"amount":         {},
+	"start_date":     {},
+	"end_date":       {},
+	"auto_renew":     {},
+	"metadata":       {},
+	"tags":           {},
+	"billing_period": {},
+	"currency":       {},
+}
+
+// patchedFields receives the patchable members of a patched document.
+type patchedFields struct {
+	ServiceName   *string         `json:"service_name"`
+	Price         *int            `json:"price"`
+	PriceMinor    *int            `json:"price_minor"`
+	Amount        *string         `json:"amount"`
+	StartDate     *string         `json:"start_date"`
+	EndDate       *string         `json:"end_date"`
+	AutoRenew     *bool           `json:"auto_renew"`
+	Metadata      json.RawMessage `json:"metadata"`
+	Tags          []string        `json:"tags"`
+	BillingPeriod *string         `json:"billing_period"`
+	Currency      *string         `json:"currency"`
+}
+
+// maxPatchAttempts bounds how often Patch re-applies a patch to a
+// subscription that keeps changing underneath it.
+const maxPatchAttempts = 3
+
+// Patch applies an RFC 6902 patch to the JSON form of the subscription and
+// saves the result like any other update, so the patched subscription is
+// held to the same rules. The save is conditional on the subscription's
+// updated_at being unchanged since it was read; when it changed, the patch
+// is applied afresh to the new version, and after maxPatchAttempts
+// domain.ErrSubscriptionModified is returned.
+func (s *subscriptionService) Patch(ctx context.Context, id uuid.UUID, patch []jsonpatch.Operation) (*domain.Subscription, error) {
+	s.logger.Info("service: patching subscription", zap.String("id", id.String()), zap.Int("operations", len(patch)))
+
+	for attempt := 1; ; attempt++ {
+		current, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			s.logger.Error("failed to load subscription", zap.String("id", id.String()), zap.Error(err))
+			return nil, err
+		}
+
+		req, problems, err := patchToUpdate(current, patch)
+		if err != nil {
+			return nil, err
+		}
+		if len(problems) > 0 {
+			s.logger.Error("invalid subscription patch", zap.String("id", id.String()), zap.Error(problems))
+			return nil, problems
+		}
+
+		subscription, err := s.update(ctx, id, current, req, true)
+		if errors.Is(err, domain.ErrSubscriptionModified) && attempt < maxPatchAttempts {
+			s.logger.Info("subscription changed while patching, reapplying", zap.String("id", id.String()), zap.Int("attempt", attempt))
+			continue
+		}
+		return subscription, err
+	}
+}
+
+// patchToUpdate applies patch to current and describes the outcome as an
+// update request. Problems with the patch itself are reported as validation
+// errors; err is only set for unexpected failures.
+func patchToUpdate(current *domain.Subscription, patch []jsonpatch.Operation) (*domain.UpdateSubscriptionRequest, domain.ValidationErrors, error) {
+	original, err := json.Marshal(current)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	patched, err := jsonpatch.Apply(original, patch)
+	var patchErr *jsonpatch.Error
+	if errors.As(err, &patchErr) {
+		return nil, domain.ValidationErrors{{Field: patchErr.Path, Message: patchErr.Error()}}, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	problems, err := checkReadOnlyMembers(original, patched)
+	if err != nil || len(problems) > 0 {
+		return nil, problems, err
+	}
+
+	var fields patchedFields
+	if err := json.Unmarshal(patched, &fields); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, domain.ValidationErrors{{Field: typeErr.Field, Message: fmt.Sprintf("%s must be a JSON %s", typeErr.Field, jsonKind(typeErr.Type))}}, nil
+		}
+		return nil, domain.ValidationErrors{{Field: "patch", Message: "patched document is not a subscription object"}}, nil
+	}
+
+	for field, value := range map[string]bool{
+		"service_name":   fields.ServiceName == nil,
+		"price":          fields.Price == nil,
+		"price_minor":    fields.PriceMinor == nil,
+		"amount":         fields.Amount == nil,
+		"start_date":     fields.StartDate == nil,
+		"auto_renew":     fields.AutoRenew == nil,
+		"billing_period": fields.BillingPeriod == nil,
+		"currency":       fields.Currency == nil,
+	} {
+		if value {
+			problems = append(problems, domain.FieldError{Field: field, Message: field + " cannot be removed"})
+		}
+	}
+	if len(problems) > 0 {
+		sort.Slice(problems, func(i, j int) bool { return problems[i].Field < problems[j].Field })
+		return nil, problems, nil
+	}
+
+	req := &domain.UpdateSubscriptionRequest{
+		ServiceName:   fields.ServiceName,
+		StartDate:     fields.StartDate,
+		EndDate:       fields.EndDate,
+		AutoRenew:     fields.AutoRenew,
+		Metadata:      fields.Metadata,
+		Tags:          fields.Tags,
+		BillingPeriod: fields.BillingPeriod,
+		Currency:      fields.Currency,
+		ClearEndDate:  fields.EndDate == nil && current.EndDate != nil,
+	}
+	// The price appears in three forms; whichever ones the patch changed
+	// are passed on, so changing two of them is reported like it is on any
+	// update, and an unchanged price is kept in minor units.
+	if *fields.Price != current.Price {
+		req.Price = fields.Price
+	}
+	if *fields.PriceMinor != current.PriceMinor {
+		req.PriceMinor = fields.PriceMinor
+	}
+	if *fields.Amount != current.Amount {
+		req.Amount = fields.Amount
+	}
+	if req.Price == nil && req.PriceMinor == nil && req.Amount == nil {
+		req.PriceMinor = fields.PriceMinor
+	}
+	if len(req.Metadata) == 0 || string(req.Metadata) == "null" {
+		req.Metadata = json.RawMessage("{}")
+	}
+	if req.Tags == nil {
+		req.Tags = []string{}
+	}
+
+	return req, nil, nil
+}
+
+// checkReadOnlyMembers reports members outside patchableFields that the
+// patch added, removed or changed.
+func checkReadOnlyMembers(original, patched []byte) (domain.ValidationErrors, error) {
+	var before map[string]interface{}
+	if err := json.Unmarshal(original, &before); err != nil {
+		return nil, err
+	}
+	var after map[string]interface{}
+	if err := json.Unmarshal(patched, &after); err != nil {
+		return domain.ValidationErrors{{Field: "patch", Message: "patched document is not a subscription object"}}, nil
+	}
+
+	var problems domain.ValidationErrors
+	for key, value := range after {
+		if _, ok := patchableFields[key]; ok {
+			continue
+		}
+		previous, existed := before[key]
+		switch {
+		case !existed:
+			problems = append(problems, domain.FieldError{Field: key, Message: "unknown field " + key})
+		case !reflect.DeepEqual(previous, value):
+			problems = append(problems, domain.FieldError{Field: key, Message: key + " is read-only"})
+		}
+	}
+	for key := range before {
+		if _, ok := patchableFields[key]; ok {
+			continue
+		}
+		if _, ok := after[key]; !ok {
+			problems = append(problems, domain.FieldError{Field: key, Message: key + " is read-only"})
+		}
+	}
+
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Field < problems[j].Field })
+	return problems, nil
+}
+
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return "integer"
+	default:
+		return t.String()
+	}
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+	"subscription-service/internal/jsonpatch"
+
+	"github.com/google/uuid"
+)
+
+func testPatchSubscription(id uuid.UUID, updatedAt time.Time, tags ...string) *domain.Subscription {
+	return &domain.Subscription{
+		ID:            id,
+		ServiceName:   "Netflix",
+		Price:         4,
+		PriceMinor:    400,
+		UserID:        uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+		StartDate:     "2025-01-01",
+		EndDate:       strPtr("2025-12-01"),
+		Metadata:      map[string]interface{}{},
+		Tags:          tags,
+		BillingPeriod: domain.BillingPeriodMonthly,
+		Currency:      domain.DefaultCurrency,
+		Amount:        "4.00",
+		Status:        domain.SubscriptionStatusActive,
+		UpdatedAt:     updatedAt,
+	}
+}
+
+func op(operation, path, value string) jsonpatch.Operation {
+	o := jsonpatch.Operation{Op: operation, Path: path}
+	if value != "" {
+		o.Value = json.RawMessage(value)
+	}
+	return o
+}
+
+func TestPatch(t *testing.T) {
+	id := uuid.New()
+	updatedAt := testToday.Add(-time.Hour)
+
+	tests := []struct {
+		name         string
+		patch        []jsonpatch.Operation
+		check        func(t *testing.T, req *domain.UpdateSubscriptionRequest)
+		wantProblems []string
+	}{
+		{
+			name:  "replace a member",
+			patch: []jsonpatch.Operation{op("replace", "/price", "500")},
+			check: func(t *testing.T, req *domain.UpdateSubscriptionRequest) {
+				if req.PriceMinor == nil || *req.PriceMinor != 50000 {
+					t.Errorf("price_minor = %v, want 500 roubles in kopecks", req.PriceMinor)
+				}
+				if req.ServiceName == nil || *req.ServiceName != "Netflix" {
+					t.Errorf("service_name = %v, want it kept", req.ServiceName)
+				}
+				if req.ClearEndDate {
+					t.Error("end date cleared by a patch that does not touch it")
+				}
+			},
+		},
+		{
+			name:  "replace the price in minor units",
+			patch: []jsonpatch.Operation{op("replace", "/price_minor", "1999")},
+			check: func(t *testing.T, req *domain.UpdateSubscriptionRequest) {
+				if req.PriceMinor == nil || *req.PriceMinor != 1999 {
+					t.Errorf("price_minor = %v, want 1999", req.PriceMinor)
+				}
+			},
+		},
+		{
+			name:  "replace the amount",
+			patch: []jsonpatch.Operation{op("replace", "/amount", `"19.99"`)},
+			check: func(t *testing.T, req *domain.UpdateSubscriptionRequest) {
+				if req.PriceMinor == nil || *req.PriceMinor != 1999 {
+					t.Errorf("price_minor = %v, want 1999", req.PriceMinor)
+				}
+			},
+		},
+		{
+			name:         "replace the price in two forms",
+			patch:        []jsonpatch.Operation{op("replace", "/price", "5"), op("replace", "/price_minor", "600")},
+			wantProblems: []string{"price_minor"},
+		},
+		{
+			name:  "remove the end date",
+			patch: []jsonpatch.Operation{op("remove", "/end_date", "")},
+			check: func(t *testing.T, req *domain.UpdateSubscriptionRequest) {
+				if !req.ClearEndDate || req.EndDate != nil {
+					t.Errorf("clear_end_date = %v, end_date = %v, want the end date cleared", req.ClearEndDate, req.EndDate)
+				}
+				if req.PriceMinor == nil || *req.PriceMinor != 400 {
+					t.Errorf("price_minor = %v, want the stored 400", req.PriceMinor)
+				}
+			},
+		},
+		{
+			name:  "remove an array element",
+			patch: []jsonpatch.Operation{op("remove", "/tags/0", "")},
+			check: func(t *testing.T, req *domain.UpdateSubscriptionRequest) {
+				if !reflect.DeepEqual(req.Tags, []string{"b"}) {
+					t.Errorf("tags = %v, want [b]", req.Tags)
+				}
+			},
+		},
+		{
+			name:         "replace a missing member",
+			patch:        []jsonpatch.Operation{op("replace", "/no_such_field", `"x"`)},
+			wantProblems: []string{"/no_such_field"},
+		},
+		{
+			name:         "remove past the end of an array",
+			patch:        []jsonpatch.Operation{op("remove", "/tags/5", "")},
+			wantProblems: []string{"/tags/5"},
+		},
+		{
+			name:         "path that is not a JSON pointer",
+			patch:        []jsonpatch.Operation{op("replace", "price", "500")},
+			wantProblems: []string{"price"},
+		},
+		{
+			name:         "remove a required member",
+			patch:        []jsonpatch.Operation{op("remove", "/price", "")},
+			wantProblems: []string{"price"},
+		},
+		{
+			name:         "change a read-only member",
+			patch:        []jsonpatch.Operation{op("replace", "/status", `"expired"`)},
+			wantProblems: []string{"status"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var saved *domain.UpdateSubscriptionRequest
+			repo := &fakeRepository{
+				getByID: func(context.Context, uuid.UUID) (*domain.Subscription, error) {
+					return testPatchSubscription(id, updatedAt, "a", "b"), nil
+				},
+				updateIfUnmodified: func(_ context.Context, _ uuid.UUID, gotUpdatedAt time.Time, req *domain.UpdateSubscriptionRequest) (*domain.Subscription, error) {
+					if !gotUpdatedAt.Equal(updatedAt) {
+						t.Errorf("conditional on updated_at %v, want %v", gotUpdatedAt, updatedAt)
+					}
+					saved = req
+					return testPatchSubscription(id, testToday), nil
+				},
+			}
+			svc := newTestService(repo, config.SubscriptionConfig{}, newFakeClock(testToday))
+
+			_, err := svc.Patch(context.Background(), id, tt.patch)
+
+			if tt.wantProblems != nil {
+				var problems domain.ValidationErrors
+				if !errors.As(err, &problems) {
+					t.Fatalf("Patch error = %v, want validation errors", err)
+				}
+				if got := fields(problems); !reflect.DeepEqual(got, tt.wantProblems) {
+					t.Errorf("problems = %v, want %v", got, tt.wantProblems)
+				}
+				if saved != nil {
+					t.Error("an invalid patch was saved")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Patch: %v", err)
+			}
+			tt.check(t, saved)
+		})
+	}
+}
+
+func TestPatchConcurrentModification(t *testing.T) {
+	id := uuid.New()
+	first, second := testToday.Add(-time.Hour), testToday.Add(-time.Minute)
+
+	tests := []struct {
+		name      string
+		conflicts int
+		wantTags  []string
+		wantErr   error
+		wantLoads int
+	}{
+		{name: "no conflict", conflicts: 0, wantTags: []string{"a", "new"}, wantLoads: 1},
+		{name: "reapplied to the concurrent version", conflicts: 1, wantTags: []string{"a", "b", "new"}, wantLoads: 2},
+		{name: "gives up after repeated conflicts", conflicts: maxPatchAttempts, wantErr: domain.ErrSubscriptionModified, wantLoads: maxPatchAttempts},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The first read sees one tag; every later read sees the tag a
+			// concurrent writer added.
+			loads, saves := 0, 0
+			var saved *domain.UpdateSubscriptionRequest
+			repo := &fakeRepository{
+				getByID: func(context.Context, uuid.UUID) (*domain.Subscription, error) {
+					loads++
+					if loads == 1 {
+						return testPatchSubscription(id, first, "a"), nil
+					}
+					return testPatchSubscription(id, second, "a", "b"), nil
+				},
+				updateIfUnmodified: func(_ context.Context, _ uuid.UUID, _ time.Time, req *domain.UpdateSubscriptionRequest) (*domain.Subscription, error) {
+					saves++
+					if saves <= tt.conflicts {
+						return nil, domain.ErrSubscriptionModified
+					}
+					saved = req
+					return testPatchSubscription(id, testToday), nil
+				},
+			}
+			svc := newTestService(repo, config.SubscriptionConfig{}, newFakeClock(testToday))
+
+			_, err := svc.Patch(context.Background(), id, []jsonpatch.Operation{op("add", "/tags/-", `"new"`)})
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Patch error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Patch: %v", err)
+			} else if !reflect.DeepEqual(saved.Tags, tt.wantTags) {
+				t.Errorf("saved tags = %v, want %v", saved.Tags, tt.wantTags)
+			}
+			if loads != tt.wantLoads {
+				t.Errorf("loads = %d, want %d", loads, tt.wantLoads)
+			}
+		})
+	}
+}
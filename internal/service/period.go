@@ -0,0 +1,116 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+const (
+	PeriodThisMonth = "this_month"
+	PeriodLastMonth = "last_month"
+)
+
+var ErrInvalidPeriod = errors.New("invalid period")
+
+// resolvePeriod turns a period keyword or an ISO 8601 date duration (e.g.
+// P3M, P1Y, P2W) into an inclusive [start, end] date window relative to now.
+// Durations describe a window of that length ending today.
+func resolvePeriod(period string, now time.Time) (string, string, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	switch period {
+	case PeriodThisMonth:
+		return monthStart.Format(dateLayout), monthStart.AddDate(0, 1, -1).Format(dateLayout), nil
+	case PeriodLastMonth:
+		return monthStart.AddDate(0, -1, 0).Format(dateLayout), monthStart.AddDate(0, 0, -1).Format(dateLayout), nil
+	}
+
+	years, months, days, err := parseISODuration(period)
+	if err != nil {
+		return "", "", err
+	}
+
+	start := today.AddDate(-years, -months, -days).AddDate(0, 0, 1)
+	return start.Format(dateLayout), today.Format(dateLayout), nil
+}
+
+func validatePeriod(period string) error {
+	if period == PeriodThisMonth || period == PeriodLastMonth {
+		return nil
+	}
+	_, _, _, err := parseISODuration(period)
+	return err
+}
+
+// parseISODuration parses the date part of an ISO 8601 duration. Time
+// components (PT...) are rejected since cost windows are day-granular.
+func parseISODuration(value string) (years, months, days int, err error) {
+	invalid := fmt.Errorf("%w: %q must be %s, %s or an ISO 8601 duration such as P3M", ErrInvalidPeriod, value, PeriodThisMonth, PeriodLastMonth)
+
+	if len(value) < 3 || value[0] != 'P' {
+		return 0, 0, 0, invalid
+	}
+
+	seen := ""
+	number := ""
+	for _, r := range value[1:] {
+		if r >= '0' && r <= '9' {
+			number += string(r)
+			continue
+		}
+
+		if number == "" {
+			return 0, 0, 0, invalid
+		}
+		n, convErr := strconv.Atoi(number)
+		if convErr != nil {
+			return 0, 0, 0, invalid
+		}
+		number = ""
+
+		unit := string(r)
+		if !validNextUnit(seen, unit) {
+			return 0, 0, 0, invalid
+		}
+		seen += unit
+
+		switch unit {
+		case "Y":
+			years = n
+		case "M":
+			months = n
+		case "W":
+			days += n * 7
+		case "D":
+			days += n
+		}
+	}
+
+	if number != "" || years+months+days == 0 {
+		return 0, 0, 0, invalid
+	}
+
+	return years, months, days, nil
+}
+
+// validNextUnit enforces that designators appear at most once and in
+// Y, M, W, D order.
+func validNextUnit(seen, unit string) bool {
+	const order = "YMWD"
+
+	position := strings.Index(order, unit)
+	if position < 0 {
+		return false
+	}
+	if seen == "" {
+		return true
+	}
+
+	return position > strings.Index(order, seen[len(seen)-1:])
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestResolvePeriod(t *testing.T) {
+	now := time.Date(2025, time.March, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		period    string
+		now       time.Time
+		wantStart string
+		wantEnd   string
+	}{
+		{name: "this month", period: PeriodThisMonth, now: now, wantStart: "2025-03-01", wantEnd: "2025-03-31"},
+		{name: "last month", period: PeriodLastMonth, now: now, wantStart: "2025-02-01", wantEnd: "2025-02-28"},
+		{name: "last month across a year boundary", period: PeriodLastMonth, now: time.Date(2025, time.January, 10, 0, 0, 0, 0, time.UTC), wantStart: "2024-12-01", wantEnd: "2024-12-31"},
+		{name: "months", period: "P3M", now: now, wantStart: "2024-12-16", wantEnd: "2025-03-15"},
+		{name: "years", period: "P1Y", now: now, wantStart: "2024-03-16", wantEnd: "2025-03-15"},
+		{name: "weeks", period: "P2W", now: now, wantStart: "2025-03-02", wantEnd: "2025-03-15"},
+		{name: "one day is today", period: "P1D", now: now, wantStart: "2025-03-15", wantEnd: "2025-03-15"},
+		{name: "combined designators", period: "P1Y2M3D", now: now, wantStart: "2024-01-13", wantEnd: "2025-03-15"},
+		{name: "time of day is ignored", period: "P1D", now: time.Date(2025, time.March, 15, 23, 59, 59, 0, time.UTC), wantStart: "2025-03-15", wantEnd: "2025-03-15"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := resolvePeriod(tt.period, tt.now)
+			if err != nil {
+				t.Fatalf("resolvePeriod(%q): %v", tt.period, err)
+			}
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("resolvePeriod(%q) = [%s, %s], want [%s, %s]", tt.period, start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestResolvePeriodRejectsInvalid(t *testing.T) {
+	now := time.Date(2025, time.March, 15, 12, 0, 0, 0, time.UTC)
+
+	for _, period := range []string{"", "P", "3M", "P1", "P0D", "PT1H", "P1.5M", "P1M1Y", "P1Y1Y", "next_month"} {
+		t.Run(period, func(t *testing.T) {
+			if _, _, err := resolvePeriod(period, now); !errors.Is(err, ErrInvalidPeriod) {
+				t.Errorf("resolvePeriod(%q) = %v, want %v", period, err, ErrInvalidPeriod)
+			}
+		})
+	}
+}
+
+func TestValidatePeriod(t *testing.T) {
+	now := time.Date(2025, time.March, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		period  string
+		wantErr bool
+	}{
+		{period: PeriodThisMonth},
+		{period: PeriodLastMonth},
+		{period: "P3M"},
+		{period: "P1Y2M3W4D"},
+		{period: "", wantErr: true},
+		{period: "P0D", wantErr: true},
+		{period: "PT1H", wantErr: true},
+		{period: "P1M1Y", wantErr: true},
+		{period: "next_month", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			err := validatePeriod(tt.period)
+			_, _, resolveErr := resolvePeriod(tt.period, now)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPeriod) {
+					t.Errorf("validatePeriod(%q) = %v, want %v", tt.period, err, ErrInvalidPeriod)
+				}
+				if resolveErr == nil {
+					t.Errorf("resolvePeriod(%q) accepted a period validatePeriod rejects", tt.period)
+				}
+				return
+			}
+			if err != nil || resolveErr != nil {
+				t.Errorf("validatePeriod(%q) = %v, resolvePeriod = %v, want both to accept it", tt.period, err, resolveErr)
+			}
+		})
+	}
+}
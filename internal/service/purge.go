@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"subscription-service/internal/clock"
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// PurgeService permanently removes subscriptions that have been soft-deleted
+// for longer than a retention period. It deletes in batches, each committing
+// on its own, so a long purge never holds one big transaction and an
+// interrupted one keeps what it already removed.
+type PurgeService interface {
+	Purge(ctx context.Context, olderThan time.Duration) (*domain.PurgeResponse, error)
+}
+
+type purgeService struct {
+	repo      repository.SubscriptionRepository
+	batchSize int
+	clock     clock.Clock
+	logger    *zap.Logger
+}
+
+func NewPurgeService(repo repository.SubscriptionRepository, batchSize int, clock clock.Clock, logger *zap.Logger) PurgeService {
+	if batchSize <= 0 {
+		batchSize = domain.DefaultPurgeBatchSize
+	}
+
+	return &purgeService{
+		repo:      repo,
+		batchSize: batchSize,
+		clock:     clock,
+		logger:    logger,
+	}
+}
+
+func (s *purgeService) Purge(ctx context.Context, olderThan time.Duration) (*domain.PurgeResponse, error) {
+	if olderThan <= 0 {
+		return nil, domain.ValidationErrors{{Field: "older_than", Message: "older_than must be positive"}}
+	}
+
+	response := &domain.PurgeResponse{Cutoff: s.clock.Now().UTC().Add(-olderThan)}
+	s.logger.Info("service: purging deleted subscriptions", zap.Time("cutoff", response.Cutoff))
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		purged, err := s.repo.PurgeDeleted(ctx, response.Cutoff, s.batchSize)
+		if err != nil {
+			return nil, err
+		}
+		response.Purged += purged
+
+		if purged < int64(s.batchSize) {
+			break
+		}
+	}
+
+	s.logger.Info("deleted subscriptions purged", zap.Int64("purged", response.Purged))
+	return response, nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"subscription-service/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+func TestPurgeBatches(t *testing.T) {
+	tests := []struct {
+		name        string
+		stored      int64
+		wantBatches []int64
+	}{
+		{name: "nothing to purge", stored: 0, wantBatches: []int64{0}},
+		{name: "less than a batch", stored: 3, wantBatches: []int64{3}},
+		{name: "exactly one batch needs a check for more", stored: 5, wantBatches: []int64{5, 0}},
+		{name: "several batches", stored: 12, wantBatches: []int64{5, 5, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remaining := tt.stored
+			var batches []int64
+			var cutoffs []time.Time
+			repo := &fakeRepository{
+				purgeDeleted: func(_ context.Context, cutoff time.Time, batchSize int) (int64, error) {
+					purged := min(remaining, int64(batchSize))
+					remaining -= purged
+					batches = append(batches, purged)
+					cutoffs = append(cutoffs, cutoff)
+					return purged, nil
+				},
+			}
+			svc := NewPurgeService(repo, 5, newFakeClock(testToday), zap.NewNop())
+
+			response, err := svc.Purge(context.Background(), 90*24*time.Hour)
+			if err != nil {
+				t.Fatalf("Purge: %v", err)
+			}
+
+			wantCutoff := testToday.AddDate(0, 0, -90)
+			if response.Purged != tt.stored || !response.Cutoff.Equal(wantCutoff) {
+				t.Errorf("response = %+v, want %d purged before %v", response, tt.stored, wantCutoff)
+			}
+			if !reflect.DeepEqual(batches, tt.wantBatches) {
+				t.Errorf("batches = %v, want %v", batches, tt.wantBatches)
+			}
+			for _, cutoff := range cutoffs {
+				if !cutoff.Equal(wantCutoff) {
+					t.Errorf("batch cutoff = %v, want every batch to use %v", cutoff, wantCutoff)
+				}
+			}
+		})
+	}
+}
+
+func TestPurgeRejectsNonPositiveAge(t *testing.T) {
+	svc := NewPurgeService(&fakeRepository{}, 5, newFakeClock(testToday), zap.NewNop())
+
+	for _, olderThan := range []time.Duration{0, -time.Hour} {
+		_, err := svc.Purge(context.Background(), olderThan)
+
+		var problems domain.ValidationErrors
+		if !errors.As(err, &problems) || !reflect.DeepEqual(fields(problems), []string{"older_than"}) {
+			t.Errorf("Purge(%v) error = %v, want an older_than validation error", olderThan, err)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"subscription-service/internal/domain"
+
+	"go.uber.org/zap"
+)
+
+// ReassignUser moves all of one user's live subscriptions to another user in
+// one transaction, for consolidating accounts.
+func (s *subscriptionService) ReassignUser(ctx context.Context, req *domain.ReassignUserRequest) (*domain.ReassignUserResponse, error) {
+	s.logger.Info("service: reassigning subscriptions", zap.String("from_user_id", req.FromUserID.String()), zap.String("to_user_id", req.ToUserID.String()))
+
+	if problems := s.validator.ValidateReassign(req); len(problems) > 0 {
+		s.logger.Error("invalid reassign request", zap.Error(problems))
+		return nil, problems
+	}
+
+	moved, err := s.repo.ReassignUser(ctx, req.FromUserID, req.ToUserID)
+	if errors.Is(err, domain.ErrDuplicateSubscription) {
+		return nil, &domain.DuplicateSubscriptionError{Conflicts: domain.ValidationErrors{{
+			Field:   "to_user_id",
+			Message: "to_user_id already has an overlapping subscription to a service being moved",
+		}}}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ReassignUserResponse{Moved: moved}, nil
+}
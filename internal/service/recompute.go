@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+
+	"subscription-service/internal/clock"
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// RecomputeService backfills derived subscription fields batch by batch.
+// Each batch commits on its own, so an interrupted run keeps its progress
+// and can be resumed from the last reported id.
+type RecomputeService interface {
+	Recompute(ctx context.Context, req *domain.RecomputeRequest) (*domain.RecomputeResponse, error)
+}
+
+type recomputeService struct {
+	repo   repository.SubscriptionRepository
+	clock  clock.Clock
+	logger *zap.Logger
+}
+
+func NewRecomputeService(repo repository.SubscriptionRepository, clock clock.Clock, logger *zap.Logger) RecomputeService {
+	return &recomputeService{
+		repo:   repo,
+		clock:  clock,
+		logger: logger,
+	}
+}
+
+func (s *recomputeService) Recompute(ctx context.Context, req *domain.RecomputeRequest) (*domain.RecomputeResponse, error) {
+	batchSize := req.BatchSize
+	if batchSize <= 0 {
+		batchSize = domain.DefaultRecomputeBatchSize
+	}
+
+	filter := &repository.RecomputeFilter{
+		ListSubscriptionsFilter: repository.ListSubscriptionsFilter{
+			UserID:           req.UserID,
+			ExactServiceName: req.ServiceName,
+			Limit:            batchSize,
+		},
+		AfterID: req.AfterID,
+		AsOf:    s.clock.Now().Format(dateLayout),
+	}
+
+	s.logger.Info("service: recompute derived fields", zap.Int("batch_size", batchSize), zap.Int("max_batches", req.MaxBatches))
+
+	response := &domain.RecomputeResponse{LastID: req.AfterID}
+	for req.MaxBatches == 0 || response.Batches < req.MaxBatches {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		batch, err := s.repo.RecomputeDerivedFields(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		if batch.Processed == 0 {
+			response.Done = true
+			break
+		}
+
+		response.Batches++
+		response.Processed += batch.Processed
+		response.Updated += batch.Updated
+		response.LastID = batch.LastID
+		filter.AfterID = batch.LastID
+
+		s.logger.Info("recompute batch committed",
+			zap.Int("batch", response.Batches),
+			zap.Int("processed", response.Processed),
+			zap.Int("updated", response.Updated),
+			zap.String("last_id", batch.LastID.String()),
+		)
+
+		if batch.Processed < batchSize {
+			response.Done = true
+			break
+		}
+	}
+
+	return response, nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// recomputeRepository serves RecomputeDerivedFields from a fixed, sorted
+// list of ids, marking every other row as changed.
+type recomputeRepository struct {
+	fakeRepository
+	ids []uuid.UUID
+}
+
+func (r *recomputeRepository) RecomputeDerivedFields(ctx context.Context, filter *repository.RecomputeFilter) (*repository.RecomputeBatch, error) {
+	start := 0
+	if filter.AfterID != nil {
+		for start < len(r.ids) && r.ids[start] != *filter.AfterID {
+			start++
+		}
+		start++
+	}
+	end := min(start+filter.Limit, len(r.ids))
+
+	batch := &repository.RecomputeBatch{}
+	for i := start; i < end; i++ {
+		batch.Processed++
+		if i%2 == 0 {
+			batch.Updated++
+		}
+		id := r.ids[i]
+		batch.LastID = &id
+	}
+	return batch, nil
+}
+
+func TestRecompute(t *testing.T) {
+	ids := make([]uuid.UUID, 5)
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+
+	tests := []struct {
+		name          string
+		req           domain.RecomputeRequest
+		wantBatches   int
+		wantProcessed int
+		wantUpdated   int
+		wantLastID    *uuid.UUID
+		wantDone      bool
+	}{
+		{
+			name:          "runs to the end",
+			req:           domain.RecomputeRequest{BatchSize: 2},
+			wantBatches:   3,
+			wantProcessed: 5,
+			wantUpdated:   3,
+			wantLastID:    &ids[4],
+			wantDone:      true,
+		},
+		{
+			name:          "stops after max batches",
+			req:           domain.RecomputeRequest{BatchSize: 2, MaxBatches: 1},
+			wantBatches:   1,
+			wantProcessed: 2,
+			wantUpdated:   1,
+			wantLastID:    &ids[1],
+		},
+		{
+			name:          "resumes after the cursor",
+			req:           domain.RecomputeRequest{BatchSize: 2, AfterID: &ids[1]},
+			wantBatches:   2,
+			wantProcessed: 3,
+			wantUpdated:   2,
+			wantLastID:    &ids[4],
+			wantDone:      true,
+		},
+		{
+			name:          "exact multiple of the batch size ends on an empty batch",
+			req:           domain.RecomputeRequest{BatchSize: 5},
+			wantBatches:   1,
+			wantProcessed: 5,
+			wantUpdated:   3,
+			wantLastID:    &ids[4],
+			wantDone:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewRecomputeService(&recomputeRepository{ids: ids}, newFakeClock(testToday), zap.NewNop())
+
+			got, err := s.Recompute(context.Background(), &tt.req)
+			if err != nil {
+				t.Fatalf("Recompute: %v", err)
+			}
+			if got.Batches != tt.wantBatches || got.Processed != tt.wantProcessed || got.Updated != tt.wantUpdated || got.Done != tt.wantDone {
+				t.Errorf("got %+v, want batches %d processed %d updated %d done %v", got, tt.wantBatches, tt.wantProcessed, tt.wantUpdated, tt.wantDone)
+			}
+			if got.LastID == nil || *got.LastID != *tt.wantLastID {
+				t.Errorf("last id = %v, want %v", got.LastID, tt.wantLastID)
+			}
+		})
+	}
+}
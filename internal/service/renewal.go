@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"subscription-service/internal/clock"
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+type RenewalService interface {
+	ProcessDueRenewals(ctx context.Context) (int, error)
+}
+
+type renewalService struct {
+	repo   repository.SubscriptionRepository
+	clock  clock.Clock
+	logger *zap.Logger
+}
+
+func NewRenewalService(repo repository.SubscriptionRepository, clock clock.Clock, logger *zap.Logger) RenewalService {
+	return &renewalService{
+		repo:   repo,
+		clock:  clock,
+		logger: logger,
+	}
+}
+
+// ProcessDueRenewals extends every auto-renewing subscription whose end date
+// has been reached by one billing month. Each due subscription is extended
+// at most once per run, and one whose extended term would overlap another
+// live subscription to the same service is skipped.
+func (s *renewalService) ProcessDueRenewals(ctx context.Context) (int, error) {
+	asOf := s.clock.Now().Format(dateLayout)
+	s.logger.Info("service: processing due renewals", zap.String("as_of", asOf))
+
+	autoRenew := true
+	filter := &repository.StreamFilter{
+		AutoRenew: &autoRenew,
+		EndsBy:    &asOf,
+	}
+
+	due, renewed := 0, 0
+	err := s.repo.StreamAll(ctx, filter, func(subscription *domain.Subscription) error {
+		due++
+
+		result, err := s.repo.Renew(ctx, subscription.ID, *subscription.EndDate)
+		if errors.Is(err, domain.ErrDuplicateSubscription) {
+			s.logger.Warn("skipping renewal that would overlap another subscription", zap.String("id", subscription.ID.String()))
+			return nil
+		}
+		if err != nil {
+			s.logger.Error("failed to renew subscription", zap.String("id", subscription.ID.String()), zap.Error(err))
+			return nil
+		}
+		if result != nil {
+			renewed++
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to process due renewals", zap.Error(err))
+		return renewed, err
+	}
+
+	s.logger.Info("due renewals processed", zap.Int("due", due), zap.Int("renewed", renewed))
+	return renewed, nil
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestProcessDueRenewalsSkipsOverlaps(t *testing.T) {
+	due := []*domain.Subscription{
+		{ID: uuid.New(), EndDate: strPtr("2025-03-15")},
+		{ID: uuid.New(), EndDate: strPtr("2025-03-10")},
+		{ID: uuid.New(), EndDate: strPtr("2025-03-01")},
+	}
+	outcomes := map[uuid.UUID]error{
+		due[1].ID: domain.ErrDuplicateSubscription,
+		due[2].ID: errors.New("connection reset"),
+	}
+
+	var attempted []uuid.UUID
+	repo := &fakeRepository{
+		streamAll: func(_ context.Context, _ *repository.StreamFilter, fn func(*domain.Subscription) error) error {
+			for _, sub := range due {
+				if err := fn(sub); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		renew: func(_ context.Context, id uuid.UUID, _ string) (*domain.Subscription, error) {
+			attempted = append(attempted, id)
+			if err := outcomes[id]; err != nil {
+				return nil, err
+			}
+			return &domain.Subscription{ID: id}, nil
+		},
+	}
+
+	svc := NewRenewalService(repo, newFakeClock(testToday), zap.NewNop())
+	renewed, err := svc.ProcessDueRenewals(context.Background())
+	if err != nil {
+		t.Fatalf("ProcessDueRenewals: %v", err)
+	}
+	if renewed != 1 {
+		t.Errorf("renewed = %d, want 1", renewed)
+	}
+	if len(attempted) != len(due) {
+		t.Errorf("attempted %d renewals, want every one of the %d due", len(attempted), len(due))
+	}
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// scheduleEventOrder breaks ties between events of one subscription on the
+// same day: the charge comes before the subscription renews or ends.
+var scheduleEventOrder = map[string]int{
+	domain.ScheduleEventBilling: 0,
+	domain.ScheduleEventRenewal: 1,
+	domain.ScheduleEventExpiry:  2,
+}
+
+// Schedule lists the upcoming events of the subscriptions matching the list
+// filters in date order, as of today according to the service clock. Every
+// matching subscription running in the window is visited, so the events are
+// computed in full before the requested page is cut from them. Pauses are
+// not taken into account.
+func (s *subscriptionService) Schedule(ctx context.Context, req *domain.ScheduleRequest) (*domain.ScheduleResponse, error) {
+	s.logger.Info("service: listing upcoming schedule", zap.Int("within_days", req.WithinDays))
+
+	if req.WithinDays == 0 {
+		req.WithinDays = domain.DefaultScheduleDays
+	}
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+	if problems := s.validator.ValidateSchedule(req); len(problems) > 0 {
+		s.logger.Error("invalid schedule request", zap.Error(problems))
+		return nil, problems
+	}
+	if err := s.checkOffset(req.Offset); err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, req.WithinDays-1)
+
+	listReq := req.ListSubscriptionsRequest
+	activeFrom, activeTo := from.Format(dateLayout), to.Format(dateLayout)
+	listReq.ActiveFrom, listReq.ActiveTo = &activeFrom, &activeTo
+
+	filter, err := s.buildListFilter(&listReq)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []domain.ScheduleEvent{}
+	err = s.repo.StreamAll(ctx, &repository.StreamFilter{ListSubscriptionsFilter: *filter}, func(subscription *domain.Subscription) error {
+		upcoming, err := scheduleEvents(subscription, from, to)
+		if err != nil {
+			return err
+		}
+		events = append(events, upcoming...)
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to list upcoming schedule", zap.Error(err))
+		return nil, err
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		if a.SubscriptionID != b.SubscriptionID {
+			return a.SubscriptionID.String() < b.SubscriptionID.String()
+		}
+		return scheduleEventOrder[a.Type] < scheduleEventOrder[b.Type]
+	})
+
+	page := []domain.ScheduleEvent{}
+	if req.Offset < len(events) {
+		page = events[req.Offset:min(req.Offset+req.Limit, len(events))]
+	}
+
+	return &domain.ScheduleResponse{
+		From:   activeFrom,
+		To:     activeTo,
+		Data:   page,
+		Total:  int64(len(events)),
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	}, nil
+}
+
+// scheduleEvents returns the events of subscription dated within [from, to].
+// An auto-renewing subscription is extended by one billing period each time
+// its end date is reached, the way the renewal job does it, so it keeps
+// billing through the window and renews instead of expiring.
+func scheduleEvents(subscription *domain.Subscription, from, to time.Time) ([]domain.ScheduleEvent, error) {
+	start, err := time.Parse(dateLayout, subscription.StartDate)
+	if err != nil {
+		return nil, err
+	}
+
+	var end *time.Time
+	if subscription.EndDate != nil {
+		parsed, err := time.Parse(dateLayout, *subscription.EndDate)
+		if err != nil {
+			return nil, err
+		}
+		end = &parsed
+	}
+
+	var events []domain.ScheduleEvent
+	add := func(eventType string, date time.Time) {
+		events = append(events, domain.ScheduleEvent{
+			Date:           date.Format(dateLayout),
+			Type:           eventType,
+			SubscriptionID: subscription.ID,
+			UserID:         subscription.UserID,
+			ServiceName:    subscription.ServiceName,
+			Price:          subscription.Price,
+			PriceMinor:     subscription.PriceMinor,
+			Currency:       subscription.Currency,
+		})
+	}
+
+	lastBilled := to
+	if end != nil && !subscription.AutoRenew && end.Before(lastBilled) {
+		lastBilled = *end
+	}
+	months := billingPeriodMonths(subscription.BillingPeriod)
+	for n := 0; ; n += months {
+		date := addMonthsClamped(start, n)
+		if date.After(lastBilled) {
+			break
+		}
+		if !date.Before(from) {
+			add(domain.ScheduleEventBilling, date)
+		}
+	}
+
+	if end != nil {
+		if subscription.AutoRenew {
+			for date := *end; !date.After(to); date = addMonthsClamped(date, months) {
+				if !date.Before(from) {
+					add(domain.ScheduleEventRenewal, date)
+				}
+			}
+		} else if !end.Before(from) && !end.After(to) {
+			add(domain.ScheduleEventExpiry, *end)
+		}
+	}
+
+	return events, nil
+}
+
+func billingPeriodMonths(period string) int {
+	switch period {
+	case domain.BillingPeriodQuarterly:
+		return 3
+	case domain.BillingPeriodYearly:
+		return 12
+	default:
+		return 1
+	}
+}
+
+// addMonthsClamped adds months to date, moving to the last day of the
+// target month when it is shorter, as PostgreSQL interval arithmetic does.
+func addMonthsClamped(date time.Time, months int) time.Time {
+	first := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(date.Day(), lastDay)-1)
+}
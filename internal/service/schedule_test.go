@@ -0,0 +1,98 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"subscription-service/internal/domain"
+)
+
+func TestScheduleEvents(t *testing.T) {
+	date := func(s string) time.Time {
+		parsed, err := time.Parse(dateLayout, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	type event struct{ date, kind string }
+
+	tests := []struct {
+		name         string
+		subscription domain.Subscription
+		from, to     string
+		want         []event
+	}{
+		{
+			name:         "monthly billing through the window",
+			subscription: domain.Subscription{StartDate: "2025-01-31", BillingPeriod: domain.BillingPeriodMonthly},
+			from:         "2025-02-01",
+			to:           "2025-04-30",
+			want: []event{
+				{"2025-02-28", domain.ScheduleEventBilling},
+				{"2025-03-31", domain.ScheduleEventBilling},
+				{"2025-04-30", domain.ScheduleEventBilling},
+			},
+		},
+		{
+			name:         "quarterly billing then expiry",
+			subscription: domain.Subscription{StartDate: "2025-01-15", EndDate: strPtr("2025-08-01"), BillingPeriod: domain.BillingPeriodQuarterly},
+			from:         "2025-01-01",
+			to:           "2025-12-31",
+			want: []event{
+				{"2025-01-15", domain.ScheduleEventBilling},
+				{"2025-04-15", domain.ScheduleEventBilling},
+				{"2025-07-15", domain.ScheduleEventBilling},
+				{"2025-08-01", domain.ScheduleEventExpiry},
+			},
+		},
+		{
+			name:         "auto-renewal renews a month at a time",
+			subscription: domain.Subscription{StartDate: "2024-12-10", EndDate: strPtr("2025-02-10"), AutoRenew: true, BillingPeriod: domain.BillingPeriodMonthly},
+			from:         "2025-01-01",
+			to:           "2025-04-30",
+			want: []event{
+				{"2025-01-10", domain.ScheduleEventBilling},
+				{"2025-02-10", domain.ScheduleEventBilling},
+				{"2025-03-10", domain.ScheduleEventBilling},
+				{"2025-04-10", domain.ScheduleEventBilling},
+				{"2025-02-10", domain.ScheduleEventRenewal},
+				{"2025-03-10", domain.ScheduleEventRenewal},
+				{"2025-04-10", domain.ScheduleEventRenewal},
+			},
+		},
+		{
+			name:         "yearly auto-renewal renews a year at a time",
+			subscription: domain.Subscription{StartDate: "2024-03-01", EndDate: strPtr("2025-03-01"), AutoRenew: true, BillingPeriod: domain.BillingPeriodYearly},
+			from:         "2025-01-01",
+			to:           "2026-12-31",
+			want: []event{
+				{"2025-03-01", domain.ScheduleEventBilling},
+				{"2026-03-01", domain.ScheduleEventBilling},
+				{"2025-03-01", domain.ScheduleEventRenewal},
+				{"2026-03-01", domain.ScheduleEventRenewal},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// scheduleEvents lists billing events before renewal and
+			// expiry events; Schedule sorts them afterwards.
+			events, err := scheduleEvents(&tt.subscription, date(tt.from), date(tt.to))
+			if err != nil {
+				t.Fatalf("scheduleEvents: %v", err)
+			}
+
+			var got []event
+			for _, e := range events {
+				got = append(got, event{e.Date, e.Type})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
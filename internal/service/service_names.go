@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"subscription-service/internal/clock"
+)
+
+const defaultServiceNamesTTL = 30 * time.Second
+
+// serviceNameCache holds the distinct service names for a short TTL. Writes
+// that may change the set invalidate it; a load that started before an
+// invalidation is not stored.
+type serviceNameCache struct {
+	ttl   time.Duration
+	clock clock.Clock
+
+	mu         sync.Mutex
+	names      []string
+	known      map[string]struct{}
+	fetchedAt  time.Time
+	generation uint64
+}
+
+func newServiceNameCache(ttl time.Duration, clock clock.Clock) *serviceNameCache {
+	if ttl <= 0 {
+		ttl = defaultServiceNamesTTL
+	}
+	return &serviceNameCache{ttl: ttl, clock: clock}
+}
+
+func (c *serviceNameCache) get(ctx context.Context, load func(context.Context) ([]string, error)) ([]string, error) {
+	c.mu.Lock()
+	if c.known != nil && c.clock.Now().Sub(c.fetchedAt) < c.ttl {
+		names := c.names
+		c.mu.Unlock()
+		return names, nil
+	}
+	generation := c.generation
+	c.mu.Unlock()
+
+	names, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation == c.generation {
+		c.names = names
+		c.known = make(map[string]struct{}, len(names))
+		for _, name := range names {
+			c.known[name] = struct{}{}
+		}
+		c.fetchedAt = c.clock.Now()
+	}
+	return names, nil
+}
+
+func (c *serviceNameCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.names = nil
+	c.known = nil
+}
+
+// observe invalidates the cache when name is not part of the cached set.
+func (c *serviceNameCache) observe(name string) {
+	c.mu.Lock()
+	_, ok := c.known[name]
+	cached := c.known != nil
+	c.mu.Unlock()
+
+	if cached && !ok {
+		c.invalidate()
+	}
+}
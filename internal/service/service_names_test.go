@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// nameStore is a fakeRepository backing that keeps subscriptions in memory
+// and counts how often the service names are loaded.
+type nameStore struct {
+	subscriptions map[uuid.UUID]*domain.Subscription
+	loads         int
+}
+
+func newNameStore() (*nameStore, *fakeRepository) {
+	store := &nameStore{subscriptions: make(map[uuid.UUID]*domain.Subscription)}
+	return store, &fakeRepository{
+		create: func(_ context.Context, req *domain.CreateSubscriptionRequest) (*domain.Subscription, error) {
+			subscription := &domain.Subscription{ID: uuid.New(), ServiceName: req.ServiceName, PriceMinor: req.PriceMinor, UserID: req.UserID, StartDate: req.StartDate}
+			store.subscriptions[subscription.ID] = subscription
+			return subscription, nil
+		},
+		getByID: func(_ context.Context, id uuid.UUID) (*domain.Subscription, error) {
+			if subscription, ok := store.subscriptions[id]; ok {
+				copied := *subscription
+				return &copied, nil
+			}
+			return nil, domain.ErrSubscriptionNotFound
+		},
+		update: func(_ context.Context, id uuid.UUID, req *domain.UpdateSubscriptionRequest) (*domain.Subscription, error) {
+			subscription := store.subscriptions[id]
+			if req.ServiceName != nil {
+				subscription.ServiceName = *req.ServiceName
+			}
+			if req.PriceMinor != nil {
+				subscription.PriceMinor = *req.PriceMinor
+			}
+			copied := *subscription
+			return &copied, nil
+		},
+		delete: func(_ context.Context, id uuid.UUID) error {
+			delete(store.subscriptions, id)
+			return nil
+		},
+		listServiceNames: func(context.Context) ([]string, error) {
+			store.loads++
+			seen := make(map[string]bool)
+			var names []string
+			for _, subscription := range store.subscriptions {
+				if !seen[subscription.ServiceName] {
+					seen[subscription.ServiceName] = true
+					names = append(names, subscription.ServiceName)
+				}
+			}
+			sort.Strings(names)
+			return names, nil
+		},
+	}
+}
+
+func TestServiceNameCacheInvalidation(t *testing.T) {
+	store, repo := newNameStore()
+	clock := newFakeClock(testToday)
+	svc := newTestService(repo, config.SubscriptionConfig{ServiceNamesTTL: time.Minute}, clock)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	create := func(name string) *domain.Subscription {
+		t.Helper()
+		subscription, err := svc.Create(ctx, &domain.CreateSubscriptionRequest{ServiceName: name, Price: 400, UserID: userID, StartDate: "2025-01-01"})
+		if err != nil {
+			t.Fatalf("Create %s: %v", name, err)
+		}
+		return subscription
+	}
+	netflix := create("Netflix")
+	spotify := create("Spotify")
+
+	// Each step reports the names after it and whether they had to be
+	// loaded again.
+	steps := []struct {
+		name     string
+		change   func()
+		want     []string
+		wantLoad bool
+	}{
+		{name: "first read loads", change: func() {}, want: []string{"Netflix", "Spotify"}, wantLoad: true},
+		{name: "second read is cached", change: func() {}, want: []string{"Netflix", "Spotify"}},
+		{name: "create with a known name keeps the cache", change: func() { create("Netflix") }, want: []string{"Netflix", "Spotify"}},
+		{name: "create with a new name invalidates", change: func() { create("Hulu") }, want: []string{"Hulu", "Netflix", "Spotify"}, wantLoad: true},
+		{name: "update keeping the name keeps the cache", change: func() {
+			if _, err := svc.Update(ctx, spotify.ID, &domain.UpdateSubscriptionRequest{PriceMinor: intPtr(500)}); err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+		}, want: []string{"Hulu", "Netflix", "Spotify"}},
+		{name: "service name change invalidates", change: func() {
+			if _, err := svc.Update(ctx, spotify.ID, &domain.UpdateSubscriptionRequest{ServiceName: strPtr("Spotify Family")}); err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+		}, want: []string{"Hulu", "Netflix", "Spotify Family"}, wantLoad: true},
+		{name: "delete invalidates", change: func() {
+			if err := svc.Delete(ctx, spotify.ID); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+		}, want: []string{"Hulu", "Netflix"}, wantLoad: true},
+		{name: "delete of one of several rows invalidates too", change: func() {
+			if err := svc.Delete(ctx, netflix.ID); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+		}, want: []string{"Hulu", "Netflix"}, wantLoad: true},
+		{name: "expiry reloads", change: func() { clock.Advance(time.Minute) }, want: []string{"Hulu", "Netflix"}, wantLoad: true},
+	}
+
+	for _, step := range steps {
+		loads := store.loads
+		step.change()
+
+		resp, err := svc.ListServiceNames(ctx, &domain.ServiceNamesRequest{})
+		if err != nil {
+			t.Fatalf("%s: ListServiceNames: %v", step.name, err)
+		}
+		if !reflect.DeepEqual(resp.Data, step.want) {
+			t.Errorf("%s: names = %v, want %v", step.name, resp.Data, step.want)
+		}
+		if loaded := store.loads > loads; loaded != step.wantLoad {
+			t.Errorf("%s: loaded = %v, want %v", step.name, loaded, step.wantLoad)
+		}
+	}
+}
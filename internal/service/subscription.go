@@ -2,9 +2,16 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"subscription-service/internal/clock"
+	"subscription-service/internal/config"
 	"subscription-service/internal/domain"
+	"subscription-service/internal/jsonpatch"
 	"subscription-service/internal/repository"
 
 	"github.com/google/uuid"
@@ -14,45 +21,126 @@ import (
 type SubscriptionService interface {
 	Create(ctx context.Context, req *domain.CreateSubscriptionRequest) (*domain.Subscription, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)
+	GetByIDIncludingDeleted(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)
+	GetComputed(ctx context.Context, id uuid.UUID) (*domain.SubscriptionWithComputed, error)
 	Update(ctx context.Context, id uuid.UUID, req *domain.UpdateSubscriptionRequest) (*domain.Subscription, error)
+	Put(ctx context.Context, id uuid.UUID, req *domain.CreateSubscriptionRequest) (*domain.Subscription, bool, error)
+	Patch(ctx context.Context, id uuid.UUID, patch []jsonpatch.Operation) (*domain.Subscription, error)
 	Delete(ctx context.Context, id uuid.UUID) error
+	Clone(ctx context.Context, id uuid.UUID, req *domain.CloneSubscriptionRequest) (*domain.Subscription, error)
 	List(ctx context.Context, req *domain.ListSubscriptionsRequest) ([]*domain.Subscription, int64, error)
+	CountActive(ctx context.Context, req *domain.ListSubscriptionsRequest) (int64, error)
+	CountByBillingPeriod(ctx context.Context, req *domain.ListSubscriptionsRequest) ([]domain.BillingPeriodCount, error)
+	Trend(ctx context.Context, req *domain.TrendRequest) (*domain.TrendResponse, error)
+	Schedule(ctx context.Context, req *domain.ScheduleRequest) (*domain.ScheduleResponse, error)
+	AddPause(ctx context.Context, id uuid.UUID, req *domain.CreatePauseRequest) (*domain.SubscriptionPause, error)
+	RemovePause(ctx context.Context, id, pauseID uuid.UUID) error
+	ListByService(ctx context.Context, serviceName string, req *domain.ServiceSubscriptionsRequest) (*domain.ServiceSubscriptionsResponse, error)
+	ListServiceNames(ctx context.Context, req *domain.ServiceNamesRequest) (*domain.ServiceNamesResponse, error)
+	BulkTag(ctx context.Context, req *domain.BulkTagRequest) (*domain.BulkTagResponse, error)
+	ReassignUser(ctx context.Context, req *domain.ReassignUserRequest) (*domain.ReassignUserResponse, error)
+	AnonymizeUser(ctx context.Context, userID uuid.UUID) (*domain.AnonymizeUserResponse, error)
+	Export(ctx context.Context, req *domain.ExportSubscriptionsRequest, fn func(*domain.Subscription) error) error
+	ExportUser(ctx context.Context, userID uuid.UUID, fn func(*domain.UserExportSubscription) error) (time.Time, error)
 	CalculateTotalCost(ctx context.Context, req *domain.TotalCostRequest) (*domain.TotalCostResponse, error)
+	Validate(ctx context.Context, req *domain.CreateSubscriptionRequest) (*domain.ValidateSubscriptionResponse, error)
+	CreateWarnings(req *domain.CreateSubscriptionRequest) []domain.FieldError
+	ValidateUpdate(ctx context.Context, id uuid.UUID, req *domain.UpdateSubscriptionRequest) (*domain.ValidateSubscriptionResponse, error)
 }
 
+const defaultMaxOffset = 10000
+
 type subscriptionService struct {
-	repo   repository.SubscriptionRepository
-	logger *zap.Logger
+	repo         repository.SubscriptionRepository
+	validator    *SubscriptionValidator
+	cfg          config.SubscriptionConfig
+	clock        clock.Clock
+	dedup        *createDeduper
+	serviceNames *serviceNameCache
+	logger       *zap.Logger
 }
 
-func NewSubscriptionService(repo repository.SubscriptionRepository, logger *zap.Logger) SubscriptionService {
-	return &subscriptionService{
-		repo:   repo,
-		logger: logger,
+func NewSubscriptionService(repo repository.SubscriptionRepository, validator *SubscriptionValidator, cfg config.SubscriptionConfig, clock clock.Clock, logger *zap.Logger) SubscriptionService {
+	s := &subscriptionService{
+		repo:         repo,
+		validator:    validator,
+		cfg:          cfg,
+		clock:        clock,
+		serviceNames: newServiceNameCache(cfg.ServiceNamesTTL, clock),
+		logger:       logger,
+	}
+	if cfg.DedupWindow > 0 {
+		s.dedup = newCreateDeduper(cfg.DedupWindow, cfg.DedupMaxEntries, clock)
 	}
+	return s
 }
 
 func (s *subscriptionService) Create(ctx context.Context, req *domain.CreateSubscriptionRequest) (*domain.Subscription, error) {
 	s.logger.Info("service: creating subscription", zap.String("service_name", req.ServiceName))
 
-	if err := s.validateDateFormat(req.StartDate); err != nil {
-		s.logger.Error("invalid start date format", zap.String("start_date", req.StartDate), zap.Error(err))
+	if err := s.applyDefaultUserID(req); err != nil {
 		return nil, err
 	}
 
-	if req.EndDate != nil {
-		if err := s.validateDateFormat(*req.EndDate); err != nil {
-			s.logger.Error("invalid end date format", zap.String("end_date", *req.EndDate), zap.Error(err))
+	if problems := s.validator.ValidateCreate(req); len(problems) > 0 {
+		s.logger.Error("invalid subscription", zap.Error(problems))
+		return nil, problems
+	}
+	applyCreatePrice(req)
+
+	if s.dedup == nil {
+		return s.create(ctx, req)
+	}
+
+	// A remembered subscription that has since been deleted is forgotten
+	// and the key claimed again; req is already validated and normalized,
+	// so only the claim is retried.
+	key := createFingerprint(req)
+	for {
+		existingID, found, err := s.dedup.claim(ctx, key)
+		if err != nil {
 			return nil, err
 		}
+		if !found {
+			break
+		}
 
-		if *req.EndDate < req.StartDate {
-			s.logger.Error("end date must be after start date")
-			return nil, errors.New("end date must be after start date")
+		existing, err := s.repo.GetByID(ctx, existingID)
+		if err == nil {
+			s.logger.Info("identical create within dedup window, returning existing subscription", zap.String("id", existingID.String()))
+			return existing, nil
 		}
+		if !errors.Is(err, domain.ErrSubscriptionNotFound) {
+			return nil, err
+		}
+		s.dedup.forget(key)
+	}
+
+	subscription, err := s.create(ctx, req)
+	if err != nil {
+		s.dedup.finish(key, nil)
+		return nil, err
+	}
+	s.dedup.finish(key, &subscription.ID)
+	return subscription, nil
+}
+
+func (s *subscriptionService) create(ctx context.Context, req *domain.CreateSubscriptionRequest) (*domain.Subscription, error) {
+	subscription, err := s.repo.Create(ctx, req)
+	if errors.Is(err, domain.ErrDuplicateSubscription) {
+		return nil, s.duplicateError(ctx, &repository.OverlapFilter{
+			UserID:      req.UserID,
+			ServiceName: req.ServiceName,
+			StartDate:   req.StartDate,
+			EndDate:     req.EndDate,
+		})
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return s.repo.Create(ctx, req)
+	s.serviceNames.observe(subscription.ServiceName)
+	return subscription, nil
 }
 
 func (s *subscriptionService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
@@ -60,30 +148,136 @@ func (s *subscriptionService) GetByID(ctx context.Context, id uuid.UUID) (*domai
 	return s.repo.GetByID(ctx, id)
 }
 
+func (s *subscriptionService) GetByIDIncludingDeleted(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	s.logger.Info("service: getting subscription, including deleted", zap.String("id", id.String()))
+	return s.repo.GetByIDIncludingDeleted(ctx, id)
+}
+
 func (s *subscriptionService) Update(ctx context.Context, id uuid.UUID, req *domain.UpdateSubscriptionRequest) (*domain.Subscription, error) {
 	s.logger.Info("service: updating subscription", zap.String("id", id.String()))
 
-	_, err := s.repo.GetByID(ctx, id)
+	current, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		s.logger.Error("subscription not found", zap.String("id", id.String()), zap.Error(err))
-		return nil, errors.New("subscription not found")
+		s.logger.Error("failed to load subscription", zap.String("id", id.String()), zap.Error(err))
+		return nil, err
 	}
 
-	if req.StartDate != nil {
-		if err := s.validateDateFormat(*req.StartDate); err != nil {
-			s.logger.Error("invalid start date format", zap.String("start_date", *req.StartDate), zap.Error(err))
-			return nil, err
-		}
+	return s.update(ctx, id, current, req, false)
+}
+
+// update validates req against current and saves it. With ifUnmodified set
+// the save only goes through while the stored subscription is still
+// current, for requests derived from the whole of current.
+func (s *subscriptionService) update(ctx context.Context, id uuid.UUID, current *domain.Subscription, req *domain.UpdateSubscriptionRequest, ifUnmodified bool) (*domain.Subscription, error) {
+	if problems := s.validator.ValidateUpdate(current, req); len(problems) > 0 {
+		s.logger.Error("invalid subscription update", zap.String("id", id.String()), zap.Error(problems))
+		return nil, problems
+	}
+	if price, _ := updatePrice(current, req); price != nil {
+		req.PriceMinor = price
+		req.Price = nil
+		req.Amount = nil
 	}
 
-	if req.EndDate != nil {
-		if err := s.validateDateFormat(*req.EndDate); err != nil {
-			s.logger.Error("invalid end date format", zap.String("end_date", *req.EndDate), zap.Error(err))
-			return nil, err
-		}
+	var subscription *domain.Subscription
+	var err error
+	if ifUnmodified {
+		subscription, err = s.repo.UpdateIfUnmodified(ctx, id, current.UpdatedAt, req)
+	} else {
+		subscription, err = s.repo.Update(ctx, id, req)
+	}
+	if errors.Is(err, domain.ErrDuplicateSubscription) {
+		merged := mergeUpdate(current, req)
+		return nil, s.duplicateError(ctx, &repository.OverlapFilter{
+			UserID:      merged.UserID,
+			ServiceName: merged.ServiceName,
+			StartDate:   merged.StartDate,
+			EndDate:     merged.EndDate,
+			ExcludeID:   &id,
+		})
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return s.repo.Update(ctx, id, req)
+	if subscription.ServiceName != current.ServiceName {
+		s.serviceNames.invalidate()
+	}
+	return subscription, nil
+}
+
+// Put creates the subscription at the client-chosen id, or replaces the
+// existing one at that id, and reports whether it was created. Fields left
+// out of req take their create defaults on replace too.
+func (s *subscriptionService) Put(ctx context.Context, id uuid.UUID, req *domain.CreateSubscriptionRequest) (*domain.Subscription, bool, error) {
+	s.logger.Info("service: putting subscription", zap.String("id", id.String()))
+
+	if err := s.applyDefaultUserID(req); err != nil {
+		return nil, false, err
+	}
+
+	if problems := s.validator.ValidatePut(id, req); len(problems) > 0 {
+		s.logger.Error("invalid subscription", zap.String("id", id.String()), zap.Error(problems))
+		return nil, false, problems
+	}
+	applyCreatePrice(req)
+
+	subscription, created, err := s.repo.Put(ctx, id, req)
+	if errors.Is(err, domain.ErrDuplicateSubscription) {
+		return nil, false, s.duplicateError(ctx, &repository.OverlapFilter{
+			UserID:      req.UserID,
+			ServiceName: req.ServiceName,
+			StartDate:   req.StartDate,
+			EndDate:     req.EndDate,
+			ExcludeID:   &id,
+		})
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if created {
+		s.serviceNames.observe(subscription.ServiceName)
+	} else {
+		s.serviceNames.invalidate()
+	}
+	return subscription, created, nil
+}
+
+// applyDefaultUserID fills in the configured default user for creates that
+// omit user_id.
+func (s *subscriptionService) applyDefaultUserID(req *domain.CreateSubscriptionRequest) error {
+	if req.UserID != uuid.Nil || s.cfg.DefaultUserID == "" {
+		return nil
+	}
+
+	userID, err := uuid.Parse(s.cfg.DefaultUserID)
+	if err != nil {
+		return err
+	}
+	req.UserID = userID
+	return nil
+}
+
+// applyCreatePrice replaces a validated price, in whichever form it was
+// given, with the price in minor units, so the layers below only deal in
+// PriceMinor.
+func applyCreatePrice(req *domain.CreateSubscriptionRequest) {
+	req.PriceMinor, _ = createPrice(req)
+	req.Price = 0
+	req.Amount = ""
+}
+
+// duplicateError builds the error for a unique active violation, listing the
+// conflicting subscriptions found by the overlap check.
+func (s *subscriptionService) duplicateError(ctx context.Context, filter *repository.OverlapFilter) error {
+	s.logger.Warn("duplicate active subscription", zap.String("user_id", filter.UserID.String()), zap.String("service_name", filter.ServiceName))
+
+	conflicts, err := s.validator.CheckOverlap(ctx, filter)
+	if err != nil {
+		return err
+	}
+	return &domain.DuplicateSubscriptionError{Conflicts: conflicts}
 }
 
 func (s *subscriptionService) Delete(ctx context.Context, id uuid.UUID) error {
@@ -91,65 +285,396 @@ func (s *subscriptionService) Delete(ctx context.Context, id uuid.UUID) error {
 
 	_, err := s.repo.GetByID(ctx, id)
 	if err != nil {
-		s.logger.Error("subscription not found", zap.String("id", id.String()), zap.Error(err))
-		return errors.New("subscription not found")
+		s.logger.Error("failed to load subscription", zap.String("id", id.String()), zap.Error(err))
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
 	}
 
-	return s.repo.Delete(ctx, id)
+	// The deleted row may have been the last one for its service.
+	s.serviceNames.invalidate()
+	return nil
+}
+
+// Clone creates a new subscription from an existing one, applying any
+// overrides. It goes through Create so the same validation applies.
+func (s *subscriptionService) Clone(ctx context.Context, id uuid.UUID, req *domain.CloneSubscriptionRequest) (*domain.Subscription, error) {
+	s.logger.Info("service: cloning subscription", zap.String("id", id.String()))
+
+	source, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Error("failed to load subscription", zap.String("id", id.String()), zap.Error(err))
+		return nil, err
+	}
+
+	metadata, err := json.Marshal(source.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	createReq := &domain.CreateSubscriptionRequest{
+		ServiceName:   source.ServiceName,
+		PriceMinor:    source.PriceMinor,
+		UserID:        source.UserID,
+		StartDate:     source.StartDate,
+		EndDate:       source.EndDate,
+		AutoRenew:     source.AutoRenew,
+		Metadata:      metadata,
+		Tags:          source.Tags,
+		BillingPeriod: source.BillingPeriod,
+		Currency:      source.Currency,
+	}
+
+	if req.Price != nil {
+		createReq.Price = *req.Price
+		createReq.PriceMinor = 0
+	}
+	if req.PriceMinor != nil {
+		createReq.PriceMinor = *req.PriceMinor
+	}
+	if req.StartDate != nil {
+		createReq.StartDate = *req.StartDate
+	}
+	if req.EndDate != nil {
+		createReq.EndDate = req.EndDate
+	}
+
+	return s.Create(ctx, createReq)
+}
+
+// AddPause records a window during which the subscription is not billed.
+// Months whose billing date falls inside a pause are left out of the total
+// cost.
+func (s *subscriptionService) AddPause(ctx context.Context, id uuid.UUID, req *domain.CreatePauseRequest) (*domain.SubscriptionPause, error) {
+	s.logger.Info("service: adding pause", zap.String("id", id.String()))
+
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		s.logger.Error("failed to load subscription", zap.String("id", id.String()), zap.Error(err))
+		return nil, err
+	}
+
+	if problems := s.validator.ValidatePause(req); len(problems) > 0 {
+		s.logger.Error("invalid pause", zap.String("id", id.String()), zap.Error(problems))
+		return nil, problems
+	}
+
+	return s.repo.CreatePause(ctx, id, req)
+}
+
+func (s *subscriptionService) RemovePause(ctx context.Context, id, pauseID uuid.UUID) error {
+	s.logger.Info("service: removing pause", zap.String("id", id.String()), zap.String("pause_id", pauseID.String()))
+
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		s.logger.Error("failed to load subscription", zap.String("id", id.String()), zap.Error(err))
+		return err
+	}
+
+	return s.repo.DeletePause(ctx, id, pauseID)
 }
 
 func (s *subscriptionService) List(ctx context.Context, req *domain.ListSubscriptionsRequest) ([]*domain.Subscription, int64, error) {
 	s.logger.Info("service: listing subscriptions")
 
+	// A negative limit is left for ValidateList to reject.
+	if req.Limit == 0 {
+		req.Limit = 20
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+	if err := s.checkOffset(req.Offset); err != nil {
+		return nil, 0, err
+	}
+
+	filter, err := s.buildListFilter(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return s.repo.List(ctx, filter)
+}
+
+// checkOffset refuses offsets past the configured maximum page depth.
+func (s *subscriptionService) checkOffset(offset int) error {
+	max := s.cfg.MaxOffset
+	if max <= 0 {
+		max = defaultMaxOffset
+	}
+	if offset > max {
+		s.logger.Warn("offset exceeds maximum page depth", zap.Int("offset", offset), zap.Int("max", max))
+		return &domain.OffsetTooLargeError{Offset: offset, Max: max}
+	}
+	return nil
+}
+
+func (s *subscriptionService) CountActive(ctx context.Context, req *domain.ListSubscriptionsRequest) (int64, error) {
+	s.logger.Info("service: counting active subscriptions")
+
+	filter, err := s.buildListFilter(req)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.repo.CountActive(ctx, filter, s.clock.Now().Format(dateLayout))
+}
+
+// ListServiceNames returns the distinct service names, for autocomplete.
+// The full set is cached briefly; the prefix filter runs on the cached copy.
+func (s *subscriptionService) ListServiceNames(ctx context.Context, req *domain.ServiceNamesRequest) (*domain.ServiceNamesResponse, error) {
+	s.logger.Info("service: listing service names", zap.String("prefix", req.Prefix))
+
+	names, err := s.serviceNames.get(ctx, s.repo.ListServiceNames)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := strings.ToLower(req.Prefix)
+	matches := make([]string, 0, len(names))
+	for _, name := range names {
+		if req.Limit > 0 && len(matches) == req.Limit {
+			break
+		}
+		if strings.HasPrefix(strings.ToLower(name), prefix) {
+			matches = append(matches, name)
+		}
+	}
+
+	return &domain.ServiceNamesResponse{Data: matches}, nil
+}
+
+func (s *subscriptionService) CountByBillingPeriod(ctx context.Context, req *domain.ListSubscriptionsRequest) ([]domain.BillingPeriodCount, error) {
+	s.logger.Info("service: counting subscriptions by billing period")
+
+	filter, err := s.buildListFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.CountByBillingPeriod(ctx, filter)
+}
+
+// Trend returns the active subscription count per interval of the requested
+// window, by month unless another granularity is asked for.
+func (s *subscriptionService) Trend(ctx context.Context, req *domain.TrendRequest) (*domain.TrendResponse, error) {
+	s.logger.Info("service: computing subscription trend", zap.String("start", req.Start), zap.String("end", req.End), zap.String("granularity", req.Granularity))
+
+	if req.Granularity == "" {
+		req.Granularity = domain.TrendGranularityMonth
+	}
+	if problems := s.validator.ValidateTrend(req); len(problems) > 0 {
+		s.logger.Error("invalid trend request", zap.Error(problems))
+		return nil, problems
+	}
+
+	filter, err := s.buildListFilter(&req.ListSubscriptionsRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := s.repo.CountActiveTrend(ctx, &repository.TrendFilter{
+		ListSubscriptionsFilter: *filter,
+		Start:                   req.Start,
+		End:                     req.End,
+		Granularity:             req.Granularity,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TrendResponse{Granularity: req.Granularity, Data: points}, nil
+}
+
+// ListByService pages through the subscriptions whose service name matches
+// exactly and adds the subscriber count and monthly revenue across those
+// active today.
+func (s *subscriptionService) ListByService(ctx context.Context, serviceName string, req *domain.ServiceSubscriptionsRequest) (*domain.ServiceSubscriptionsResponse, error) {
+	s.logger.Info("service: listing subscriptions for service", zap.String("service_name", serviceName))
+
+	if problems := checkServiceName(serviceName); len(problems) > 0 {
+		s.logger.Error("invalid service name", zap.Error(problems))
+		return nil, problems
+	}
+
 	if req.Limit <= 0 {
 		req.Limit = 20
 	}
 	if req.Limit > 100 {
 		req.Limit = 100
 	}
+	if err := s.checkOffset(req.Offset); err != nil {
+		return nil, err
+	}
+
+	subscriptions, total, err := s.repo.List(ctx, &repository.ListSubscriptionsFilter{
+		ExactServiceName: &serviceName,
+		Limit:            req.Limit,
+		Offset:           req.Offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if total == 0 && s.cfg.EmptyServiceNotFound {
+		return nil, domain.ErrServiceNotFound
+	}
+
+	stats, err := s.repo.GetServiceStats(ctx, serviceName, s.clock.Now().Format(dateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	var legacyRevenue int64
+	for _, revenue := range stats.MonthlyRevenue {
+		if revenue.Currency == domain.DefaultCurrency {
+			legacyRevenue = revenue.Total
+		}
+	}
+
+	return &domain.ServiceSubscriptionsResponse{
+		ServiceName:     serviceName,
+		SubscriberCount: stats.SubscriberCount,
+		MonthlyRevenue:  legacyRevenue,
+		Revenue:         stats.MonthlyRevenue,
+		Data:            subscriptions,
+		Total:           total,
+		Limit:           req.Limit,
+		Offset:          req.Offset,
+	}, nil
+}
+
+// Export streams every subscription matching the list filters to fn,
+// ignoring limit and offset. Rows come in a stable order (created_at, id by
+// default) so repeated exports of unchanged data are identical.
+func (s *subscriptionService) Export(ctx context.Context, req *domain.ExportSubscriptionsRequest, fn func(*domain.Subscription) error) error {
+	s.logger.Info("service: exporting subscriptions", zap.String("sort", req.Sort))
+
+	filter, err := s.buildListFilter(&req.ListSubscriptionsRequest)
+	if err != nil {
+		return err
+	}
+
+	streamFilter := &repository.StreamFilter{ListSubscriptionsFilter: *filter}
+	if req.Sort != "" {
+		sort := repository.SortOrder{Column: strings.TrimPrefix(req.Sort, "-"), Desc: strings.HasPrefix(req.Sort, "-")}
+		if !repository.IsSortableColumn(sort.Column) {
+			s.logger.Error("invalid sort field", zap.String("sort", req.Sort))
+			return domain.ValidationErrors{{Field: "sort", Message: fmt.Sprintf("cannot sort by %q", sort.Column)}}
+		}
+		streamFilter.Sort = &sort
+	}
+
+	return s.repo.StreamAll(ctx, streamFilter, fn)
+}
+
+func (s *subscriptionService) buildListFilter(req *domain.ListSubscriptionsRequest) (*repository.ListSubscriptionsFilter, error) {
+	if problems := s.validator.ValidateList(req); len(problems) > 0 {
+		s.logger.Error("invalid list request", zap.Error(problems))
+		return nil, problems
+	}
 
 	filter := &repository.ListSubscriptionsFilter{
-		ServiceName: req.ServiceName,
-		Limit:       req.Limit,
-		Offset:      req.Offset,
+		Metadata:          metadataFilterValues(req.Metadata),
+		MinPrice:          req.MinPrice,
+		MaxPrice:          req.MaxPrice,
+		ActiveFrom:        req.ActiveFrom,
+		ActiveTo:          req.ActiveTo,
+		Tag:               req.Tag,
+		ServiceNamePrefix: req.ServiceNamePrefix,
+		OpenEnded:         req.OpenEnded,
+		IncludeDeleted:    req.IncludeDeleted,
+		Limit:             req.Limit,
+		Offset:            req.Offset,
 	}
 
-	if req.UserID != nil && *req.UserID != "" {
-		userID, err := uuid.Parse(*req.UserID)
+	userIDs := make([]uuid.UUID, len(req.UserIDs()))
+	for i, value := range req.UserIDs() {
+		userID, err := uuid.Parse(value)
 		if err != nil {
-			s.logger.Error("invalid user_id format", zap.String("user_id", *req.UserID), zap.Error(err))
-			return nil, 0, errors.New("invalid user_id format")
+			return nil, domain.ValidationErrors{{Field: "user_id", Message: fmt.Sprintf("invalid user_id format: %q", value)}}
 		}
-		filter.UserID = &userID
+		userIDs[i] = userID
+	}
+	switch len(userIDs) {
+	case 0:
+	case 1:
+		filter.UserID = &userIDs[0]
+	default:
+		filter.UserIDs = userIDs
 	}
 
-	return s.repo.List(ctx, filter)
+	filter.ServiceNames = req.ServiceNames()
+
+	if req.UpdatedSince != nil {
+		updatedSince, err := time.Parse(time.RFC3339Nano, *req.UpdatedSince)
+		if err != nil {
+			return nil, domain.ValidationErrors{{Field: "updated_since", Message: "updated_since must be an RFC 3339 timestamp"}}
+		}
+		filter.UpdatedSince = &updatedSince
+	}
+	if req.UpdatedAfterID != nil {
+		afterID, err := uuid.Parse(*req.UpdatedAfterID)
+		if err != nil {
+			return nil, domain.ValidationErrors{{Field: "updated_after_id", Message: fmt.Sprintf("invalid updated_after_id format: %q", *req.UpdatedAfterID)}}
+		}
+		filter.UpdatedAfterID = &afterID
+	}
+
+	return filter, nil
 }
 
 func (s *subscriptionService) CalculateTotalCost(ctx context.Context, req *domain.TotalCostRequest) (*domain.TotalCostResponse, error) {
 	s.logger.Info("service: calculating total cost")
 
-	if err := s.validateDateFormat(req.StartDate); err != nil {
-		s.logger.Error("invalid start date format", zap.String("start_date", req.StartDate), zap.Error(err))
-		return nil, err
+	if problems := s.validator.ValidateTotalCost(req); len(problems) > 0 {
+		s.logger.Error("invalid total cost request", zap.Error(problems))
+		return nil, problems
 	}
 
-	if err := s.validateDateFormat(req.EndDate); err != nil {
-		s.logger.Error("invalid end date format", zap.String("end_date", req.EndDate), zap.Error(err))
-		return nil, err
+	if req.Period != "" {
+		startDate, endDate, err := resolvePeriod(req.Period, s.clock.Now())
+		if err != nil {
+			s.logger.Error("invalid period", zap.String("period", req.Period), zap.Error(err))
+			return nil, domain.ValidationErrors{{Field: "period", Message: err.Error()}}
+		}
+
+		req.StartDate = startDate
+		req.EndDate = endDate
+	}
+
+	start, problem := checkDate("start_date", req.StartDate)
+	if problem != nil {
+		return nil, domain.ValidationErrors{*problem}
+	}
+	end, problem := checkDate("end_date", req.EndDate)
+	if problem != nil {
+		return nil, domain.ValidationErrors{*problem}
+	}
+
+	windowField := "end_date"
+	if req.Period != "" {
+		windowField = "period"
+	}
+	if problems := s.validator.checkCostWindow(windowField, start, end); len(problems) > 0 {
+		s.logger.Error("invalid total cost window", zap.String("start_date", req.StartDate), zap.String("end_date", req.EndDate), zap.Error(problems))
+		return nil, problems
 	}
 
 	filter := &repository.TotalCostFilter{
 		ServiceName: req.ServiceName,
+		Currency:    domain.DefaultCurrency,
 		StartDate:   req.StartDate,
 		EndDate:     req.EndDate,
 	}
+	if req.Currency != nil {
+		filter.Currency = *req.Currency
+	}
 
 	if req.UserID != nil && *req.UserID != "" {
 		userID, err := uuid.Parse(*req.UserID)
 		if err != nil {
-			s.logger.Error("invalid user_id format", zap.String("user_id", *req.UserID), zap.Error(err))
-			return nil, errors.New("invalid user_id format")
+			return nil, domain.ValidationErrors{{Field: "user_id", Message: "invalid user_id format"}}
 		}
 		filter.UserID = &userID
 	}
@@ -159,25 +684,144 @@ func (s *subscriptionService) CalculateTotalCost(ctx context.Context, req *domai
 		return nil, err
 	}
 
-	return &domain.TotalCostResponse{TotalCost: totalCost}, nil
+	response := &domain.TotalCostResponse{
+		TotalCost:      int(domain.WholeUnits(int64(totalCost), filter.Currency)),
+		TotalCostMinor: totalCost,
+		Currency:       filter.Currency,
+		Amount:         domain.FormatAmount(int64(totalCost), filter.Currency),
+	}
+	if req.GroupBy == domain.TotalCostGroupByService {
+		breakdown, err := s.totalCostByService(ctx, filter, req)
+		if err != nil {
+			return nil, err
+		}
+		response.Breakdown = breakdown
+	}
+
+	return response, nil
+}
+
+// totalCostByService returns one page of the per-service breakdown, biggest
+// spend first unless another order is requested.
+func (s *subscriptionService) totalCostByService(ctx context.Context, filter *repository.TotalCostFilter, req *domain.TotalCostRequest) (*domain.TotalCostBreakdown, error) {
+	if req.Order == "" {
+		req.Order = domain.TotalCostOrderCostDesc
+	}
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.Limit > 100 {
+		req.Limit = 100
+	}
+
+	costs, groups, err := s.repo.CalculateTotalCostByService(ctx, &repository.TotalCostBreakdownFilter{
+		TotalCostFilter: *filter,
+		Order:           req.Order,
+		Limit:           req.Limit,
+		Offset:          req.Offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.TotalCostBreakdown{
+		Data:   costs,
+		Total:  groups,
+		Limit:  req.Limit,
+		Offset: req.Offset,
+	}, nil
+}
+
+// CreateWarnings returns the non-fatal warnings for a create request, as of
+// today according to the service clock.
+func (s *subscriptionService) CreateWarnings(req *domain.CreateSubscriptionRequest) []domain.FieldError {
+	return s.validator.CreateWarnings(req, s.clock.Now())
 }
 
-func (s *subscriptionService) validateDateFormat(date string) error {
-	if len(date) != 10 {
-		return errors.New("date must be in YYYY-MM-DD format")
+func (s *subscriptionService) Validate(ctx context.Context, req *domain.CreateSubscriptionRequest) (*domain.ValidateSubscriptionResponse, error) {
+	s.logger.Info("service: validating subscription", zap.String("service_name", req.ServiceName))
+
+	if err := s.applyDefaultUserID(req); err != nil {
+		return nil, err
 	}
 
-	if date[4] != '-' || date[7] != '-' {
-		return errors.New("date must be in YYYY-MM-DD format")
+	if problems := s.validator.ValidateCreate(req); len(problems) > 0 {
+		return newValidateResponse(problems, nil), nil
 	}
 
-	year := date[:4]
-	month := date[5:7]
-	day := date[8:]
+	problems, warnings, err := s.validator.CheckOverlapRule(ctx, &repository.OverlapFilter{
+		UserID:      req.UserID,
+		ServiceName: req.ServiceName,
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newValidateResponse(problems, warnings), nil
+}
+
+func (s *subscriptionService) ValidateUpdate(ctx context.Context, id uuid.UUID, req *domain.UpdateSubscriptionRequest) (*domain.ValidateSubscriptionResponse, error) {
+	s.logger.Info("service: validating subscription update", zap.String("id", id.String()))
 
-	if len(year) != 4 || len(month) != 2 || len(day) != 2 {
-		return errors.New("date must be in YYYY-MM-DD format")
+	current, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Error("failed to load subscription", zap.String("id", id.String()), zap.Error(err))
+		return nil, err
 	}
 
-	return nil
+	if problems := s.validator.ValidateUpdate(current, req); len(problems) > 0 {
+		return newValidateResponse(problems, nil), nil
+	}
+
+	merged := mergeUpdate(current, req)
+	problems, warnings, err := s.validator.CheckOverlapRule(ctx, &repository.OverlapFilter{
+		UserID:      merged.UserID,
+		ServiceName: merged.ServiceName,
+		StartDate:   merged.StartDate,
+		EndDate:     merged.EndDate,
+		ExcludeID:   &id,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newValidateResponse(problems, warnings), nil
+}
+
+func newValidateResponse(problems, warnings domain.ValidationErrors) *domain.ValidateSubscriptionResponse {
+	if problems == nil {
+		problems = domain.ValidationErrors{}
+	}
+	if warnings == nil {
+		warnings = domain.ValidationErrors{}
+	}
+	return &domain.ValidateSubscriptionResponse{
+		Valid:    len(problems) == 0,
+		Problems: problems,
+		Warnings: warnings,
+	}
+}
+
+// metadataFilterValues converts query-string metadata filters into JSON
+// values, keeping numbers and booleans typed so that ?metadata.seats=5 matches
+// {"seats": 5} as well as string values matching {"external_ref": "abc"}.
+func metadataFilterValues(raw map[string]string) map[string]interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	values := make(map[string]interface{}, len(raw))
+	for key, value := range raw {
+		var typed interface{}
+		if err := json.Unmarshal([]byte(value), &typed); err == nil {
+			switch typed.(type) {
+			case float64, bool:
+				values[key] = typed
+				continue
+			}
+		}
+		values[key] = value
+	}
+
+	return values
 }
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository"
+
+	"go.uber.org/zap"
+)
+
+// BulkTag adds and removes tags on every subscription matching the filter
+// in one transaction and reports how many subscriptions changed.
+func (s *subscriptionService) BulkTag(ctx context.Context, req *domain.BulkTagRequest) (*domain.BulkTagResponse, error) {
+	s.logger.Info("service: bulk tagging subscriptions", zap.Int("ids", len(req.Filter.IDs)), zap.Bool("confirm", req.Confirm))
+
+	if problems := s.validator.ValidateBulkTags(req); len(problems) > 0 {
+		s.logger.Error("invalid bulk tag request", zap.Error(problems))
+		return nil, problems
+	}
+
+	affected, err := s.repo.BulkTag(ctx, &repository.BulkTagFilter{
+		IDs:              req.Filter.IDs,
+		UserID:           req.Filter.UserID,
+		ExactServiceName: req.Filter.ServiceName,
+		Tag:              req.Filter.Tag,
+	}, req.Add, req.Remove)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.BulkTagResponse{Affected: affected}, nil
+}
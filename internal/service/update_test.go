@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestUpdateEndDate(t *testing.T) {
+	id := uuid.New()
+	current := &domain.Subscription{ID: id, ServiceName: "Netflix", PriceMinor: 40000, Currency: "RUB", UserID: uuid.New(), StartDate: "2025-01-01", EndDate: strPtr("2025-06-01")}
+
+	tests := []struct {
+		name         string
+		req          domain.UpdateSubscriptionRequest
+		wantEnd      *string
+		wantClear    bool
+		wantProblems []string
+	}{
+		{name: "omitted keeps it", req: domain.UpdateSubscriptionRequest{ServiceName: strPtr("Hulu")}},
+		{name: "end_date changes it", req: domain.UpdateSubscriptionRequest{EndDate: strPtr("2025-09-01")}, wantEnd: strPtr("2025-09-01")},
+		{name: "clear_end_date clears it", req: domain.UpdateSubscriptionRequest{ClearEndDate: true}, wantClear: true},
+		{name: "clear_end_date with end_date is rejected", req: domain.UpdateSubscriptionRequest{ClearEndDate: true, EndDate: strPtr("2025-09-01")}, wantProblems: []string{"clear_end_date"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var saved *domain.UpdateSubscriptionRequest
+			repo := &fakeRepository{
+				getByID: func(context.Context, uuid.UUID) (*domain.Subscription, error) {
+					return current, nil
+				},
+				update: func(_ context.Context, _ uuid.UUID, req *domain.UpdateSubscriptionRequest) (*domain.Subscription, error) {
+					saved = req
+					return current, nil
+				},
+			}
+			svc := newTestService(repo, config.SubscriptionConfig{}, newFakeClock(testToday))
+
+			_, err := svc.Update(context.Background(), id, &tt.req)
+
+			if tt.wantProblems != nil {
+				var problems domain.ValidationErrors
+				if !errors.As(err, &problems) {
+					t.Fatalf("Update error = %v, want validation errors", err)
+				}
+				if got := fields(problems); !reflect.DeepEqual(got, tt.wantProblems) {
+					t.Errorf("problems = %v, want %v", got, tt.wantProblems)
+				}
+				if saved != nil {
+					t.Error("a rejected update reached the repository")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+			if !reflect.DeepEqual(saved.EndDate, tt.wantEnd) || saved.ClearEndDate != tt.wantClear {
+				t.Errorf("saved end_date = %v, clear_end_date = %v, want %v, %v", saved.EndDate, saved.ClearEndDate, tt.wantEnd, tt.wantClear)
+			}
+		})
+	}
+}
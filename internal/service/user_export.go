@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ExportUser streams every subscription of userID, soft-deleted ones
+// included, to fn along with its pauses and history, one subscription at a
+// time so memory stays bounded however many the user has. It returns the
+// time the export was taken.
+func (s *subscriptionService) ExportUser(ctx context.Context, userID uuid.UUID, fn func(*domain.UserExportSubscription) error) (time.Time, error) {
+	s.logger.Info("service: exporting user data", zap.String("user_id", userID.String()))
+
+	exportedAt := s.clock.Now().UTC()
+	filter := &repository.StreamFilter{
+		ListSubscriptionsFilter: repository.ListSubscriptionsFilter{
+			UserIDs:        []uuid.UUID{userID},
+			IncludeDeleted: true,
+		},
+	}
+
+	count := 0
+	err := s.repo.StreamAll(ctx, filter, func(subscription *domain.Subscription) error {
+		pauses, err := s.repo.ListPauses(ctx, subscription.ID)
+		if err != nil {
+			return err
+		}
+		history, err := s.repo.ListHistory(ctx, subscription.ID)
+		if err != nil {
+			return err
+		}
+
+		count++
+		return fn(&domain.UserExportSubscription{Subscription: *subscription, Pauses: pauses, History: history})
+	})
+	if err != nil {
+		s.logger.Error("failed to export user data", zap.String("user_id", userID.String()), zap.Int("exported", count), zap.Error(err))
+		return time.Time{}, err
+	}
+
+	s.logger.Info("user data exported", zap.String("user_id", userID.String()), zap.Int("count", count))
+	return exportedAt, nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+func TestValidate(t *testing.T) {
+	overlapping := uuid.New()
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		cfg          config.SubscriptionConfig
+		req          domain.CreateSubscriptionRequest
+		overlaps     []uuid.UUID
+		wantValid    bool
+		wantProblems []string
+		wantWarnings []string
+	}{
+		{
+			name:         "multiple issues are all reported",
+			req:          domain.CreateSubscriptionRequest{ServiceName: "Netflix", Price: -1, StartDate: "2025-02-30", EndDate: strPtr("2025-13")},
+			wantProblems: []string{"price", "user_id", "start_date", "end_date"},
+		},
+		{
+			name:         "end before start",
+			req:          domain.CreateSubscriptionRequest{ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: "2025-06-01", EndDate: strPtr("2025-01-01")},
+			wantProblems: []string{"end_date"},
+		},
+		{
+			name:         "overlap is a warning when uniqueness is not enforced",
+			req:          domain.CreateSubscriptionRequest{ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: "2025-01-01"},
+			overlaps:     []uuid.UUID{overlapping},
+			wantValid:    true,
+			wantProblems: []string{},
+			wantWarnings: []string{"start_date"},
+		},
+		{
+			name:         "overlap is a problem when uniqueness is enforced",
+			cfg:          config.SubscriptionConfig{EnforceUniqueActive: true},
+			req:          domain.CreateSubscriptionRequest{ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: "2025-01-01"},
+			overlaps:     []uuid.UUID{overlapping},
+			wantProblems: []string{"start_date"},
+			wantWarnings: []string{},
+		},
+		{
+			name:         "valid request",
+			req:          domain.CreateSubscriptionRequest{ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: "2025-01-01"},
+			wantValid:    true,
+			wantProblems: []string{},
+			wantWarnings: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepository{
+				findOverlapping: func(ctx context.Context, filter *repository.OverlapFilter) ([]uuid.UUID, error) {
+					return tt.overlaps, nil
+				},
+			}
+			s := newTestService(repo, tt.cfg, newFakeClock(testToday))
+
+			got, err := s.Validate(context.Background(), &tt.req)
+			if err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if got.Valid != tt.wantValid {
+				t.Errorf("valid = %v, want %v", got.Valid, tt.wantValid)
+			}
+			if p := fields(got.Problems); !reflect.DeepEqual(p, tt.wantProblems) {
+				t.Errorf("problems = %v (%v), want %v", p, got.Problems, tt.wantProblems)
+			}
+			if tt.wantWarnings != nil {
+				if w := fields(got.Warnings); !reflect.DeepEqual(w, tt.wantWarnings) {
+					t.Errorf("warnings = %v, want %v", w, tt.wantWarnings)
+				}
+			}
+		})
+	}
+}
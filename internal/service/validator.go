@@ -0,0 +1,779 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	minSubscriptionYear = 1900
+	maxSubscriptionYear = 2100
+	maxServiceNameLen   = 255
+
+	defaultMaxCostWindowMonths = 120
+
+	lowPriceWarningThreshold = 10
+	farEndDateWarningYears   = 10
+)
+
+var errDateFormat = errors.New("date must be in YYYY-MM-DD format")
+
+// SubscriptionValidator holds every subscription input rule so that the
+// service, dry-run and validate endpoints all apply the same checks.
+type SubscriptionValidator struct {
+	repo   repository.SubscriptionRepository
+	cfg    config.SubscriptionConfig
+	logger *zap.Logger
+}
+
+func NewSubscriptionValidator(repo repository.SubscriptionRepository, cfg config.SubscriptionConfig, logger *zap.Logger) *SubscriptionValidator {
+	return &SubscriptionValidator{
+		repo:   repo,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+func (v *SubscriptionValidator) ValidateCreate(req *domain.CreateSubscriptionRequest) domain.ValidationErrors {
+	var problems domain.ValidationErrors
+
+	problems = append(problems, checkServiceName(req.ServiceName)...)
+	currencyProblems := checkCurrency("currency", createCurrency(req))
+	problems = append(problems, currencyProblems...)
+	if len(currencyProblems) == 0 {
+		_, priceProblems := createPrice(req)
+		problems = append(problems, priceProblems...)
+	}
+
+	if req.UserID == uuid.Nil {
+		problems = append(problems, domain.FieldError{Field: "user_id", Message: "user_id is required"})
+	}
+
+	var start, end *time.Time
+	if req.StartDate == "" {
+		problems = append(problems, domain.FieldError{Field: "start_date", Message: "start_date is required"})
+	} else {
+		parsed, fieldErr := checkDate("start_date", req.StartDate)
+		if fieldErr != nil {
+			problems = append(problems, *fieldErr)
+		} else {
+			start = &parsed
+		}
+	}
+
+	if req.EndDate != nil {
+		parsed, fieldErr := checkDate("end_date", *req.EndDate)
+		if fieldErr != nil {
+			problems = append(problems, *fieldErr)
+		} else {
+			end = &parsed
+		}
+	} else if v.cfg.RequireEndDate {
+		problems = append(problems, domain.FieldError{Field: "end_date", Message: "end_date is required"})
+	}
+
+	problems = append(problems, checkOrder(start, end)...)
+	problems = append(problems, checkMetadata(req.Metadata)...)
+	problems = append(problems, checkTags("tags", req.Tags)...)
+	if req.BillingPeriod != "" {
+		problems = append(problems, checkBillingPeriod(req.BillingPeriod)...)
+	}
+
+	return problems
+}
+
+// ValidatePut checks a create-or-replace at a client-chosen id. The id must
+// be a random (version 4) UUID so that clients picking their own ids are
+// unlikely to collide.
+func (v *SubscriptionValidator) ValidatePut(id uuid.UUID, req *domain.CreateSubscriptionRequest) domain.ValidationErrors {
+	var problems domain.ValidationErrors
+	if id.Version() != 4 || id.Variant() != uuid.RFC4122 {
+		problems = append(problems, domain.FieldError{Field: "id", Message: "id must be a version 4 UUID"})
+	}
+	return append(problems, v.ValidateCreate(req)...)
+}
+
+// ValidateUpdate checks the changed fields and the subscription that would
+// result from applying req to current.
+func (v *SubscriptionValidator) ValidateUpdate(current *domain.Subscription, req *domain.UpdateSubscriptionRequest) domain.ValidationErrors {
+	var problems domain.ValidationErrors
+
+	if req.ServiceName != nil {
+		problems = append(problems, checkServiceName(*req.ServiceName)...)
+	}
+
+	var currencyProblems domain.ValidationErrors
+	if req.Currency != nil {
+		currencyProblems = checkCurrency("currency", *req.Currency)
+		if len(currencyProblems) == 0 && req.Price == nil && req.PriceMinor == nil && req.Amount == nil {
+			currencyProblems = domain.ValidationErrors{{Field: "currency", Message: "currency changes the scale of the price, so price, price_minor or amount is required with it"}}
+		}
+	}
+	problems = append(problems, currencyProblems...)
+	if len(currencyProblems) == 0 {
+		_, priceProblems := updatePrice(current, req)
+		problems = append(problems, priceProblems...)
+	}
+
+	if req.ClearEndDate && req.EndDate != nil {
+		problems = append(problems, domain.FieldError{Field: "clear_end_date", Message: "clear_end_date cannot be combined with end_date"})
+	}
+
+	merged := mergeUpdate(current, req)
+
+	var start, end *time.Time
+	parsedStart, fieldErr := checkDate("start_date", merged.StartDate)
+	if fieldErr != nil {
+		problems = append(problems, *fieldErr)
+	} else {
+		start = &parsedStart
+	}
+
+	if merged.EndDate != nil {
+		parsed, fieldErr := checkDate("end_date", *merged.EndDate)
+		if fieldErr != nil {
+			problems = append(problems, *fieldErr)
+		} else {
+			end = &parsed
+		}
+	} else if req.ClearEndDate && v.cfg.RequireEndDate {
+		problems = append(problems, domain.FieldError{Field: "end_date", Message: "end_date is required"})
+	}
+
+	problems = append(problems, checkOrder(start, end)...)
+	problems = append(problems, checkMetadata(req.Metadata)...)
+	problems = append(problems, checkTags("tags", req.Tags)...)
+	if req.BillingPeriod != nil {
+		problems = append(problems, checkBillingPeriod(*req.BillingPeriod)...)
+	}
+
+	return problems
+}
+
+// ValidateBulkTags checks the tags to add and remove and requires confirm
+// for filters that could match every subscription of every user.
+func (v *SubscriptionValidator) ValidateBulkTags(req *domain.BulkTagRequest) domain.ValidationErrors {
+	var problems domain.ValidationErrors
+
+	if len(req.Add) == 0 && len(req.Remove) == 0 {
+		problems = append(problems, domain.FieldError{Field: "add", Message: "at least one tag to add or remove is required"})
+	}
+	problems = append(problems, checkTags("add", req.Add)...)
+	problems = append(problems, checkTags("remove", req.Remove)...)
+
+	removing := make(map[string]struct{}, len(req.Remove))
+	for _, tag := range req.Remove {
+		removing[tag] = struct{}{}
+	}
+	for _, tag := range req.Add {
+		if _, ok := removing[tag]; ok {
+			problems = append(problems, domain.FieldError{Field: "remove", Message: fmt.Sprintf("tag %q cannot be both added and removed", tag)})
+		}
+	}
+
+	if req.Filter.ServiceName != nil {
+		problems = append(problems, checkServiceName(*req.Filter.ServiceName)...)
+	}
+	if req.Filter.Tag != nil {
+		problems = append(problems, checkTags("filter.tag", []string{*req.Filter.Tag})...)
+	}
+	if req.Filter.IsBroad() && !req.Confirm {
+		problems = append(problems, domain.FieldError{Field: "confirm", Message: "confirm must be true for filters without ids or user_id"})
+	}
+
+	return problems
+}
+
+func (v *SubscriptionValidator) ValidateList(req *domain.ListSubscriptionsRequest) domain.ValidationErrors {
+	var problems domain.ValidationErrors
+
+	userIDs := req.UserIDs()
+	if len(userIDs) > domain.MaxUserIDFilters {
+		problems = append(problems, domain.FieldError{Field: "user_id", Message: fmt.Sprintf("at most %d user ids may be given", domain.MaxUserIDFilters)})
+	}
+	for _, userID := range userIDs {
+		if _, err := uuid.Parse(userID); err != nil {
+			problems = append(problems, domain.FieldError{Field: "user_id", Message: fmt.Sprintf("invalid user_id format: %q", userID)})
+		}
+	}
+
+	if req.MinPrice != nil && *req.MinPrice < 0 {
+		problems = append(problems, domain.FieldError{Field: "min_price", Message: "min_price must not be negative"})
+	}
+	if req.MaxPrice != nil && *req.MaxPrice < 0 {
+		problems = append(problems, domain.FieldError{Field: "max_price", Message: "max_price must not be negative"})
+	}
+	if req.MinPrice != nil && req.MaxPrice != nil && *req.MinPrice > *req.MaxPrice {
+		problems = append(problems, domain.FieldError{Field: "min_price", Message: "min_price must not be greater than max_price"})
+	}
+
+	var activeFrom, activeTo *time.Time
+	if req.ActiveFrom != nil {
+		if parsed, problem := checkDate("active_from", *req.ActiveFrom); problem != nil {
+			problems = append(problems, *problem)
+		} else {
+			activeFrom = &parsed
+		}
+	}
+	if req.ActiveTo != nil {
+		if parsed, problem := checkDate("active_to", *req.ActiveTo); problem != nil {
+			problems = append(problems, *problem)
+		} else {
+			activeTo = &parsed
+		}
+	}
+	if activeFrom != nil && activeTo != nil && activeTo.Before(*activeFrom) {
+		problems = append(problems, domain.FieldError{Field: "active_to", Message: "active_to must not be before active_from"})
+	}
+
+	if req.UpdatedSince != nil {
+		if _, err := time.Parse(time.RFC3339Nano, *req.UpdatedSince); err != nil {
+			problems = append(problems, domain.FieldError{Field: "updated_since", Message: "updated_since must be an RFC 3339 timestamp"})
+		}
+	}
+	if req.UpdatedAfterID != nil {
+		if req.UpdatedSince == nil {
+			problems = append(problems, domain.FieldError{Field: "updated_after_id", Message: "updated_after_id requires updated_since"})
+		}
+		if _, err := uuid.Parse(*req.UpdatedAfterID); err != nil {
+			problems = append(problems, domain.FieldError{Field: "updated_after_id", Message: fmt.Sprintf("invalid updated_after_id format: %q", *req.UpdatedAfterID)})
+		}
+	}
+
+	serviceNames := req.ServiceNames()
+	if len(serviceNames) > domain.MaxServiceNameFilters {
+		problems = append(problems, domain.FieldError{Field: "service_name", Message: fmt.Sprintf("at most %d service names may be given", domain.MaxServiceNameFilters)})
+	}
+	for _, name := range serviceNames {
+		problems = append(problems, checkServiceName(name)...)
+	}
+	if req.ServiceNamePrefix != nil {
+		if *req.ServiceNamePrefix == "" {
+			problems = append(problems, domain.FieldError{Field: "service_name_prefix", Message: "service_name_prefix must not be empty"})
+		} else if len(*req.ServiceNamePrefix) > maxServiceNameLen {
+			problems = append(problems, domain.FieldError{Field: "service_name_prefix", Message: fmt.Sprintf("service_name_prefix must be at most %d characters", maxServiceNameLen)})
+		}
+	}
+
+	if req.Tag != nil {
+		problems = append(problems, checkTags("tag", []string{*req.Tag})...)
+	}
+
+	if req.Limit < 0 {
+		problems = append(problems, domain.FieldError{Field: "limit", Message: "limit must not be negative"})
+	}
+	if req.Offset < 0 {
+		problems = append(problems, domain.FieldError{Field: "offset", Message: "offset must not be negative"})
+	}
+
+	return problems
+}
+
+func (v *SubscriptionValidator) ValidateTotalCost(req *domain.TotalCostRequest) domain.ValidationErrors {
+	var problems domain.ValidationErrors
+
+	if req.Period != "" {
+		if req.StartDate != "" || req.EndDate != "" {
+			problems = append(problems, domain.FieldError{Field: "period", Message: "period is mutually exclusive with start_date and end_date"})
+		} else if err := validatePeriod(req.Period); err != nil {
+			problems = append(problems, domain.FieldError{Field: "period", Message: err.Error()})
+		}
+	} else {
+		var start, end *time.Time
+
+		if req.StartDate == "" {
+			problems = append(problems, domain.FieldError{Field: "start_date", Message: "start_date is required unless period is set"})
+		} else if parsed, problem := checkDate("start_date", req.StartDate); problem != nil {
+			problems = append(problems, *problem)
+		} else {
+			start = &parsed
+		}
+
+		if req.EndDate == "" {
+			problems = append(problems, domain.FieldError{Field: "end_date", Message: "end_date is required unless period is set"})
+		} else if parsed, problem := checkDate("end_date", req.EndDate); problem != nil {
+			problems = append(problems, *problem)
+		} else {
+			end = &parsed
+		}
+
+		if start != nil && end != nil {
+			problems = append(problems, v.checkCostWindow("end_date", *start, *end)...)
+		}
+	}
+
+	if req.UserID != nil && *req.UserID != "" {
+		if _, err := uuid.Parse(*req.UserID); err != nil {
+			problems = append(problems, domain.FieldError{Field: "user_id", Message: "invalid user_id format"})
+		}
+	}
+
+	if req.Currency != nil {
+		problems = append(problems, checkCurrency("currency", *req.Currency)...)
+	}
+
+	if req.GroupBy != "" && req.GroupBy != domain.TotalCostGroupByService {
+		problems = append(problems, domain.FieldError{Field: "group_by", Message: fmt.Sprintf("group_by must be %s", domain.TotalCostGroupByService)})
+	}
+
+	switch req.Order {
+	case "", domain.TotalCostOrderCostDesc, domain.TotalCostOrderCostAsc, domain.TotalCostOrderServiceName:
+	default:
+		problems = append(problems, domain.FieldError{
+			Field:   "order",
+			Message: fmt.Sprintf("order must be one of %s, %s, %s", domain.TotalCostOrderCostDesc, domain.TotalCostOrderCostAsc, domain.TotalCostOrderServiceName),
+		})
+	}
+
+	if req.Limit < 0 {
+		problems = append(problems, domain.FieldError{Field: "limit", Message: "limit must not be negative"})
+	}
+	if req.Offset < 0 {
+		problems = append(problems, domain.FieldError{Field: "offset", Message: "offset must not be negative"})
+	}
+
+	return problems
+}
+
+// ValidateTrend checks the trend window and granularity; the embedded list
+// filters are checked separately by ValidateList.
+func (v *SubscriptionValidator) ValidateTrend(req *domain.TrendRequest) domain.ValidationErrors {
+	var problems domain.ValidationErrors
+	var start, end *time.Time
+
+	if req.Start == "" {
+		problems = append(problems, domain.FieldError{Field: "start", Message: "start is required"})
+	} else if parsed, problem := checkDate("start", req.Start); problem != nil {
+		problems = append(problems, *problem)
+	} else {
+		start = &parsed
+	}
+
+	if req.End == "" {
+		problems = append(problems, domain.FieldError{Field: "end", Message: "end is required"})
+	} else if parsed, problem := checkDate("end", req.End); problem != nil {
+		problems = append(problems, *problem)
+	} else {
+		end = &parsed
+	}
+
+	switch req.Granularity {
+	case domain.TrendGranularityDay, domain.TrendGranularityWeek, domain.TrendGranularityMonth:
+		if start != nil && end != nil {
+			if end.Before(*start) {
+				problems = append(problems, domain.FieldError{Field: "end", Message: "end must not be before start"})
+			} else if points := trendPoints(*start, *end, req.Granularity); points > domain.MaxTrendPoints {
+				problems = append(problems, domain.FieldError{
+					Field:   "granularity",
+					Message: fmt.Sprintf("the window spans %d %s intervals, at most %d are allowed", points, req.Granularity, domain.MaxTrendPoints),
+				})
+			}
+		}
+	default:
+		problems = append(problems, domain.FieldError{
+			Field:   "granularity",
+			Message: fmt.Sprintf("granularity must be one of %s, %s, %s", domain.TrendGranularityDay, domain.TrendGranularityWeek, domain.TrendGranularityMonth),
+		})
+	}
+
+	return problems
+}
+
+// trendPoints counts the intervals between start and end, both included,
+// with weeks starting on Monday as in Postgres date_trunc.
+func trendPoints(start, end time.Time, granularity string) int {
+	switch granularity {
+	case domain.TrendGranularityDay:
+		return int(end.Sub(start).Hours()/24) + 1
+	case domain.TrendGranularityWeek:
+		monday := func(t time.Time) time.Time {
+			return t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7))
+		}
+		return int(monday(end).Sub(monday(start)).Hours()/(24*7)) + 1
+	default:
+		return (end.Year()-start.Year())*12 + int(end.Month()) - int(start.Month()) + 1
+	}
+}
+
+func (v *SubscriptionValidator) ValidateSchedule(req *domain.ScheduleRequest) domain.ValidationErrors {
+	var problems domain.ValidationErrors
+
+	if req.WithinDays < 1 || req.WithinDays > domain.MaxScheduleDays {
+		problems = append(problems, domain.FieldError{Field: "within_days", Message: fmt.Sprintf("within_days must be between 1 and %d", domain.MaxScheduleDays)})
+	}
+	if req.Offset < 0 {
+		problems = append(problems, domain.FieldError{Field: "offset", Message: "offset must not be negative"})
+	}
+
+	return problems
+}
+
+func (v *SubscriptionValidator) ValidateReassign(req *domain.ReassignUserRequest) domain.ValidationErrors {
+	var problems domain.ValidationErrors
+
+	if req.FromUserID == uuid.Nil {
+		problems = append(problems, domain.FieldError{Field: "from_user_id", Message: "from_user_id is required"})
+	}
+	if req.ToUserID == uuid.Nil {
+		problems = append(problems, domain.FieldError{Field: "to_user_id", Message: "to_user_id is required"})
+	}
+	if len(problems) == 0 && req.FromUserID == req.ToUserID {
+		problems = append(problems, domain.FieldError{Field: "to_user_id", Message: "to_user_id must differ from from_user_id"})
+	}
+
+	return problems
+}
+
+// CreateWarnings flags create input that passes validation but is unusual
+// enough to be a likely mistake: a price below lowPriceWarningThreshold
+// whole units of its currency or an end date more than
+// farEndDateWarningYears after today. Warnings never block the create.
+func (v *SubscriptionValidator) CreateWarnings(req *domain.CreateSubscriptionRequest, today time.Time) []domain.FieldError {
+	warnings := []domain.FieldError{}
+
+	currency := createCurrency(req)
+	if price, problems := createPrice(req); len(problems) == 0 {
+		exponent, _ := domain.CurrencyExponent(currency)
+		if float64(price) < lowPriceWarningThreshold*math.Pow10(exponent) {
+			warnings = append(warnings, domain.FieldError{Field: "price", Message: fmt.Sprintf("price %s %s is unusually low", domain.FormatAmount(int64(price), currency), currency)})
+		}
+	}
+
+	if req.EndDate != nil {
+		if end, err := time.Parse(dateLayout, *req.EndDate); err == nil && end.After(today.AddDate(farEndDateWarningYears, 0, 0)) {
+			warnings = append(warnings, domain.FieldError{Field: "end_date", Message: fmt.Sprintf("end_date is more than %d years away", farEndDateWarningYears)})
+		}
+	}
+
+	return warnings
+}
+
+// ValidatePause checks that a pause window is a valid date range.
+func (v *SubscriptionValidator) ValidatePause(req *domain.CreatePauseRequest) domain.ValidationErrors {
+	var problems domain.ValidationErrors
+
+	start, problem := checkDate("pause_start", req.PauseStart)
+	if problem != nil {
+		problems = append(problems, *problem)
+	}
+
+	end, problem := checkDate("pause_end", req.PauseEnd)
+	if problem != nil {
+		problems = append(problems, *problem)
+	}
+
+	if len(problems) == 0 && end.Before(start) {
+		problems = append(problems, domain.FieldError{Field: "pause_end", Message: "pause end must not be before pause start"})
+	}
+
+	return problems
+}
+
+// checkCostWindow rejects inverted total-cost windows and ones spanning more
+// months than configured, since the cost query does work per month in the
+// window. A single-day window (start equal to end) is allowed.
+func (v *SubscriptionValidator) checkCostWindow(field string, start, end time.Time) domain.ValidationErrors {
+	if end.Before(start) {
+		return domain.ValidationErrors{{
+			Field:   field,
+			Message: fmt.Sprintf("start_date %s must not be after end_date %s", start.Format(dateLayout), end.Format(dateLayout)),
+		}}
+	}
+
+	limit := v.cfg.MaxCostWindowMonths
+	if limit <= 0 {
+		limit = defaultMaxCostWindowMonths
+	}
+
+	months := (end.Year()-start.Year())*12 + int(end.Month()) - int(start.Month()) + 1
+	if months > limit {
+		return domain.ValidationErrors{{Field: field, Message: fmt.Sprintf("window spans %d months, at most %d allowed", months, limit)}}
+	}
+	return nil
+}
+
+// CheckOverlap reports every existing subscription of the same user and
+// service whose active period intersects the one described by filter.
+func (v *SubscriptionValidator) CheckOverlap(ctx context.Context, filter *repository.OverlapFilter) (domain.ValidationErrors, error) {
+	ids, err := v.repo.FindOverlapping(ctx, filter)
+	if err != nil {
+		v.logger.Error("failed to check overlapping subscriptions", zap.Error(err))
+		return nil, err
+	}
+
+	problems := make(domain.ValidationErrors, 0, len(ids))
+	for _, id := range ids {
+		problems = append(problems, domain.FieldError{
+			Field:   "start_date",
+			Message: fmt.Sprintf("overlaps with existing subscription %s", id),
+		})
+	}
+
+	return problems, nil
+}
+
+// CheckOverlapRule applies the uniqueness rule the writes enforce to the
+// overlaps CheckOverlap finds: they are problems when enforce_unique_active
+// is set, since the write would be rejected, and warnings otherwise.
+func (v *SubscriptionValidator) CheckOverlapRule(ctx context.Context, filter *repository.OverlapFilter) (problems, warnings domain.ValidationErrors, err error) {
+	overlaps, err := v.CheckOverlap(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	if v.cfg.EnforceUniqueActive {
+		return overlaps, nil, nil
+	}
+	return nil, overlaps, nil
+}
+
+func checkDate(field, value string) (time.Time, *domain.FieldError) {
+	if err := validateDateFormat(value); err != nil {
+		return time.Time{}, &domain.FieldError{Field: field, Message: err.Error()}
+	}
+
+	parsed, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, &domain.FieldError{Field: field, Message: fmt.Sprintf("%s is not a valid calendar date", value)}
+	}
+
+	if parsed.Year() < minSubscriptionYear || parsed.Year() > maxSubscriptionYear {
+		return time.Time{}, &domain.FieldError{
+			Field:   field,
+			Message: fmt.Sprintf("year must be between %d and %d", minSubscriptionYear, maxSubscriptionYear),
+		}
+	}
+
+	return parsed, nil
+}
+
+func validateDateFormat(date string) error {
+	if len(date) != 10 {
+		return errDateFormat
+	}
+
+	if date[4] != '-' || date[7] != '-' {
+		return errDateFormat
+	}
+
+	year := date[:4]
+	month := date[5:7]
+	day := date[8:]
+
+	if len(year) != 4 || len(month) != 2 || len(day) != 2 {
+		return errDateFormat
+	}
+
+	return nil
+}
+
+func checkServiceName(name string) domain.ValidationErrors {
+	if name == "" {
+		return domain.ValidationErrors{{Field: "service_name", Message: "service_name is required"}}
+	}
+	if len(name) > maxServiceNameLen {
+		return domain.ValidationErrors{{Field: "service_name", Message: fmt.Sprintf("service_name must be at most %d characters", maxServiceNameLen)}}
+	}
+	return nil
+}
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.:-]*$`)
+
+// checkTags validates a list of tags: lowercase letters, digits and _ . : -,
+// starting with a letter or digit, at most MaxTagLength long, no repeats.
+func checkTags(field string, tags []string) domain.ValidationErrors {
+	var problems domain.ValidationErrors
+	if len(tags) > domain.MaxTagsPerSubscription {
+		problems = append(problems, domain.FieldError{Field: field, Message: fmt.Sprintf("%s must have at most %d entries", field, domain.MaxTagsPerSubscription)})
+	}
+
+	seen := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		if len(tag) > domain.MaxTagLength || !tagPattern.MatchString(tag) {
+			problems = append(problems, domain.FieldError{Field: field, Message: fmt.Sprintf("invalid tag %q: use up to %d lowercase letters, digits, _ . : or -", tag, domain.MaxTagLength)})
+			continue
+		}
+		if _, ok := seen[tag]; ok {
+			problems = append(problems, domain.FieldError{Field: field, Message: fmt.Sprintf("duplicate tag %q", tag)})
+		}
+		seen[tag] = struct{}{}
+	}
+	return problems
+}
+
+func checkBillingPeriod(period string) domain.ValidationErrors {
+	for _, allowed := range domain.BillingPeriods {
+		if period == allowed {
+			return nil
+		}
+	}
+	return domain.ValidationErrors{{Field: "billing_period", Message: "billing_period must be one of " + strings.Join(domain.BillingPeriods, ", ")}}
+}
+
+func checkPrice(field string, price int) domain.ValidationErrors {
+	if price < 1 || price > math.MaxInt32 {
+		return domain.ValidationErrors{{Field: field, Message: fmt.Sprintf("%s must be between 1 and %d", field, math.MaxInt32)}}
+	}
+	return nil
+}
+
+// checkWholePrice converts a price in whole units of currency into minor
+// units and holds the result to the same bounds as price_minor.
+func checkWholePrice(price int, currency string) (int, domain.ValidationErrors) {
+	minor, ok := domain.FromWholeUnits(int64(price), currency)
+	if price < 1 || !ok || minor > math.MaxInt32 {
+		return 0, domain.ValidationErrors{{Field: "price", Message: fmt.Sprintf("price must be between 1 and %d", domain.WholeUnits(math.MaxInt32, currency))}}
+	}
+	return int(minor), nil
+}
+
+func checkCurrency(field, code string) domain.ValidationErrors {
+	if _, ok := domain.CurrencyExponent(code); !ok {
+		return domain.ValidationErrors{{Field: field, Message: field + " must be one of " + strings.Join(domain.Currencies(), ", ")}}
+	}
+	return nil
+}
+
+// checkAmount converts a decimal amount into minor units of currency and
+// holds the result to the same bounds as a price.
+func checkAmount(amount, currency string) (int, domain.ValidationErrors) {
+	minor, err := domain.ParseAmount(amount, currency)
+	if err != nil {
+		return 0, domain.ValidationErrors{{Field: "amount", Message: err.Error()}}
+	}
+	if minor < 1 || minor > math.MaxInt32 {
+		return 0, domain.ValidationErrors{{Field: "amount", Message: fmt.Sprintf("amount must be between %s and %s",
+			domain.FormatAmount(1, currency), domain.FormatAmount(math.MaxInt32, currency))}}
+	}
+	return int(minor), nil
+}
+
+func createCurrency(req *domain.CreateSubscriptionRequest) string {
+	if req.Currency == "" {
+		return domain.DefaultCurrency
+	}
+	return req.Currency
+}
+
+// priceInputs names the ways of giving a price that a request used, out of
+// price in whole units, price_minor and amount.
+func priceInputs(price, priceMinor, amount bool) []string {
+	var given []string
+	for _, input := range []struct {
+		field string
+		used  bool
+	}{{"price", price}, {"price_minor", priceMinor}, {"amount", amount}} {
+		if input.used {
+			given = append(given, input.field)
+		}
+	}
+	return given
+}
+
+// createPrice returns the price of a create in minor units, taken from
+// whichever of price, price_minor and amount it gives.
+func createPrice(req *domain.CreateSubscriptionRequest) (int, domain.ValidationErrors) {
+	given := priceInputs(req.Price != 0, req.PriceMinor != 0, req.Amount != "")
+	switch {
+	case len(given) == 0:
+		return 0, domain.ValidationErrors{{Field: "price", Message: "one of price, price_minor and amount is required"}}
+	case len(given) > 1:
+		return 0, domain.ValidationErrors{{Field: given[1], Message: given[1] + " cannot be combined with " + given[0]}}
+	case req.Amount != "":
+		return checkAmount(req.Amount, createCurrency(req))
+	case req.PriceMinor != 0:
+		return req.PriceMinor, checkPrice("price_minor", req.PriceMinor)
+	default:
+		return checkWholePrice(req.Price, createCurrency(req))
+	}
+}
+
+// updatePrice returns the price an update sets in minor units, or nil when
+// it leaves the price alone. The price is read in the currency the
+// subscription has after the update.
+func updatePrice(current *domain.Subscription, req *domain.UpdateSubscriptionRequest) (*int, domain.ValidationErrors) {
+	given := priceInputs(req.Price != nil, req.PriceMinor != nil, req.Amount != nil)
+	if len(given) == 0 {
+		return nil, nil
+	}
+	if len(given) > 1 {
+		return nil, domain.ValidationErrors{{Field: given[1], Message: given[1] + " cannot be combined with " + given[0]}}
+	}
+
+	currency := current.Currency
+	if req.Currency != nil {
+		currency = *req.Currency
+	}
+
+	var price int
+	var problems domain.ValidationErrors
+	switch {
+	case req.Amount != nil:
+		price, problems = checkAmount(*req.Amount, currency)
+	case req.PriceMinor != nil:
+		price, problems = *req.PriceMinor, checkPrice("price_minor", *req.PriceMinor)
+	default:
+		price, problems = checkWholePrice(*req.Price, currency)
+	}
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	return &price, nil
+}
+
+func checkOrder(start, end *time.Time) domain.ValidationErrors {
+	if start != nil && end != nil && end.Before(*start) {
+		return domain.ValidationErrors{{Field: "end_date", Message: "end date must be after start date"}}
+	}
+	return nil
+}
+
+func checkMetadata(metadata json.RawMessage) domain.ValidationErrors {
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	var object map[string]interface{}
+	if err := json.Unmarshal(metadata, &object); err != nil || object == nil {
+		return domain.ValidationErrors{{Field: "metadata", Message: "metadata must be a JSON object"}}
+	}
+	return nil
+}
+
+func mergeUpdate(current *domain.Subscription, req *domain.UpdateSubscriptionRequest) domain.Subscription {
+	merged := *current
+	if req.ServiceName != nil {
+		merged.ServiceName = *req.ServiceName
+	}
+	if req.PriceMinor != nil {
+		merged.PriceMinor = *req.PriceMinor
+	}
+	if req.Currency != nil {
+		merged.Currency = *req.Currency
+	}
+	if req.StartDate != nil {
+		merged.StartDate = *req.StartDate
+	}
+	if req.EndDate != nil {
+		merged.EndDate = req.EndDate
+	}
+	if req.ClearEndDate {
+		merged.EndDate = nil
+	}
+	if req.Tags != nil {
+		merged.Tags = req.Tags
+	}
+	return merged
+}
@@ -0,0 +1,417 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"subscription-service/internal/config"
+	"subscription-service/internal/domain"
+	"subscription-service/internal/repository"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestValidateList(t *testing.T) {
+	userID := uuid.NewString()
+	tooManyUsers := make([]string, domain.MaxUserIDFilters+1)
+	for i := range tooManyUsers {
+		tooManyUsers[i] = uuid.NewString()
+	}
+
+	tests := []struct {
+		name         string
+		req          domain.ListSubscriptionsRequest
+		wantProblems []string
+	}{
+		{name: "empty request", req: domain.ListSubscriptionsRequest{}},
+		{
+			name: "valid filters",
+			req: domain.ListSubscriptionsRequest{
+				UserID:       []string{userID + "," + uuid.NewString()},
+				MinPrice:     intPtr(100),
+				MaxPrice:     intPtr(100),
+				ActiveFrom:   strPtr("2025-01-01"),
+				ActiveTo:     strPtr("2025-01-01"),
+				UpdatedSince: strPtr("2025-01-01T10:00:00.123456Z"),
+			},
+		},
+		{name: "malformed user id", req: domain.ListSubscriptionsRequest{UserID: []string{userID, "not-a-uuid"}}, wantProblems: []string{"user_id"}},
+		{name: "too many user ids", req: domain.ListSubscriptionsRequest{UserID: tooManyUsers}, wantProblems: []string{"user_id"}},
+		{name: "negative prices", req: domain.ListSubscriptionsRequest{MinPrice: intPtr(-1), MaxPrice: intPtr(-1)}, wantProblems: []string{"min_price", "max_price"}},
+		{name: "min price above max", req: domain.ListSubscriptionsRequest{MinPrice: intPtr(200), MaxPrice: intPtr(100)}, wantProblems: []string{"min_price"}},
+		{name: "malformed active date", req: domain.ListSubscriptionsRequest{ActiveFrom: strPtr("2025-02-30")}, wantProblems: []string{"active_from"}},
+		{name: "active window ends before it starts", req: domain.ListSubscriptionsRequest{ActiveFrom: strPtr("2025-02-01"), ActiveTo: strPtr("2025-01-01")}, wantProblems: []string{"active_to"}},
+		{name: "updated_since is not RFC 3339", req: domain.ListSubscriptionsRequest{UpdatedSince: strPtr("2025-01-01")}, wantProblems: []string{"updated_since"}},
+		{name: "updated_after_id without updated_since", req: domain.ListSubscriptionsRequest{UpdatedAfterID: strPtr(userID)}, wantProblems: []string{"updated_after_id"}},
+		{name: "malformed updated_after_id", req: domain.ListSubscriptionsRequest{UpdatedSince: strPtr("2025-01-01T00:00:00Z"), UpdatedAfterID: strPtr("42")}, wantProblems: []string{"updated_after_id"}},
+		{name: "empty service name prefix", req: domain.ListSubscriptionsRequest{ServiceNamePrefix: strPtr("")}, wantProblems: []string{"service_name_prefix"}},
+		{name: "overlong service name", req: domain.ListSubscriptionsRequest{ServiceName: []string{strings.Repeat("x", maxServiceNameLen+1)}}, wantProblems: []string{"service_name"}},
+		{name: "negative paging", req: domain.ListSubscriptionsRequest{Limit: -1, Offset: -1}, wantProblems: []string{"limit", "offset"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewSubscriptionValidator(&fakeRepository{}, config.SubscriptionConfig{}, zap.NewNop())
+
+			problems := v.ValidateList(&tt.req)
+			if got := fields(problems); !reflect.DeepEqual(got, nonNil(tt.wantProblems)) {
+				t.Errorf("problems = %v (%v), want %v", got, problems, tt.wantProblems)
+			}
+		})
+	}
+}
+
+func TestValidateTotalCost(t *testing.T) {
+	tests := []struct {
+		name         string
+		cfg          config.SubscriptionConfig
+		req          domain.TotalCostRequest
+		wantProblems []string
+	}{
+		{name: "explicit window", req: domain.TotalCostRequest{StartDate: "2025-01-01", EndDate: "2025-12-31"}},
+		{name: "period", req: domain.TotalCostRequest{Period: "P3M"}},
+		{name: "missing window", req: domain.TotalCostRequest{}, wantProblems: []string{"start_date", "end_date"}},
+		{name: "period with dates", req: domain.TotalCostRequest{Period: "P3M", StartDate: "2025-01-01"}, wantProblems: []string{"period"}},
+		{name: "invalid period", req: domain.TotalCostRequest{Period: "P3X"}, wantProblems: []string{"period"}},
+		{name: "malformed dates", req: domain.TotalCostRequest{StartDate: "2025-1-1", EndDate: "2025-02-30"}, wantProblems: []string{"start_date", "end_date"}},
+		{name: "end before start", req: domain.TotalCostRequest{StartDate: "2025-06-01", EndDate: "2025-01-01"}, wantProblems: []string{"end_date"}},
+		{
+			name:         "window longer than allowed",
+			cfg:          config.SubscriptionConfig{MaxCostWindowMonths: 12},
+			req:          domain.TotalCostRequest{StartDate: "2025-01-01", EndDate: "2026-01-01"},
+			wantProblems: []string{"end_date"},
+		},
+		{name: "malformed user id", req: domain.TotalCostRequest{Period: "P1M", UserID: strPtr("123")}, wantProblems: []string{"user_id"}},
+		{name: "unsupported currency", req: domain.TotalCostRequest{Period: "P1M", Currency: strPtr("XYZ")}, wantProblems: []string{"currency"}},
+		{name: "unknown grouping and order", req: domain.TotalCostRequest{Period: "P1M", GroupBy: "user", Order: "random"}, wantProblems: []string{"group_by", "order"}},
+		{name: "negative paging", req: domain.TotalCostRequest{Period: "P1M", Limit: -1, Offset: -1}, wantProblems: []string{"limit", "offset"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewSubscriptionValidator(&fakeRepository{}, tt.cfg, zap.NewNop())
+
+			problems := v.ValidateTotalCost(&tt.req)
+			if got := fields(problems); !reflect.DeepEqual(got, nonNil(tt.wantProblems)) {
+				t.Errorf("problems = %v (%v), want %v", got, problems, tt.wantProblems)
+			}
+		})
+	}
+}
+
+func TestValidatePause(t *testing.T) {
+	tests := []struct {
+		name         string
+		req          domain.CreatePauseRequest
+		wantProblems []string
+	}{
+		{name: "across a month boundary", req: domain.CreatePauseRequest{PauseStart: "2025-03-15", PauseEnd: "2025-05-10"}},
+		{name: "single day", req: domain.CreatePauseRequest{PauseStart: "2025-03-01", PauseEnd: "2025-03-01"}},
+		{name: "malformed dates", req: domain.CreatePauseRequest{PauseStart: "2025-3-1", PauseEnd: "2025-02-30"}, wantProblems: []string{"pause_start", "pause_end"}},
+		{name: "end before start", req: domain.CreatePauseRequest{PauseStart: "2025-05-01", PauseEnd: "2025-04-30"}, wantProblems: []string{"pause_end"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewSubscriptionValidator(&fakeRepository{}, config.SubscriptionConfig{}, zap.NewNop())
+
+			problems := v.ValidatePause(&tt.req)
+			if got := fields(problems); !reflect.DeepEqual(got, nonNil(tt.wantProblems)) {
+				t.Errorf("problems = %v (%v), want %v", got, problems, tt.wantProblems)
+			}
+		})
+	}
+}
+
+func TestValidateTrend(t *testing.T) {
+	trend := func(start, end, granularity string) domain.TrendRequest {
+		return domain.TrendRequest{Start: start, End: end, Granularity: granularity}
+	}
+
+	tests := []struct {
+		name         string
+		req          domain.TrendRequest
+		wantProblems []string
+	}{
+		{name: "months", req: trend("2025-01-01", "2025-04-15", domain.TrendGranularityMonth)},
+		{name: "weeks", req: trend("2025-02-19", "2025-03-02", domain.TrendGranularityWeek)},
+		{name: "single day", req: trend("2025-02-27", "2025-02-27", domain.TrendGranularityDay)},
+		{name: "missing window", req: trend("", "", domain.TrendGranularityMonth), wantProblems: []string{"start", "end"}},
+		{name: "malformed dates", req: trend("2025-1-1", "2025-02-30", domain.TrendGranularityMonth), wantProblems: []string{"start", "end"}},
+		{name: "end before start", req: trend("2025-04-01", "2025-01-01", domain.TrendGranularityMonth), wantProblems: []string{"end"}},
+		{name: "unknown granularity", req: trend("2025-01-01", "2025-04-01", "year"), wantProblems: []string{"granularity"}},
+		{name: "as many points as allowed", req: trend("2025-01-01", "2027-09-27", domain.TrendGranularityDay)},
+		{name: "one point too many", req: trend("2025-01-01", "2027-09-28", domain.TrendGranularityDay), wantProblems: []string{"granularity"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewSubscriptionValidator(&fakeRepository{}, config.SubscriptionConfig{}, zap.NewNop())
+
+			problems := v.ValidateTrend(&tt.req)
+			if got := fields(problems); !reflect.DeepEqual(got, nonNil(tt.wantProblems)) {
+				t.Errorf("problems = %v (%v), want %v", got, problems, tt.wantProblems)
+			}
+		})
+	}
+}
+
+func TestTrendPoints(t *testing.T) {
+	date := func(value string) time.Time {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			t.Fatalf("parse %s: %v", value, err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		start, end  string
+		granularity string
+		want        int
+	}{
+		{"2025-01-01", "2025-01-01", domain.TrendGranularityDay, 1},
+		{"2025-02-27", "2025-03-01", domain.TrendGranularityDay, 3},
+		// 2025-02-19 is a Wednesday; its week starts on Monday the 17th.
+		{"2025-02-19", "2025-03-02", domain.TrendGranularityWeek, 2},
+		{"2025-02-19", "2025-03-03", domain.TrendGranularityWeek, 3},
+		{"2025-01-31", "2025-02-01", domain.TrendGranularityMonth, 2},
+		{"2024-11-15", "2025-04-15", domain.TrendGranularityMonth, 6},
+	}
+
+	for _, tt := range tests {
+		if got := trendPoints(date(tt.start), date(tt.end), tt.granularity); got != tt.want {
+			t.Errorf("trendPoints(%s, %s, %s) = %d, want %d", tt.start, tt.end, tt.granularity, got, tt.want)
+		}
+	}
+}
+
+func TestValidateReassign(t *testing.T) {
+	from, to := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name         string
+		req          domain.ReassignUserRequest
+		wantProblems []string
+	}{
+		{name: "two users", req: domain.ReassignUserRequest{FromUserID: from, ToUserID: to}},
+		{name: "missing users", req: domain.ReassignUserRequest{}, wantProblems: []string{"from_user_id", "to_user_id"}},
+		{name: "missing target", req: domain.ReassignUserRequest{FromUserID: from}, wantProblems: []string{"to_user_id"}},
+		{name: "same user", req: domain.ReassignUserRequest{FromUserID: from, ToUserID: from}, wantProblems: []string{"to_user_id"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewSubscriptionValidator(&fakeRepository{}, config.SubscriptionConfig{}, zap.NewNop())
+
+			problems := v.ValidateReassign(&tt.req)
+			if got := fields(problems); !reflect.DeepEqual(got, nonNil(tt.wantProblems)) {
+				t.Errorf("problems = %v (%v), want %v", got, problems, tt.wantProblems)
+			}
+		})
+	}
+}
+
+func TestCreateWarnings(t *testing.T) {
+	create := func(mutate func(req *domain.CreateSubscriptionRequest)) domain.CreateSubscriptionRequest {
+		req := domain.CreateSubscriptionRequest{ServiceName: "Netflix", UserID: uuid.New(), StartDate: "2025-01-01", Price: 400}
+		mutate(&req)
+		return req
+	}
+	minor := func(price int, currency string) domain.CreateSubscriptionRequest {
+		return create(func(req *domain.CreateSubscriptionRequest) {
+			req.Price, req.PriceMinor, req.Currency = 0, price, currency
+		})
+	}
+
+	// The low-price threshold is lowPriceWarningThreshold whole units of
+	// the subscription's own currency, whatever its minor-unit scale.
+	tests := []struct {
+		name         string
+		req          domain.CreateSubscriptionRequest
+		wantWarnings []string
+	}{
+		{name: "ordinary request", req: create(func(*domain.CreateSubscriptionRequest) {})},
+		{name: "whole units below the threshold", req: create(func(req *domain.CreateSubscriptionRequest) { req.Price = 9 }), wantWarnings: []string{"price"}},
+		{name: "whole units at the threshold", req: create(func(req *domain.CreateSubscriptionRequest) { req.Price = 10 })},
+		{name: "kopecks just below the threshold", req: minor(999, "RUB"), wantWarnings: []string{"price"}},
+		{name: "kopecks at the threshold", req: minor(1000, "RUB")},
+		{name: "yen, without minor units, below the threshold", req: minor(9, "JPY"), wantWarnings: []string{"price"}},
+		{name: "yen at the threshold", req: minor(10, "JPY")},
+		{name: "fils, three decimals, below the threshold", req: minor(9999, "BHD"), wantWarnings: []string{"price"}},
+		{name: "fils at the threshold", req: minor(10000, "BHD")},
+		{name: "amount below the threshold", req: create(func(req *domain.CreateSubscriptionRequest) { req.Price, req.Amount, req.Currency = 0, "9.99", "USD" }), wantWarnings: []string{"price"}},
+		{name: "invalid price is left to validation", req: create(func(req *domain.CreateSubscriptionRequest) { req.Price = -1 })},
+		{name: "end date ten years away", req: create(func(req *domain.CreateSubscriptionRequest) { req.EndDate = strPtr("2035-03-15") })},
+		{name: "end date past ten years", req: create(func(req *domain.CreateSubscriptionRequest) { req.EndDate = strPtr("2035-03-16") }), wantWarnings: []string{"end_date"}},
+		{
+			name:         "both",
+			req:          create(func(req *domain.CreateSubscriptionRequest) { req.Price, req.EndDate = 1, strPtr("2099-01-01") }),
+			wantWarnings: []string{"price", "end_date"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewSubscriptionValidator(&fakeRepository{}, config.SubscriptionConfig{}, zap.NewNop())
+
+			warnings := v.CreateWarnings(&tt.req, testToday)
+			if got := fields(warnings); !reflect.DeepEqual(got, nonNil(tt.wantWarnings)) {
+				t.Errorf("warnings = %v (%v), want %v", got, warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestBuildListFilter(t *testing.T) {
+	first, second := uuid.New(), uuid.New()
+	since := time.Date(2025, time.January, 1, 10, 0, 0, 123456000, time.UTC)
+
+	tests := []struct {
+		name         string
+		req          domain.ListSubscriptionsRequest
+		check        func(t *testing.T, filter *repository.ListSubscriptionsFilter)
+		wantProblems []string
+	}{
+		{
+			name: "one user id",
+			req:  domain.ListSubscriptionsRequest{UserID: []string{first.String()}},
+			check: func(t *testing.T, filter *repository.ListSubscriptionsFilter) {
+				if filter.UserID == nil || *filter.UserID != first || filter.UserIDs != nil {
+					t.Errorf("user_id = %v, user_ids = %v, want just %s", filter.UserID, filter.UserIDs, first)
+				}
+			},
+		},
+		{
+			name: "several user ids",
+			req:  domain.ListSubscriptionsRequest{UserID: []string{first.String() + "," + second.String()}},
+			check: func(t *testing.T, filter *repository.ListSubscriptionsFilter) {
+				if filter.UserID != nil || !reflect.DeepEqual(filter.UserIDs, []uuid.UUID{first, second}) {
+					t.Errorf("user_id = %v, user_ids = %v, want [%s %s]", filter.UserID, filter.UserIDs, first, second)
+				}
+			},
+		},
+		{
+			name: "updated since",
+			req:  domain.ListSubscriptionsRequest{UpdatedSince: strPtr(since.Format(time.RFC3339Nano))},
+			check: func(t *testing.T, filter *repository.ListSubscriptionsFilter) {
+				if filter.UpdatedSince == nil || !filter.UpdatedSince.Equal(since) {
+					t.Errorf("updated_since = %v, want %v", filter.UpdatedSince, since)
+				}
+			},
+		},
+		{name: "malformed user id", req: domain.ListSubscriptionsRequest{UserID: []string{"nope"}}, wantProblems: []string{"user_id"}},
+		{name: "malformed updated_since", req: domain.ListSubscriptionsRequest{UpdatedSince: strPtr("yesterday")}, wantProblems: []string{"updated_since"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(&fakeRepository{}, config.SubscriptionConfig{}, newFakeClock(testToday))
+
+			filter, err := s.buildListFilter(&tt.req)
+
+			if tt.wantProblems != nil {
+				var problems domain.ValidationErrors
+				if !errors.As(err, &problems) {
+					t.Fatalf("buildListFilter error = %v, want validation errors", err)
+				}
+				if got := fields(problems); !reflect.DeepEqual(got, tt.wantProblems) {
+					t.Errorf("problems = %v, want %v", got, tt.wantProblems)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildListFilter: %v", err)
+			}
+			tt.check(t, filter)
+		})
+	}
+}
+
+func TestCalculateTotalCostRejectsMalformedInput(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       domain.TotalCostRequest
+		wantField string
+	}{
+		{name: "user id", req: domain.TotalCostRequest{Period: "P1M", UserID: strPtr("not-a-uuid")}, wantField: "user_id"},
+		{name: "period", req: domain.TotalCostRequest{Period: "last_week"}, wantField: "period"},
+		{name: "start date", req: domain.TotalCostRequest{StartDate: "01.01.2025", EndDate: "2025-12-31"}, wantField: "start_date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeRepository{
+				calculateTotalCost: func(context.Context, *repository.TotalCostFilter) (int, error) {
+					t.Fatal("malformed request reached the repository")
+					return 0, nil
+				},
+			}
+			s := newTestService(repo, config.SubscriptionConfig{}, newFakeClock(testToday))
+
+			_, err := s.CalculateTotalCost(context.Background(), &tt.req)
+
+			var problems domain.ValidationErrors
+			if !errors.As(err, &problems) {
+				t.Fatalf("CalculateTotalCost error = %v, want validation errors", err)
+			}
+			if got := fields(problems); !reflect.DeepEqual(got, []string{tt.wantField}) {
+				t.Errorf("problems = %v, want [%s]", got, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestRequireEndDate(t *testing.T) {
+	userID := uuid.New()
+	current := &domain.Subscription{ServiceName: "Netflix", PriceMinor: 40000, Currency: "RUB", UserID: userID, StartDate: "2025-01-01", EndDate: strPtr("2025-12-01")}
+	create := func(endDate *string) func(v *SubscriptionValidator) domain.ValidationErrors {
+		return func(v *SubscriptionValidator) domain.ValidationErrors {
+			return v.ValidateCreate(&domain.CreateSubscriptionRequest{ServiceName: "Netflix", Price: 400, UserID: userID, StartDate: "2025-01-01", EndDate: endDate})
+		}
+	}
+	update := func(req domain.UpdateSubscriptionRequest) func(v *SubscriptionValidator) domain.ValidationErrors {
+		return func(v *SubscriptionValidator) domain.ValidationErrors {
+			return v.ValidateUpdate(current, &req)
+		}
+	}
+
+	tests := []struct {
+		name         string
+		require      bool
+		validate     func(v *SubscriptionValidator) domain.ValidationErrors
+		wantProblems []string
+	}{
+		{name: "optional: create without an end date", validate: create(nil)},
+		{name: "optional: create with an end date", validate: create(strPtr("2025-12-01"))},
+		{name: "optional: clear the end date", validate: update(domain.UpdateSubscriptionRequest{ClearEndDate: true})},
+		{name: "required: create without an end date", require: true, validate: create(nil), wantProblems: []string{"end_date"}},
+		{name: "required: create with an end date", require: true, validate: create(strPtr("2025-12-01"))},
+		{name: "required: clear the end date", require: true, validate: update(domain.UpdateSubscriptionRequest{ClearEndDate: true}), wantProblems: []string{"end_date"}},
+		{name: "required: update other fields", require: true, validate: update(domain.UpdateSubscriptionRequest{ServiceName: strPtr("Hulu")})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewSubscriptionValidator(&fakeRepository{}, config.SubscriptionConfig{RequireEndDate: tt.require}, zap.NewNop())
+
+			problems := tt.validate(v)
+			if got := fields(problems); !reflect.DeepEqual(got, nonNil(tt.wantProblems)) {
+				t.Errorf("problems = %v (%v), want %v", got, problems, tt.wantProblems)
+			}
+		})
+	}
+}
+
+// nonNil lets a missing wantProblems compare equal to fields of no problems.
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE subscriptions ADD COLUMN auto_renew BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_subscriptions_auto_renew_end_date ON subscriptions(end_date) WHERE auto_renew;
+
+CREATE TABLE subscription_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    action VARCHAR(50) NOT NULL,
+    details JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_subscription_history_subscription_id ON subscription_history(subscription_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_subscription_history_subscription_id;
+DROP TABLE IF EXISTS subscription_history;
+DROP INDEX IF EXISTS idx_subscriptions_auto_renew_end_date;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS auto_renew;
@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE subscriptions ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}'::JSONB;
+
+CREATE INDEX idx_subscriptions_metadata ON subscriptions USING GIN (metadata jsonb_path_ops);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_subscriptions_metadata;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS metadata;
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE subscription_pauses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    pause_start DATE NOT NULL,
+    pause_end DATE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT subscription_pauses_order CHECK (pause_end >= pause_start)
+);
+
+CREATE INDEX idx_subscription_pauses_subscription_id ON subscription_pauses(subscription_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_subscription_pauses_subscription_id;
+DROP TABLE IF EXISTS subscription_pauses;
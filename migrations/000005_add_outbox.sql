@@ -0,0 +1,22 @@
+-- +goose Up
+-- next_attempt_at holds failed events back until their retry is due and
+-- hides claimed ones from other relays while they are being published.
+-- Events that keep failing are dead-lettered instead of retried forever.
+CREATE TABLE outbox_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    event_type VARCHAR(100) NOT NULL,
+    aggregate_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMP WITH TIME ZONE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    dead_lettered_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_outbox_events_due ON outbox_events(next_attempt_at) WHERE published_at IS NULL AND dead_lettered_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_outbox_events_due;
+DROP TABLE IF EXISTS outbox_events;
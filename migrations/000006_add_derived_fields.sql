@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE subscriptions ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active';
+ALTER TABLE subscriptions ADD COLUMN next_renewal_date DATE;
+
+-- Rows are backfilled with POST /admin/recompute after the migration.
+
+-- +goose Down
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS next_renewal_date;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS status;
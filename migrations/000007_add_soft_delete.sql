@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE subscriptions ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_subscriptions_updated_at ON subscriptions(updated_at, id);
+
+-- Uniqueness of active subscriptions is checked by the service inside the
+-- write transaction, which also catches overlapping dated terms. Drop the
+-- partial unique index older releases created on startup and index the
+-- lookup the check runs, leaving deleted rows out of it.
+DROP INDEX IF EXISTS idx_subscriptions_unique_active;
+CREATE INDEX idx_subscriptions_user_service ON subscriptions(user_id, service_name) WHERE deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_subscriptions_user_service;
+DROP INDEX IF EXISTS idx_subscriptions_updated_at;
+DELETE FROM subscriptions WHERE deleted_at IS NOT NULL;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS deleted_at;
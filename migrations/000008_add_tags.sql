@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE subscriptions ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_subscriptions_tags ON subscriptions USING GIN (tags);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_subscriptions_tags;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS tags;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Existing rows were all billed monthly and take the default.
+ALTER TABLE subscriptions ADD COLUMN billing_period VARCHAR(20) NOT NULL DEFAULT 'monthly';
+
+-- +goose Down
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS billing_period;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Lets the purge find old soft-deleted rows without scanning live ones.
+CREATE INDEX idx_subscriptions_deleted_at ON subscriptions(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_subscriptions_deleted_at;
@@ -0,0 +1,33 @@
+-- +goose Up
+-- Prices become integers in minor units of the subscription's currency.
+-- Existing rows were whole roubles and move to kopecks. The column stays
+-- INTEGER, so a price too large to hold in kopecks fails the migration with
+-- a clear message instead of an overflow halfway through the update.
+-- +goose StatementBegin
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM subscriptions WHERE price > 2147483647 / 100) THEN
+        RAISE EXCEPTION 'cannot convert prices to kopecks: subscriptions exist with a price above % roubles', 2147483647 / 100;
+    END IF;
+END
+$$;
+-- +goose StatementEnd
+ALTER TABLE subscriptions ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'RUB';
+UPDATE subscriptions SET price = price * 100;
+
+-- +goose Down
+-- Older releases read every price as whole roubles, so only rows that
+-- convert back exactly may be rolled back. With subscriptions in other
+-- currencies, or rouble prices with kopecks, the rollback fails instead of
+-- losing them; those rows have to be dealt with first.
+-- +goose StatementBegin
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM subscriptions WHERE currency <> 'RUB' OR price % 100 <> 0) THEN
+        RAISE EXCEPTION 'cannot drop currency: subscriptions exist in other currencies or with fractional rouble prices';
+    END IF;
+END
+$$;
+-- +goose StatementEnd
+UPDATE subscriptions SET price = price / 100;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS currency;
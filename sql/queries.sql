@@ -1,38 +1,119 @@
 -- name: CreateSubscription :one
-INSERT INTO subscriptions (service_name, price, user_id, start_date, end_date)
-VALUES ($1, $2, $3, $4, $5)
+INSERT INTO subscriptions (service_name, price, user_id, start_date, end_date, auto_renew, metadata, tags, billing_period, currency)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING *;
+
+-- name: CreateSubscriptionWithID :one
+INSERT INTO subscriptions (id, service_name, price, user_id, start_date, end_date, auto_renew, metadata, tags, billing_period, currency)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 RETURNING *;
 
 -- name: GetSubscription :one
+SELECT * FROM subscriptions WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: GetSubscriptionForUpdate :one
+SELECT * FROM subscriptions WHERE id = $1 AND deleted_at IS NULL FOR UPDATE;
+
+-- name: GetSubscriptionIncludingDeleted :one
 SELECT * FROM subscriptions WHERE id = $1;
 
+-- name: GetSubscriptionOwner :one
+SELECT user_id, deleted_at FROM subscriptions WHERE id = $1 FOR UPDATE;
+
 -- name: UpdateSubscription :one
 UPDATE subscriptions 
 SET 
     service_name = COALESCE($2, service_name),
     price = COALESCE($3, price),
     start_date = COALESCE($4, start_date),
-    end_date = COALESCE($5, end_date),
+    end_date = $5,
+    auto_renew = COALESCE($6, auto_renew),
+    metadata = COALESCE($7, metadata),
+    tags = COALESCE($8, tags),
+    billing_period = COALESCE($9, billing_period),
+    currency = COALESCE($10, currency),
     updated_at = NOW()
-WHERE id = $1
+WHERE id = $1 AND deleted_at IS NULL
 RETURNING *;
 
 -- name: DeleteSubscription :execrows
-DELETE FROM subscriptions WHERE id = $1;
-
--- name: ListSubscriptions :many
-SELECT * FROM subscriptions
-WHERE 
-    (sqlc.narg('user_id')::UUID IS NULL OR user_id = sqlc.narg('user_id')) AND
-    (sqlc.narg('service_name')::VARCHAR IS NULL OR service_name ILIKE '%' || sqlc.narg('service_name') || '%')
-ORDER BY created_at DESC
-LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+UPDATE subscriptions
+SET deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: PurgeDeletedSubscriptions :execrows
+DELETE FROM subscriptions
+WHERE id IN (
+    SELECT id FROM subscriptions
+    WHERE deleted_at < sqlc.arg('cutoff')
+    ORDER BY deleted_at
+    LIMIT sqlc.arg('batch_size')
+);
 
--- name: CountSubscriptions :one
-SELECT COUNT(*) FROM subscriptions
-WHERE 
-    (sqlc.narg('user_id')::UUID IS NULL OR user_id = sqlc.narg('user_id')) AND
-    (sqlc.narg('service_name')::VARCHAR IS NULL OR service_name ILIKE '%' || sqlc.narg('service_name') || '%');
+-- name: GetServiceStats :one
+SELECT COUNT(DISTINCT user_id)::BIGINT AS subscriber_count
+FROM subscriptions
+WHERE
+    service_name = sqlc.arg('service_name') AND
+    start_date <= sqlc.arg('as_of')::DATE AND
+    (end_date IS NULL OR end_date >= sqlc.arg('as_of')::DATE) AND
+    deleted_at IS NULL;
+
+-- name: GetServiceRevenue :many
+SELECT
+    currency,
+    ROUND(SUM(
+        CASE billing_period
+            WHEN 'yearly' THEN price / 12.0
+            WHEN 'quarterly' THEN price / 3.0
+            ELSE price
+        END
+    ))::BIGINT AS monthly_revenue
+FROM subscriptions
+WHERE
+    service_name = sqlc.arg('service_name') AND
+    start_date <= sqlc.arg('as_of')::DATE AND
+    (end_date IS NULL OR end_date >= sqlc.arg('as_of')::DATE) AND
+    deleted_at IS NULL
+GROUP BY currency
+ORDER BY currency;
+
+-- name: GetSubscriptionKPIs :one
+SELECT
+    COUNT(*) FILTER (WHERE
+        deleted_at IS NULL AND
+        start_date <= sqlc.arg('as_of')::DATE AND
+        (end_date IS NULL OR end_date >= sqlc.arg('as_of')::DATE)
+    )::BIGINT AS active_count,
+    COUNT(*) FILTER (WHERE created_at >= sqlc.arg('since')::TIMESTAMPTZ)::BIGINT AS new_count,
+    COUNT(*) FILTER (WHERE
+        deleted_at >= sqlc.arg('since')::TIMESTAMPTZ OR
+        (deleted_at IS NULL AND end_date >= sqlc.arg('since')::DATE AND end_date < sqlc.arg('as_of')::DATE)
+    )::BIGINT AS churned_count
+FROM subscriptions;
+
+-- name: GetMonthlyRecurringRevenue :many
+SELECT
+    currency,
+    ROUND(SUM(
+        CASE billing_period
+            WHEN 'yearly' THEN price / 12.0
+            WHEN 'quarterly' THEN price / 3.0
+            ELSE price
+        END
+    ))::BIGINT AS monthly_recurring_revenue
+FROM subscriptions
+WHERE
+    deleted_at IS NULL AND
+    start_date <= sqlc.arg('as_of')::DATE AND
+    (end_date IS NULL OR end_date >= sqlc.arg('as_of')::DATE)
+GROUP BY currency
+ORDER BY currency;
+
+-- name: ListServiceNames :many
+SELECT DISTINCT service_name FROM subscriptions
+WHERE deleted_at IS NULL
+ORDER BY service_name;
 
 -- name: CalculateTotalCost :one
 WITH date_range AS (
@@ -42,16 +123,191 @@ WITH date_range AS (
 subscription_costs AS (
     SELECT 
         s.id as subscription_id,
-        s.price,
+        CASE s.billing_period
+            WHEN 'yearly' THEN s.price / 12.0
+            WHEN 'quarterly' THEN s.price / 3.0
+            ELSE s.price
+        END AS monthly_price,
+        COUNT(DISTINCT dr.month_start) as months_count
+    FROM subscriptions s
+    CROSS JOIN date_range dr
+    WHERE 
+        (sqlc.narg('user_id')::UUID IS NULL OR s.user_id = sqlc.narg('user_id')) AND
+        (sqlc.narg('service_name')::VARCHAR IS NULL OR s.service_name ILIKE '%' || sqlc.narg('service_name') || '%') AND
+        s.currency = sqlc.arg('currency') AND
+        (s.start_date <= dr.month_start) AND
+        (s.end_date IS NULL OR s.end_date >= dr.month_start) AND
+        s.deleted_at IS NULL AND
+        NOT EXISTS (
+            SELECT 1 FROM subscription_pauses p
+            WHERE p.subscription_id = s.id AND dr.month_start BETWEEN p.pause_start AND p.pause_end
+        )
+    GROUP BY s.id, s.price, s.billing_period
+)
+SELECT COALESCE(ROUND(SUM(monthly_price * months_count)), 0)::BIGINT as total_cost
+FROM subscription_costs;
+
+-- name: CreatePause :one
+INSERT INTO subscription_pauses (subscription_id, pause_start, pause_end)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ListPauses :many
+SELECT * FROM subscription_pauses
+WHERE subscription_id = $1
+ORDER BY pause_start, id;
+
+-- name: DeletePause :execrows
+DELETE FROM subscription_pauses WHERE id = $1 AND subscription_id = $2;
+
+-- name: CalculateTotalCostByService :many
+WITH date_range AS (
+    SELECT 
+        generate_series(sqlc.arg('start_date')::DATE, sqlc.arg('end_date')::DATE, '1 month'::interval)::DATE AS month_start
+),
+subscription_costs AS (
+    SELECT 
+        s.id as subscription_id,
+        s.service_name,
+        CASE s.billing_period
+            WHEN 'yearly' THEN s.price / 12.0
+            WHEN 'quarterly' THEN s.price / 3.0
+            ELSE s.price
+        END AS monthly_price,
         COUNT(DISTINCT dr.month_start) as months_count
     FROM subscriptions s
     CROSS JOIN date_range dr
     WHERE 
         (sqlc.narg('user_id')::UUID IS NULL OR s.user_id = sqlc.narg('user_id')) AND
         (sqlc.narg('service_name')::VARCHAR IS NULL OR s.service_name ILIKE '%' || sqlc.narg('service_name') || '%') AND
+        s.currency = sqlc.arg('currency') AND
         (s.start_date <= dr.month_start) AND
-        (s.end_date IS NULL OR s.end_date >= dr.month_start)
-    GROUP BY s.id, s.price
+        (s.end_date IS NULL OR s.end_date >= dr.month_start) AND
+        s.deleted_at IS NULL AND
+        NOT EXISTS (
+            SELECT 1 FROM subscription_pauses p
+            WHERE p.subscription_id = s.id AND dr.month_start BETWEEN p.pause_start AND p.pause_end
+        )
+    GROUP BY s.id, s.service_name, s.price, s.billing_period
 )
-SELECT COALESCE(SUM(price * months_count), 0)::BIGINT as total_cost
-FROM subscription_costs;
\ No newline at end of file
+SELECT
+    service_name,
+    ROUND(SUM(monthly_price * months_count))::BIGINT AS total_cost,
+    COUNT(*) OVER ()::BIGINT AS group_count
+FROM subscription_costs
+GROUP BY service_name
+ORDER BY
+    CASE WHEN sqlc.arg('sort_order')::TEXT = 'cost_asc' THEN SUM(monthly_price * months_count) END ASC,
+    CASE WHEN sqlc.arg('sort_order')::TEXT = 'cost_desc' THEN SUM(monthly_price * months_count) END DESC,
+    service_name ASC
+LIMIT sqlc.arg('limit') OFFSET sqlc.arg('offset');
+
+-- name: RecomputeDerivedFields :many
+UPDATE subscriptions
+SET
+    status = CASE
+        WHEN end_date IS NOT NULL AND end_date < COALESCE(sqlc.narg('as_of')::DATE, CURRENT_DATE) THEN 'expired'
+        WHEN start_date > COALESCE(sqlc.narg('as_of')::DATE, CURRENT_DATE) THEN 'scheduled'
+        ELSE 'active'
+    END,
+    next_renewal_date = CASE WHEN auto_renew THEN end_date END,
+    updated_at = NOW()
+WHERE
+    id = ANY(sqlc.arg('ids')::UUID[]) AND (
+        status IS DISTINCT FROM (CASE
+            WHEN end_date IS NOT NULL AND end_date < COALESCE(sqlc.narg('as_of')::DATE, CURRENT_DATE) THEN 'expired'
+            WHEN start_date > COALESCE(sqlc.narg('as_of')::DATE, CURRENT_DATE) THEN 'scheduled'
+            ELSE 'active'
+        END) OR
+        next_renewal_date IS DISTINCT FROM (CASE WHEN auto_renew THEN end_date END)
+    )
+RETURNING *;
+
+-- name: RenewSubscription :one
+UPDATE subscriptions
+SET
+    end_date = (end_date + CASE billing_period
+        WHEN 'yearly' THEN INTERVAL '1 year'
+        WHEN 'quarterly' THEN INTERVAL '3 months'
+        ELSE INTERVAL '1 month'
+    END)::DATE,
+    updated_at = NOW()
+WHERE id = sqlc.arg('id') AND deleted_at IS NULL AND auto_renew AND end_date = sqlc.arg('current_end_date')::DATE
+RETURNING *;
+
+-- name: ReassignUserSubscriptions :many
+UPDATE subscriptions
+SET user_id = sqlc.arg('to_user_id'), updated_at = NOW()
+WHERE user_id = sqlc.arg('from_user_id') AND deleted_at IS NULL
+RETURNING *;
+
+-- name: AnonymizeUserSubscriptions :many
+UPDATE subscriptions
+SET user_id = sqlc.arg('tombstone_user_id'), metadata = '{}'::JSONB, updated_at = NOW()
+WHERE user_id = sqlc.arg('user_id')
+RETURNING *;
+
+-- name: ScrubSubscriptionHistory :exec
+UPDATE subscription_history SET details = NULL
+WHERE subscription_id = ANY(sqlc.arg('subscription_ids')::UUID[])
+    OR details->>'from_user_id' = sqlc.arg('user_id')::TEXT
+    OR details->>'to_user_id' = sqlc.arg('user_id')::TEXT;
+
+-- name: ScrubUserOutboxEvents :execrows
+UPDATE outbox_events
+SET payload = jsonb_set(jsonb_set(payload, '{user_id}', to_jsonb(sqlc.arg('tombstone_user_id')::TEXT)), '{metadata}', '{}'::JSONB)
+WHERE payload->>'user_id' = sqlc.arg('user_id')::TEXT;
+
+-- name: CreateHistoryEntry :exec
+INSERT INTO subscription_history (subscription_id, action, details)
+VALUES ($1, $2, $3);
+
+-- name: ListHistoryEntries :many
+SELECT * FROM subscription_history
+WHERE subscription_id = $1
+ORDER BY created_at, id;
+
+-- name: ListOverlappingSubscriptionIDs :many
+SELECT id FROM subscriptions
+WHERE
+    user_id = sqlc.arg('user_id') AND
+    service_name = sqlc.arg('service_name') AND
+    (sqlc.narg('exclude_id')::UUID IS NULL OR id <> sqlc.narg('exclude_id')) AND
+    start_date <= COALESCE(sqlc.narg('end_date')::DATE, 'infinity'::DATE) AND
+    (end_date IS NULL OR end_date >= sqlc.arg('start_date')::DATE) AND
+    deleted_at IS NULL
+ORDER BY start_date, id;
+
+-- name: CreateOutboxEvent :exec
+INSERT INTO outbox_events (event_type, aggregate_id, payload)
+VALUES ($1, $2, $3);
+
+-- name: ClaimDueOutboxEvents :many
+UPDATE outbox_events
+SET next_attempt_at = NOW() + sqlc.arg('claim_seconds')::FLOAT8 * INTERVAL '1 second'
+WHERE id IN (
+    SELECT id FROM outbox_events
+    WHERE published_at IS NULL AND dead_lettered_at IS NULL AND next_attempt_at <= NOW()
+    ORDER BY next_attempt_at, created_at, id
+    LIMIT sqlc.arg('batch_size')
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CountPendingOutboxEvents :one
+SELECT COUNT(*) FROM outbox_events WHERE published_at IS NULL AND dead_lettered_at IS NULL;
+
+-- name: MarkOutboxEventPublished :exec
+UPDATE outbox_events
+SET published_at = NOW(), attempts = attempts + 1, last_error = NULL
+WHERE id = $1;
+
+-- name: RecordOutboxEventFailure :one
+UPDATE outbox_events
+SET
+    attempts = attempts + 1,
+    last_error = sqlc.arg('last_error'),
+    next_attempt_at = NOW() + sqlc.arg('retry_seconds')::FLOAT8 * INTERVAL '1 second',
+    dead_lettered_at = CASE WHEN attempts + 1 >= sqlc.arg('max_attempts')::INT THEN NOW() END
+WHERE id = sqlc.arg('id')
+RETURNING dead_lettered_at IS NOT NULL AS dead_lettered;